@@ -0,0 +1,356 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// batchOperation names batch generations in responses
+const batchOperation = "generate_batch"
+
+// batchEntry is one line of a batch file. Plain text lines are prompts; JSON
+// lines may also override the command's flags, using the parameter names of
+// generate_video_from_text.
+type batchEntry struct {
+	Line           int      `json:"-"`
+	Prompt         string   `json:"prompt"`
+	Model          *string  `json:"model"`
+	ModelVersion   *string  `json:"model_version"`
+	Resolution     *string  `json:"resolution"`
+	AspectRatio    *string  `json:"aspect_ratio"`
+	Duration       *int     `json:"duration"`
+	NegativePrompt *string  `json:"negative_prompt"`
+	Effect         *string  `json:"effect"`
+	NumFrames      *int     `json:"num_frames"`
+	FPS            *int     `json:"fps"`
+	GoFast         *bool    `json:"go_fast"`
+	SampleShift    *float64 `json:"sample_shift"`
+	Seed           *int     `json:"seed"`
+	Filename       *string  `json:"filename"`
+	Project        *string  `json:"project"`
+}
+
+// apply overrides the flag parameters with the ones the entry sets
+func (e batchEntry) apply(params *generation.VideoParams) {
+	params.Prompt = e.Prompt
+	if e.Model != nil {
+		params.Model = *e.Model
+	}
+	if e.ModelVersion != nil {
+		params.Version = *e.ModelVersion
+	}
+	if e.Resolution != nil {
+		params.Resolution = *e.Resolution
+	}
+	if e.AspectRatio != nil {
+		params.AspectRatio = *e.AspectRatio
+	}
+	if e.Duration != nil {
+		params.Duration = *e.Duration
+	}
+	if e.NegativePrompt != nil {
+		params.NegativePrompt = *e.NegativePrompt
+	}
+	if e.Effect != nil {
+		params.Effect = *e.Effect
+	}
+	if e.NumFrames != nil {
+		params.NumFrames = *e.NumFrames
+	}
+	if e.FPS != nil {
+		params.FramesPerSecond = *e.FPS
+	}
+	if e.GoFast != nil {
+		params.GoFast = e.GoFast
+	}
+	if e.SampleShift != nil {
+		params.SampleShift = *e.SampleShift
+	}
+	if e.Seed != nil {
+		params.Seed = e.Seed
+	}
+	if e.Filename != nil {
+		params.Filename = *e.Filename
+	}
+	if e.Project != nil {
+		params.Project = *e.Project
+	}
+}
+
+// readBatch parses a batch file, or stdin for "-". Blank lines and lines
+// starting with # are skipped.
+func readBatch(path string) ([]batchEntry, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var entries []batchEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		entry := batchEntry{Prompt: text}
+		if strings.HasPrefix(text, "{") {
+			entry = batchEntry{}
+			decoder := json.NewDecoder(bytes.NewReader([]byte(text)))
+			decoder.DisallowUnknownFields()
+			if err := decoder.Decode(&entry); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if strings.TrimSpace(entry.Prompt) == "" {
+				return nil, fmt.Errorf("line %d: prompt is required", line)
+			}
+		}
+		entry.Line = line
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no prompts in %s", path)
+	}
+	return entries, nil
+}
+
+// batchRow tracks one generation of a batch
+type batchRow struct {
+	line         int
+	params       generation.VideoParams
+	predictionID string
+	storageID    string
+	status       string
+	path         string
+	err          error
+	logs         string
+}
+
+// runBatch starts one generation per line of the batch file, validating
+// every line before starting any, and prints a table of the predictions.
+// With -wait it blocks until all of them finished. It exits 1 when a
+// generation failed.
+func runBatch(f *generateFlags) {
+	entries, err := readBatch(f.batch)
+	if err != nil {
+		fatal(batchOperation, "invalid_parameters", nil, "Invalid batch file: %v", err)
+	}
+
+	t := openTerminal(false)
+	defer t.close()
+
+	base := f.params(t, "t2v")
+	rows := make([]*batchRow, 0, len(entries))
+	for _, entry := range entries {
+		params := base
+		entry.apply(&params)
+		if params.Model == generation.AutoModel {
+			params.Model = autoModel(t.gen, batchOperation, "t2v", params.Resolution)
+		}
+		if err := validateBatchParams(params); err != nil {
+			fatal(batchOperation, "invalid_parameters", map[string]interface{}{"line": entry.Line}, "Line %d: %v", entry.Line, err)
+		}
+		rows = append(rows, &batchRow{line: entry.Line, params: params})
+	}
+
+	info("Starting %d text-to-video generations...\n", len(rows))
+	for _, row := range rows {
+		result, err := t.gen.GenerateTextToVideo(context.Background(), row.params)
+		if err != nil {
+			row.status, row.err = types.StatusFailed, err
+			continue
+		}
+		row.predictionID, row.storageID, row.status = result.PredictionID, result.ID, types.StatusStarting
+	}
+
+	interrupted := f.wait && !waitForBatch(t, rows)
+	printBatch(rows)
+
+	if interrupted {
+		t.exit(130)
+	}
+	for _, row := range rows {
+		if row.status != "completed" && (f.wait || row.err != nil) {
+			t.exit(1)
+		}
+	}
+}
+
+// validateBatchParams checks a line's parameters the way
+// generate_video_from_text does, so a bad line fails before any generation
+// starts
+func validateBatchParams(params generation.VideoParams) error {
+	if !generation.IsTextToVideoModel(params.Model) {
+		return fmt.Errorf("model %s does not support text-to-video generation", params.Model)
+	}
+	if params.Project != "" {
+		if err := storage.ValidateProjectName(params.Project); err != nil {
+			return err
+		}
+	}
+	return generation.ValidateParams(params)
+}
+
+// waitForBatch polls the started generations concurrently until all of them
+// finished, showing how many are done on stderr. An interrupt stops waiting;
+// the remaining predictions keep running on Replicate. It reports whether
+// every generation finished.
+func waitForBatch(t *terminal, rows []*batchRow) bool {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, row := range rows {
+		if row.err != nil {
+			continue
+		}
+		wg.Add(1)
+		go func(row *batchRow) {
+			defer wg.Done()
+			for {
+				result, err := t.gen.ContinueGeneration(ctx, row.predictionID, row.storageID, waitPollInterval)
+				mu.Lock()
+				switch {
+				case err == nil:
+					row.status, row.path = result.Status, result.FilePath
+				case result != nil && isPending(result.Status):
+					row.status = result.Status
+				case ctx.Err() != nil:
+					// Interrupted; the row keeps its last known status
+				default:
+					row.status, row.err = types.StatusFailed, err
+					if result != nil {
+						if result.Status != "" {
+							row.status = result.Status
+						}
+						row.logs = result.Logs
+					}
+				}
+				pending := isPending(row.status) && ctx.Err() == nil
+				mu.Unlock()
+				if !pending {
+					return
+				}
+			}
+		}(row)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	progress := newProgressLine(0)
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for {
+		mu.Lock()
+		summary := batchSummary(rows)
+		mu.Unlock()
+		select {
+		case <-done:
+			progress.finish(batchSummary(rows))
+			if ctx.Err() != nil {
+				fmt.Fprintln(os.Stderr, "Stopped waiting; the remaining generations keep running.")
+				return false
+			}
+			return true
+		case <-ticker.C:
+			progress.update(summary)
+		}
+	}
+}
+
+// batchSummary counts the rows by outcome for the progress line
+func batchSummary(rows []*batchRow) string {
+	var completed, failed, running int
+	for _, row := range rows {
+		switch {
+		case row.status == "completed":
+			completed++
+		case isPending(row.status):
+			running++
+		default:
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d/%d completed, %d running, %d failed", completed, len(rows), running, failed)
+}
+
+// printBatch prints the batch as a table, or as a batch status response
+// with -json
+func printBatch(rows []*batchRow) {
+	if jsonOutput {
+		results := make([]types.PredictionStatus, 0, len(rows))
+		for _, row := range rows {
+			result := types.PredictionStatus{
+				PredictionID: row.predictionID,
+				StorageID:    row.storageID,
+				Status:       row.status,
+			}
+			if row.path != "" {
+				result.Paths = map[string]string{"output": row.path}
+			}
+			if row.err != nil {
+				result.Error = row.err.Error()
+				result.ErrorType = client.ErrorType(row.err, "generation_failed")
+				if row.logs != "" {
+					result.LogsTail = storage.LogTail(row.logs)
+				}
+			}
+			results = append(results, result)
+		}
+		fmt.Println(responses.BuildBatchStatusResponse(batchOperation, results))
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LINE\tPREDICTION ID\tSTORAGE ID\tMODEL\tSTATUS\tDETAIL")
+	for _, row := range rows {
+		detail := truncate(row.params.Prompt, 50)
+		switch {
+		case row.err != nil:
+			detail = row.err.Error()
+		case row.path != "":
+			detail = row.path
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", row.line, row.predictionID, row.storageID, row.params.Model, row.status, detail)
+	}
+	w.Flush()
+
+	info("\n%s\n", batchSummary(rows))
+	for _, row := range rows {
+		if isPending(row.status) {
+			info("\nTo check status, run:\n  %s status <prediction_id>\n", programName)
+			break
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/moderation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/notify"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// programName is the binary name shown in usage text
+const programName = "replicate-video-ai"
+
+// debugMode is set by the global -debug flag or REPLICATE_VIDEO_DEBUG=true
+var debugMode bool
+
+// jsonOutput is set by a command's -json flag: stdout then carries only the
+// structured response the matching MCP tool returns, and failures are
+// printed as error responses
+var jsonOutput bool
+
+// command is a CLI subcommand
+type command struct {
+	name    string
+	args    string // Positional arguments, shown in usage
+	summary string
+	json    bool // Accepts -json
+	run     func(cmd command, args []string)
+}
+
+// commands lists the subcommands in the order help shows them
+var commands = []command{
+	{name: "generate", args: "<prompt>", summary: "Start a text-to-video generation", json: true, run: runGenerate},
+	{name: "i2v", args: "<image> [prompt]", summary: "Start an image-to-video generation", json: true, run: runImageToVideo},
+	{name: "status", args: "<prediction_id|storage_id>", summary: "Check a generation and download the video once it completed", json: true, run: runStatus},
+	{name: "list", summary: "List stored videos", json: true, run: runList},
+	{name: "models", summary: "List the available video models", json: true, run: runModels},
+	{name: "recover", args: "<prediction_id>", summary: "Adopt a prediction made elsewhere into storage and download its video", json: true, run: runRecover},
+	{name: "cancel", args: "<prediction_id|storage_id>", summary: "Cancel a running generation on Replicate", json: true, run: runCancel},
+	{name: "prune", summary: "Delete failed or canceled generations", json: true, run: runPrune},
+	{name: "export", args: "[storage_id...]", summary: "Bundle stored videos into a zip archive or folder", json: true, run: runExport},
+	{name: "import", args: "<video>", summary: "Import an existing video file into storage", json: true, run: runImport},
+	{name: "health", summary: "Check the network, API token, storage, and ffmpeg", json: true, run: runHealth},
+	{name: "serve", summary: "Run the MCP server on stdin/stdout (the default without a command)", run: func(command, []string) { runServer() }},
+	{name: "version", summary: "Show the version", json: true, run: runVersion},
+	{name: "test-async", summary: "Run a generation end to end to test the async flow", run: runAsyncTest},
+}
+
+// findCommand looks up a subcommand by name
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// flagSet returns a flag set for the command whose usage shows its
+// arguments, summary, and flags
+func (cmd command) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	if cmd.json {
+		fs.BoolVar(&jsonOutput, "json", false, "Print only the JSON response, as the MCP tools return it")
+	}
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: %s %s [flags] %s\n\n%s\n", programName, cmd.name, cmd.args, cmd.summary)
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// printUsage lists the subcommands
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [-debug] [-config file] [-profile name] <command> [flags] [arguments]\n\n", programName)
+	fmt.Fprintln(out, "Without a command the MCP server runs on stdin/stdout.")
+	fmt.Fprintln(out, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-11s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nRun '%s help <command>' for a command's flags. Commands other than serve\n", programName)
+	fmt.Fprintln(out, "and test-async accept -json to print only the JSON response the MCP tools return.")
+}
+
+// runHelp shows the usage of one command, or lists them all
+func runHelp(args []string) {
+	if len(args) == 0 {
+		printUsage()
+		return
+	}
+	cmd, ok := findCommand(args[0])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n", args[0])
+		printUsage()
+		os.Exit(2)
+	}
+	cmd.run(cmd, []string{"-h"})
+}
+
+// requireArgs exits with the command's usage unless it got between min and
+// max positional arguments
+func requireArgs(fs *flag.FlagSet, min, max int) {
+	if fs.NArg() < min || fs.NArg() > max {
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// info prints output meant for people, which -json leaves out
+func info(format string, args ...interface{}) {
+	if !jsonOutput {
+		fmt.Printf(format, args...)
+	}
+}
+
+// fatal reports a failed command and exits 1: as an error response on
+// stdout with -json, otherwise as a message on stderr
+func fatal(operation, errorType string, details map[string]interface{}, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	if jsonOutput {
+		fmt.Println(responses.BuildErrorResponse(operation, errorType, message, details))
+		os.Exit(1)
+	}
+	log.Fatal(message)
+}
+
+// terminal holds the components terminal commands work with
+type terminal struct {
+	gen        *generation.Generator
+	store      *storage.Storage
+	rootFolder string
+	closers    []func()
+}
+
+// openTerminal sets up logging, storage, and the generator the way the MCP
+// server does. Local commands need no Replicate API token.
+func openTerminal(local bool) *terminal {
+	// Mock mode runs offline without an API token
+	mockCfg, err := config.LoadMockConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	httpCfg, err := config.LoadHTTPConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Get API key from environment; replaying a cassette needs none
+	apiKey := os.Getenv("REPLICATE_API_TOKEN")
+	replay := httpCfg.Cassette != "" && httpCfg.CassetteMode == config.CassetteReplay
+	if apiKey == "" && !mockCfg.Enabled && !local && !replay {
+		log.Fatal("A Replicate API token is required: set REPLICATE_API_TOKEN or a token source in the config file")
+	}
+
+	// Get root folder from environment or use the platform default
+	rootFolder, err := config.RootFolder()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t := &terminal{rootFolder: rootFolder}
+
+	// Log to file, mirrored to stderr in debug mode
+	logCfg, err := config.LoadLoggingConfig(rootFolder, debugMode)
+	if err != nil {
+		log.Fatal(err)
+	}
+	logCloser, err := logging.Setup(logging.Options{
+		Path:       logCfg.File,
+		Level:      logCfg.Level,
+		MaxSize:    int64(logCfg.MaxSizeMB) * 1024 * 1024,
+		MaxBackups: logCfg.MaxBackups,
+		Console:    debugMode,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up logging: %v", err)
+	}
+	t.closers = append(t.closers, func() { logCloser.Close() })
+
+	transport, err := client.NewTransport(httpCfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Create components
+	var replicateClient client.Client
+	if mockCfg.Enabled {
+		fmt.Fprintln(os.Stderr, "Mock mode enabled: no requests will be sent to Replicate")
+		mockClient, err := client.NewMockClient(mockCfg.Delay, mockCfg.Output)
+		if err != nil {
+			log.Fatal(err)
+		}
+		replicateClient = mockClient
+	} else {
+		apiClient := client.NewReplicateClient(apiKey, debugMode)
+		apiClient.SetTimeout(httpCfg.APITimeout)
+		apiClient.SetTransport(transport)
+		if httpCfg.Cassette != "" {
+			recorder, err := client.NewRecorder(httpCfg.Cassette, httpCfg.CassetteMode, transport)
+			if err != nil {
+				log.Fatal(err)
+			}
+			apiClient.SetTransport(recorder)
+		}
+		replicateClient = apiClient
+	}
+	storeRoot, err := storage.NamespaceRoot(rootFolder, os.Getenv("REPLICATE_VIDEO_NAMESPACE"))
+	if err != nil {
+		log.Fatalf("Invalid REPLICATE_VIDEO_NAMESPACE: %v", err)
+	}
+	t.store = storage.NewStorage(storeRoot, debugMode)
+	t.store.SetDownloadIdleTimeout(httpCfg.DownloadIdleTimeout)
+	t.store.SetDownloadTransport(transport)
+	if err := t.store.SetFilenameTemplate(os.Getenv("REPLICATE_VIDEO_FILENAME_TEMPLATE")); err != nil {
+		log.Fatalf("Invalid REPLICATE_VIDEO_FILENAME_TEMPLATE: %v", err)
+	}
+	imagesCfg, err := config.LoadImagesConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.store.SetImageLimits(imagesCfg.MaxBytes, imagesCfg.Types)
+
+	storage.SetMediaToolPaths(os.Getenv("FFMPEG_PATH"), os.Getenv("FFPROBE_PATH"))
+	mediaCfg, err := config.LoadMediaConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	storage.SetMediaLimits(mediaCfg.ProbeTimeout, mediaCfg.RenderTimeout, mediaCfg.MaxConcurrent)
+	configureModels(rootFolder)
+	configureMessages(rootFolder)
+	t.gen = generation.NewGenerator(replicateClient, t.store, debugMode)
+	timeouts, err := config.LoadTimeouts()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.gen.SetSyncWait(timeouts.SyncWait)
+
+	notifyCfg, err := config.LoadNotificationsConfig(rootFolder)
+	if err != nil {
+		log.Fatal(err)
+	}
+	notifier, err := notify.NewDispatcherFromConfig(notifyCfg)
+	if err != nil {
+		log.Fatalf("Failed to configure notifications: %v", err)
+	}
+	t.gen.SetNotifier(notifier)
+	t.gen.SetQualityReport(os.Getenv("REPLICATE_VIDEO_QUALITY_REPORT") == "true")
+
+	moderationCfg, err := config.LoadModerationConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	moderator, err := moderation.NewChecker(moderationCfg)
+	if err != nil {
+		log.Fatalf("Failed to configure moderation: %v", err)
+	}
+	t.gen.SetModerator(moderator)
+
+	limitsCfg, err := config.LoadLimitsConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+	t.gen.SetQuota(generation.NewQuota(limitsCfg))
+	t.closers = append(t.closers, notifier.Wait)
+
+	return t
+}
+
+// configureModels applies the configured defaults and model overrides the
+// way the MCP server does
+func configureModels(rootFolder string) {
+	modelsCfg, err := config.LoadModelsConfig(rootFolder)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := generation.Configure(config.LoadDefaultsConfig(), modelsCfg); err != nil {
+		log.Fatalf("Invalid models config: %v", err)
+	}
+}
+
+// configureMessages selects the locale and messages of responses the way
+// the MCP server does
+func configureMessages(rootFolder string) {
+	messagesCfg, err := config.LoadMessagesConfig(rootFolder)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := responses.Configure(os.Getenv("REPLICATE_VIDEO_LOCALE"), messagesCfg); err != nil {
+		log.Fatalf("Invalid REPLICATE_VIDEO_LOCALE or messages config: %v", err)
+	}
+}
+
+// close waits for pending notifications and closes the log, in reverse
+// order of setup. Later calls do nothing, so commands can close before
+// exiting with a status code.
+func (t *terminal) close() {
+	for i := len(t.closers) - 1; i >= 0; i-- {
+		t.closers[i]()
+	}
+	t.closers = nil
+}
+
+// exit closes the terminal and exits with code
+func (t *terminal) exit(code int) {
+	t.close()
+	os.Exit(code)
+}
+
+// splitList splits a comma-separated flag value, dropping blanks
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
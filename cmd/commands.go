@@ -0,0 +1,869 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// generateFlags holds the flags shared by generate and i2v
+type generateFlags struct {
+	fs             *flag.FlagSet
+	operation      string // text_to_video or image_to_video, for responses
+	model          string
+	modelVersion   string
+	resolution     string
+	aspectRatio    string
+	duration       int
+	negativePrompt string
+	effect         string
+	numFrames      int
+	fps            int
+	goFast         bool
+	sampleShift    float64
+	seed           int
+	autoRetry      int
+	retryStrategy  string
+	safetyChecker  bool
+	outputFile     string
+	outputDir      string
+	project        string
+	wait           bool
+	batch          string
+}
+
+// newGenerateFlags registers the generation flags on a command's flag set
+func newGenerateFlags(fs *flag.FlagSet, i2v bool) *generateFlags {
+	f := &generateFlags{fs: fs, operation: "text_to_video"}
+	if i2v {
+		f.operation = "image_to_video"
+	}
+	// Configure hasn't run yet, so these are the built-in defaults
+	usage := "Model alias, or auto to pick one (default: default_model from the config file, or " + generation.DefaultTextModel() + ")"
+	if i2v {
+		usage = "Model alias, or auto to pick one (default: default_image_model from the config file, or " + generation.DefaultImageModel() + ")"
+	}
+	fs.StringVar(&f.model, "model", "", usage)
+	fs.StringVar(&f.modelVersion, "model-version", "", "Replicate version ID to use instead of the pinned or latest version")
+	fs.StringVar(&f.resolution, "resolution", "", "Video resolution (480p, 720p, 1080p)")
+	if !i2v {
+		fs.StringVar(&f.aspectRatio, "aspect", "", "Aspect ratio (16:9, 9:16, 1:1)")
+	}
+	fs.IntVar(&f.duration, "duration", 0, "Video duration in seconds (5 or 10, for Kling)")
+	fs.StringVar(&f.negativePrompt, "negative", "", "Negative prompt (what to avoid)")
+	fs.StringVar(&f.effect, "effect", "", "Effect template, for models that have them (see models)")
+	fs.IntVar(&f.numFrames, "frames", 0, "Number of frames (81-121, for Wan)")
+	fs.IntVar(&f.fps, "fps", 0, "Frames per second (5-30, for Wan)")
+	fs.BoolVar(&f.goFast, "go-fast", true, "Speed optimizations (for Wan); -go-fast=false for slower, cleaner output")
+	fs.Float64Var(&f.sampleShift, "sample-shift", 0, "Sampling shift (1-20, for Wan; default 12)")
+	fs.IntVar(&f.seed, "seed", 0, "Random seed for reproducible results (default: the model picks one)")
+	fs.IntVar(&f.autoRetry, "auto-retry", 0, "Times to resubmit the prediction if it fails with a transient model error (0-3)")
+	fs.StringVar(&f.retryStrategy, "retry-strategy", "", "How -auto-retry resubmits: same or new_seed (default: same)")
+	if i2v {
+		fs.BoolVar(&f.safetyChecker, "safety-checker", true, "Keep the safety checker enabled (for wan-i2v-fast; false requires REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE=true)")
+	}
+	fs.StringVar(&f.outputFile, "output", "", "Filename for the downloaded video, without directories (see -output-dir)")
+	fs.StringVar(&f.outputDir, "output-dir", "", "Save the video under this directory instead of the storage root")
+	fs.StringVar(&f.project, "project", "", "Group the video under <root>/<project>/")
+	fs.BoolVar(&f.wait, "wait", false, "Wait for the video and download it; exits non-zero if the generation fails")
+	if !i2v {
+		fs.StringVar(&f.batch, "batch", "", "Start one generation per line of a prompts file (.txt, or JSONL objects with parameter overrides; - reads stdin)")
+	}
+	return f
+}
+
+// params builds the generation parameters from the flags. Flags that
+// weren't given are left unset, so the model defaults apply.
+func (f *generateFlags) params(t *terminal, mode string) generation.VideoParams {
+	params := generation.VideoParams{
+		Model:           f.model,
+		Version:         f.modelVersion,
+		Resolution:      f.resolution,
+		AspectRatio:     f.aspectRatio,
+		Duration:        f.duration,
+		NumFrames:       f.numFrames,
+		FramesPerSecond: f.fps,
+		SampleShift:     f.sampleShift,
+		NegativePrompt:  f.negativePrompt,
+		Effect:          f.effect,
+		Filename:        f.outputFile,
+		Project:         f.project,
+		Retry:           generation.RetryPolicy{Count: f.autoRetry, Strategy: f.retryStrategy},
+	}
+	if params.Model == "" {
+		params.Model = generation.DefaultTextModel()
+		if mode == "i2v" {
+			params.Model = generation.DefaultImageModel()
+		}
+	}
+	f.fs.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "go-fast":
+			params.GoFast = &f.goFast
+		case "seed":
+			params.Seed = &f.seed
+		case "safety-checker":
+			params.SafetyChecker = &f.safetyChecker
+		}
+	})
+
+	// Changing the safety checker is opt-in
+	if params.SafetyChecker != nil && os.Getenv("REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE") != "true" {
+		fatal(f.operation, "invalid_parameters", nil, "-safety-checker can only be changed when REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE=true")
+	}
+	if f.project != "" {
+		if err := storage.ValidateProjectName(f.project); err != nil {
+			fatal(f.operation, "invalid_parameters", nil, "%v", err)
+		}
+	}
+
+	// Validate the per-run output directory override
+	if f.outputDir != "" {
+		dir, err := storage.ValidateOutputDir(f.outputDir, config.LoadAllowedOutputDirs())
+		if err != nil {
+			fatal(f.operation, "invalid_parameters", nil, "%v", err)
+		}
+		params.OutputDir = dir
+	}
+
+	if params.Model == generation.AutoModel {
+		params.Model = autoModel(t.gen, f.operation, mode, f.resolution)
+	}
+	return params
+}
+
+// runGenerate starts a text-to-video generation
+func runGenerate(cmd command, args []string) {
+	fs := cmd.flagSet()
+	f := newGenerateFlags(fs, false)
+	fs.Parse(args)
+	if f.batch != "" {
+		requireArgs(fs, 0, 0)
+		runBatch(f)
+		return
+	}
+	requireArgs(fs, 0, 1)
+
+	t := openTerminal(false)
+	defer t.close()
+
+	params := f.params(t, "t2v")
+	params.Prompt = fs.Arg(0)
+	if params.Prompt == "" {
+		params.Prompt = "A beautiful sunset over mountains with a lake in the foreground, golden hour lighting"
+	}
+
+	info("Generating text-to-video with %s...\n", params.Model)
+	info("Prompt: %s\n", params.Prompt)
+
+	result, err := t.gen.GenerateTextToVideo(context.Background(), params)
+	if err != nil {
+		fatal(f.operation, client.ErrorType(err, "generation_failed"), nil, "Text-to-video generation failed: %v", err)
+	}
+	// A fast model's prediction may already be done; download it now
+	if f.wait || result.Status == types.StatusSucceeded {
+		waitForGeneration(t, f.operation, params, result)
+		return
+	}
+	printStarted(t, f.operation, params, result)
+}
+
+// runImageToVideo starts an image-to-video generation
+func runImageToVideo(cmd command, args []string) {
+	fs := cmd.flagSet()
+	f := newGenerateFlags(fs, true)
+	fs.Parse(args)
+	requireArgs(fs, 1, 2)
+
+	t := openTerminal(false)
+	defer t.close()
+
+	params := f.params(t, "i2v")
+	params.ImagePath = fs.Arg(0)
+	params.Prompt = fs.Arg(1)
+	if params.Prompt == "" {
+		params.Prompt = "Bring the image to life with natural motion"
+	}
+
+	info("Generating image-to-video with %s...\n", params.Model)
+	info("Input image: %s\n", params.ImagePath)
+	info("Prompt: %s\n", params.Prompt)
+
+	result, err := t.gen.GenerateImageToVideo(context.Background(), params)
+	if err != nil {
+		fatal(f.operation, client.ErrorType(err, "generation_failed"), nil, "Image-to-video generation failed: %v", err)
+	}
+	// A fast model's prediction may already be done; download it now
+	if f.wait || result.Status == types.StatusSucceeded {
+		waitForGeneration(t, f.operation, params, result)
+		return
+	}
+	printStarted(t, f.operation, params, result)
+}
+
+// printStarted reports a started generation and how to check on it
+func printStarted(t *terminal, operation string, params generation.VideoParams, result *generation.VideoResult) {
+	expected := t.gen.ExpectedDuration(params.Model)
+	warnings := generation.IgnoredParams(params)
+	response := responses.BuildOperationResponse(
+		operation,
+		"",
+		result.PredictionID,
+		result.ID,
+		int(generation.SuggestedWait(expected, 0).Seconds()),
+		int(expected.Seconds()),
+		nil,
+		warnings,
+	)
+	fmt.Println(response)
+	for _, warning := range warnings {
+		info("Warning: %s\n", warning)
+	}
+	info("\n✓ Generation started. Prediction ID: %s\n", result.PredictionID)
+	info("Storage ID: %s\n", result.ID)
+	info("\nTo check status, run:\n")
+	info("  %s status %s\n", programName, result.PredictionID)
+}
+
+// autoModel picks the best registered model for -model auto
+func autoModel(gen *generation.Generator, operation, mode, resolution string) string {
+	candidates, err := gen.RecommendModel(generation.ModelConstraints{Mode: mode, Resolution: resolution})
+	if err != nil {
+		fatal(operation, "invalid_parameters", nil, "Model auto: %v", err)
+	}
+	info("Model auto selected %s\n", candidates[0].Model)
+	return candidates[0].Model
+}
+
+// resolveGeneration accepts a prediction ID or a storage ID and returns
+// both. The storage ID is empty for predictions not stored here.
+func resolveGeneration(store *storage.Storage, id string) (predictionID, storageID string) {
+	if metadata, err := store.LoadMetadata(id); err == nil {
+		if predictionID, _ := metadata["prediction_id"].(string); predictionID != "" {
+			return predictionID, id
+		}
+	}
+	storageID, _ = store.FindByPrediction(id)
+	return id, storageID
+}
+
+// runStatus checks a generation, downloading the video once it completed
+func runStatus(cmd command, args []string) {
+	fs := cmd.flagSet()
+	waitTime := fs.Duration("wait-time", 60*time.Second, "How long to wait for the generation before reporting it still running")
+	fs.Parse(args)
+	requireArgs(fs, 1, 1)
+
+	t := openTerminal(false)
+	defer t.close()
+
+	predictionID, storageID := resolveGeneration(t.store, fs.Arg(0))
+	if storageID != "" {
+		if metadata, err := t.store.LoadMetadata(storageID); err == nil && metadata["status"] == "completed" {
+			if path, err := t.store.OutputPath(storageID); err == nil {
+				metrics, _ := metadata["metrics"].(map[string]interface{})
+				printCompleted(storageID, predictionID, path, metrics)
+				return
+			}
+		}
+	}
+	runContinue(context.Background(), t.gen, predictionID, storageID, *waitTime)
+}
+
+func runContinue(ctx context.Context, gen *generation.Generator, predictionID, storageID string, waitTime time.Duration) {
+	info("Checking status of prediction %s...\n", predictionID)
+
+	// A prediction with no local record is recovered into a new storage
+	// folder, so its video has somewhere to go
+	if storageID == "" {
+		storageID = recoverPrediction(ctx, gen, predictionID, generation.VideoParams{})
+	}
+
+	result, err := gen.ContinueGeneration(ctx, predictionID, storageID, waitTime)
+	if err != nil {
+		// Check if it's still processing
+		if result != nil && isPending(result.Status) {
+			printPending(gen, predictionID, storageID)
+			return
+		}
+		fatal("continue_operation", client.ErrorType(err, "operation_failed"), failureDetails(predictionID, storageID, result), "Failed to check status: %v", err)
+	}
+
+	if result.Status == "completed" && result.FilePath != "" {
+		printCompleted(result.ID, result.PredictionID, result.FilePath, resultMetrics(result))
+	} else {
+		printPending(gen, predictionID, storageID)
+	}
+}
+
+// runRecover adopts a prediction with no local record into storage and
+// downloads its video once it completed
+func runRecover(cmd command, args []string) {
+	fs := cmd.flagSet()
+	waitTime := fs.Duration("wait-time", 60*time.Second, "How long to wait for the generation before reporting it still running")
+	project := fs.String("project", "", "Group the video under <root>/<project>/")
+	outputDir := fs.String("output-dir", "", "Save the video under this directory instead of the storage root")
+	fs.Parse(args)
+	requireArgs(fs, 1, 1)
+
+	t := openTerminal(false)
+	defer t.close()
+
+	var params generation.VideoParams
+	if *project != "" {
+		if err := storage.ValidateProjectName(*project); err != nil {
+			fatal("recover_prediction", "invalid_parameters", nil, "%v", err)
+		}
+		params.Project = *project
+	}
+	if *outputDir != "" {
+		dir, err := storage.ValidateOutputDir(*outputDir, config.LoadAllowedOutputDirs())
+		if err != nil {
+			fatal("recover_prediction", "invalid_parameters", nil, "%v", err)
+		}
+		params.OutputDir = dir
+	}
+
+	ctx := context.Background()
+	predictionID := fs.Arg(0)
+	storageID := recoverPrediction(ctx, t.gen, predictionID, params)
+	runContinue(ctx, t.gen, predictionID, storageID, *waitTime)
+}
+
+// recoverPrediction rebuilds the storage record of a prediction, returning
+// its storage ID
+func recoverPrediction(ctx context.Context, gen *generation.Generator, predictionID string, params generation.VideoParams) string {
+	result, err := gen.RecoverPrediction(ctx, predictionID, params)
+	if err != nil {
+		fatal("recover_prediction", client.ErrorType(err, "recover_failed"), map[string]interface{}{"prediction_id": predictionID}, "Failed to recover prediction %s: %v", predictionID, err)
+	}
+	if result.Status == "" {
+		info("Prediction %s is already stored as storage ID %s\n", predictionID, result.ID)
+	} else {
+		info("Recovered prediction %s as storage ID %s\n", predictionID, result.ID)
+	}
+	return result.ID
+}
+
+// isPending reports whether a prediction is still running
+func isPending(status string) bool {
+	return status == types.StatusStarting || status == types.StatusProcessing
+}
+
+// printPending reports a generation that is still running
+func printPending(gen *generation.Generator, predictionID, storageID string) {
+	wait, remaining := gen.ContinueHints(storageID)
+	if jsonOutput {
+		fmt.Println(responses.BuildProcessingResponse("continue_operation", predictionID, storageID, int(wait.Seconds()), int(remaining.Seconds())))
+		return
+	}
+	fmt.Printf("Still processing... Try again later.\n")
+}
+
+// printCompleted reports a downloaded video
+func printCompleted(storageID, predictionID, path string, metrics map[string]interface{}) {
+	response := responses.BuildSuccessResponse(
+		"continue_operation",
+		storageID,
+		map[string]string{
+			"output": path,
+		},
+		nil,
+		map[string]string{},
+		map[string]interface{}{},
+		metrics,
+		predictionID,
+		nil,
+		nil,
+	)
+	fmt.Println(response)
+	info("\n✓ Video saved to: %s\n", path)
+}
+
+// resultMetrics returns the metrics reported for a downloaded video
+func resultMetrics(result *generation.VideoResult) map[string]interface{} {
+	return map[string]interface{}{
+		"generation_time": result.Metrics.GenerationTime,
+		"file_size":       result.Metrics.FileSize,
+	}
+}
+
+// failureDetails returns the error details of a failed generation, with the
+// tail of its prediction logs when there are any
+func failureDetails(predictionID, storageID string, result *generation.VideoResult) map[string]interface{} {
+	details := map[string]interface{}{
+		"prediction_id": predictionID,
+		"storage_id":    storageID,
+	}
+	if result != nil && result.Logs != "" {
+		details["logs_tail"] = storage.LogTail(result.Logs)
+	}
+	return details
+}
+
+// runList prints stored videos, newest first
+func runList(cmd command, args []string) {
+	fs := cmd.flagSet()
+	var filter storage.Filter
+	fs.StringVar(&filter.Status, "status", "", "Only videos with this status (completed, processing, failed, ...)")
+	fs.StringVar(&filter.Project, "project", "", "Only videos of this project")
+	fs.StringVar(&filter.SessionID, "session", "", "Only videos of this session")
+	fs.StringVar(&filter.Tag, "tag", "", "Only videos with this tag")
+	limit := fs.Int("limit", 20, "Maximum number of videos to list (0 lists all)")
+	fs.Parse(args)
+	requireArgs(fs, 0, 0)
+
+	t := openTerminal(true)
+	defer t.close()
+
+	records := t.store.ListRecords(filter)
+	if *limit > 0 && len(records) > *limit {
+		records = records[:*limit]
+	}
+	if jsonOutput {
+		videos := make([]types.VideoSummary, 0, len(records))
+		for _, record := range records {
+			videos = append(videos, t.store.Summary(record))
+		}
+		filters := filter.Summary()
+		filters["limit"] = *limit
+		fmt.Println(responses.BuildListResponse("list_videos", videos, filters))
+		return
+	}
+	if len(records) == 0 {
+		fmt.Println("No videos found")
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STORAGE ID\tSTATUS\tMODEL\tCREATED\tPROMPT")
+	for _, record := range records {
+		created := record.String("created_at")
+		if t, err := time.Parse(time.RFC3339, created); err == nil {
+			created = t.Local().Format("2006-01-02 15:04")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.StorageID, record.String("status"), record.ModelName(), created, truncate(record.Parameter("prompt"), 50))
+	}
+	w.Flush()
+}
+
+// truncate shortens s to at most n runes, marking the cut
+func truncate(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if runes := []rune(s); len(runes) > n {
+		return string(runes[:n-1]) + "…"
+	}
+	return s
+}
+
+// runModels prints the registered models
+func runModels(cmd command, args []string) {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+	requireArgs(fs, 0, 0)
+
+	rootFolder, err := config.RootFolder()
+	if err != nil {
+		log.Fatal(err)
+	}
+	configureModels(rootFolder)
+
+	aliases := make([]string, 0, len(generation.ModelConfigs))
+	for alias := range generation.ModelConfigs {
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	if jsonOutput {
+		models := make([]types.ModelInfo, 0, len(aliases))
+		for _, alias := range aliases {
+			model := generation.ModelConfigs[alias]
+			models = append(models, types.ModelInfo{
+				Alias:             alias,
+				ID:                model.ID,
+				Name:              model.Name,
+				Version:           model.Version,
+				Type:              model.Type,
+				DefaultResolution: model.DefaultRes,
+				MaxDuration:       model.MaxDuration,
+				Features:          model.Features,
+				Effects:           model.EffectNames(),
+				EstimatedCost:     model.Cost,
+				CostPerSecond:     model.CostPerSecond,
+			})
+		}
+		fmt.Println(responses.BuildCapabilitiesResponse(serverInfo(), nil, models, nil))
+		return
+	}
+
+	modes := map[string]string{"t2v": "text", "i2v": "image", "both": "text, image"}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MODEL\tNAME\tINPUT\tCOST\tFEATURES")
+	for _, alias := range aliases {
+		config := generation.ModelConfigs[alias]
+		fmt.Fprintf(w, "%s\t%s\t%s\t~$%.2f\t%s\n", alias, config.Name, modes[config.Type], config.Cost, strings.Join(config.Features, ", "))
+	}
+	w.Flush()
+	for _, alias := range aliases {
+		if effects := generation.ModelConfigs[alias].EffectNames(); len(effects) > 0 {
+			fmt.Printf("\nEffects for %s (-effect): %s\n", alias, strings.Join(effects, ", "))
+		}
+	}
+	fmt.Printf("\nDefaults: %s for generate, %s for i2v. Use -model auto to pick one.\n", generation.DefaultTextModel(), generation.DefaultImageModel())
+}
+
+// runCancel cancels a running generation
+func runCancel(cmd command, args []string) {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+	requireArgs(fs, 1, 1)
+
+	t := openTerminal(false)
+	defer t.close()
+
+	predictionID, _ := resolveGeneration(t.store, fs.Arg(0))
+	storageID, err := t.gen.CancelGeneration(context.Background(), predictionID)
+	if err != nil {
+		fatal("cancel", client.ErrorType(err, "cancel_failed"), map[string]interface{}{"prediction_id": predictionID}, "Failed to cancel prediction %s: %v", predictionID, err)
+	}
+	if jsonOutput {
+		fmt.Println(responses.BuildInfoResponse("cancel", storageID, types.StatusCanceled, nil, nil, nil, nil, nil, predictionID))
+		return
+	}
+	fmt.Printf("✓ Canceled prediction %s\n", predictionID)
+	if storageID != "" {
+		fmt.Printf("Storage ID: %s\n", storageID)
+	}
+}
+
+// runPrune deletes generations with the given statuses, optionally only
+// older ones
+func runPrune(cmd command, args []string) {
+	fs := cmd.flagSet()
+	statuses := fs.String("status", "failed,canceled", "Comma-separated statuses to delete, or any")
+	olderThan := fs.Duration("older-than", 0, "Only delete generations created longer ago than this, e.g. 720h")
+	project := fs.String("project", "", "Only delete generations of this project")
+	dryRun := fs.Bool("dry-run", false, "List what would be deleted without deleting it")
+	fs.Parse(args)
+	requireArgs(fs, 0, 0)
+
+	t := openTerminal(true)
+	defer t.close()
+
+	if len(splitList(*statuses)) == 0 {
+		fatal("delete_videos", "invalid_parameters", nil, "-status must name at least one status")
+	}
+
+	records, errs := t.store.Prune(storage.PruneOptions{
+		Statuses:  splitList(*statuses),
+		OlderThan: *olderThan,
+		Project:   *project,
+		DryRun:    *dryRun,
+	})
+
+	var freed int64
+	deleted := make([]types.VideoSummary, 0, len(records))
+	for _, record := range records {
+		summary := t.store.Summary(record)
+		if *dryRun {
+			info("Would delete %s (%s, %s)\n", record.StorageID, record.String("status"), truncate(record.Parameter("prompt"), 40))
+		} else {
+			summary = types.VideoSummary{StorageID: record.StorageID, SessionID: summary.SessionID, Project: summary.Project, Status: "deleted"}
+		}
+		deleted = append(deleted, summary)
+		freed += record.Size
+	}
+	failures := make(map[string]interface{})
+	for storageID, err := range errs {
+		if !jsonOutput {
+			fmt.Fprintf(os.Stderr, "Failed to delete %s: %v\n", storageID, err)
+		}
+		failures[storageID] = err.Error()
+	}
+
+	if jsonOutput {
+		filters := map[string]interface{}{
+			"status":      splitList(*statuses),
+			"dry_run":     *dryRun,
+			"freed_bytes": freed,
+		}
+		if *olderThan > 0 {
+			filters["older_than"] = olderThan.String()
+		}
+		if *project != "" {
+			filters["project"] = *project
+		}
+		if len(failures) > 0 {
+			filters["failures"] = failures
+		}
+		fmt.Println(responses.BuildListResponse("delete_videos", deleted, filters))
+		return
+	}
+
+	verb := "Deleted"
+	if *dryRun {
+		verb = "Would delete"
+	}
+	fmt.Printf("%s %d generations (%.1f MB)\n", verb, len(records), float64(freed)/(1024*1024))
+}
+
+// runExport bundles stored videos, given by storage ID or selected by
+// project or session
+func runExport(cmd command, args []string) {
+	fs := cmd.flagSet()
+	format := fs.String("format", storage.ExportZip, "zip or directory")
+	dir := fs.String("dir", "", "Destination directory (default: <root>/exports)")
+	name := fs.String("name", "", "Name of the archive or folder (default: replicate-videos-<timestamp>)")
+	var filter storage.Filter
+	fs.StringVar(&filter.Project, "project", "", "Export every video of this project")
+	fs.StringVar(&filter.SessionID, "session", "", "Export every video of this session")
+	fs.Parse(args)
+
+	storageIDs := fs.Args()
+	if len(storageIDs) == 0 && filter.Project == "" && filter.SessionID == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	t := openTerminal(true)
+	defer t.close()
+
+	if len(storageIDs) == 0 {
+		for _, record := range t.store.ListRecords(filter) {
+			if record.String("status") == "completed" {
+				storageIDs = append(storageIDs, record.StorageID)
+			}
+		}
+	}
+
+	result, err := t.store.Export(storageIDs, *dir, *name, *format)
+	if err != nil {
+		fatal("export_videos", "export_failed", nil, "Export failed: %v", err)
+	}
+	if jsonOutput {
+		exported := make([]string, 0, len(result.Manifest.Videos))
+		for _, video := range result.Manifest.Videos {
+			exported = append(exported, video.StorageID)
+		}
+		fmt.Println(responses.BuildExportResponse(result.Format, result.Path, result.Size, exported))
+		return
+	}
+	fmt.Printf("✓ Exported %d videos to %s (%.1f MB)\n", result.Manifest.Count, result.Path, float64(result.Size)/(1024*1024))
+}
+
+// runImport copies an existing video into storage
+func runImport(cmd command, args []string) {
+	fs := cmd.flagSet()
+	description := fs.String("description", "", "Description stored as the prompt, so search finds it")
+	project := fs.String("project", "", "Group the video under <root>/<project>/")
+	fs.Parse(args)
+	requireArgs(fs, 1, 1)
+
+	t := openTerminal(true)
+	defer t.close()
+
+	path := fs.Arg(0)
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	if *project != "" {
+		if err := storage.ValidateProjectName(*project); err != nil {
+			fatal("import_video", "invalid_parameters", nil, "%v", err)
+		}
+	}
+
+	storageID, metadata, err := t.store.ImportLocalVideo(context.Background(), path, storage.ImportOptions{
+		Description: *description,
+		Project:     *project,
+	})
+	if err != nil {
+		fatal("import_video", "import_failed", map[string]interface{}{"path": path}, "Failed to import video: %v", err)
+	}
+	if jsonOutput {
+		summary := t.store.Summary(storage.Record{StorageID: storageID, Metadata: metadata})
+		parameters := map[string]interface{}{
+			"source_path": metadata["source_path"],
+		}
+		if *description != "" {
+			parameters["description"] = *description
+		}
+		metrics, _ := metadata["metrics"].(map[string]interface{})
+		fmt.Println(responses.BuildSuccessResponse(
+			"import_video",
+			storageID,
+			summary.Paths,
+			nil,
+			map[string]string{"id": "imported", "name": summary.Model},
+			parameters,
+			metrics,
+			"",
+			nil,
+			nil,
+		))
+		return
+	}
+
+	fmt.Printf("✓ Video imported. Storage ID: %s\n", storageID)
+	fmt.Printf("Saved to: %s\n", t.store.GetStoragePath(storageID))
+}
+
+// runHealth runs the self-test, exiting 1 when a check failed
+func runHealth(cmd command, args []string) {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+	requireArgs(fs, 0, 0)
+
+	t := openTerminal(true)
+	defer t.close()
+
+	report := t.gen.HealthCheck(context.Background())
+	if jsonOutput {
+		fmt.Println(responses.BuildHealthCheckResponse(report))
+	} else {
+		marks := map[string]string{types.HealthOK: "✓", types.HealthWarning: "!", types.HealthError: "✗"}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, check := range report.Checks {
+			fmt.Fprintf(w, "%s %s\t%s\n", marks[check.Status], check.Name, check.Message)
+		}
+		w.Flush()
+
+		var available, unavailable []string
+		for capability, ok := range report.Capabilities {
+			if ok {
+				available = append(available, capability)
+			} else {
+				unavailable = append(unavailable, capability)
+			}
+		}
+		sort.Strings(available)
+		sort.Strings(unavailable)
+		fmt.Printf("\nWorking: %s\n", strings.Join(available, ", "))
+		if len(unavailable) > 0 {
+			fmt.Printf("Unavailable: %s\n", strings.Join(unavailable, ", "))
+		}
+	}
+
+	if !report.Healthy {
+		t.exit(1)
+	}
+}
+
+func runAsyncTest(cmd command, args []string) {
+	fs := cmd.flagSet()
+	fs.Parse(args)
+	requireArgs(fs, 0, 0)
+
+	t := openTerminal(false)
+	defer t.close()
+	ctx := context.Background()
+	gen := t.gen
+	fmt.Println("\n=== Testing Async Video Generation Flow ===")
+	fmt.Println()
+
+	// Step 1: Start generation
+	fmt.Println("Step 1: Starting text-to-video generation...")
+	params := generation.VideoParams{
+		Prompt:      "A serene lake at sunset with birds flying overhead",
+		Model:       "wan-t2v-fast",
+		Resolution:  "480p",
+		AspectRatio: "16:9",
+	}
+
+	result, err := gen.GenerateTextToVideo(ctx, params)
+	if err != nil {
+		log.Fatalf("Failed to start generation: %v", err)
+	}
+
+	fmt.Printf("✓ Generation started\n")
+	fmt.Printf("  Prediction ID: %s\n", result.PredictionID)
+	fmt.Printf("  Storage ID: %s\n", result.ID)
+	fmt.Printf("  Status: %s\n", result.Status)
+	fmt.Println()
+
+	// Step 2: Wait and check status
+	fmt.Println("Step 2: Waiting 10 seconds before checking status...")
+	time.Sleep(10 * time.Second)
+
+	fmt.Println("Step 3: Checking generation status...")
+	finalResult, err := gen.ContinueGeneration(ctx, result.PredictionID, result.ID, 2*time.Minute)
+	if err != nil {
+		fmt.Printf("Generation not complete yet: %v\n", err)
+		if finalResult != nil {
+			fmt.Printf("Current status: %s\n", finalResult.Status)
+		}
+		fmt.Println("\nTry running the continue command manually:")
+		fmt.Printf("  %s status %s\n", programName, result.PredictionID)
+		return
+	}
+
+	// Step 3: Show results
+	if finalResult.Status == "completed" && finalResult.FilePath != "" {
+		fmt.Printf("✓ Video generation completed!\n")
+		fmt.Printf("  Output path: %s\n", finalResult.FilePath)
+		fmt.Printf("  File size: %d bytes\n", finalResult.Metrics.FileSize)
+		fmt.Printf("  Generation time: %.2f seconds\n", finalResult.Metrics.GenerationTime)
+
+		// Print formatted response
+		response := responses.BuildSuccessResponse(
+			"async_test",
+			finalResult.ID,
+			map[string]string{
+				"output": finalResult.FilePath,
+			},
+			nil,
+			map[string]string{
+				"name": "wan-t2v-fast",
+			},
+			convertParamsToMap(params),
+			map[string]interface{}{
+				"generation_time": finalResult.Metrics.GenerationTime,
+				"file_size":       finalResult.Metrics.FileSize,
+			},
+			finalResult.PredictionID,
+			nil,
+			nil,
+		)
+		fmt.Println("\nFormatted response:")
+		fmt.Println(response)
+	} else {
+		fmt.Printf("Unexpected status: %s\n", finalResult.Status)
+	}
+
+	fmt.Println("\n=== Async Test Complete ===")
+}
+
+// Helper to convert VideoParams to map for response
+func convertParamsToMap(p generation.VideoParams) map[string]interface{} {
+	params := make(map[string]interface{})
+	params["prompt"] = p.Prompt
+	if p.Resolution != "" {
+		params["resolution"] = p.Resolution
+	}
+	if p.AspectRatio != "" {
+		params["aspect_ratio"] = p.AspectRatio
+	}
+	if p.Duration > 0 {
+		params["duration"] = p.Duration
+	}
+	if p.NegativePrompt != "" {
+		params["negative_prompt"] = p.NegativePrompt
+	}
+	if p.Effect != "" {
+		params["effect"] = p.Effect
+	}
+	return params
+}
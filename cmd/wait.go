@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// waitPollInterval is how long each status check of -wait blocks before the
+// progress line is refreshed
+const waitPollInterval = 5 * time.Second
+
+// waitForGeneration polls a started generation until it finishes, showing
+// its progress on stderr, and downloads the video. It exits 1 when the
+// generation fails and 130 when interrupted; the prediction keeps running on
+// Replicate after an interrupt.
+func waitForGeneration(t *terminal, operation string, params generation.VideoParams, started *generation.VideoResult) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	info("Prediction ID: %s\n", started.PredictionID)
+	info("Storage ID: %s\n", started.ID)
+	for _, warning := range generation.IgnoredParams(params) {
+		info("Warning: %s\n", warning)
+	}
+
+	progress := newProgressLine(t.gen.ExpectedDuration(params.Model))
+	status := types.StatusStarting
+	for {
+		progress.update(status)
+		result, err := t.gen.ContinueGeneration(ctx, started.PredictionID, started.ID, waitPollInterval)
+		if err == nil {
+			progress.finish(result.Status)
+			printCompleted(result.ID, result.PredictionID, result.FilePath, resultMetrics(result))
+			return
+		}
+		if result != nil && isPending(result.Status) {
+			status = result.Status
+			continue
+		}
+
+		if errors.Is(err, context.Canceled) {
+			progress.finish("interrupted")
+			if jsonOutput {
+				wait, remaining := t.gen.ContinueHints(started.ID)
+				fmt.Println(responses.BuildProcessingResponse(operation, started.PredictionID, started.ID, int(wait.Seconds()), int(remaining.Seconds())))
+			}
+			fmt.Fprintf(os.Stderr, "Stopped waiting; the generation keeps running. To check status, run:\n  %s status %s\n", programName, started.PredictionID)
+			t.exit(130)
+		}
+		if result != nil && result.Status != "" {
+			status = result.Status
+		} else {
+			status = types.StatusFailed
+		}
+		progress.finish(status)
+		t.close()
+		if !jsonOutput && result != nil && result.Logs != "" {
+			fmt.Fprintf(os.Stderr, "Prediction logs:\n%s\n\n", strings.TrimSpace(result.Logs))
+		}
+		fatal(operation, client.ErrorType(err, "generation_failed"), failureDetails(started.PredictionID, started.ID, result), "Generation %s failed: %v", started.ID, err)
+	}
+}
+
+// progressLine shows the elapsed time and Replicate status of a generation
+// on stderr. On a terminal one line is rewritten in place; otherwise, e.g.
+// when stderr goes to a log file, a line is printed per status change.
+type progressLine struct {
+	start    time.Time
+	expected time.Duration
+	tty      bool
+	status   string
+	width    int
+}
+
+func newProgressLine(expected time.Duration) *progressLine {
+	info, err := os.Stderr.Stat()
+	return &progressLine{
+		start:    time.Now(),
+		expected: expected,
+		tty:      err == nil && info.Mode()&os.ModeCharDevice != 0,
+	}
+}
+
+// update shows the current status
+func (p *progressLine) update(status string) {
+	if !p.tty {
+		if status != p.status {
+			fmt.Fprintln(os.Stderr, p.text(status))
+		}
+		p.status = status
+		return
+	}
+	line := p.text(status)
+	// Pad with spaces to clear what's left of a longer previous line
+	fmt.Fprintf(os.Stderr, "\r%-*s", p.width, line)
+	p.width = len(line)
+	p.status = status
+}
+
+// finish shows the final status and ends the line
+func (p *progressLine) finish(status string) {
+	p.update(status)
+	if p.tty {
+		fmt.Fprintln(os.Stderr)
+	}
+}
+
+func (p *progressLine) text(status string) string {
+	elapsed := time.Since(p.start).Round(time.Second)
+	if p.expected > 0 {
+		return fmt.Sprintf("[%s] %s (usually takes about %s)", elapsed, status, p.expected.Round(time.Second))
+	}
+	return fmt.Sprintf("[%s] %s", elapsed, status)
+}
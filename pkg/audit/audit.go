@@ -0,0 +1,137 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// maxStringLength caps string parameter values in audit records
+const maxStringLength = 1000
+
+// Query selects records from the log
+type Query struct {
+	Namespace string    // Only this namespace ("" is the default namespace)
+	Tool      string    // Only this tool
+	Status    string    // Only this result status
+	Since     time.Time // Only records at or after this time
+	Limit     int       // Most records returned, newest first
+}
+
+// Log appends records as JSON lines to a file. A nil Log records nothing.
+type Log struct {
+	mu   sync.Mutex
+	path string
+}
+
+// Open creates a log appending to path, creating its directory if needed
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	return &Log{path: path}, nil
+}
+
+// Path returns the log file path
+func (l *Log) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Append writes one record. Parameters are copied with data URLs replaced
+// and long strings truncated, so image bytes never reach the log.
+func (l *Log) Append(record types.AuditRecord) error {
+	if l == nil {
+		return nil
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	if record.Parameters != nil {
+		record.Parameters, _ = responses.Sanitize(record.Parameters, maxStringLength).(map[string]interface{})
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Records hold prompts, so only the owner may read a new log
+	file, err := os.OpenFile(l.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+// Recent returns the records matching the query, newest first
+func (l *Log) Recent(query Query) ([]types.AuditRecord, error) {
+	if l == nil {
+		return nil, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return []types.AuditRecord{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var matched []types.AuditRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var record types.AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue // Skip lines damaged by a crash mid-write
+		}
+		if record.Namespace != query.Namespace {
+			continue
+		}
+		if query.Tool != "" && record.Tool != query.Tool {
+			continue
+		}
+		if query.Status != "" && record.Status != query.Status {
+			continue
+		}
+		if !query.Since.IsZero() && record.Timestamp.Before(query.Since) {
+			continue
+		}
+		matched = append(matched, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	// Newest records are at the end of the file
+	records := make([]types.AuditRecord, 0, len(matched))
+	for i := len(matched) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(records) >= query.Limit {
+			break
+		}
+		records = append(records, matched[i])
+	}
+	return records, nil
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// Cassette holds recorded Replicate API interactions, in the order they
+// happened
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded API request and its response
+type Interaction struct {
+	Request    RecordedRequest  `json:"request"`
+	Response   RecordedResponse `json:"response"`
+	RecordedAt time.Time        `json:"recorded_at"`
+}
+
+// RecordedRequest is the part of a request used to match it on replay. The
+// Authorization header is never recorded.
+type RecordedRequest struct {
+	Method   string          `json:"method"`
+	URL      string          `json:"url"`
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"body_text,omitempty"` // Body that isn't JSON
+}
+
+// RecordedResponse is a response as Replicate sent it
+type RecordedResponse struct {
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	BodyText string            `json:"body_text,omitempty"` // Body that isn't JSON, e.g. a proxy's error page
+}
+
+// Recorder is an http.RoundTripper that records API interactions to a
+// cassette file, or replays them from one without touching the network
+type Recorder struct {
+	path string
+	mode string
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// NewRecorder wraps next in a recorder for the cassette at path. In
+// config.CassetteRecord mode every interaction is appended to the file as it
+// completes; in config.CassetteReplay mode requests are answered from the
+// file, and next is never used.
+func NewRecorder(path, mode string, next http.RoundTripper) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, next: next}
+	switch mode {
+	case config.CassetteRecord:
+		logging.Info("recording Replicate API interactions", "cassette", path)
+	case config.CassetteReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+		logging.Info("replaying Replicate API interactions", "cassette", path, "interactions", len(r.cassette.Interactions))
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+	return r, nil
+}
+
+// RoundTrip records or replays one request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{Method: req.Method, URL: req.URL.String()}
+	recorded.Body, recorded.BodyText = encodeBody(body)
+
+	if r.mode == config.CassetteReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+// replay answers with the first unused interaction for the same method, URL
+// and body, so repeated polls of a prediction get the recorded sequence
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !sameRequest(interaction.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		body := interaction.Response.Body
+		if len(body) == 0 {
+			body = []byte(interaction.Response.BodyText)
+		}
+		resp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        make(http.Header),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}
+		for name, value := range interaction.Response.Headers {
+			resp.Header.Set(name, value)
+		}
+		return resp, nil
+	}
+	return nil, fmt.Errorf("no recorded interaction left for %s %s in cassette %s", recorded.Method, recorded.URL, r.path)
+}
+
+// record sends the request and saves it with its response
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	next := r.next
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	resp, err := next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Request:    recorded,
+		Response:   RecordedResponse{Status: resp.StatusCode},
+		RecordedAt: time.Now().UTC(),
+	}
+	interaction.Response.Body, interaction.Response.BodyText = encodeBody(body)
+	// Only headers the client reads are kept
+	for _, name := range []string{"Content-Type", "Retry-After"} {
+		if value := resp.Header.Get(name); value != "" {
+			if interaction.Response.Headers == nil {
+				interaction.Response.Headers = make(map[string]string)
+			}
+			interaction.Response.Headers[name] = value
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	// Saved after each interaction, so a crash keeps what led up to it
+	if err := r.save(); err != nil {
+		logging.Error("failed to save cassette", "cassette", r.path, "error", err)
+	}
+	return resp, nil
+}
+
+// save writes the cassette, which holds prompts and output URLs but no
+// API token, readable only by its owner
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0600)
+}
+
+// sameRequest reports whether a recorded request matches, comparing bodies
+// as JSON so key order and whitespace don't matter
+func sameRequest(a, b RecordedRequest) bool {
+	if a.Method != b.Method || a.URL != b.URL || a.BodyText != b.BodyText {
+		return false
+	}
+	if len(a.Body) == 0 || len(b.Body) == 0 {
+		return len(a.Body) == len(b.Body)
+	}
+	var av, bv interface{}
+	if json.Unmarshal(a.Body, &av) != nil || json.Unmarshal(b.Body, &bv) != nil {
+		return bytes.Equal(a.Body, b.Body)
+	}
+	ac, _ := json.Marshal(av)
+	bc, _ := json.Marshal(bv)
+	return bytes.Equal(ac, bc)
+}
+
+// encodeBody keeps a JSON body as JSON, so the cassette stays readable, and
+// returns anything else as text
+func encodeBody(body []byte) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	if json.Valid(body) {
+		return json.RawMessage(body), ""
+	}
+	return nil, string(body)
+}
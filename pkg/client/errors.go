@@ -0,0 +1,121 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Kinds of Replicate failures callers can react to. Errors returned by the
+// client wrap one of these when the failure is recognized, so they can be
+// matched with errors.Is.
+var (
+	ErrBilling        = errors.New("billing issue")
+	ErrRateLimited    = errors.New("rate limited")
+	ErrContentFlagged = errors.New("content flagged")
+	ErrModelNotFound  = errors.New("model not found")
+	ErrTimeout        = errors.New("timed out")
+	ErrCanceled       = errors.New("canceled")
+
+	// ErrTransient is a model failure from the hardware or infrastructure
+	// running it, e.g. running out of GPU memory, that resubmitting the same
+	// input may not hit
+	ErrTransient = errors.New("transient failure")
+)
+
+// Error is a Replicate failure of a known kind. The message is kept as
+// Replicate (or the client) reported it.
+type Error struct {
+	Kind       error // One of the Err* kinds
+	StatusCode int   // HTTP status, when the failure came from a response
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Kind
+}
+
+// Phrases in Replicate error messages that identify a failure kind. Model
+// errors are free text, so these are matched case-insensitively.
+var errorPhrases = []struct {
+	kind    error
+	phrases []string
+}{
+	{ErrContentFlagged, []string{"nsfw", "flagged as sensitive", "safety filter", "content policy", "(e005)", "moderation"}},
+	{ErrBilling, []string{"insufficient credit", "billing", "payment required"}},
+	{ErrRateLimited, []string{"rate limit", "throttled", "too many requests"}},
+	{ErrTransient, []string{"out of memory", "cuda error", "prediction interrupted", "connection reset", "connection refused",
+		"internal server error", "service unavailable", "temporarily unavailable", "worker died", "worker exited", "timed out", "please retry"}},
+}
+
+// classifyMessage returns the kind of failure a Replicate error message
+// describes, or nil if it isn't recognized
+func classifyMessage(message string) error {
+	lower := strings.ToLower(message)
+	for _, entry := range errorPhrases {
+		for _, phrase := range entry.phrases {
+			if strings.Contains(lower, phrase) {
+				return entry.kind
+			}
+		}
+	}
+	return nil
+}
+
+// classifyStatus returns the kind of failure an HTTP status reports, or nil.
+// A 404 only means a missing model on requests that name one.
+func classifyStatus(statusCode int, modelRequest bool) error {
+	switch statusCode {
+	case http.StatusPaymentRequired:
+		return ErrBilling
+	case http.StatusTooManyRequests:
+		return ErrRateLimited
+	case http.StatusNotFound:
+		if modelRequest {
+			return ErrModelNotFound
+		}
+	}
+	return nil
+}
+
+// newError wraps message in an Error when the status code or the message
+// identifies its kind, and returns a plain error otherwise
+func newError(statusCode int, modelRequest bool, message string) error {
+	kind := classifyStatus(statusCode, modelRequest)
+	if kind == nil {
+		kind = classifyMessage(message)
+	}
+	if kind == nil {
+		return errors.New(message)
+	}
+	return &Error{Kind: kind, StatusCode: statusCode, Message: message}
+}
+
+// ErrorType maps an error onto the error type reported in tool responses,
+// returning fallback for errors of no known kind
+func ErrorType(err error, fallback string) string {
+	switch {
+	case err == nil:
+		return fallback
+	case errors.Is(err, ErrBilling):
+		return "billing"
+	case errors.Is(err, ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, ErrContentFlagged):
+		return "content_flagged"
+	case errors.Is(err, ErrModelNotFound):
+		return "model_not_found"
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, ErrCanceled), errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, ErrTransient):
+		return "transient"
+	}
+	return fallback
+}
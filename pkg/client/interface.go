@@ -9,8 +9,14 @@ import (
 
 // Client defines the interface for Replicate API client
 type Client interface {
-	CreatePrediction(ctx context.Context, modelVersion string, input map[string]interface{}) (*types.ReplicatePredictionResponse, error)
+	// CreatePrediction holds the request open up to wait for the prediction
+	// to finish (Replicate's Prefer: wait); 0 returns as soon as it is created
+	CreatePrediction(ctx context.Context, modelVersion string, input map[string]interface{}, wait time.Duration) (*types.ReplicatePredictionResponse, error)
 	GetPrediction(ctx context.Context, predictionID string) (*types.ReplicatePredictionResponse, error)
 	WaitForCompletion(ctx context.Context, predictionID string, timeout time.Duration) (*types.ReplicatePredictionResponse, error)
 	CancelPrediction(ctx context.Context, predictionID string) error
-}
\ No newline at end of file
+	CheckAccount(ctx context.Context) (*types.AccountStatus, error)
+	ListModelVersions(ctx context.Context, modelID string) ([]types.ReplicateModelVersion, error)
+	ListDeployments(ctx context.Context) ([]types.ReplicateDeployment, error)
+	Ping(ctx context.Context) error
+}
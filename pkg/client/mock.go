@@ -0,0 +1,323 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+	"github.com/google/uuid"
+)
+
+const (
+	// DefaultMockDelay is how long a mock prediction takes to succeed
+	DefaultMockDelay = 10 * time.Second
+
+	// mockVersion is the version of every simulated model and deployment
+	mockVersion = "mock0000000000000000000000000000000000000000000000000000000000000"
+)
+
+// MockClient simulates the Replicate prediction lifecycle without network access
+type MockClient struct {
+	mu          sync.Mutex
+	predictions map[string]*mockPrediction
+	delay       time.Duration
+	output      string
+}
+
+// mockPrediction tracks a simulated prediction
+type mockPrediction struct {
+	response  types.ReplicatePredictionResponse
+	createdAt time.Time
+	canceled  bool
+}
+
+// NewMockClient creates a mock client. Predictions succeed after delay and
+// return output, which may be a URL or a local file path, or a generated
+// sample video when it is empty.
+func NewMockClient(delay time.Duration, output string) (*MockClient, error) {
+	if delay < 0 {
+		delay = 0
+	}
+	if output == "" {
+		sample, err := writeMockSample()
+		if err != nil {
+			return nil, err
+		}
+		output = sample
+	}
+	// Local files are returned as file:// URLs so storage can copy them
+	if !strings.Contains(output, "://") {
+		if abs, err := filepath.Abs(output); err == nil {
+			output = abs
+		}
+		output = "file://" + output
+	}
+
+	return &MockClient{
+		predictions: make(map[string]*mockPrediction),
+		delay:       delay,
+		output:      output,
+	}, nil
+}
+
+// CreatePrediction registers a new simulated prediction. With a wait it
+// returns once the prediction succeeds or the wait passes, like Replicate.
+func (c *MockClient) CreatePrediction(ctx context.Context, modelVersion string, input map[string]interface{}, wait time.Duration) (*types.ReplicatePredictionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	// Encode the creation time in the ID so other processes (e.g. the CLI
+	// continue command) can reconstruct the prediction's progress
+	id := fmt.Sprintf("mock-%d-%s", now.UnixNano(), strings.ReplaceAll(uuid.New().String(), "-", "")[:8])
+
+	model, version, _ := strings.Cut(modelVersion, ":")
+	if strings.HasPrefix(modelVersion, DeploymentPrefix) {
+		model, version = "", mockVersion
+	}
+
+	prediction := &mockPrediction{
+		response: types.ReplicatePredictionResponse{
+			ID:        id,
+			Model:     model,
+			Version:   version,
+			Status:    types.StatusStarting,
+			Input:     input,
+			CreatedAt: now.Format(time.RFC3339),
+			URLs: map[string]string{
+				"get":    fmt.Sprintf("mock://predictions/%s", id),
+				"cancel": fmt.Sprintf("mock://predictions/%s/cancel", id),
+			},
+		},
+		createdAt: now,
+	}
+
+	c.mu.Lock()
+	c.predictions[id] = prediction
+	c.mu.Unlock()
+
+	if wait > 0 {
+		select {
+		case <-time.After(min(wait, c.delay)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if wait > 0 {
+		c.advance(prediction)
+	}
+	response := prediction.response
+	return &response, nil
+}
+
+// GetPrediction returns the simulated status of a prediction
+func (c *MockClient) GetPrediction(ctx context.Context, predictionID string) (*types.ReplicatePredictionResponse, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prediction, ok := c.lookup(predictionID)
+	if !ok {
+		return nil, fmt.Errorf("API error (status 404): prediction %s not found", predictionID)
+	}
+
+	c.advance(prediction)
+	response := prediction.response
+	return &response, nil
+}
+
+// WaitForCompletion waits for a simulated prediction to complete or timeout
+func (c *MockClient) WaitForCompletion(ctx context.Context, predictionID string, timeout time.Duration) (*types.ReplicatePredictionResponse, error) {
+	var deadline time.Time
+	if timeout == 0 {
+		if d, ok := ctx.Deadline(); ok {
+			deadline = d
+		} else {
+			deadline = time.Now().Add(10 * time.Minute)
+		}
+	} else {
+		deadline = time.Now().Add(timeout)
+	}
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		prediction, err := c.GetPrediction(ctx, predictionID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch prediction.Status {
+		case types.StatusSucceeded:
+			return prediction, nil
+		case types.StatusCanceled:
+			return prediction, &Error{Kind: ErrCanceled, Message: "prediction was canceled"}
+		}
+
+		if time.Now().After(deadline) {
+			return prediction, &Error{Kind: ErrTimeout, Message: fmt.Sprintf("operation timed out after %v", timeout)}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// CancelPrediction cancels a simulated prediction
+func (c *MockClient) CancelPrediction(ctx context.Context, predictionID string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prediction, ok := c.lookup(predictionID)
+	if !ok {
+		return fmt.Errorf("failed to cancel prediction (status 404): prediction %s not found", predictionID)
+	}
+
+	c.advance(prediction)
+	if prediction.response.Status != types.StatusSucceeded {
+		prediction.canceled = true
+		prediction.response.Status = types.StatusCanceled
+		prediction.response.CompletedAt = time.Now().Format(time.RFC3339)
+	}
+
+	return nil
+}
+
+// CheckAccount reports a valid mock account in good standing
+func (c *MockClient) CheckAccount(ctx context.Context) (*types.AccountStatus, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &types.AccountStatus{
+		TokenValid:    true,
+		BillingStatus: types.BillingOK,
+		Account: &types.ReplicateAccount{
+			Type:     "user",
+			Username: "mock",
+			Name:     "Mock Account",
+		},
+		Message: "mock mode: no requests are sent to Replicate",
+	}, nil
+}
+
+// Ping always succeeds, since the mock needs no network
+func (c *MockClient) Ping(ctx context.Context) error {
+	return ctx.Err()
+}
+
+// ListModelVersions returns a single simulated version with a minimal schema
+func (c *MockClient) ListModelVersions(ctx context.Context, modelID string) ([]types.ReplicateModelVersion, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return []types.ReplicateModelVersion{{
+		ID:        mockVersion,
+		CreatedAt: time.Now().Format(time.RFC3339),
+		OpenAPISchema: map[string]interface{}{
+			"components": map[string]interface{}{
+				"schemas": map[string]interface{}{
+					"Input": map[string]interface{}{
+						"type":     "object",
+						"required": []string{"prompt"},
+						"properties": map[string]interface{}{
+							"prompt": map[string]interface{}{"type": "string"},
+						},
+					},
+				},
+			},
+		},
+	}}, nil
+}
+
+// ListDeployments returns a single simulated deployment of the fast Wan
+// text-to-video model
+func (c *MockClient) ListDeployments(ctx context.Context) ([]types.ReplicateDeployment, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	deployment := types.ReplicateDeployment{
+		Owner: "mock",
+		Name:  "wan-t2v-fast",
+		CurrentRelease: types.ReplicateDeploymentRelease{
+			Number:    1,
+			Model:     "wan-video/wan-2.2-t2v-fast",
+			Version:   mockVersion,
+			CreatedAt: time.Now().Format(time.RFC3339),
+		},
+	}
+	deployment.CurrentRelease.Configuration.Hardware = "gpu-a100-large"
+	deployment.CurrentRelease.Configuration.MaxInstances = 1
+	return []types.ReplicateDeployment{deployment}, nil
+}
+
+// lookup finds a prediction by ID, reconstructing it from the creation time
+// encoded in the ID when it was created by another process. Caller must hold c.mu.
+func (c *MockClient) lookup(predictionID string) (*mockPrediction, bool) {
+	if prediction, ok := c.predictions[predictionID]; ok {
+		return prediction, true
+	}
+
+	parts := strings.Split(predictionID, "-")
+	if len(parts) != 3 || parts[0] != "mock" {
+		return nil, false
+	}
+	nanos, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	createdAt := time.Unix(0, nanos)
+	prediction := &mockPrediction{
+		response: types.ReplicatePredictionResponse{
+			ID:        predictionID,
+			Status:    types.StatusStarting,
+			CreatedAt: createdAt.Format(time.RFC3339),
+		},
+		createdAt: createdAt,
+	}
+	c.predictions[predictionID] = prediction
+	return prediction, true
+}
+
+// advance moves a prediction through starting -> processing -> succeeded
+// based on how long ago it was created. Caller must hold c.mu.
+func (c *MockClient) advance(prediction *mockPrediction) {
+	if prediction.canceled || prediction.response.Status == types.StatusSucceeded {
+		return
+	}
+
+	elapsed := time.Since(prediction.createdAt)
+	switch {
+	case elapsed >= c.delay:
+		prediction.response.Status = types.StatusSucceeded
+		prediction.response.Output = c.output
+		prediction.response.StartedAt = prediction.createdAt.Add(c.delay / 4).Format(time.RFC3339)
+		prediction.response.CompletedAt = prediction.createdAt.Add(c.delay).Format(time.RFC3339)
+		prediction.response.Logs = "mock: generation complete\n"
+	case elapsed >= c.delay/4:
+		prediction.response.Status = types.StatusProcessing
+		prediction.response.StartedAt = prediction.createdAt.Add(c.delay / 4).Format(time.RFC3339)
+		// Log a progress bar like the real models' tqdm output
+		steps := int(50 * elapsed / c.delay)
+		prediction.response.Logs = fmt.Sprintf("mock: generating frames\n%3d%%|%-10s| %d/50 [%s]\n", steps*2, strings.Repeat("#", steps/5), steps, elapsed.Round(time.Second))
+	}
+}
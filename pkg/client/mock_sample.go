@@ -0,0 +1,280 @@
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// The mock sample is a still 16:9 gradient, one frame a second
+const (
+	mockSampleWidth   = 256
+	mockSampleHeight  = 144
+	mockSampleSeconds = 5
+	mockSampleFile    = "replicate-video-ai-mock.mp4"
+)
+
+// writeMockSample writes the sample video returned by mock predictions to
+// the temporary folder, so mock mode needs no network, and returns its path
+func writeMockSample() (string, error) {
+	data := mockSampleVideo()
+	path := filepath.Join(os.TempDir(), mockSampleFile)
+	if info, err := os.Stat(path); err == nil && info.Size() == int64(len(data)) {
+		return path, nil
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write mock sample video: %w", err)
+	}
+	return path, nil
+}
+
+// mockSampleVideo builds an H.264 MP4 without an encoder: the first frame
+// stores its macroblocks uncompressed (I_PCM) and the others skip every
+// macroblock, repeating it
+func mockSampleVideo() []byte {
+	sps, pps := mockSPS(), mockPPS()
+	// Each sample is one slice, prefixed with its length
+	var samples [][]byte
+	for frame := 0; frame < mockSampleSeconds; frame++ {
+		var slice []byte
+		if frame == 0 {
+			slice = mockIDRSlice()
+		} else {
+			slice = mockSkipSlice(frame)
+		}
+		samples = append(samples, append(u32(uint32(len(slice))), slice...))
+	}
+
+	ftyp := box("ftyp", []byte("isom"), u32(512), []byte("isomiso2avc1mp41"))
+	moov := mockMoov(sps, pps, samples, 0)
+	// The media data follows the header, so its offset is known once the
+	// header is built
+	moov = mockMoov(sps, pps, samples, uint32(len(ftyp)+len(moov)+8))
+
+	var mdat []byte
+	for _, sample := range samples {
+		mdat = append(mdat, sample...)
+	}
+	return bytes.Join([][]byte{ftyp, moov, box("mdat", mdat)}, nil)
+}
+
+// mockSPS is a Baseline sequence parameter set for the sample's size
+func mockSPS() []byte {
+	var w bitWriter
+	w.bits(66, 8)   // profile_idc: Baseline
+	w.bits(0xc0, 8) // constraint_set0_flag and constraint_set1_flag
+	w.bits(30, 8)   // level_idc: 3.0
+	w.ue(0)         // seq_parameter_set_id
+	w.ue(0)         // log2_max_frame_num_minus4
+	w.ue(2)         // pic_order_cnt_type: output in decoding order
+	w.ue(1)         // max_num_ref_frames
+	w.bits(0, 1)    // gaps_in_frame_num_value_allowed_flag
+	w.ue(mockSampleWidth/16 - 1)
+	w.ue(mockSampleHeight/16 - 1)
+	w.bits(1, 1) // frame_mbs_only_flag
+	w.bits(1, 1) // direct_8x8_inference_flag
+	w.bits(0, 1) // frame_cropping_flag
+	w.bits(0, 1) // vui_parameters_present_flag
+	return nalUnit(3, 7, w.trailing())
+}
+
+// mockPPS is a CAVLC picture parameter set
+func mockPPS() []byte {
+	var w bitWriter
+	w.ue(0)      // pic_parameter_set_id
+	w.ue(0)      // seq_parameter_set_id
+	w.bits(0, 1) // entropy_coding_mode_flag: CAVLC
+	w.bits(0, 1) // bottom_field_pic_order_in_frame_present_flag
+	w.ue(0)      // num_slice_groups_minus1
+	w.ue(0)      // num_ref_idx_l0_default_active_minus1
+	w.ue(0)      // num_ref_idx_l1_default_active_minus1
+	w.bits(0, 1) // weighted_pred_flag
+	w.bits(0, 2) // weighted_bipred_idc
+	w.se(0)      // pic_init_qp_minus26
+	w.se(0)      // pic_init_qs_minus26
+	w.se(0)      // chroma_qp_index_offset
+	w.bits(0, 1) // deblocking_filter_control_present_flag
+	w.bits(0, 1) // constrained_intra_pred_flag
+	w.bits(0, 1) // redundant_pic_cnt_present_flag
+	return nalUnit(3, 8, w.trailing())
+}
+
+// mockIDRSlice is the first frame, every macroblock stored as I_PCM samples
+func mockIDRSlice() []byte {
+	var w bitWriter
+	w.ue(0)      // first_mb_in_slice
+	w.ue(7)      // slice_type: I, as are all slices of the picture
+	w.ue(0)      // pic_parameter_set_id
+	w.bits(0, 4) // frame_num
+	w.ue(0)      // idr_pic_id
+	w.bits(0, 1) // no_output_of_prior_pics_flag
+	w.bits(0, 1) // long_term_reference_flag
+	w.se(0)      // slice_qp_delta
+
+	for mbY := 0; mbY < mockSampleHeight/16; mbY++ {
+		for mbX := 0; mbX < mockSampleWidth/16; mbX++ {
+			w.ue(25) // mb_type: I_PCM
+			w.align()
+			for y := 0; y < 16; y++ {
+				for x := 0; x < 16; x++ {
+					w.bits(uint32(mockLuma(mbX*16+x, mbY*16+y)), 8)
+				}
+			}
+			for i := 0; i < 2*8*8; i++ {
+				w.bits(128, 8) // Neutral chroma
+			}
+		}
+	}
+	return nalUnit(3, 5, w.trailing())
+}
+
+// mockSkipSlice repeats the previous frame by skipping every macroblock
+func mockSkipSlice(frame int) []byte {
+	var w bitWriter
+	w.ue(0)                     // first_mb_in_slice
+	w.ue(5)                     // slice_type: P, as are all slices of the picture
+	w.ue(0)                     // pic_parameter_set_id
+	w.bits(uint32(frame%16), 4) // frame_num
+	w.bits(0, 1)                // num_ref_idx_active_override_flag
+	w.bits(0, 1)                // ref_pic_list_modification_flag_l0
+	w.bits(0, 1)                // adaptive_ref_pic_marking_mode_flag
+	w.se(0)                     // slice_qp_delta
+	// mb_skip_run covers the whole picture
+	w.ue((mockSampleWidth / 16) * (mockSampleHeight / 16))
+	return nalUnit(2, 1, w.trailing())
+}
+
+// mockLuma is a diagonal gradient, kept within the video range
+func mockLuma(x, y int) byte {
+	return byte(16 + (x+y)*219/(mockSampleWidth+mockSampleHeight))
+}
+
+// mockMoov is the movie header for samples stored from mdatOffset
+func mockMoov(sps, pps []byte, samples [][]byte, mdatOffset uint32) []byte {
+	const timescale = 1000
+	count := uint32(len(samples))
+	duration := u32(count * timescale)
+	matrix := bytes.Join([][]byte{u32(0x10000), u32(0), u32(0), u32(0), u32(0x10000), u32(0), u32(0), u32(0), u32(0x40000000)}, nil)
+
+	mvhd := fullBox("mvhd", 0, u32(0), u32(0), u32(timescale), duration,
+		u32(0x10000), u16(0x100), make([]byte, 10), matrix, make([]byte, 24), u32(2))
+	tkhd := fullBox("tkhd", 3, u32(0), u32(0), u32(1), u32(0), duration, make([]byte, 8),
+		u16(0), u16(0), u16(0), u16(0), matrix, u32(mockSampleWidth<<16), u32(mockSampleHeight<<16))
+	mdhd := fullBox("mdhd", 0, u32(0), u32(0), u32(timescale), duration, u16(0x55c4), u16(0)) // "und"
+	hdlr := fullBox("hdlr", 0, u32(0), []byte("vide"), make([]byte, 12), []byte("VideoHandler\x00"))
+
+	avcC := box("avcC", []byte{1, 66, 0xc0, 30, 0xff, 0xe1}, u16(uint16(len(sps))), sps, []byte{1}, u16(uint16(len(pps))), pps)
+	avc1 := box("avc1", make([]byte, 6), u16(1), make([]byte, 16),
+		u16(mockSampleWidth), u16(mockSampleHeight), u32(0x480000), u32(0x480000), u32(0), u16(1),
+		make([]byte, 32), u16(0x18), u16(0xffff), avcC)
+
+	sizes := u32(0)
+	sizes = append(sizes, u32(count)...)
+	for _, sample := range samples {
+		sizes = append(sizes, u32(uint32(len(sample)))...)
+	}
+	stbl := box("stbl",
+		fullBox("stsd", 0, u32(1), avc1),
+		fullBox("stts", 0, u32(1), u32(count), u32(timescale)),
+		fullBox("stss", 0, u32(1), u32(1)),
+		fullBox("stsc", 0, u32(1), u32(1), u32(count), u32(1)),
+		fullBox("stsz", 0, sizes),
+		fullBox("stco", 0, u32(1), u32(mdatOffset)),
+	)
+	minf := box("minf",
+		fullBox("vmhd", 1, make([]byte, 8)),
+		box("dinf", fullBox("dref", 0, u32(1), fullBox("url ", 1))),
+		stbl,
+	)
+	trak := box("trak", tkhd, box("mdia", mdhd, hdlr, minf))
+	return box("moov", mvhd, trak)
+}
+
+// box builds an MP4 box of the given type around its payload
+func box(name string, payload ...[]byte) []byte {
+	data := bytes.Join(payload, nil)
+	return bytes.Join([][]byte{u32(uint32(8 + len(data))), []byte(name), data}, nil)
+}
+
+// fullBox builds an MP4 box with a version of 0 and flags
+func fullBox(name string, flags uint32, payload ...[]byte) []byte {
+	return box(name, append([][]byte{u32(flags)}, payload...)...)
+}
+
+func u32(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+func u16(v uint16) []byte { return binary.BigEndian.AppendUint16(nil, v) }
+
+// nalUnit prefixes an RBSP with its NAL header, inserting emulation
+// prevention bytes so no start code appears inside it
+func nalUnit(refIdc, unitType byte, rbsp []byte) []byte {
+	nal := []byte{refIdc<<5 | unitType}
+	zeros := 0
+	for _, b := range rbsp {
+		if zeros == 2 && b <= 3 {
+			nal = append(nal, 3)
+			zeros = 0
+		}
+		nal = append(nal, b)
+		if b == 0 {
+			zeros++
+		} else {
+			zeros = 0
+		}
+	}
+	return nal
+}
+
+// bitWriter writes the bit fields and Exp-Golomb codes of an H.264 RBSP
+type bitWriter struct {
+	data  []byte
+	nbits int
+}
+
+// bits writes the low n bits of v, most significant first
+func (w *bitWriter) bits(v uint32, n int) {
+	for i := n - 1; i >= 0; i-- {
+		if w.nbits%8 == 0 {
+			w.data = append(w.data, 0)
+		}
+		if v>>uint(i)&1 == 1 {
+			w.data[len(w.data)-1] |= 0x80 >> uint(w.nbits%8)
+		}
+		w.nbits++
+	}
+}
+
+// ue writes an unsigned Exp-Golomb code
+func (w *bitWriter) ue(v int) {
+	code := uint32(v + 1)
+	n := 0
+	for c := code; c > 1; c >>= 1 {
+		n++
+	}
+	w.bits(0, n)
+	w.bits(code, n+1)
+}
+
+// se writes a signed Exp-Golomb code
+func (w *bitWriter) se(v int) {
+	if v > 0 {
+		w.ue(2*v - 1)
+	} else {
+		w.ue(-2 * v)
+	}
+}
+
+// align pads with zero bits to the next byte
+func (w *bitWriter) align() {
+	for w.nbits%8 != 0 {
+		w.bits(0, 1)
+	}
+}
+
+// trailing ends the RBSP with its stop bit and returns it
+func (w *bitWriter) trailing() []byte {
+	w.bits(1, 1)
+	w.align()
+	return w.data
+}
@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// findBox returns the payload of the first box of the given type in data,
+// descending through the listed containers
+func findBox(data []byte, path ...string) []byte {
+	for len(data) >= 8 {
+		size := binary.BigEndian.Uint32(data)
+		if size < 8 || int(size) > len(data) {
+			return nil
+		}
+		if string(data[4:8]) == path[0] {
+			if len(path) == 1 {
+				return data[8:size]
+			}
+			return findBox(data[8:size], path[1:]...)
+		}
+		data = data[size:]
+	}
+	return nil
+}
+
+func TestMockSampleVideo(t *testing.T) {
+	data := mockSampleVideo()
+
+	var top []string
+	for rest := data; len(rest) >= 8; rest = rest[binary.BigEndian.Uint32(rest):] {
+		top = append(top, string(rest[4:8]))
+	}
+	if !reflect.DeepEqual(top, []string{"ftyp", "moov", "mdat"}) {
+		t.Fatalf("top level boxes = %v, want [ftyp moov mdat]", top)
+	}
+
+	stco := findBox(data, "moov", "trak", "mdia", "minf", "stbl", "stco")
+	if len(stco) != 12 {
+		t.Fatalf("stco = %x", stco)
+	}
+	offset := binary.BigEndian.Uint32(stco[8:])
+	// The first sample is a length-prefixed IDR slice
+	if int(offset)+5 > len(data) || data[offset+4] != 0x65 {
+		t.Errorf("first sample at %d doesn't start with an IDR slice", offset)
+	}
+
+	stsz := findBox(data, "moov", "trak", "mdia", "minf", "stbl", "stsz")
+	if got := binary.BigEndian.Uint32(stsz[8:]); got != mockSampleSeconds {
+		t.Errorf("sample count = %d, want %d", got, mockSampleSeconds)
+	}
+	var total int
+	for i := 0; i < mockSampleSeconds; i++ {
+		total += int(binary.BigEndian.Uint32(stsz[12+4*i:]))
+	}
+	if mdat := findBox(data, "mdat"); len(mdat) != total {
+		t.Errorf("mdat holds %d bytes, samples add up to %d", len(mdat), total)
+	}
+}
@@ -7,14 +7,25 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
 	"github.com/gomcpgo/replicate_video_ai/pkg/types"
 )
 
 const (
 	replicateAPIURL = "https://api.replicate.com/v1"
+
+	// DeploymentPrefix marks a model reference as one of the account's
+	// deployments, e.g. deployment:acme/my-wan
+	DeploymentPrefix = "deployment:"
+
+	// MaxSyncWait is the longest Replicate holds a create request open
+	// waiting for the prediction to finish
+	MaxSyncWait = 60 * time.Second
 )
 
 // ReplicateClient handles communication with the Replicate API
@@ -22,6 +33,11 @@ type ReplicateClient struct {
 	apiToken   string
 	httpClient *http.Client
 	debug      bool
+
+	// billingDetail holds the last billing error returned when creating a
+	// prediction, cleared once a prediction is created successfully
+	mu            sync.Mutex
+	billingDetail string
 }
 
 // NewReplicateClient creates a new Replicate API client
@@ -29,20 +45,34 @@ func NewReplicateClient(apiToken string, debug bool) *ReplicateClient {
 	return &ReplicateClient{
 		apiToken: apiToken,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Timeout: 30 * time.Second,
 		},
 		debug: debug,
 	}
 }
 
-// CreatePrediction creates a new prediction on Replicate
-func (c *ReplicateClient) CreatePrediction(ctx context.Context, modelVersion string, input map[string]interface{}) (*types.ReplicatePredictionResponse, error) {
+// SetTimeout limits how long each API request may take in total
+func (c *ReplicateClient) SetTimeout(timeout time.Duration) {
+	c.httpClient.Timeout = timeout
+}
+
+// CreatePrediction creates a new prediction on Replicate. With a wait, up to
+// MaxSyncWait, Replicate answers once the prediction finishes or the wait
+// passes, so fast models can return their output without polling.
+func (c *ReplicateClient) CreatePrediction(ctx context.Context, modelVersion string, input map[string]interface{}, wait time.Duration) (*types.ReplicatePredictionResponse, error) {
 	var url string
 	var body []byte
 	var err error
 
 	// Check if modelVersion contains a version hash (has colon)
-	if strings.Contains(modelVersion, ":") {
+	if deployment, ok := strings.CutPrefix(modelVersion, DeploymentPrefix); ok {
+		// Deployments run the version of their current release
+		reqBody := map[string]interface{}{
+			"input": input,
+		}
+		body, err = json.Marshal(reqBody)
+		url = fmt.Sprintf("%s/deployments/%s/predictions", replicateAPIURL, deployment)
+	} else if strings.Contains(modelVersion, ":") {
 		// Use version endpoint for specific versions
 		req := types.ReplicatePredictionRequest{
 			Version: modelVersion,
@@ -63,7 +93,7 @@ func (c *ReplicateClient) CreatePrediction(ctx context.Context, modelVersion str
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	logging.Debug("creating prediction", "model", modelVersion, "url", url, "input_keys", inputKeys(input), "wait", wait)
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
@@ -73,7 +103,22 @@ func (c *ReplicateClient) CreatePrediction(ctx context.Context, modelVersion str
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
 	httpReq.Header.Set("Content-Type", "application/json")
 
-	resp, err := c.httpClient.Do(httpReq)
+	httpClient := c.httpClient
+	if wait > 0 {
+		if wait > MaxSyncWait {
+			wait = MaxSyncWait
+		}
+		seconds := int((wait + time.Second - 1) / time.Second)
+		httpReq.Header.Set("Prefer", fmt.Sprintf("wait=%d", seconds))
+		// The request may take the whole wait longer than others
+		if c.httpClient.Timeout > 0 {
+			extended := *c.httpClient
+			extended.Timeout += time.Duration(seconds) * time.Second
+			httpClient = &extended
+		}
+	}
+
+	resp, err := httpClient.Do(httpReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
@@ -84,21 +129,23 @@ func (c *ReplicateClient) CreatePrediction(ctx context.Context, modelVersion str
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	logging.Debug("create prediction response", "status_code", resp.StatusCode, "body_size", len(respBody))
 
 	// Handle specific error codes
 	if resp.StatusCode == http.StatusPaymentRequired {
 		var errorResp map[string]interface{}
 		if err := json.Unmarshal(respBody, &errorResp); err == nil {
 			if detail, ok := errorResp["detail"].(string); ok {
-				return nil, fmt.Errorf("billing issue: %s", detail)
+				c.setBillingDetail(detail)
+				return nil, &Error{Kind: ErrBilling, StatusCode: resp.StatusCode, Message: fmt.Sprintf("billing issue: %s", detail)}
 			}
 		}
-		return nil, fmt.Errorf("billing issue (status 402): %s", string(respBody))
+		c.setBillingDetail(string(respBody))
+		return nil, &Error{Kind: ErrBilling, StatusCode: resp.StatusCode, Message: fmt.Sprintf("billing issue (status 402): %s", string(respBody))}
 	}
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, newError(resp.StatusCode, true, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(respBody)))
 	}
 
 	var prediction types.ReplicatePredictionResponse
@@ -106,6 +153,7 @@ func (c *ReplicateClient) CreatePrediction(ctx context.Context, modelVersion str
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
+	c.setBillingDetail("")
 	return &prediction, nil
 }
 
@@ -130,7 +178,7 @@ func (c *ReplicateClient) GetPrediction(ctx context.Context, predictionID string
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+		return nil, newError(resp.StatusCode, false, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(respBody)))
 	}
 
 	var prediction types.ReplicatePredictionResponse
@@ -143,7 +191,7 @@ func (c *ReplicateClient) GetPrediction(ctx context.Context, predictionID string
 
 // WaitForCompletion waits for a prediction to complete or timeout
 func (c *ReplicateClient) WaitForCompletion(ctx context.Context, predictionID string, timeout time.Duration) (*types.ReplicatePredictionResponse, error) {
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	logging.Debug("waiting for prediction", "prediction_id", predictionID, "timeout", timeout.String())
 
 	// If timeout is 0, use context deadline or a very long timeout
 	var deadline time.Time
@@ -165,27 +213,26 @@ func (c *ReplicateClient) WaitForCompletion(ctx context.Context, predictionID st
 	for {
 		select {
 		case <-ctx.Done():
-			// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+			logging.Debug("wait canceled", "prediction_id", predictionID, "polls", pollCount, "error", ctx.Err())
 			return nil, ctx.Err()
 		case <-ticker.C:
 			pollCount++
 			if time.Now().After(deadline) {
-				// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+				logging.Debug("wait timed out", "prediction_id", predictionID, "polls", pollCount)
 				prediction, _ := c.GetPrediction(ctx, predictionID)
-				return prediction, fmt.Errorf("operation timed out after %v", timeout)
+				return prediction, &Error{Kind: ErrTimeout, Message: fmt.Sprintf("operation timed out after %v", timeout)}
 			}
 
-			// Note: Debug logging disabled in MCP mode to avoid stdout pollution
 			prediction, err := c.GetPrediction(ctx, predictionID)
 			if err != nil {
-				// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+				logging.Warn("failed to poll prediction", "prediction_id", predictionID, "poll", pollCount, "error", err)
 				return nil, err
 			}
 
-			// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+			logging.Debug("polled prediction", "prediction_id", predictionID, "poll", pollCount, "status", prediction.Status)
 			switch prediction.Status {
 			case types.StatusSucceeded:
-				// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+				logging.Debug("prediction succeeded", "prediction_id", predictionID, "polls", pollCount)
 				return prediction, nil
 			case types.StatusFailed:
 				errMsg := "prediction failed"
@@ -198,9 +245,10 @@ func (c *ReplicateClient) WaitForCompletion(ctx context.Context, predictionID st
 						}
 					}
 				}
-				return prediction, fmt.Errorf(errMsg)
+				logging.Warn("prediction failed", "prediction_id", predictionID, "error", errMsg)
+				return prediction, newError(0, false, errMsg)
 			case types.StatusCanceled:
-				return prediction, fmt.Errorf("prediction was canceled")
+				return prediction, &Error{Kind: ErrCanceled, Message: "prediction was canceled"}
 			}
 			// Continue polling for "starting" or "processing" status
 		}
@@ -228,4 +276,177 @@ func (c *ReplicateClient) CancelPrediction(ctx context.Context, predictionID str
 	}
 
 	return nil
+}
+
+// CheckAccount verifies the API token against Replicate's account endpoint and
+// reports any billing issue, either returned by the endpoint itself or seen
+// on the most recent prediction request
+func (c *ReplicateClient) CheckAccount(ctx context.Context) (*types.AccountStatus, error) {
+	status := &types.AccountStatus{BillingStatus: types.BillingUnknown}
+	if c.apiToken == "" {
+		status.Message = "REPLICATE_API_TOKEN is not set"
+		return status, nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/account", replicateAPIURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	logging.Debug("account response", "status_code", resp.StatusCode, "body_size", len(respBody))
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var account types.ReplicateAccount
+		if err := json.Unmarshal(respBody, &account); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		status.TokenValid = true
+		status.Account = &account
+	case http.StatusUnauthorized, http.StatusForbidden:
+		status.Message = "API token was rejected; check REPLICATE_API_TOKEN"
+		return status, nil
+	case http.StatusPaymentRequired:
+		status.TokenValid = true
+		status.BillingStatus = types.BillingIssue
+		status.BillingDetail = apiErrorDetail(respBody)
+		return status, nil
+	default:
+		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	c.mu.Lock()
+	detail := c.billingDetail
+	c.mu.Unlock()
+
+	if detail != "" {
+		status.BillingStatus = types.BillingIssue
+		status.BillingDetail = detail
+	} else {
+		status.BillingStatus = types.BillingOK
+	}
+
+	return status, nil
+}
+
+// Ping checks that the Replicate API can be reached. Any HTTP response
+// counts, so it needs no valid token.
+func (c *ReplicateClient) Ping(ctx context.Context) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", replicateAPIURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", replicateAPIURL, err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ListModelVersions lists a model's versions, newest first
+func (c *ReplicateClient) ListModelVersions(ctx context.Context, modelID string) ([]types.ReplicateModelVersion, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/models/%s/versions", replicateAPIURL, modelID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(resp.StatusCode, true, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(respBody)))
+	}
+
+	var list types.ReplicateVersionList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return list.Results, nil
+}
+
+// ListDeployments lists the account's deployments
+func (c *ReplicateClient) ListDeployments(ctx context.Context) ([]types.ReplicateDeployment, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/deployments", replicateAPIURL), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiToken))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newError(resp.StatusCode, false, fmt.Sprintf("API error (status %d): %s", resp.StatusCode, string(respBody)))
+	}
+
+	var list types.ReplicateDeploymentList
+	if err := json.Unmarshal(respBody, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return list.Results, nil
+}
+
+// setBillingDetail records (or clears) the last billing error
+func (c *ReplicateClient) setBillingDetail(detail string) {
+	c.mu.Lock()
+	c.billingDetail = detail
+	c.mu.Unlock()
+}
+
+// apiErrorDetail extracts the "detail" message from an API error body
+func apiErrorDetail(body []byte) string {
+	var errorResp map[string]interface{}
+	if err := json.Unmarshal(body, &errorResp); err == nil {
+		if detail, ok := errorResp["detail"].(string); ok {
+			return detail
+		}
+	}
+	return string(body)
+}
+
+// inputKeys returns the input parameter names without their values, which
+// may contain large base64 image data
+func inputKeys(input map[string]interface{}) []string {
+	keys := make([]string, 0, len(input))
+	for k := range input {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
 }
\ No newline at end of file
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// NewTransport returns the HTTP transport for the Replicate API and video
+// downloads: proxies from HTTPS_PROXY and NO_PROXY, and the system CA
+// certificates plus any configured bundle, e.g. for a proxy inspecting TLS
+func NewTransport(cfg config.HTTPConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+
+	if cfg.CABundle == "" && !cfg.InsecureSkipVerify {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CABundle != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", cfg.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.InsecureSkipVerify {
+		logging.Warn("TLS certificate verification is disabled; connections to Replicate can be intercepted")
+		tlsConfig.InsecureSkipVerify = true
+	}
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// SetTransport makes API requests go through transport
+func (c *ReplicateClient) SetTransport(transport http.RoundTripper) {
+	c.httpClient.Transport = transport
+}
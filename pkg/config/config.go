@@ -7,34 +7,65 @@ import (
 	"time"
 )
 
+// Version is the server version reported by the CLI and capabilities tool
+const Version = "1.0.0"
+
 // Config holds the configuration for the Replicate Video AI server
 type Config struct {
 	ReplicateAPIToken   string
 	VideosRootFolder    string
-	DebugMode          bool
-	DefaultTimeout     time.Duration
-	PollInterval       time.Duration
+	DebugMode           bool
+	DefaultTimeout      time.Duration
+	PollInterval        time.Duration
+	Mock                MockConfig
+	Logging             LoggingConfig
+	Notifications       NotificationsConfig
+	Messages            MessagesConfig
+	Models              ModelsConfig
+	FileServer          FileServerConfig
+	AllowedOutputDirs   []string
+	AllowedInputDirs    []string
+	FilenameTemplate    string
+	CancelOnShutdown    bool
+	ReconcileOnStartup  bool
+	MCPNotifications    bool   // Send generation events to the MCP client
+	CompactResponses    bool   // Compact every tool response unless a call asks otherwise
+	StructuredContent   bool   // Return responses as structured content too, when the MCP library supports it
+	ResponseFormat      string // json, yaml, or markdown, unless a call asks otherwise
+	Locale              string // Language of response messages; empty is English
+	AllowSafetyOverride bool
+	QualityReport       bool
+	Moderation          ModerationConfig
+	Limits              LimitsConfig
+	AuditLog            string // JSONL record of tool calls; empty when disabled
+	Namespace           string // Confines all tool calls to one namespace
+	Profile             string // Config file profile in use
+	FFmpegPath          string // Empty looks ffmpeg up in PATH
+	FFprobePath         string // Empty looks ffprobe up in PATH
+	Defaults            DefaultsConfig
+	Retention           RetentionConfig
+	HTTP                HTTPConfig
+	Media               MediaConfig
+	Images              ImagesConfig
 }
 
-// LoadConfig loads configuration from environment variables
+// LoadConfig loads configuration from environment variables, which
+// LoadFile fills in from the config file
 func LoadConfig() (*Config, error) {
 	cfg := &Config{
 		DefaultTimeout: 5 * time.Minute,
-		PollInterval:  2 * time.Second,
+		PollInterval:   2 * time.Second,
 	}
 
 	// Optional: API token (MCP server can start without it)
 	cfg.ReplicateAPIToken = os.Getenv("REPLICATE_API_TOKEN")
 
-	// Optional: Videos root folder
-	cfg.VideosRootFolder = os.Getenv("REPLICATE_VIDEOS_ROOT_FOLDER")
-	if cfg.VideosRootFolder == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		cfg.VideosRootFolder = filepath.Join(homeDir, "Library", "Application Support", "Savant", "replicate_video_ai")
+	// Optional: Videos root folder (defaults to the platform data directory)
+	rootFolder, err := RootFolder()
+	if err != nil {
+		return nil, err
 	}
+	cfg.VideosRootFolder = rootFolder
 
 	// Create videos directory if it doesn't exist
 	if err := os.MkdirAll(cfg.VideosRootFolder, 0755); err != nil {
@@ -53,6 +84,41 @@ func LoadConfig() (*Config, error) {
 		cfg.DefaultTimeout = duration
 	}
 
+	// Optional: Name downloaded videos from a template instead of video.mp4
+	cfg.FilenameTemplate = os.Getenv("REPLICATE_VIDEO_FILENAME_TEMPLATE")
+
+	// Optional: Cancel in-flight predictions when the server exits
+	cfg.CancelOnShutdown = os.Getenv("REPLICATE_CANCEL_ON_SHUTDOWN") == "true"
+
+	// Optional: Skip checking pending generations against Replicate at startup
+	cfg.ReconcileOnStartup = os.Getenv("REPLICATE_VIDEO_RECONCILE_ON_STARTUP") != "false"
+
+	// Optional: Compact tool responses for token-constrained clients
+	cfg.CompactResponses = os.Getenv("REPLICATE_VIDEO_COMPACT_RESPONSES") == "true"
+
+	// Optional: Return responses as text only
+	cfg.StructuredContent = os.Getenv("REPLICATE_VIDEO_STRUCTURED_CONTENT") != "false"
+
+	// Optional: Format of tool responses
+	cfg.ResponseFormat = "json"
+	if format := os.Getenv("REPLICATE_VIDEO_RESPONSE_FORMAT"); format != "" {
+		switch format {
+		case "json", "yaml", "markdown":
+			cfg.ResponseFormat = format
+		default:
+			return nil, fmt.Errorf("invalid REPLICATE_VIDEO_RESPONSE_FORMAT %q (expected json, yaml, or markdown)", format)
+		}
+	}
+
+	// Optional: Don't send generation events to the MCP client
+	cfg.MCPNotifications = os.Getenv("REPLICATE_VIDEO_MCP_NOTIFICATIONS") != "false"
+
+	// Optional: Allow requests to turn off model safety checkers
+	cfg.AllowSafetyOverride = os.Getenv("REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE") == "true"
+
+	// Optional: Check completed videos for black, frozen, or silent output
+	cfg.QualityReport = os.Getenv("REPLICATE_VIDEO_QUALITY_REPORT") == "true"
+
 	// Optional: Poll interval
 	if interval := os.Getenv("REPLICATE_VIDEO_POLL_INTERVAL"); interval != "" {
 		duration, err := time.ParseDuration(interval + "s")
@@ -62,5 +128,118 @@ func LoadConfig() (*Config, error) {
 		cfg.PollInterval = duration
 	}
 
+	// Optional: Mock client for offline development
+	mock, err := LoadMockConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Mock = mock
+
+	// Optional: Structured log file
+	logging, err := LoadLoggingConfig(cfg.VideosRootFolder, cfg.DebugMode)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Logging = logging
+
+	// Optional: Notification channels
+	notifications, err := LoadNotificationsConfig(cfg.VideosRootFolder)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Notifications = notifications
+
+	// Optional: Localized response messages
+	cfg.Locale = os.Getenv("REPLICATE_VIDEO_LOCALE")
+	messages, err := LoadMessagesConfig(cfg.VideosRootFolder)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Messages = messages
+
+	// Optional: Model version pins
+	models, err := LoadModelsConfig(cfg.VideosRootFolder)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Models = models
+
+	// Optional: HTTP server for signed result URLs
+	fileServer, err := LoadFileServerConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.FileServer = fileServer
+
+	// Optional: Timeouts for API calls and video downloads
+	httpCfg, err := LoadHTTPConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.HTTP = httpCfg
+
+	// Optional: ffmpeg timeouts and process limit
+	media, err := LoadMediaConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Media = media
+
+	// Optional: Input image size and type limits
+	images, err := LoadImagesConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Images = images
+
+	// Optional: Screen prompts before creating predictions
+	moderation, err := LoadModerationConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Moderation = moderation
+
+	// Optional: Limit predictions and estimated spend
+	limits, err := LoadLimitsConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Limits = limits
+
+	// Optional: Audit log of tool calls, on by default; "off" disables it
+	cfg.AuditLog = os.Getenv("REPLICATE_VIDEO_AUDIT_LOG")
+	switch cfg.AuditLog {
+	case "":
+		cfg.AuditLog = filepath.Join(cfg.VideosRootFolder, "audit.log")
+	case "off":
+		cfg.AuditLog = ""
+	}
+
+	// Optional: Namespace for this server instance, e.g. one per user
+	cfg.Namespace = os.Getenv("REPLICATE_VIDEO_NAMESPACE")
+
+	// Optional: ffmpeg and ffprobe outside PATH
+	cfg.FFmpegPath = os.Getenv("FFMPEG_PATH")
+	cfg.FFprobePath = os.Getenv("FFPROBE_PATH")
+
+	// Optional: Config file profile, applied by LoadFile
+	cfg.Profile = os.Getenv("REPLICATE_VIDEO_PROFILE")
+
+	// Optional: Restrict per-request output directories
+	cfg.AllowedOutputDirs = LoadAllowedOutputDirs()
+
+	// Optional: Restrict the files tool calls may read
+	cfg.AllowedInputDirs = LoadAllowedInputDirs()
+
+	// Optional: Model and resolution for requests that set none
+	cfg.Defaults = LoadDefaultsConfig()
+
+	// Optional: Delete old generations at startup
+	retention, err := LoadRetentionConfig()
+	if err != nil {
+		return nil, err
+	}
+	cfg.Retention = retention
+
 	return cfg, nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultsConfig holds the model and resolution used when a request sets
+// none. Empty fields keep the built-in defaults.
+type DefaultsConfig struct {
+	TextModel  string
+	ImageModel string
+	Resolution string
+}
+
+// LoadDefaultsConfig reads the request defaults from environment variables
+func LoadDefaultsConfig() DefaultsConfig {
+	return DefaultsConfig{
+		TextModel:  os.Getenv("REPLICATE_VIDEO_DEFAULT_MODEL"),
+		ImageModel: os.Getenv("REPLICATE_VIDEO_DEFAULT_IMAGE_MODEL"),
+		Resolution: os.Getenv("REPLICATE_VIDEO_DEFAULT_RESOLUTION"),
+	}
+}
+
+// RetentionConfig sets how long generations are kept before the server
+// deletes them at startup. Zero keeps them forever.
+type RetentionConfig struct {
+	Failed    time.Duration // Failed and canceled generations
+	Completed time.Duration // Completed generations, including their videos
+}
+
+// Enabled reports whether any retention period is set
+func (c RetentionConfig) Enabled() bool {
+	return c.Failed > 0 || c.Completed > 0
+}
+
+// LoadRetentionConfig reads retention periods, in days, from environment
+// variables
+func LoadRetentionConfig() (RetentionConfig, error) {
+	var cfg RetentionConfig
+	days := map[string]*time.Duration{
+		"REPLICATE_VIDEO_RETENTION_FAILED_DAYS":    &cfg.Failed,
+		"REPLICATE_VIDEO_RETENTION_COMPLETED_DAYS": &cfg.Completed,
+	}
+	for name, period := range days {
+		if v := os.Getenv(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				return cfg, fmt.Errorf("invalid %s: must be a non-negative number of days", name)
+			}
+			*period = time.Duration(n) * 24 * time.Hour
+		}
+	}
+	return cfg, nil
+}
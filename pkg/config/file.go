@@ -0,0 +1,288 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the optional YAML config file. Each setting applies only when
+// its environment variable is unset or empty, so the environment takes
+// precedence over the file. A profile's settings replace those at the top
+// level.
+type File struct {
+	APITokenFile      string            `yaml:"api_token_file"`      // File holding the Replicate API token, mode 0600
+	TokenCommand      string            `yaml:"token_command"`       // Command printing the API token
+	KeychainService   string            `yaml:"keychain_service"`    // macOS keychain item holding the API token
+	KeychainAccount   string            `yaml:"keychain_account"`    // Account of the keychain item, if several share the service
+	RootFolder        string            `yaml:"root_folder"`         // REPLICATE_VIDEOS_ROOT_FOLDER
+	DefaultModel      string            `yaml:"default_model"`       // REPLICATE_VIDEO_DEFAULT_MODEL
+	DefaultImageModel string            `yaml:"default_image_model"` // REPLICATE_VIDEO_DEFAULT_IMAGE_MODEL
+	DefaultResolution string            `yaml:"default_resolution"`  // REPLICATE_VIDEO_DEFAULT_RESOLUTION
+	FFmpegPath        string            `yaml:"ffmpeg_path"`         // FFMPEG_PATH
+	FFprobePath       string            `yaml:"ffprobe_path"`        // FFPROBE_PATH
+	Timeouts          FileTimeouts      `yaml:"timeouts"`
+	Retention         FileRetention     `yaml:"retention"`
+	Limits            FileLimits        `yaml:"limits"`
+	HTTP              FileHTTP          `yaml:"http"`
+	Models            ModelsConfig      `yaml:"models"`   // Merged under models.yaml
+	Env               map[string]string `yaml:"env"`      // Any other REPLICATE_* variable
+	Profiles          map[string]File   `yaml:"profiles"` // Selected with --profile or REPLICATE_VIDEO_PROFILE
+}
+
+// FileTimeouts sets the timeout variables, in seconds
+type FileTimeouts struct {
+	Default           int `yaml:"default"`            // REPLICATE_VIDEO_DEFAULT_TIMEOUT
+	PollInterval      int `yaml:"poll_interval"`      // REPLICATE_VIDEO_POLL_INTERVAL
+	MaxContinueWait   int `yaml:"max_continue_wait"`  // REPLICATE_VIDEO_MAX_CONTINUE_WAIT
+	HeartbeatInterval int `yaml:"heartbeat_interval"` // REPLICATE_VIDEO_HEARTBEAT_INTERVAL
+	API               int `yaml:"api"`                // REPLICATE_VIDEO_API_TIMEOUT
+	DownloadIdle      int `yaml:"download_idle"`      // REPLICATE_VIDEO_DOWNLOAD_IDLE_TIMEOUT
+	FFmpeg            int `yaml:"ffmpeg"`             // REPLICATE_VIDEO_FFMPEG_TIMEOUT
+	FFmpegRender      int `yaml:"ffmpeg_render"`      // REPLICATE_VIDEO_FFMPEG_RENDER_TIMEOUT
+	SyncWait          int `yaml:"sync_wait"`          // REPLICATE_VIDEO_SYNC_WAIT
+}
+
+// FileRetention sets the retention variables, in days
+type FileRetention struct {
+	FailedDays    int `yaml:"failed_days"`    // REPLICATE_VIDEO_RETENTION_FAILED_DAYS
+	CompletedDays int `yaml:"completed_days"` // REPLICATE_VIDEO_RETENTION_COMPLETED_DAYS
+}
+
+// FileLimits sets the prediction limits
+type FileLimits struct {
+	MaxConcurrent int     `yaml:"max_concurrent"`  // REPLICATE_VIDEO_MAX_CONCURRENT
+	MaxPerHour    int     `yaml:"max_per_hour"`    // REPLICATE_VIDEO_MAX_PER_HOUR
+	MaxDailySpend float64 `yaml:"max_daily_spend"` // REPLICATE_VIDEO_MAX_DAILY_SPEND
+	MaxFFmpeg     int     `yaml:"max_ffmpeg"`      // REPLICATE_VIDEO_FFMPEG_MAX_CONCURRENT
+	MaxImageMB    int     `yaml:"max_image_mb"`    // REPLICATE_VIDEO_MAX_IMAGE_MB
+}
+
+// FileHTTP sets the proxy and TLS settings
+type FileHTTP struct {
+	Proxy       string `yaml:"proxy"`        // HTTPS_PROXY
+	NoProxy     string `yaml:"no_proxy"`     // NO_PROXY
+	CABundle    string `yaml:"ca_bundle"`    // REPLICATE_VIDEO_CA_BUNDLE
+	TLSInsecure *bool  `yaml:"tls_insecure"` // REPLICATE_VIDEO_TLS_INSECURE; a profile's false turns verification back on
+}
+
+// fileModels holds the models section of the loaded config file
+var fileModels ModelsConfig
+
+// DefaultConfigFile returns the config file read when neither --config nor
+// REPLICATE_VIDEO_CONFIG names one: $XDG_CONFIG_HOME/replicate-video-ai/
+// config.yaml, with XDG_CONFIG_HOME defaulting to ~/.config
+func DefaultConfigFile() (string, error) {
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(configHome) {
+		return filepath.Join(configHome, "replicate-video-ai", "config.yaml"), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".config", "replicate-video-ai", "config.yaml"), nil
+}
+
+// LoadFile reads the config file at path, or REPLICATE_VIDEO_CONFIG, or the
+// default location, and applies its settings, with those of the named
+// profile or REPLICATE_VIDEO_PROFILE on top, to the environment so every
+// loader sees them. A missing default file is not an error unless a profile
+// is selected. It returns the path of the file it loaded, or "" when there
+// was none.
+func LoadFile(path, profile string) (string, error) {
+	if profile == "" {
+		profile = os.Getenv("REPLICATE_VIDEO_PROFILE")
+	}
+	if path == "" {
+		path = os.Getenv("REPLICATE_VIDEO_CONFIG")
+	}
+	// A selected profile must come from somewhere
+	explicit := path != "" || profile != ""
+	if path == "" {
+		defaultPath, err := DefaultConfigFile()
+		if err != nil {
+			if !explicit {
+				return "", File{}.apply()
+			}
+			return "", err
+		}
+		path = defaultPath
+	}
+	path = expandHome(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return "", File{}.apply()
+		}
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var file File
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	if profile != "" {
+		// The profiles again, to tell which settings each one sets
+		var raw struct {
+			Profiles map[string]yaml.Node `yaml:"profiles"`
+		}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return "", fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		selected, err := file.profile(profile, raw.Profiles)
+		if err != nil {
+			return "", fmt.Errorf("config file %s: %w", path, err)
+		}
+		file = selected
+		os.Setenv("REPLICATE_VIDEO_PROFILE", profile)
+	}
+	if err := file.apply(); err != nil {
+		return "", fmt.Errorf("config file %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// profile returns the file's settings with those the named profile sets
+// replacing them, including a 0 or "" that returns a setting to its
+// default. Models and env entries are replaced one by one.
+func (f File) profile(name string, nodes map[string]yaml.Node) (File, error) {
+	p, ok := f.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(f.Profiles))
+		for n := range f.Profiles {
+			names = append(names, n)
+		}
+		if len(names) == 0 {
+			return f, fmt.Errorf("profile %s not found: the file defines no profiles", name)
+		}
+		sort.Strings(names)
+		return f, fmt.Errorf("profile %s not found (available: %s)", name, strings.Join(names, ", "))
+	}
+	if len(p.Profiles) > 0 {
+		return f, fmt.Errorf("profile %s: profiles can't be nested", name)
+	}
+
+	// A profile's token source replaces the top-level one entirely
+	if p.APITokenFile != "" || p.TokenCommand != "" || p.KeychainService != "" {
+		f.APITokenFile, f.TokenCommand = "", ""
+		f.KeychainService, f.KeychainAccount = "", ""
+	}
+	models := f.Models
+	env := make(map[string]string, len(f.Env)+len(p.Env))
+	for name, value := range f.Env {
+		env[name] = value
+	}
+	f.Models, f.Env, f.Profiles = nil, env, nil
+
+	// Decoding the profile onto the file only sets the keys it contains
+	node := nodes[name]
+	if err := node.Decode(&f); err != nil {
+		return f, fmt.Errorf("profile %s: %w", name, err)
+	}
+	f.Models = mergeModels(models, f.Models)
+	return f, nil
+}
+
+// apply sets the environment variable of each setting in the file, unless
+// the environment already sets it
+func (f File) apply() error {
+	settings := make(map[string]string)
+	for name, value := range f.Env {
+		if !strings.HasPrefix(name, "REPLICATE_") {
+			return fmt.Errorf("env: %s is not a REPLICATE_ variable", name)
+		}
+		settings[name] = value
+	}
+
+	set := func(name, value string) {
+		if value != "" {
+			settings[name] = value
+		}
+	}
+	set("REPLICATE_VIDEOS_ROOT_FOLDER", expandHome(f.RootFolder))
+	set("REPLICATE_VIDEO_DEFAULT_MODEL", f.DefaultModel)
+	set("REPLICATE_VIDEO_DEFAULT_IMAGE_MODEL", f.DefaultImageModel)
+	set("REPLICATE_VIDEO_DEFAULT_RESOLUTION", f.DefaultResolution)
+	set("FFMPEG_PATH", expandHome(f.FFmpegPath))
+	set("FFPROBE_PATH", expandHome(f.FFprobePath))
+	set("HTTPS_PROXY", f.HTTP.Proxy)
+	set("NO_PROXY", f.HTTP.NoProxy)
+	set("REPLICATE_VIDEO_CA_BUNDLE", expandHome(f.HTTP.CABundle))
+	if f.HTTP.TLSInsecure != nil && *f.HTTP.TLSInsecure {
+		settings["REPLICATE_VIDEO_TLS_INSECURE"] = "true"
+	}
+
+	positive := map[string]int{
+		"REPLICATE_VIDEO_DEFAULT_TIMEOUT":          f.Timeouts.Default,
+		"REPLICATE_VIDEO_POLL_INTERVAL":            f.Timeouts.PollInterval,
+		"REPLICATE_VIDEO_MAX_CONTINUE_WAIT":        f.Timeouts.MaxContinueWait,
+		"REPLICATE_VIDEO_HEARTBEAT_INTERVAL":       f.Timeouts.HeartbeatInterval,
+		"REPLICATE_VIDEO_API_TIMEOUT":              f.Timeouts.API,
+		"REPLICATE_VIDEO_DOWNLOAD_IDLE_TIMEOUT":    f.Timeouts.DownloadIdle,
+		"REPLICATE_VIDEO_RETENTION_FAILED_DAYS":    f.Retention.FailedDays,
+		"REPLICATE_VIDEO_RETENTION_COMPLETED_DAYS": f.Retention.CompletedDays,
+		"REPLICATE_VIDEO_MAX_CONCURRENT":           f.Limits.MaxConcurrent,
+		"REPLICATE_VIDEO_MAX_PER_HOUR":             f.Limits.MaxPerHour,
+		"REPLICATE_VIDEO_FFMPEG_TIMEOUT":           f.Timeouts.FFmpeg,
+		"REPLICATE_VIDEO_FFMPEG_RENDER_TIMEOUT":    f.Timeouts.FFmpegRender,
+		"REPLICATE_VIDEO_SYNC_WAIT":                f.Timeouts.SyncWait,
+		"REPLICATE_VIDEO_FFMPEG_MAX_CONCURRENT":    f.Limits.MaxFFmpeg,
+		"REPLICATE_VIDEO_MAX_IMAGE_MB":             f.Limits.MaxImageMB,
+	}
+	for name, value := range positive {
+		if value < 0 {
+			return fmt.Errorf("%s must not be negative", name)
+		}
+		if value > 0 {
+			settings[name] = strconv.Itoa(value)
+		}
+	}
+	if f.Limits.MaxDailySpend < 0 {
+		return fmt.Errorf("REPLICATE_VIDEO_MAX_DAILY_SPEND must not be negative")
+	}
+	if f.Limits.MaxDailySpend > 0 {
+		settings["REPLICATE_VIDEO_MAX_DAILY_SPEND"] = strconv.FormatFloat(f.Limits.MaxDailySpend, 'f', -1, 64)
+	}
+
+	if os.Getenv("REPLICATE_API_TOKEN") == "" {
+		token, err := f.loadToken()
+		if err != nil {
+			return err
+		}
+		if token != "" {
+			settings["REPLICATE_API_TOKEN"] = token
+		}
+	}
+
+	// Proxy variables also count when set in lowercase
+	for name, value := range settings {
+		if os.Getenv(name) == "" && os.Getenv(strings.ToLower(name)) == "" {
+			os.Setenv(name, value)
+		}
+	}
+	fileModels = f.Models
+	return nil
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+}
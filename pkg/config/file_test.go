@@ -0,0 +1,192 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfigFile writes content to a config file in a temporary folder
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	path := writeConfigFile(t, `
+default_model: veo3
+timeouts:
+  poll_interval: 10
+env:
+  REPLICATE_VIDEO_SYNC_WAIT: "30"
+`)
+
+	tests := []struct {
+		name string
+		env  map[string]string
+		want map[string]string
+	}{
+		{
+			name: "file settings",
+			want: map[string]string{
+				"REPLICATE_VIDEO_DEFAULT_MODEL": "veo3",
+				"REPLICATE_VIDEO_POLL_INTERVAL": "10",
+				"REPLICATE_VIDEO_SYNC_WAIT":     "30",
+			},
+		},
+		{
+			name: "environment wins",
+			env:  map[string]string{"REPLICATE_VIDEO_DEFAULT_MODEL": "hailuo", "REPLICATE_VIDEO_SYNC_WAIT": "5"},
+			want: map[string]string{
+				"REPLICATE_VIDEO_DEFAULT_MODEL": "hailuo",
+				"REPLICATE_VIDEO_POLL_INTERVAL": "10",
+				"REPLICATE_VIDEO_SYNC_WAIT":     "5",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for name := range tests[0].want {
+				t.Setenv(name, tt.env[name])
+			}
+			loaded, err := LoadFile(path, "")
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			if loaded != path {
+				t.Errorf("LoadFile() = %q, want %q", loaded, path)
+			}
+			for name, want := range tt.want {
+				if got := os.Getenv(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{"unknown field", "default_modle: veo3\n", "field default_modle not found"},
+		{"env outside REPLICATE_", "env:\n  HOME: /tmp\n", "HOME is not a REPLICATE_ variable"},
+		{"negative timeout", "timeouts:\n  default: -1\n", "REPLICATE_VIDEO_DEFAULT_TIMEOUT must not be negative"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadFile(writeConfigFile(t, tt.content), "")
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("LoadFile() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "missing.yaml"), ""); err == nil {
+		t.Error("LoadFile() of a missing file named explicitly succeeded")
+	}
+}
+
+func TestLoadFileProfile(t *testing.T) {
+	path := writeConfigFile(t, `
+default_model: veo3
+default_resolution: 720p
+http:
+  tls_insecure: true
+limits:
+  max_per_hour: 20
+env:
+  REPLICATE_VIDEO_SYNC_WAIT: "30"
+profiles:
+  fast:
+    default_model: hailuo
+    env:
+      REPLICATE_VIDEO_SYNC_WAIT: "5"
+  nested:
+    profiles:
+      inner: {}
+  secure:
+    http:
+      tls_insecure: false
+  unlimited:
+    default_resolution: ""
+    limits:
+      max_per_hour: 0
+`)
+
+	tests := []struct {
+		name       string
+		profile    string
+		envProfile string
+		want       map[string]string
+		wantErr    string
+	}{
+		{
+			name:    "profile replaces top level",
+			profile: "fast",
+			want: map[string]string{
+				"REPLICATE_VIDEO_DEFAULT_MODEL":      "hailuo",
+				"REPLICATE_VIDEO_DEFAULT_RESOLUTION": "720p",
+				"REPLICATE_VIDEO_SYNC_WAIT":          "5",
+				"REPLICATE_VIDEO_TLS_INSECURE":       "true",
+				"REPLICATE_VIDEO_MAX_PER_HOUR":       "20",
+				"REPLICATE_VIDEO_PROFILE":            "fast",
+			},
+		},
+		{
+			name:       "profile from the environment",
+			envProfile: "fast",
+			want:       map[string]string{"REPLICATE_VIDEO_DEFAULT_MODEL": "hailuo"},
+		},
+		{
+			name:    "profile turns TLS verification back on",
+			profile: "secure",
+			want:    map[string]string{"REPLICATE_VIDEO_TLS_INSECURE": ""},
+		},
+		{
+			name:    "profile returns settings to their defaults",
+			profile: "unlimited",
+			want: map[string]string{
+				"REPLICATE_VIDEO_DEFAULT_MODEL":      "veo3",
+				"REPLICATE_VIDEO_DEFAULT_RESOLUTION": "",
+				"REPLICATE_VIDEO_MAX_PER_HOUR":       "",
+			},
+		},
+		{name: "unknown profile", profile: "slow", wantErr: "profile slow not found (available: fast, nested, secure, unlimited)"},
+		{name: "nested profiles", profile: "nested", wantErr: "profiles can't be nested"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"REPLICATE_VIDEO_DEFAULT_MODEL", "REPLICATE_VIDEO_DEFAULT_RESOLUTION", "REPLICATE_VIDEO_SYNC_WAIT", "REPLICATE_VIDEO_TLS_INSECURE", "REPLICATE_VIDEO_MAX_PER_HOUR"} {
+				t.Setenv(name, "")
+			}
+			t.Setenv("REPLICATE_VIDEO_PROFILE", tt.envProfile)
+
+			_, err := LoadFile(path, tt.profile)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadFile() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadFile() error = %v", err)
+			}
+			for name, want := range tt.want {
+				if got := os.Getenv(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
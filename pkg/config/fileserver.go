@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// FileServerConfig holds settings for the optional HTTP server that serves
+// completed videos under signed, expiring URLs
+type FileServerConfig struct {
+	Enabled bool
+	Addr    string        // Listen address, e.g. ":8765"
+	BaseURL string        // Public URL clients use to reach the server
+	Secret  string        // URL signing key; random per run when empty
+	URLTTL  time.Duration // How long signed URLs stay valid
+}
+
+// LoadFileServerConfig reads file server settings from environment
+// variables. The server is enabled by setting REPLICATE_VIDEO_HTTP_ADDR.
+func LoadFileServerConfig() (FileServerConfig, error) {
+	cfg := FileServerConfig{
+		Addr:    os.Getenv("REPLICATE_VIDEO_HTTP_ADDR"),
+		BaseURL: strings.TrimRight(os.Getenv("REPLICATE_VIDEO_HTTP_BASE_URL"), "/"),
+		Secret:  os.Getenv("REPLICATE_VIDEO_HTTP_SECRET"),
+		URLTTL:  time.Hour,
+	}
+	if cfg.Addr == "" {
+		return cfg, nil
+	}
+	cfg.Enabled = true
+
+	host, port, err := net.SplitHostPort(cfg.Addr)
+	if err != nil {
+		return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_HTTP_ADDR: %w", err)
+	}
+	if cfg.BaseURL == "" {
+		if host == "" || host == "0.0.0.0" || host == "::" {
+			host = "localhost"
+		}
+		cfg.BaseURL = "http://" + net.JoinHostPort(host, port)
+	}
+
+	if ttl := os.Getenv("REPLICATE_VIDEO_HTTP_URL_TTL"); ttl != "" {
+		duration, err := time.ParseDuration(ttl + "s")
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_HTTP_URL_TTL: must be a positive number of seconds")
+		}
+		cfg.URLTTL = duration
+	}
+
+	return cfg, nil
+}
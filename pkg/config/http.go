@@ -0,0 +1,73 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// HTTPConfig holds the timeouts, TLS settings and cassette for requests to
+// Replicate. API calls are short, while video downloads may take minutes and
+// are only abandoned once they stall. Proxies come from HTTPS_PROXY and
+// NO_PROXY.
+type HTTPConfig struct {
+	APITimeout          time.Duration // Whole request, for Replicate API calls
+	DownloadIdleTimeout time.Duration // Longest a download may go without receiving data
+	CABundle            string        // PEM file of CA certificates trusted besides the system ones
+	InsecureSkipVerify  bool          // Don't verify server certificates
+	Cassette            string        // JSON file recording API interactions, for tests and bug reports
+	CassetteMode        string        // CassetteRecord or CassetteReplay
+}
+
+// Cassette modes: record sends requests to Replicate and saves them with
+// their responses; replay answers them from the cassette, offline
+const (
+	CassetteRecord = "record"
+	CassetteReplay = "replay"
+)
+
+// LoadHTTPConfig reads HTTP timeouts and TLS settings from environment
+// variables
+func LoadHTTPConfig() (HTTPConfig, error) {
+	cfg := HTTPConfig{
+		APITimeout:          30 * time.Second,
+		DownloadIdleTimeout: 60 * time.Second,
+	}
+
+	if timeout := os.Getenv("REPLICATE_VIDEO_API_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout + "s")
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_API_TIMEOUT: must be a positive number of seconds")
+		}
+		cfg.APITimeout = duration
+	}
+
+	if timeout := os.Getenv("REPLICATE_VIDEO_DOWNLOAD_IDLE_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout + "s")
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_DOWNLOAD_IDLE_TIMEOUT: must be a positive number of seconds")
+		}
+		cfg.DownloadIdleTimeout = duration
+	}
+
+	cfg.CABundle = os.Getenv("REPLICATE_VIDEO_CA_BUNDLE")
+	if cfg.CABundle != "" {
+		if _, err := os.Stat(cfg.CABundle); err != nil {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_CA_BUNDLE: %w", err)
+		}
+	}
+	cfg.InsecureSkipVerify = os.Getenv("REPLICATE_VIDEO_TLS_INSECURE") == "true"
+
+	cfg.Cassette = os.Getenv("REPLICATE_VIDEO_CASSETTE")
+	if cfg.Cassette != "" {
+		cfg.CassetteMode = os.Getenv("REPLICATE_VIDEO_CASSETTE_MODE")
+		if cfg.CassetteMode == "" {
+			cfg.CassetteMode = CassetteReplay
+		}
+		if cfg.CassetteMode != CassetteRecord && cfg.CassetteMode != CassetteReplay {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_CASSETTE_MODE: must be %s or %s", CassetteRecord, CassetteReplay)
+		}
+	}
+
+	return cfg, nil
+}
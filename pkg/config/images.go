@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ImagesConfig limits the input images sent to models
+type ImagesConfig struct {
+	MaxBytes int64    // Largest input image accepted
+	Types    []string // MIME types accepted, detected from the file's content
+}
+
+// LoadImagesConfig reads the input image limits from environment variables
+func LoadImagesConfig() (ImagesConfig, error) {
+	cfg := ImagesConfig{
+		MaxBytes: 25 << 20,
+		Types:    []string{"image/jpeg", "image/png", "image/webp", "image/gif"},
+	}
+
+	if v := os.Getenv("REPLICATE_VIDEO_MAX_IMAGE_MB"); v != "" {
+		mb, err := strconv.ParseFloat(v, 64)
+		if err != nil || mb <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MAX_IMAGE_MB: must be a positive number of megabytes")
+		}
+		cfg.MaxBytes = int64(mb * (1 << 20))
+	}
+
+	// Types may be given as MIME types or short names, e.g. "png,jpeg"
+	if v := os.Getenv("REPLICATE_VIDEO_IMAGE_TYPES"); v != "" {
+		cfg.Types = nil
+		for _, t := range strings.Split(v, ",") {
+			t = strings.ToLower(strings.TrimSpace(t))
+			switch {
+			case t == "":
+				continue
+			case t == "jpg":
+				t = "image/jpeg"
+			case !strings.Contains(t, "/"):
+				t = "image/" + t
+			}
+			if !strings.HasPrefix(t, "image/") {
+				return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_IMAGE_TYPES: %s is not an image type", t)
+			}
+			cfg.Types = append(cfg.Types, t)
+		}
+		if len(cfg.Types) == 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_IMAGE_TYPES: no types given")
+		}
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// LimitsConfig caps how many predictions this server instance starts and
+// how much they are estimated to cost. Zero disables a limit.
+type LimitsConfig struct {
+	MaxConcurrent int     // Predictions running at the same time
+	MaxPerHour    int     // Predictions started in any rolling hour
+	MaxDailySpend float64 // Estimated USD spent per calendar day
+}
+
+// Enabled reports whether any limit is set
+func (c LimitsConfig) Enabled() bool {
+	return c.MaxConcurrent > 0 || c.MaxPerHour > 0 || c.MaxDailySpend > 0
+}
+
+// LoadLimitsConfig reads prediction limits from environment variables
+func LoadLimitsConfig() (LimitsConfig, error) {
+	var cfg LimitsConfig
+
+	if v := os.Getenv("REPLICATE_VIDEO_MAX_CONCURRENT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MAX_CONCURRENT: must be a non-negative integer")
+		}
+		cfg.MaxConcurrent = n
+	}
+
+	if v := os.Getenv("REPLICATE_VIDEO_MAX_PER_HOUR"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MAX_PER_HOUR: must be a non-negative integer")
+		}
+		cfg.MaxPerHour = n
+	}
+
+	if v := os.Getenv("REPLICATE_VIDEO_MAX_DAILY_SPEND"); v != "" {
+		amount, err := strconv.ParseFloat(v, 64)
+		if err != nil || amount < 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MAX_DAILY_SPEND: must be a non-negative amount in USD")
+		}
+		cfg.MaxDailySpend = amount
+	}
+
+	return cfg, nil
+}
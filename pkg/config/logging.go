@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// LoggingConfig holds settings for the structured log file
+type LoggingConfig struct {
+	File       string
+	Level      string
+	MaxSizeMB  int
+	MaxBackups int
+}
+
+// LoadLoggingConfig reads logging settings from environment variables.
+// Logs default to <rootFolder>/logs/replicate-video-ai.log.
+func LoadLoggingConfig(rootFolder string, debug bool) (LoggingConfig, error) {
+	cfg := LoggingConfig{
+		File:       os.Getenv("REPLICATE_VIDEO_LOG_FILE"),
+		Level:      os.Getenv("REPLICATE_VIDEO_LOG_LEVEL"),
+		MaxSizeMB:  10,
+		MaxBackups: 5,
+	}
+
+	if cfg.File == "" {
+		cfg.File = filepath.Join(rootFolder, "logs", "replicate-video-ai.log")
+	}
+
+	if cfg.Level == "" {
+		cfg.Level = "info"
+		if debug {
+			cfg.Level = "debug"
+		}
+	}
+
+	if size := os.Getenv("REPLICATE_VIDEO_LOG_MAX_SIZE_MB"); size != "" {
+		n, err := strconv.Atoi(size)
+		if err != nil || n <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_LOG_MAX_SIZE_MB: %s", size)
+		}
+		cfg.MaxSizeMB = n
+	}
+
+	if backups := os.Getenv("REPLICATE_VIDEO_LOG_MAX_BACKUPS"); backups != "" {
+		n, err := strconv.Atoi(backups)
+		if err != nil || n < 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_LOG_MAX_BACKUPS: %s", backups)
+		}
+		cfg.MaxBackups = n
+	}
+
+	return cfg, nil
+}
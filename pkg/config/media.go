@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MediaConfig bounds the ffmpeg and ffprobe processes the server runs
+type MediaConfig struct {
+	ProbeTimeout  time.Duration // Metadata, thumbnails, frames and checks
+	RenderTimeout time.Duration // Edits, joins and quality analysis, which decode the whole video
+	MaxConcurrent int           // ffmpeg and ffprobe processes running at once
+}
+
+// LoadMediaConfig reads ffmpeg timeouts and the process limit from
+// environment variables
+func LoadMediaConfig() (MediaConfig, error) {
+	cfg := MediaConfig{
+		ProbeTimeout:  60 * time.Second,
+		RenderTimeout: 10 * time.Minute,
+		MaxConcurrent: 2,
+	}
+
+	if timeout := os.Getenv("REPLICATE_VIDEO_FFMPEG_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout + "s")
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_FFMPEG_TIMEOUT: must be a positive number of seconds")
+		}
+		cfg.ProbeTimeout = duration
+	}
+
+	if timeout := os.Getenv("REPLICATE_VIDEO_FFMPEG_RENDER_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout + "s")
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_FFMPEG_RENDER_TIMEOUT: must be a positive number of seconds")
+		}
+		cfg.RenderTimeout = duration
+	}
+
+	if v := os.Getenv("REPLICATE_VIDEO_FFMPEG_MAX_CONCURRENT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_FFMPEG_MAX_CONCURRENT: must be at least 1")
+		}
+		cfg.MaxConcurrent = n
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,41 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MessagesConfig maps locales (es, pt-BR, ...) to response message keys and
+// their text, adding to or replacing the built-in translations
+type MessagesConfig map[string]map[string]string
+
+// LoadMessagesConfig reads response messages from the YAML file at
+// REPLICATE_VIDEO_MESSAGES_FILE, or <rootFolder>/messages.yaml. A missing
+// file means the built-in messages only.
+func LoadMessagesConfig(rootFolder string) (MessagesConfig, error) {
+	path := os.Getenv("REPLICATE_VIDEO_MESSAGES_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(rootFolder, "messages.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return MessagesConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read messages config: %w", err)
+	}
+
+	var cfg MessagesConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse messages config: %w", err)
+	}
+	if cfg == nil {
+		cfg = MessagesConfig{}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// MockConfig holds settings for the offline mock client
+type MockConfig struct {
+	Enabled bool
+	Delay   time.Duration
+	Output  string // Sample video URL or local file path; empty for a generated one
+}
+
+// LoadMockConfig reads mock client settings from environment variables
+func LoadMockConfig() (MockConfig, error) {
+	cfg := MockConfig{
+		Enabled: os.Getenv("REPLICATE_VIDEO_MOCK") == "true",
+		Delay:   10 * time.Second,
+		Output:  os.Getenv("REPLICATE_VIDEO_MOCK_OUTPUT"),
+	}
+
+	// A duration such as "5s", or a bare number of seconds
+	if delay := os.Getenv("REPLICATE_VIDEO_MOCK_DELAY"); delay != "" {
+		duration, err := time.ParseDuration(delay)
+		if err != nil {
+			seconds, numErr := strconv.ParseFloat(delay, 64)
+			if numErr != nil {
+				return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MOCK_DELAY: %w", err)
+			}
+			duration = time.Duration(seconds * float64(time.Second))
+		}
+		cfg.Delay = duration
+	}
+
+	return cfg, nil
+}
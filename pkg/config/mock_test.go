@@ -0,0 +1,41 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadMockConfigDelay(t *testing.T) {
+	tests := []struct {
+		delay   string
+		want    time.Duration
+		wantErr string
+	}{
+		{"", 10 * time.Second, ""},
+		{"5s", 5 * time.Second, ""},
+		{"500ms", 500 * time.Millisecond, ""},
+		{"5", 5 * time.Second, ""},
+		{"1.5", 1500 * time.Millisecond, ""},
+		{"soon", 0, "invalid REPLICATE_VIDEO_MOCK_DELAY"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.delay, func(t *testing.T) {
+			t.Setenv("REPLICATE_VIDEO_MOCK_DELAY", tt.delay)
+			cfg, err := LoadMockConfig()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("LoadMockConfig() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadMockConfig() error = %v", err)
+			}
+			if cfg.Delay != tt.want {
+				t.Errorf("Delay = %v, want %v", cfg.Delay, tt.want)
+			}
+		})
+	}
+}
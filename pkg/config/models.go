@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModelOverride customizes a registered model
+type ModelOverride struct {
+	Version    string `yaml:"version,omitempty"`    // Pinned Replicate version hash
+	Resolution string `yaml:"resolution,omitempty"` // Used when a request sets none
+}
+
+// ModelsConfig maps model aliases (wan-t2v-fast, kling-master, ...) to
+// their overrides
+type ModelsConfig map[string]ModelOverride
+
+// LoadModelsConfig reads model overrides from the YAML file at
+// REPLICATE_VIDEO_MODELS_FILE, or <rootFolder>/models.yaml, on top of the
+// models section of the config file. A missing file means every model uses
+// Replicate's latest version.
+func LoadModelsConfig(rootFolder string) (ModelsConfig, error) {
+	path := os.Getenv("REPLICATE_VIDEO_MODELS_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(rootFolder, "models.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return mergeModels(fileModels, nil), nil
+		}
+		return nil, fmt.Errorf("failed to read models config: %w", err)
+	}
+
+	var cfg ModelsConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse models config: %w", err)
+	}
+	return mergeModels(fileModels, cfg), nil
+}
+
+// mergeModels returns base with each alias of overrides replacing its entry
+func mergeModels(base, overrides ModelsConfig) ModelsConfig {
+	merged := ModelsConfig{}
+	for alias, override := range base {
+		merged[alias] = override
+	}
+	for alias, override := range overrides {
+		merged[alias] = override
+	}
+	return merged
+}
+
+// Resolutions returns the default resolution for each model that overrides it
+func (c ModelsConfig) Resolutions() map[string]string {
+	resolutions := make(map[string]string)
+	for alias, override := range c {
+		if override.Resolution != "" {
+			resolutions[alias] = override.Resolution
+		}
+	}
+	return resolutions
+}
+
+// Versions returns the pinned version for each model that has one
+func (c ModelsConfig) Versions() map[string]string {
+	versions := make(map[string]string)
+	for alias, override := range c {
+		if override.Version != "" {
+			versions[alias] = override.Version
+		}
+	}
+	return versions
+}
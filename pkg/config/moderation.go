@@ -0,0 +1,48 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// ModerationConfig holds settings for screening prompts before a paid
+// prediction is created. Both checks are off unless configured.
+type ModerationConfig struct {
+	BlocklistFile string        // Blocked phrases and regexes, one per line
+	APIURL        string        // OpenAI-compatible moderation endpoint
+	APIKey        string        // Bearer token for the moderation endpoint
+	Timeout       time.Duration // Per-request timeout for the moderation API
+}
+
+// Enabled reports whether any prompt check is configured
+func (c ModerationConfig) Enabled() bool {
+	return c.BlocklistFile != "" || c.APIURL != ""
+}
+
+// LoadModerationConfig reads prompt screening settings from environment
+// variables
+func LoadModerationConfig() (ModerationConfig, error) {
+	cfg := ModerationConfig{
+		BlocklistFile: os.Getenv("REPLICATE_VIDEO_BLOCKLIST"),
+		APIURL:        os.Getenv("REPLICATE_VIDEO_MODERATION_URL"),
+		APIKey:        os.Getenv("REPLICATE_VIDEO_MODERATION_API_KEY"),
+		Timeout:       10 * time.Second,
+	}
+
+	if cfg.BlocklistFile != "" {
+		if _, err := os.Stat(cfg.BlocklistFile); err != nil {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_BLOCKLIST: %w", err)
+		}
+	}
+
+	if timeout := os.Getenv("REPLICATE_VIDEO_MODERATION_TIMEOUT"); timeout != "" {
+		duration, err := time.ParseDuration(timeout + "s")
+		if err != nil || duration <= 0 {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MODERATION_TIMEOUT: must be a positive number of seconds")
+		}
+		cfg.Timeout = duration
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,49 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NotifierConfig configures a single notification channel
+type NotifierConfig struct {
+	Type    string            `yaml:"type"` // slack, webhook, command, desktop
+	URL     string            `yaml:"url,omitempty"`
+	Command string            `yaml:"command,omitempty"`
+	Headers map[string]string `yaml:"headers,omitempty"`
+}
+
+// NotificationsConfig maps event types (completed, failed, budget_warning,
+// progress) to the channels that should receive them
+type NotificationsConfig map[string][]NotifierConfig
+
+// LoadNotificationsConfig reads notification channels from the YAML file at
+// REPLICATE_VIDEO_NOTIFICATIONS_FILE, or <rootFolder>/notifications.yaml.
+// A missing file means no notifications.
+func LoadNotificationsConfig(rootFolder string) (NotificationsConfig, error) {
+	path := os.Getenv("REPLICATE_VIDEO_NOTIFICATIONS_FILE")
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(rootFolder, "notifications.yaml")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return NotificationsConfig{}, nil
+		}
+		return nil, fmt.Errorf("failed to read notifications config: %w", err)
+	}
+
+	var cfg NotificationsConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse notifications config: %w", err)
+	}
+	if cfg == nil {
+		cfg = NotificationsConfig{}
+	}
+	return cfg, nil
+}
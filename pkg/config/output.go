@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// LoadAllowedOutputDirs reads the directories per-request output_dir
+// overrides must fall under, from REPLICATE_VIDEO_ALLOWED_OUTPUT_DIRS
+// (separated like PATH). An empty list allows any absolute directory.
+func LoadAllowedOutputDirs() []string {
+	return dirList("REPLICATE_VIDEO_ALLOWED_OUTPUT_DIRS")
+}
+
+// LoadAllowedInputDirs reads the directories that files tool calls ask the
+// server to read, such as image_path, must be inside, from
+// REPLICATE_VIDEO_ALLOWED_INPUT_DIRS (separated like PATH). An empty list
+// allows any file.
+func LoadAllowedInputDirs() []string {
+	return dirList("REPLICATE_VIDEO_ALLOWED_INPUT_DIRS")
+}
+
+// dirList splits a PATH-like environment variable into directories
+func dirList(name string) []string {
+	var dirs []string
+	for _, dir := range filepath.SplitList(os.Getenv(name)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// appDir is the application folder created inside the platform data directory
+var appDir = filepath.Join("Savant", "replicate_video_ai")
+
+// RootFolder returns the videos root folder: REPLICATE_VIDEOS_ROOT_FOLDER if
+// set, otherwise the platform default from DefaultRootFolder
+func RootFolder() (string, error) {
+	if root := os.Getenv("REPLICATE_VIDEOS_ROOT_FOLDER"); root != "" {
+		return root, nil
+	}
+	return DefaultRootFolder()
+}
+
+// DefaultRootFolder returns the platform's per-user data directory for videos:
+//   - macOS:   ~/Library/Application Support/Savant/replicate_video_ai
+//   - Windows: %APPDATA%\Savant\replicate_video_ai
+//   - Linux and others: $XDG_DATA_HOME/Savant/replicate_video_ai
+//     (XDG_DATA_HOME defaults to ~/.local/share)
+//
+// Earlier versions used the macOS folder on every platform. Where that
+// folder exists and the platform one doesn't yet, it is kept, so existing
+// videos stay listed.
+func DefaultRootFolder() (string, error) {
+	root, err := platformRootFolder()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(root); !os.IsNotExist(err) {
+		return root, nil
+	}
+	if legacy, ok := legacyRootFolder(); ok {
+		return legacy, nil
+	}
+	return root, nil
+}
+
+// legacyRootFolder returns the root folder of earlier versions, if it
+// exists
+func legacyRootFolder() (string, bool) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	legacy := filepath.Join(homeDir, "Library", "Application Support", appDir)
+	if info, err := os.Stat(legacy); err != nil || !info.IsDir() {
+		return "", false
+	}
+	return legacy, true
+}
+
+// platformRootFolder returns the platform's data directory for videos
+func platformRootFolder() (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if appData := os.Getenv("APPDATA"); appData != "" {
+			return filepath.Join(appData, appDir), nil
+		}
+		configDir, err := os.UserConfigDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to locate application data directory: %w", err)
+		}
+		return filepath.Join(configDir, appDir), nil
+
+	case "darwin":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return filepath.Join(homeDir, "Library", "Application Support", appDir), nil
+
+	default:
+		if dataHome := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(dataHome) {
+			return filepath.Join(dataHome, appDir), nil
+		}
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		return filepath.Join(homeDir, ".local", "share", appDir), nil
+	}
+}
@@ -1,6 +1,10 @@
 package config
 
-import "time"
+import (
+	"fmt"
+	"os"
+	"time"
+)
 
 // TimeoutConfig holds timeout configuration for video operations
 type TimeoutConfig struct {
@@ -8,14 +12,57 @@ type TimeoutConfig struct {
 	MaxWait      time.Duration
 	PollInterval time.Duration
 	TotalTimeout time.Duration
+
+	// MaxContinueWait caps how long one continue_operation call may block
+	MaxContinueWait time.Duration
+	// HeartbeatInterval is how often a background poll records progress
+	HeartbeatInterval time.Duration
+	// SyncWait is how long creating a fast model's prediction waits for it
+	// to finish (Replicate's Prefer: wait); 0 turns this off
+	SyncWait time.Duration
 }
 
-// LoadTimeouts returns default timeout configuration
-func LoadTimeouts() TimeoutConfig {
-	return TimeoutConfig{
-		InitialWait:  30 * time.Second,
-		MaxWait:      5 * time.Minute,
-		PollInterval: 2 * time.Second,
-		TotalTimeout: 10 * time.Minute,
+// LoadTimeouts returns timeout configuration, with continue_operation limits
+// read from environment variables
+func LoadTimeouts() (TimeoutConfig, error) {
+	cfg := TimeoutConfig{
+		InitialWait:       30 * time.Second,
+		MaxWait:           5 * time.Minute,
+		PollInterval:      2 * time.Second,
+		TotalTimeout:      10 * time.Minute,
+		MaxContinueWait:   5 * time.Minute,
+		HeartbeatInterval: 15 * time.Second,
+		SyncWait:          60 * time.Second,
+	}
+
+	if wait := os.Getenv("REPLICATE_VIDEO_MAX_CONTINUE_WAIT"); wait != "" {
+		duration, err := time.ParseDuration(wait + "s")
+		if err != nil || duration < 5*time.Second {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_MAX_CONTINUE_WAIT: must be at least 5 seconds")
+		}
+		cfg.MaxContinueWait = duration
 	}
-}
\ No newline at end of file
+
+	if interval := os.Getenv("REPLICATE_VIDEO_HEARTBEAT_INTERVAL"); interval != "" {
+		duration, err := time.ParseDuration(interval + "s")
+		if err != nil || duration < time.Second {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_HEARTBEAT_INTERVAL: must be at least 1 second")
+		}
+		cfg.HeartbeatInterval = duration
+	}
+
+	if wait := os.Getenv("REPLICATE_VIDEO_SYNC_WAIT"); wait != "" {
+		duration, err := time.ParseDuration(wait + "s")
+		if err != nil || duration < 0 || duration > 60*time.Second {
+			return cfg, fmt.Errorf("invalid REPLICATE_VIDEO_SYNC_WAIT: must be 0 to 60 seconds")
+		}
+		cfg.SyncWait = duration
+	}
+
+	// Background polls must outlive the longest wait
+	if cfg.TotalTimeout < cfg.MaxContinueWait {
+		cfg.TotalTimeout = cfg.MaxContinueWait
+	}
+
+	return cfg, nil
+}
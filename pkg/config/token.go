@@ -0,0 +1,117 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/shell"
+)
+
+// tokenCommandTimeout limits how long token_command and the keychain lookup
+// may take, since a credential helper waiting for input would block startup
+const tokenCommandTimeout = 30 * time.Second
+
+// DefaultCredentialsFile returns the credentials file read when no other
+// source provides a token: credentials next to the default config file
+func DefaultCredentialsFile() (string, error) {
+	configFile, err := DefaultConfigFile()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configFile), "credentials"), nil
+}
+
+// loadToken returns the Replicate API token from the first source the file
+// configures: token_command, the macOS keychain, or api_token_file. Without
+// any it reads the default credentials file if it exists. It returns "" when
+// no source provides a token.
+func (f File) loadToken() (string, error) {
+	switch {
+	case f.TokenCommand != "":
+		return runTokenCommand(f.TokenCommand)
+	case f.KeychainService != "":
+		return readKeychain(f.KeychainService, f.KeychainAccount)
+	case f.APITokenFile != "":
+		return readCredentialsFile(expandHome(f.APITokenFile))
+	}
+
+	path, err := DefaultCredentialsFile()
+	if err != nil {
+		return "", nil
+	}
+	token, err := readCredentialsFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	return token, err
+}
+
+// readCredentialsFile reads a token from a file only its owner can access,
+// so a token shared with other users isn't used unnoticed
+func readCredentialsFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	// Windows has no permission bits to check
+	if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		return "", fmt.Errorf("credentials file %s is accessible by other users (mode %04o); run chmod 600 %s", path, info.Mode().Perm(), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("credentials file %s is empty", path)
+	}
+	return token, nil
+}
+
+// runTokenCommand runs command through the platform shell and returns what
+// it prints, e.g. `op read op://Private/Replicate/token`
+func runTokenCommand(command string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	output, err := shell.Run(ctx, command, nil)
+	if err != nil {
+		return "", fmt.Errorf("token_command failed: %w", err)
+	}
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("token_command printed no token")
+	}
+	return token, nil
+}
+
+// readKeychain reads a generic password from the macOS keychain, as stored
+// with `security add-generic-password -s <service> -a <account> -w`
+func readKeychain(service, account string) (string, error) {
+	if runtime.GOOS != "darwin" {
+		return "", fmt.Errorf("keychain_service is only supported on macOS; use token_command with your platform's credential store")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tokenCommandTimeout)
+	defer cancel()
+
+	args := []string{"find-generic-password", "-s", service, "-w"}
+	if account != "" {
+		args = append(args, "-a", account)
+	}
+	output, err := exec.CommandContext(ctx, "security", args...).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read keychain item %s: %w", service, err)
+	}
+	token := strings.TrimSpace(string(output))
+	if token == "" {
+		return "", fmt.Errorf("keychain item %s is empty", service)
+	}
+	return token, nil
+}
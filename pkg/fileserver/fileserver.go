@@ -0,0 +1,163 @@
+// Package fileserver serves stored videos and thumbnails over HTTP under
+// signed, expiring URLs, for clients that run on a different machine than the
+// MCP server
+package fileserver
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// filesPath prefixes served files: /files/<storage_id>/<file>
+const filesPath = "/files/"
+
+// Server serves files from storage folders
+type Server struct {
+	storage *storage.Storage
+	secret  []byte
+	ttl     time.Duration
+	baseURL string
+	server  *http.Server
+}
+
+// New creates a file server. Without a configured secret a random one is
+// generated, so URLs stop working when the server restarts.
+func New(cfg config.FileServerConfig, store *storage.Storage) (*Server, error) {
+	secret := []byte(cfg.Secret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("failed to generate URL signing key: %w", err)
+		}
+		logging.Warn("REPLICATE_VIDEO_HTTP_SECRET not set; file URLs expire when the server restarts")
+	}
+
+	s := &Server{
+		storage: store,
+		secret:  secret,
+		ttl:     cfg.URLTTL,
+		baseURL: cfg.BaseURL,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(filesPath, s.serveFile)
+	s.server = &http.Server{
+		Addr:              cfg.Addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s, nil
+}
+
+// Start listens on the configured address and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to start file server: %w", err)
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Error("file server stopped", "error", err)
+		}
+	}()
+	logging.Info("file server started", "addr", listener.Addr().String(), "base_url", s.baseURL)
+	return nil
+}
+
+// Shutdown stops the server, waiting for in-flight downloads until ctx ends
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// URLs returns signed URLs for the files among paths (keyed like paths) that
+// live in the storage ID's folder
+func (s *Server) URLs(storageID string, paths map[string]string) map[string]string {
+	folder := s.storage.GetStoragePath(storageID)
+	urls := make(map[string]string)
+	for key, path := range paths {
+		if path == "" || filepath.Dir(path) != folder {
+			continue
+		}
+		urls[key] = s.URL(storageID, filepath.Base(path))
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+	return urls
+}
+
+// URL returns a signed URL for a file in a storage folder
+func (s *Server) URL(storageID, file string) string {
+	expires := strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)
+	query := url.Values{
+		"expires": {expires},
+		"sig":     {s.sign(storageID, file, expires)},
+	}
+	return s.baseURL + filesPath + url.PathEscape(storageID) + "/" + url.PathEscape(file) + "?" + query.Encode()
+}
+
+// sign computes the signature of a file URL
+func (s *Server) sign(storageID, file, expires string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(storageID + "/" + file + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// serveFile serves a file after checking its signature and expiry. Range
+// requests are supported so players can seek.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	storageID, file, ok := strings.Cut(strings.TrimPrefix(r.URL.Path, filesPath), "/")
+	if !ok || storageID == "" || file == "" || storageID != filepath.Base(storageID) || file != filepath.Base(file) ||
+		storageID == ".." || file == ".." {
+		http.NotFound(w, r)
+		return
+	}
+
+	expires := r.URL.Query().Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(r.URL.Query().Get("sig")), []byte(s.sign(storageID, file, expires))) {
+		http.Error(w, "invalid signature", http.StatusForbidden)
+		return
+	}
+	if time.Now().Unix() > expiresAt {
+		http.Error(w, "URL expired", http.StatusGone)
+		return
+	}
+
+	path := filepath.Join(s.storage.GetStoragePath(storageID), file)
+	f, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || !info.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+
+	logging.Debug("serving file", "storage_id", storageID, "file", file, "remote", r.RemoteAddr)
+	http.ServeContent(w, r, file, info.ModTime(), f)
+}
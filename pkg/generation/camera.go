@@ -0,0 +1,47 @@
+package generation
+
+import "strings"
+
+// CameraMotions are the canonical camera movements accepted as
+// camera_motion, in the order shown to clients
+var CameraMotions = []string{
+	"static",
+	"pan_left",
+	"pan_right",
+	"tilt_up",
+	"tilt_down",
+	"zoom_in",
+	"zoom_out",
+}
+
+// promptCameraPhrases describe each camera movement as a prompt sentence,
+// for models that take camera directions in the prompt rather than as an
+// input of their own
+var promptCameraPhrases = map[string]string{
+	"static":    "The camera stays completely still.",
+	"pan_left":  "The camera pans slowly to the left.",
+	"pan_right": "The camera pans slowly to the right.",
+	"tilt_up":   "The camera tilts slowly upward.",
+	"tilt_down": "The camera tilts slowly downward.",
+	"zoom_in":   "The camera slowly zooms in.",
+	"zoom_out":  "The camera slowly zooms out.",
+}
+
+// validCameraMotion reports whether motion is a canonical camera movement
+func validCameraMotion(motion string) bool {
+	for _, m := range CameraMotions {
+		if m == motion {
+			return true
+		}
+	}
+	return false
+}
+
+// withCameraDirection appends a camera direction sentence to a prompt
+func withCameraDirection(prompt, phrase string) string {
+	prompt = strings.TrimSpace(prompt)
+	if prompt != "" && !strings.HasSuffix(prompt, ".") {
+		prompt += "."
+	}
+	return strings.TrimSpace(prompt + " " + phrase)
+}
@@ -0,0 +1,27 @@
+package generation
+
+import (
+	"context"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// CancelGeneration cancels a running prediction on Replicate, so it stops
+// billing, and records the generation as canceled. It returns the storage ID
+// of the generation, or "" when the prediction isn't stored here.
+func (g *Generator) CancelGeneration(ctx context.Context, predictionID string) (string, error) {
+	if err := g.client.CancelPrediction(ctx, predictionID); err != nil {
+		return "", err
+	}
+
+	storageID, ok := g.storage.FindByPrediction(predictionID)
+	if !ok {
+		logging.Info("canceled prediction", "prediction_id", predictionID)
+		return "", nil
+	}
+	g.quota.release(storageID)
+	g.recordFailure(storageID, predictionID, types.StatusCanceled, "canceled by request", "")
+	logging.Info("canceled prediction", "storage_id", storageID, "prediction_id", predictionID)
+	return storageID, nil
+}
@@ -0,0 +1,132 @@
+package generation
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// customModelPattern matches "owner/model" with an optional ":version", or
+// "deployment:owner/name"
+var customModelPattern = regexp.MustCompile(`^(deployment:[a-z0-9][a-z0-9._-]*/[a-z0-9][a-z0-9._-]*|[a-z0-9][a-z0-9._-]*/[a-z0-9][a-z0-9._-]*(:[a-z0-9]+)?)$`)
+
+// ParseCustomModel validates an arbitrary Replicate model reference of the
+// form owner/model[:version], or deployment:owner/name for one of the
+// account's deployments
+func ParseCustomModel(ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	if !customModelPattern.MatchString(ref) {
+		return "", fmt.Errorf("invalid model %q: expected owner/model, owner/model:version, or deployment:owner/name", ref)
+	}
+	return ref, nil
+}
+
+// GenerateCustom runs any Replicate model with a raw input object, bypassing
+// the model registry. Storage, metadata, and download work like registered
+// models. Only SessionID, Project, and OutputDir are used from params.
+func (g *Generator) GenerateCustom(ctx context.Context, modelRef string, input map[string]interface{}, params VideoParams) (*VideoResult, error) {
+	startTime := time.Now()
+
+	modelRef, err := ParseCustomModel(modelRef)
+	if err != nil {
+		return nil, err
+	}
+	if input == nil {
+		input = make(map[string]interface{})
+	}
+	if prompt, ok := input["prompt"].(string); ok {
+		if err := g.moderator.Check(ctx, prompt); err != nil {
+			return nil, err
+		}
+	}
+	modelID, _, _ := strings.Cut(modelRef, ":")
+	deployment := strings.HasPrefix(modelRef, client.DeploymentPrefix)
+	if deployment {
+		modelID = modelRef
+	}
+
+	// Create storage ID
+	storageID := g.storage.GenerateStorageID()
+	if err := g.placeStorage(storageID, params); err != nil {
+		return nil, err
+	}
+
+	// Create prediction
+	logging.Debug("creating custom prediction", "model", modelRef, "storage_id", storageID, "input_keys", len(input))
+
+	// Custom models have no cost estimate, so they count towards the
+	// prediction limits but not the daily spend
+	if err := g.reserveQuota(storageID, modelID, 0); err != nil {
+		g.discardStorage(storageID, params)
+		return nil, err
+	}
+	prediction, err := g.client.CreatePrediction(ctx, modelRef, input, 0)
+	if err != nil {
+		g.quota.abort(storageID)
+		g.discardStorage(storageID, params)
+		return nil, fmt.Errorf("failed to create prediction: %w", err)
+	}
+
+	// Deployments run whichever version their current release pins, which
+	// the prediction reports
+	version := versionOf(modelRef)
+	if deployment {
+		version = prediction.Version
+	}
+
+	prompt, _ := input["prompt"].(string)
+
+	// Save metadata with the same structure as registered models
+	metadata := map[string]interface{}{
+		"operation":     "custom_model",
+		"status":        prediction.Status,
+		"prediction_id": prediction.ID,
+		"storage_id":    storageID,
+		"session_id":    params.SessionID,
+		"output_dir":    params.OutputDir,
+		"project":       params.Project,
+		"created_at":    time.Now().Format(time.RFC3339),
+
+		// Model information
+		"model": map[string]interface{}{
+			"id":      modelID,
+			"name":    modelID,
+			"version": version,
+		},
+
+		// Parameters (user inputs)
+		"parameters": map[string]interface{}{
+			"prompt":    prompt,
+			"raw_input": input,
+		},
+
+		// Metrics (will be updated on completion)
+		"metrics": map[string]interface{}{
+			"generation_type": "custom",
+		},
+
+		// Paths will be added on completion
+		"paths": map[string]interface{}{},
+	}
+
+	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
+		logging.Warn("failed to save metadata", "storage_id", storageID, "error", err)
+	}
+
+	return &VideoResult{
+		ID:           storageID,
+		Model:        modelRef,
+		ModelName:    modelID,
+		PredictionID: prediction.ID,
+		Parameters:   input,
+		Status:       prediction.Status,
+		Metrics: VideoMetrics{
+			GenerationTime: time.Since(startTime).Seconds(),
+		},
+	}, nil
+}
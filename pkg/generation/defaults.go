@@ -0,0 +1,71 @@
+package generation
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+)
+
+// Models and resolution for requests that set none, changed by Configure
+var (
+	defaultTextModel  = "wan-t2v-fast"
+	defaultImageModel = "wan-i2v-fast"
+	defaultResolution string            // Empty uses each model's own default
+	modelResolutions  map[string]string // Per-model defaults, by alias
+)
+
+// resolutionFor returns the resolution a request that sets none uses: the
+// model's configured default, else the configured default resolution if the
+// model accepts it, else the model's own default
+func resolutionFor(alias string, config ModelConfig) string {
+	if resolution, ok := modelResolutions[alias]; ok {
+		return resolution
+	}
+	if defaultResolution == config.DefaultRes || slices.Contains(config.Resolutions, defaultResolution) {
+		return defaultResolution
+	}
+	return config.DefaultRes
+}
+
+// DefaultTextModel returns the model text-to-video requests use when they
+// name none
+func DefaultTextModel() string {
+	return defaultTextModel
+}
+
+// DefaultImageModel returns the model image-to-video requests use when they
+// name none
+func DefaultImageModel() string {
+	return defaultImageModel
+}
+
+// Configure applies the configured defaults and per-model overrides: pinned
+// versions and default resolutions from models.yaml or the config file
+func Configure(defaults config.DefaultsConfig, models config.ModelsConfig) error {
+	if err := PinVersions(models.Versions()); err != nil {
+		return err
+	}
+	resolutions := models.Resolutions()
+	for alias := range resolutions {
+		if _, ok := ModelConfigs[alias]; !ok {
+			return fmt.Errorf("unknown model: %s", alias)
+		}
+	}
+	modelResolutions = resolutions
+
+	if defaults.TextModel != "" {
+		if !IsTextToVideoModel(defaults.TextModel) {
+			return fmt.Errorf("default model %s is not a registered text-to-video model", defaults.TextModel)
+		}
+		defaultTextModel = defaults.TextModel
+	}
+	if defaults.ImageModel != "" {
+		if !IsImageToVideoModel(defaults.ImageModel) {
+			return fmt.Errorf("default image model %s is not a registered image-to-video model", defaults.ImageModel)
+		}
+		defaultImageModel = defaults.ImageModel
+	}
+	defaultResolution = defaults.Resolution
+	return nil
+}
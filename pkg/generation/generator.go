@@ -2,30 +2,172 @@ package generation
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/moderation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/notify"
 	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
 	"github.com/gomcpgo/replicate_video_ai/pkg/types"
 )
 
+// defaultSyncWait is how long creating a fast model's prediction waits for
+// it to finish, unless SetSyncWait changes it
+const defaultSyncWait = 60 * time.Second
+
 // Generator handles video generation operations
 type Generator struct {
-	client  client.Client
-	storage *storage.Storage
-	debug   bool
+	client   client.Client
+	storage  *storage.Storage
+	notifier *notify.Dispatcher
+	debug    bool
+
+	// qualityReport runs the automatic quality check on completed videos
+	qualityReport bool
+
+	// moderator screens prompts before a prediction is created; nil allows all
+	moderator *moderation.Checker
+
+	// quota limits how many predictions are started; nil allows all
+	quota *Quota
+
+	// syncWait is how long creating a prediction for a model with Sync set
+	// waits for it to finish; 0 always creates predictions asynchronously
+	syncWait time.Duration
 }
 
 // NewGenerator creates a new video generator
 func NewGenerator(client client.Client, storage *storage.Storage, debug bool) *Generator {
 	return &Generator{
-		client:  client,
-		storage: storage,
-		debug:   debug,
+		client:   client,
+		storage:  storage,
+		debug:    debug,
+		syncWait: defaultSyncWait,
+	}
+}
+
+// placeStorage redirects a new generation's files to the requested output
+// directory or project folder, if any. output_dir takes precedence.
+func (g *Generator) placeStorage(storageID string, params VideoParams) error {
+	var folder string
+	var err error
+	switch {
+	case params.OutputDir != "":
+		folder, err = g.storage.SetOutputDir(storageID, params.OutputDir)
+	case params.Project != "":
+		folder, err = g.storage.SetProject(storageID, params.Project)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	logging.Debug("using storage folder", "storage_id", storageID, "folder", folder)
+	return nil
+}
+
+// newStorageID returns the storage ID of a new generation, placed in its
+// folder: the one it was given when queued, or a new one
+func (g *Generator) newStorageID(params VideoParams) (string, error) {
+	storageID := params.StorageID
+	if storageID == "" {
+		storageID = g.storage.GenerateStorageID()
+	}
+	if err := g.placeStorage(storageID, params); err != nil {
+		return "", err
+	}
+	return storageID, nil
+}
+
+// discardStorage removes the folder of a new generation whose prediction
+// wasn't created, so no folder without metadata is left for the index to
+// find. A queued generation keeps its folder, since it may start later.
+func (g *Generator) discardStorage(storageID string, params VideoParams) {
+	if params.StorageID == "" {
+		g.storage.DiscardStorage(storageID)
+	}
+}
+
+// versionOf returns the version part of an "owner/model:version" reference,
+// or "latest" when none is pinned
+func versionOf(modelRef string) string {
+	if i := strings.Index(modelRef, ":"); i >= 0 {
+		return modelRef[i+1:]
 	}
+	return "latest"
+}
+
+// SetNotifier configures where completion and failure events are sent
+func (g *Generator) SetNotifier(notifier *notify.Dispatcher) {
+	g.notifier = notifier
+}
+
+// SetQualityReport enables the automatic quality check of completed videos
+// (see storage.AnalyzeQuality)
+func (g *Generator) SetQualityReport(enabled bool) {
+	g.qualityReport = enabled
+}
+
+// SetModerator configures the prompt check run before each paid prediction
+func (g *Generator) SetModerator(moderator *moderation.Checker) {
+	g.moderator = moderator
+}
+
+// SetQuota configures the limits on predictions started by this instance
+func (g *Generator) SetQuota(quota *Quota) {
+	g.quota = quota
+}
+
+// SetSyncWait sets how long creating a prediction for a fast model waits
+// for it to finish, so it can be returned without a continue_operation
+// round trip; 0 turns this off
+func (g *Generator) SetSyncWait(wait time.Duration) {
+	g.syncWait = wait
+}
+
+// createWait returns how long creating a prediction for a model waits for it
+// to finish
+func (g *Generator) createWait(modelConfig ModelConfig) time.Duration {
+	if !modelConfig.Sync {
+		return 0
+	}
+	return g.syncWait
+}
+
+// QuotaUsage returns the usage counted against the configured limits
+func (g *Generator) QuotaUsage() QuotaUsage {
+	return g.quota.Usage()
+}
+
+// reserveQuota counts a prediction about to be created against the limits,
+// sending a budget warning when it brings today's estimated spend close to
+// the daily limit
+func (g *Generator) reserveQuota(storageID, model string, cost float64) error {
+	warn, err := g.quota.acquire(storageID, cost)
+	if err != nil {
+		logging.Warn("prediction refused by quota", "storage_id", storageID, "model", model, "error", err)
+		return err
+	}
+	if warn {
+		usage := g.quota.Usage()
+		g.notifier.Dispatch(notify.Event{
+			Type:      notify.EventBudgetWarning,
+			StorageID: storageID,
+			Model:     model,
+			Message:   fmt.Sprintf("Estimated spend today is $%.2f of the $%.2f daily limit", usage.SpentToday, g.quota.limits.MaxDailySpend),
+			Details: map[string]interface{}{
+				"spent_today":     usage.SpentToday,
+				"max_daily_spend": g.quota.limits.MaxDailySpend,
+			},
+		})
+	}
+	return nil
 }
 
 // GenerateTextToVideo generates a video from text prompt
@@ -42,43 +184,97 @@ func (g *Generator) GenerateTextToVideo(ctx context.Context, params VideoParams)
 		return nil, fmt.Errorf("model %s does not support text-to-video", params.Model)
 	}
 
+	if err := ValidateParams(params); err != nil {
+		return nil, err
+	}
+
+	if err := g.moderator.Check(ctx, params.Prompt); err != nil {
+		return nil, err
+	}
+
+	// Convert the style reference image, if any, to a data URL
+	var styleURL string
+	if params.StyleImagePath != "" {
+		dataURL, err := g.storage.ImageToDataURL(params.StyleImagePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert style image: %w", err)
+		}
+		styleURL = dataURL
+	}
+
 	// Build input parameters based on model
-	input := g.buildTextToVideoInput(params, modelConfig)
+	input := g.buildInput(params, modelConfig, inputImages{Style: styleURL})
 
 	// Create storage ID
-	storageID := g.storage.GenerateStorageID()
+	storageID, err := g.newStorageID(params)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create prediction
-	if g.debug {
-		log.Printf("DEBUG: Creating T2V prediction with model %s", modelConfig.ID)
+	// Keep a copy of the style image with the video
+	styleImage := ""
+	if params.StyleImagePath != "" {
+		if path, err := g.storage.SaveStyleImage(storageID, params.StyleImagePath); err != nil {
+			logging.Warn("failed to save style image", "storage_id", storageID, "error", err)
+		} else {
+			styleImage = filepath.Base(path)
+		}
 	}
 
-	prediction, err := g.client.CreatePrediction(ctx, modelConfig.ID, input)
+	// Create prediction
+	modelRef := modelConfig.ModelRef(params.Version)
+	logging.Debug("creating T2V prediction", "model", modelRef, "storage_id", storageID)
+
+	if err := g.reserveQuota(storageID, modelConfig.Name, modelConfig.EstimateCost(params.Duration)); err != nil {
+		g.discardStorage(storageID, params)
+		return nil, err
+	}
+	prediction, err := g.client.CreatePrediction(ctx, modelRef, input, g.createWait(modelConfig))
 	if err != nil {
+		g.quota.abort(storageID)
+		g.discardStorage(storageID, params)
 		return nil, fmt.Errorf("failed to create prediction: %w", err)
 	}
 
 	// Save metadata with consistent structure
 	metadata := map[string]interface{}{
-		"operation":     "text_to_video",
-		"status":        prediction.Status,
-		"prediction_id": prediction.ID,
-		"storage_id":    storageID,
-		"created_at":    time.Now().Format(time.RFC3339),
+		"operation":        "text_to_video",
+		"status":           prediction.Status,
+		"prediction_id":    prediction.ID,
+		"storage_id":       storageID,
+		"session_id":       params.SessionID,
+		"comparison_id":    params.ComparisonID,
+		"variation_set_id": params.VariationSetID,
+		"variation":        params.Variation,
+		"storyboard_id":    params.StoryboardID,
+		"filename":         params.Filename,
+		"storyboard_scene": params.Scene,
+		"output_dir":       params.OutputDir,
+		"project":          params.Project,
+		"created_at":       time.Now().Format(time.RFC3339),
 		
 		// Model information
 		"model": map[string]interface{}{
-			"id":   modelConfig.ID,
-			"name": modelConfig.Name,
+			"id":      modelConfig.ID,
+			"name":    modelConfig.Name,
+			"version": versionOf(modelRef),
 		},
 		
 		// Parameters (user inputs)
 		"parameters": map[string]interface{}{
 			"prompt":          params.Prompt,
+			"style_image":     styleImage, // Relative path
 			"resolution":      params.Resolution,
 			"aspect_ratio":    params.AspectRatio,
 			"duration":        params.Duration,
 			"negative_prompt": params.NegativePrompt,
+			"guidance_scale":  params.GuidanceScale,
+			"inference_steps": params.InferenceSteps,
+			"camera_motion":   params.CameraMotion,
+			"motion_strength": params.MotionStrength,
+			"effect":          params.Effect,
+			"seed":            seedSetting(params),
+			"safety_checker":  safetyCheckerSetting(params, modelConfig),
 			"raw_input":       input, // Keep raw input for reference
 		},
 		
@@ -91,8 +287,18 @@ func (g *Generator) GenerateTextToVideo(ctx context.Context, params VideoParams)
 		"paths": map[string]interface{}{},
 	}
 
+	// Record parameters the model ignored, so the completed result reports them
+	if warnings := IgnoredParams(params); len(warnings) > 0 {
+		metadata["warnings"] = warnings
+	}
+
+	// Record the retry policy, so a transient failure is resubmitted
+	if retry := retrySetting(params.Retry); retry != nil {
+		metadata["auto_retry"] = retry
+	}
+
 	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
-		log.Printf("WARNING: Failed to save metadata: %v", err)
+		logging.Warn("failed to save metadata", "storage_id", storageID, "error", err)
 	}
 
 	// Return immediately with prediction ID (async by default)
@@ -125,55 +331,107 @@ func (g *Generator) GenerateImageToVideo(ctx context.Context, params VideoParams
 		return nil, fmt.Errorf("model %s does not support image-to-video", params.Model)
 	}
 
-	// Convert image to data URL
+	if err := ValidateParams(params); err != nil {
+		return nil, err
+	}
+
+	if err := g.moderator.Check(ctx, params.Prompt); err != nil {
+		return nil, err
+	}
+
+	// Convert images to data URLs
 	dataURL, err := g.storage.ImageToDataURL(params.ImagePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to convert image: %w", err)
 	}
+	images := inputImages{Image: dataURL}
+	for _, path := range params.ImagePaths {
+		url, err := g.storage.ImageToDataURL(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert image %s: %w", path, err)
+		}
+		images.Images = append(images.Images, url)
+	}
 
 	// Build input parameters based on model
-	input := g.buildImageToVideoInput(params, modelConfig, dataURL)
+	input := g.buildInput(params, modelConfig, images)
 
 	// Create storage ID
-	storageID := g.storage.GenerateStorageID()
+	storageID, err := g.newStorageID(params)
+	if err != nil {
+		return nil, err
+	}
 
-	// Save input image
-	if _, err := g.storage.SaveInputImage(storageID, params.ImagePath); err != nil {
-		log.Printf("WARNING: Failed to save input image: %v", err)
+	// Save input images
+	inputImagePath, err := g.storage.SaveInputImage(storageID, params.ImagePath)
+	if err != nil {
+		logging.Warn("failed to save input image", "storage_id", storageID, "error", err)
+	}
+	var extraImages []string
+	for i, path := range params.ImagePaths {
+		saved, err := g.storage.SaveInputImageN(storageID, path, i+2)
+		if err != nil {
+			logging.Warn("failed to save input image", "storage_id", storageID, "path", path, "error", err)
+			continue
+		}
+		extraImages = append(extraImages, filepath.Base(saved))
 	}
 
 	// Create prediction
-	if g.debug {
-		log.Printf("DEBUG: Creating I2V prediction with model %s", modelConfig.ID)
-	}
+	modelRef := modelConfig.ModelRef(params.Version)
+	logging.Debug("creating I2V prediction", "model", modelRef, "storage_id", storageID)
 
-	prediction, err := g.client.CreatePrediction(ctx, modelConfig.ID, input)
+	if err := g.reserveQuota(storageID, modelConfig.Name, modelConfig.EstimateCost(params.Duration)); err != nil {
+		g.discardStorage(storageID, params)
+		return nil, err
+	}
+	prediction, err := g.client.CreatePrediction(ctx, modelRef, input, g.createWait(modelConfig))
 	if err != nil {
+		g.quota.abort(storageID)
+		g.discardStorage(storageID, params)
 		return nil, fmt.Errorf("failed to create prediction: %w", err)
 	}
 
 	// Save metadata with consistent structure
 	metadata := map[string]interface{}{
-		"operation":     "image_to_video",
-		"status":        prediction.Status,
-		"prediction_id": prediction.ID,
-		"storage_id":    storageID,
-		"created_at":    time.Now().Format(time.RFC3339),
+		"operation":        "image_to_video",
+		"status":           prediction.Status,
+		"prediction_id":    prediction.ID,
+		"storage_id":       storageID,
+		"session_id":       params.SessionID,
+		"comparison_id":    params.ComparisonID,
+		"variation_set_id": params.VariationSetID,
+		"variation":        params.Variation,
+		"storyboard_id":    params.StoryboardID,
+		"filename":         params.Filename,
+		"storyboard_scene": params.Scene,
+		"output_dir":       params.OutputDir,
+		"project":          params.Project,
+		"created_at":       time.Now().Format(time.RFC3339),
 		
 		// Model information
 		"model": map[string]interface{}{
-			"id":   modelConfig.ID,
-			"name": modelConfig.Name,
+			"id":      modelConfig.ID,
+			"name":    modelConfig.Name,
+			"version": versionOf(modelRef),
 		},
 		
 		// Parameters (user inputs)
 		"parameters": map[string]interface{}{
 			"prompt":          params.Prompt,
 			"input_image":     "input" + filepath.Ext(params.ImagePath), // Relative path
+			"input_images":    extraImages,                              // Relative paths of image_paths
 			"resolution":      params.Resolution,
 			"aspect_ratio":    params.AspectRatio,
 			"duration":        params.Duration,
 			"negative_prompt": params.NegativePrompt,
+			"guidance_scale":  params.GuidanceScale,
+			"inference_steps": params.InferenceSteps,
+			"camera_motion":   params.CameraMotion,
+			"motion_strength": params.MotionStrength,
+			"effect":          params.Effect,
+			"seed":            seedSetting(params),
+			"safety_checker":  safetyCheckerSetting(params, modelConfig),
 			"raw_input":       input, // Keep raw input for reference
 		},
 		
@@ -186,8 +444,25 @@ func (g *Generator) GenerateImageToVideo(ctx context.Context, params VideoParams
 		"paths": map[string]interface{}{},
 	}
 
+	// Hash the input image for duplicate detection
+	if inputImagePath != "" {
+		if hash, err := storage.HashFile(inputImagePath); err == nil {
+			storage.SetHash(metadata, storage.HashInputImage, hash)
+		}
+	}
+
+	// Record parameters the model ignored, so the completed result reports them
+	if warnings := IgnoredParams(params); len(warnings) > 0 {
+		metadata["warnings"] = warnings
+	}
+
+	// Record the retry policy, so a transient failure is resubmitted
+	if retry := retrySetting(params.Retry); retry != nil {
+		metadata["auto_retry"] = retry
+	}
+
 	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
-		log.Printf("WARNING: Failed to save metadata: %v", err)
+		logging.Warn("failed to save metadata", "storage_id", storageID, "error", err)
 	}
 
 	// Return immediately with prediction ID (async by default)
@@ -210,15 +485,41 @@ func (g *Generator) GenerateImageToVideo(ctx context.Context, params VideoParams
 func (g *Generator) ContinueGeneration(ctx context.Context, predictionID string, storageID string, waitTime time.Duration) (*VideoResult, error) {
 	startTime := time.Now()
 
+	// A prediction that failed and was resubmitted continues as its retry
+	predictionID = g.latestPrediction(storageID, predictionID)
+
 	// Wait for completion with timeout
 	prediction, err := g.client.WaitForCompletion(ctx, predictionID, waitTime)
+	// A finished prediction frees its concurrency slot
+	if prediction != nil && prediction.Status != types.StatusStarting && prediction.Status != types.StatusProcessing {
+		g.quota.release(storageID)
+	}
 	if err != nil {
 		// Check if we at least got a prediction back
 		if prediction != nil {
+			// Transient failures are resubmitted when the generation asked
+			// for it, and the wait goes on with the new prediction
+			if prediction.Status == types.StatusFailed {
+				if retry := g.retryFailed(ctx, storageID, prediction, err); retry != nil {
+					return g.ContinueGeneration(ctx, retry.ID, storageID, max(waitTime-time.Since(startTime), time.Second))
+				}
+			}
+
+			var logs string
+			var progress float64
+			switch prediction.Status {
+			case types.StatusFailed, types.StatusCanceled:
+				g.recordFailure(storageID, predictionID, prediction.Status, err.Error(), prediction.Logs)
+				logs = prediction.Logs
+			case types.StatusStarting, types.StatusProcessing:
+				progress = LogProgress(prediction.Logs)
+			}
 			return &VideoResult{
 				ID:           storageID,
 				PredictionID: predictionID,
 				Status:       prediction.Status,
+				Logs:         logs,
+				Progress:     progress,
 				Metrics: VideoMetrics{
 					GenerationTime: time.Since(startTime).Seconds(),
 				},
@@ -229,40 +530,71 @@ func (g *Generator) ContinueGeneration(ctx context.Context, predictionID string,
 
 	// Check if succeeded
 	if prediction.Status != types.StatusSucceeded {
+		g.recordFailure(storageID, predictionID, prediction.Status, fmt.Sprintf("generation failed with status: %s", prediction.Status), prediction.Logs)
 		return &VideoResult{
 			ID:           storageID,
 			PredictionID: predictionID,
 			Status:       prediction.Status,
+			Logs:         prediction.Logs,
 			Metrics: VideoMetrics{
 				GenerationTime: time.Since(startTime).Seconds(),
 			},
 		}, fmt.Errorf("generation failed with status: %s", prediction.Status)
 	}
 
-	// Download video from output URL
-	outputURL, ok := prediction.Output.(string)
-	if !ok {
-		return nil, fmt.Errorf("unexpected output format: %T", prediction.Output)
+	// Download the video, and any other files, from the output
+	files := outputFiles(prediction.Output)
+	if len(files) == 0 {
+		return nil, fmt.Errorf("unexpected output format: no file URL in %T output", prediction.Output)
 	}
+	outputURL := files[0].URL
 
-	// Save video
-	videoPath, fileSize, err := g.storage.SaveVideoFromURL(outputURL, storageID, "")
-	if err != nil {
-		return nil, fmt.Errorf("failed to save video: %w", err)
+	// Hold the storage lock so concurrent continues (from this or another
+	// process) download the video once
+	unlock := g.storage.Lock(storageID)
+	defer unlock()
+	if result, ok := g.completedResult(storageID, predictionID); ok {
+		return result, nil
 	}
 
 	// Load existing metadata to preserve generation parameters
 	existingMetadata, err := g.storage.LoadMetadata(storageID)
 	if err != nil {
-		log.Printf("WARNING: Failed to load existing metadata: %v", err)
+		logging.Warn("failed to load existing metadata", "storage_id", storageID, "error", err)
 		existingMetadata = make(map[string]interface{})
 	}
-	
+
+	// Save the video under the filename requested when the generation
+	// started, checked again since metadata can be edited; otherwise the
+	// filename template names it
+	filename, _ := existingMetadata["filename"].(string)
+	if filename != "" {
+		if filename, err = storage.CleanFilename(filename); err != nil {
+			logging.Warn("ignoring requested filename", "storage_id", storageID, "error", err)
+			filename = ""
+		}
+	}
+	download, err := g.storage.SaveVideoFromURL(ctx, outputURL, storageID, filename)
+	if err != nil {
+		g.notify(notify.EventFailed, storageID, predictionID, "", err.Error())
+		return nil, fmt.Errorf("failed to save video: %w", err)
+	}
+
+	videoPath, fileSize := download.Path, download.Size
+
+	// Check the download is a complete, readable video before marking the
+	// generation completed; the next continue downloads it again
+	if err := g.storage.VerifyVideo(ctx, videoPath); err != nil {
+		os.Remove(videoPath)
+		g.notify(notify.EventFailed, storageID, predictionID, "", err.Error())
+		return nil, err
+	}
+
 	// Extract video metadata using ffmpeg if available
-	duration, resolution, _ := g.storage.ExtractVideoMetadata(videoPath)
+	duration, resolution, _ := g.storage.ExtractVideoMetadata(ctx, videoPath)
 	
 	// Generate thumbnail if ffmpeg is available
-	thumbnailPath, _ := g.storage.GenerateThumbnail(storageID, videoPath)
+	thumbnailPath, _ := g.storage.GenerateThumbnail(ctx, storageID, videoPath)
 	
 	// IMPORTANT: Start with existing metadata to preserve all original fields
 	metadata := existingMetadata
@@ -270,10 +602,11 @@ func (g *Generator) ContinueGeneration(ctx context.Context, predictionID string,
 	// Update status
 	metadata["status"] = "completed"
 	metadata["completed_at"] = time.Now().Format(time.RFC3339)
+	delete(metadata, "pending_at_shutdown")
 	
 	// Update paths with relative paths (consistent structure)
 	paths := map[string]interface{}{
-		"output": "video.mp4", // Always relative
+		"output": filepath.Base(videoPath), // Always relative
 	}
 	if thumbnailPath != "" {
 		paths["thumbnail"] = "thumbnail.jpg" // Always relative
@@ -293,7 +626,7 @@ func (g *Generator) ContinueGeneration(ctx context.Context, predictionID string,
 	if resolution != "" {
 		metrics["actual_resolution"] = resolution
 	}
-	metrics["format"] = "mp4"
+	metrics["format"] = strings.TrimPrefix(filepath.Ext(videoPath), ".")
 	if genType, ok := metadata["generation_type"].(string); ok {
 		metrics["generation_type"] = genType
 	}
@@ -301,11 +634,31 @@ func (g *Generator) ContinueGeneration(ctx context.Context, predictionID string,
 	
 	// Store the output URL separately for reference
 	metadata["output_url"] = outputURL
+	metadata["download"] = download.Metadata()
+
+	// Keep the other files the model produced, e.g. a separate audio track
+	if len(files) > 1 {
+		metadata["extra_outputs"] = g.saveExtraOutputs(ctx, storageID, files[1:], paths)
+	}
+
+	// Flag obviously broken videos
+	if g.qualityReport {
+		if report := g.checkQuality(ctx, storageID, videoPath, metadata); report != nil {
+			metadata["quality"] = report.Map()
+		}
+	}
+
+	// Record the hash computed while downloading, for duplicate detection
+	storage.SetHash(metadata, storage.HashVideo, download.SHA256)
 
 	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
-		log.Printf("WARNING: Failed to update metadata: %v", err)
+		logging.Warn("failed to update metadata", "storage_id", storageID, "error", err)
 	}
 
+	logging.Info("video generation completed", "storage_id", storageID, "prediction_id", predictionID, "path", videoPath, "size", fileSize)
+
+	g.notify(notify.EventCompleted, storageID, predictionID, videoPath, "")
+
 	result := &VideoResult{
 		ID:           storageID,
 		FilePath:     videoPath,
@@ -320,91 +673,144 @@ func (g *Generator) ContinueGeneration(ctx context.Context, predictionID string,
 	return result, nil
 }
 
-// buildTextToVideoInput builds input parameters for T2V generation
-func (g *Generator) buildTextToVideoInput(params VideoParams, config ModelConfig) map[string]interface{} {
-	input := make(map[string]interface{})
-	input["prompt"] = params.Prompt
-
-	// Handle resolution
-	if params.Resolution != "" {
-		input["resolution"] = params.Resolution
-	} else {
-		input["resolution"] = config.DefaultRes
+// completedResult returns the result of a generation whose video was
+// already downloaded, e.g. by a concurrent continue. Callers hold the storage
+// lock.
+func (g *Generator) completedResult(storageID, predictionID string) (*VideoResult, bool) {
+	metadata, err := g.storage.LoadMetadata(storageID)
+	if err != nil || metadata["status"] != "completed" {
+		return nil, false
+	}
+	record := storage.Record{StorageID: storageID, Metadata: metadata}
+	output := record.Paths()["output"]
+	if output == "" {
+		return nil, false
+	}
+	videoPath := filepath.Join(g.storage.GetStoragePath(storageID), output)
+	info, err := os.Stat(videoPath)
+	if err != nil {
+		return nil, false
 	}
 
-	// Handle aspect ratio
-	if params.AspectRatio != "" {
-		input["aspect_ratio"] = params.AspectRatio
+	logging.Debug("video already downloaded", "storage_id", storageID, "prediction_id", predictionID)
+	return &VideoResult{
+		ID:           storageID,
+		FilePath:     videoPath,
+		PredictionID: predictionID,
+		Status:       "completed",
+		Metrics: VideoMetrics{
+			FileSize: info.Size(),
+		},
+	}, true
+}
+
+// recordFailure marks a generation as failed or canceled in metadata so it is
+// no longer treated as pending, saves the prediction's logs to logs.txt for
+// debugging model-side failures, and sends a failure event
+func (g *Generator) recordFailure(storageID, predictionID, status, errMsg, logs string) {
+	err := g.storage.UpdateMetadata(storageID, func(metadata map[string]interface{}) error {
+		if len(metadata) == 0 {
+			return errNoMetadata
+		}
+		metadata["status"] = status
+		metadata["error"] = errMsg
+		metadata["completed_at"] = time.Now().Format(time.RFC3339)
+		if logs != "" {
+			if path, err := g.storage.SaveLogs(storageID, logs); err != nil {
+				logging.Warn("failed to save prediction logs", "storage_id", storageID, "error", err)
+			} else {
+				paths, _ := metadata["paths"].(map[string]interface{})
+				if paths == nil {
+					paths = make(map[string]interface{})
+				}
+				paths["logs"] = filepath.Base(path)
+				metadata["paths"] = paths
+			}
+		}
+		return nil
+	})
+	if err != nil && err != errNoMetadata {
+		logging.Warn("failed to update metadata", "storage_id", storageID, "error", err)
 	}
 
-	// Model-specific parameters
-	switch params.Model {
-	case "wan-t2v-fast":
-		input["go_fast"] = true
-		input["num_frames"] = 81 // Default
-		input["frames_per_second"] = 16
-		input["sample_shift"] = 12
-		input["optimize_prompt"] = false
+	g.notify(notify.EventFailed, storageID, predictionID, "", errMsg)
+}
 
-	case "veo3":
-		if params.NegativePrompt != "" {
-			input["negative_prompt"] = params.NegativePrompt
-		}
+// errNoMetadata skips metadata updates for generations this server didn't record
+var errNoMetadata = errors.New("no metadata")
 
-	case "kling-master":
-		if params.Duration > 0 {
-			input["duration"] = params.Duration
-		} else {
-			input["duration"] = 5 // Default
-		}
-		if params.NegativePrompt != "" {
-			input["negative_prompt"] = params.NegativePrompt
-		}
+// notify sends a completion or failure event, enriched with the model and
+// prompt recorded in metadata
+func (g *Generator) notify(eventType, storageID, predictionID, path, errMsg string) {
+	if !g.notifier.HasNotifiers(eventType) {
+		return
 	}
 
-	return input
+	event := notify.Event{
+		Type:         eventType,
+		StorageID:    storageID,
+		PredictionID: predictionID,
+		Path:         path,
+		Error:        errMsg,
+	}
+	g.describeEvent(&event)
+
+	switch eventType {
+	case notify.EventCompleted:
+		event.Message = fmt.Sprintf("Video %s completed", storageID)
+	case notify.EventFailed:
+		event.Message = fmt.Sprintf("Video %s failed", storageID)
+	default:
+		event.Message = fmt.Sprintf("%s: %s", eventType, storageID)
+	}
+	if event.Model != "" {
+		event.Message += fmt.Sprintf(" (%s)", event.Model)
+	}
+
+	g.notifier.Dispatch(event)
 }
 
-// buildImageToVideoInput builds input parameters for I2V generation
-func (g *Generator) buildImageToVideoInput(params VideoParams, config ModelConfig, dataURL string) map[string]interface{} {
-	input := make(map[string]interface{})
-	input["prompt"] = params.Prompt
-	input["image"] = dataURL
-
-	// Handle resolution
-	if params.Resolution != "" {
-		input["resolution"] = params.Resolution
-	} else {
-		input["resolution"] = config.DefaultRes
-	}
-
-	// Model-specific parameters
-	switch params.Model {
-	case "wan-i2v-fast":
-		input["go_fast"] = true
-		input["num_frames"] = 81 // Default
-		input["frames_per_second"] = 16
-		input["sample_shift"] = 12
-		input["disable_safety_checker"] = false
-
-	case "veo3":
-		if params.NegativePrompt != "" {
-			input["negative_prompt"] = params.NegativePrompt
-		}
+// describeEvent adds the model and prompt recorded in metadata to an event
+func (g *Generator) describeEvent(event *notify.Event) {
+	metadata, err := g.storage.LoadMetadata(event.StorageID)
+	if err != nil {
+		return
+	}
+	if model, ok := metadata["model"].(map[string]interface{}); ok {
+		event.Model, _ = model["name"].(string)
+	}
+	if params, ok := metadata["parameters"].(map[string]interface{}); ok {
+		event.Prompt, _ = params["prompt"].(string)
+	}
+}
 
-	case "kling-master":
-		// For kling-master in I2V mode, it requires start_image
-		delete(input, "image")
-		input["start_image"] = dataURL
-		if params.Duration > 0 {
-			input["duration"] = params.Duration
-		} else {
-			input["duration"] = 5 // Default
-		}
-		if params.NegativePrompt != "" {
-			input["negative_prompt"] = params.NegativePrompt
+// checkQuality analyzes a completed video, adding the no_audio flag when the
+// model is known to generate sound. Failures are logged, since the report
+// is optional.
+func (g *Generator) checkQuality(ctx context.Context, storageID, videoPath string, metadata map[string]interface{}) *storage.QualityReport {
+	report, err := g.storage.AnalyzeQuality(ctx, videoPath)
+	if err != nil {
+		logging.Warn("quality report failed", "storage_id", storageID, "error", err)
+		return nil
+	}
+
+	if !report.HasAudio {
+		if alias, ok := recordModelAlias(storage.Record{StorageID: storageID, Metadata: metadata}); ok && hasFeature(ModelConfigs[alias], "audio") {
+			report.Flags = append(report.Flags, storage.FlagNoAudio)
 		}
 	}
+	if len(report.Flags) > 0 {
+		logging.Warn("quality check flagged video", "storage_id", storageID, "flags", report.Flags)
+	}
+	return report
+}
 
-	return input
-}
\ No newline at end of file
+// hasFeature reports whether a model lists a feature
+func hasFeature(config ModelConfig, feature string) bool {
+	for _, f := range config.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,219 @@
+package generation
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// AutoModel is the model value that lets the server pick a model
+const AutoModel = "auto"
+
+// unhealthySuccessRate is the success rate below which a model with enough
+// recent generations is not recommended
+const unhealthySuccessRate = 0.5
+
+// minHealthSamples is how many finished generations a model needs before its
+// success rate is trusted
+const minHealthSamples = 3
+
+// ModelHealth summarizes a model's recent finished generations
+type ModelHealth struct {
+	Samples     int // Completed plus failed generations considered
+	Succeeded   int
+	Failed      int
+	SuccessRate float64       // Succeeded / Samples; 1 without samples
+	MedianWait  time.Duration // Median creation-to-completion time of the successes; zero without any
+}
+
+// Healthy reports whether the model has not been failing recently
+func (h ModelHealth) Healthy() bool {
+	return h.Samples < minHealthSamples || h.SuccessRate >= unhealthySuccessRate
+}
+
+// ModelHealth returns the health of every registered model from the last
+// historySampleSize finished generations of each in the storage index
+func (g *Generator) ModelHealth() map[string]ModelHealth {
+	waits := make(map[string][]time.Duration)
+	health := make(map[string]ModelHealth)
+	for _, record := range g.storage.ListRecords(storage.Filter{}) { // Newest first
+		status := record.String("status")
+		if status != "completed" && status != types.StatusFailed {
+			continue
+		}
+		alias, ok := recordModelAlias(record)
+		if !ok {
+			continue
+		}
+		h := health[alias]
+		if h.Samples >= historySampleSize {
+			continue
+		}
+		h.Samples++
+		if status == types.StatusFailed {
+			h.Failed++
+		} else {
+			h.Succeeded++
+			created, err1 := time.Parse(time.RFC3339, record.String("created_at"))
+			completed, err2 := time.Parse(time.RFC3339, record.String("completed_at"))
+			if err1 == nil && err2 == nil && completed.After(created) {
+				waits[alias] = append(waits[alias], completed.Sub(created))
+			}
+		}
+		health[alias] = h
+	}
+
+	for alias := range ModelConfigs {
+		h := health[alias]
+		h.SuccessRate = 1
+		if h.Samples > 0 {
+			h.SuccessRate = float64(h.Succeeded) / float64(h.Samples)
+		}
+		if samples := waits[alias]; len(samples) > 0 {
+			sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+			h.MedianWait = samples[len(samples)/2].Round(time.Second)
+		}
+		health[alias] = h
+	}
+	return health
+}
+
+// ModelConstraints limits which models RecommendModel may pick. Zero values
+// don't constrain.
+type ModelConstraints struct {
+	Mode       string  // "t2v" or "i2v"
+	MaxCost    float64 // Estimated USD per generation
+	MaxWait    time.Duration
+	NeedAudio  bool
+	Resolution string // e.g. "1080p"; the model must be able to produce at least this
+	Duration   int    // Seconds, for the cost of models priced by duration; 0 for their default
+}
+
+// ModelCandidate is a model considered by RecommendModel
+type ModelCandidate struct {
+	Model        string
+	Name         string
+	Cost         float64
+	ExpectedWait time.Duration
+	Health       ModelHealth
+	Excluded     string // Why the model doesn't fit the constraints; empty if it does
+}
+
+// RecommendModel ranks the registered models for the constraints. Models
+// that fit come first, best first: higher quality tier, then higher recent
+// success rate, then lower cost, then shorter expected wait. Models that
+// don't fit follow with the reason. It fails when no model fits.
+func (g *Generator) RecommendModel(constraints ModelConstraints) ([]ModelCandidate, error) {
+	if constraints.Resolution != "" {
+		if _, ok := resolutionLines(constraints.Resolution); !ok {
+			return nil, fmt.Errorf("invalid resolution %q: use a preset such as 720p", constraints.Resolution)
+		}
+	}
+
+	health := g.ModelHealth()
+	candidates := make([]ModelCandidate, 0, len(ModelConfigs))
+	for alias, config := range ModelConfigs {
+		candidate := ModelCandidate{
+			Model:        alias,
+			Name:         config.Name,
+			Cost:         config.EstimateCost(constraints.Duration),
+			ExpectedWait: health[alias].MedianWait,
+			Health:       health[alias],
+		}
+		if candidate.ExpectedWait == 0 {
+			candidate.ExpectedWait = g.ExpectedDuration(alias)
+		}
+		candidate.Excluded = excludedReason(alias, config, candidate, constraints)
+		candidates = append(candidates, candidate)
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if (a.Excluded == "") != (b.Excluded == "") {
+			return a.Excluded == ""
+		}
+		if qa, qb := qualityTier(ModelConfigs[a.Model]), qualityTier(ModelConfigs[b.Model]); qa != qb {
+			return qa > qb
+		}
+		if a.Health.SuccessRate != b.Health.SuccessRate {
+			return a.Health.SuccessRate > b.Health.SuccessRate
+		}
+		if a.Cost != b.Cost {
+			return a.Cost < b.Cost
+		}
+		if a.ExpectedWait != b.ExpectedWait {
+			return a.ExpectedWait < b.ExpectedWait
+		}
+		return a.Model < b.Model
+	})
+
+	if len(candidates) == 0 || candidates[0].Excluded != "" {
+		return candidates, fmt.Errorf("no registered model fits the constraints")
+	}
+	return candidates, nil
+}
+
+// excludedReason returns why a model doesn't fit the constraints, or ""
+func excludedReason(alias string, config ModelConfig, candidate ModelCandidate, constraints ModelConstraints) string {
+	switch {
+	case constraints.Mode == "t2v" && !IsTextToVideoModel(alias):
+		return "does not support text-to-video"
+	case constraints.Mode == "i2v" && !IsImageToVideoModel(alias):
+		return "does not support image-to-video"
+	case constraints.NeedAudio && !hasFeature(config, "audio"):
+		return "does not generate audio"
+	case constraints.MaxCost > 0 && candidate.Cost > constraints.MaxCost:
+		return fmt.Sprintf("costs about $%.2f (max $%.2f)", candidate.Cost, constraints.MaxCost)
+	case constraints.MaxWait > 0 && candidate.ExpectedWait > constraints.MaxWait:
+		return fmt.Sprintf("typically takes %s (max %s)", candidate.ExpectedWait, constraints.MaxWait)
+	case constraints.Resolution != "" && maxResolution(config) == "":
+		return "has no resolution setting"
+	case constraints.Resolution != "" && !supportsResolution(config, constraints.Resolution):
+		return fmt.Sprintf("can't produce %s (max %s)", constraints.Resolution, maxResolution(config))
+	case !candidate.Health.Healthy():
+		return fmt.Sprintf("only %d of its last %d generations succeeded", candidate.Health.Succeeded, candidate.Health.Samples)
+	}
+	return ""
+}
+
+// qualityTier ranks models by their quality features
+func qualityTier(config ModelConfig) int {
+	switch {
+	case hasFeature(config, "premium"):
+		return 3
+	case hasFeature(config, "high_quality"):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// supportsResolution reports whether a model can produce at least the
+// target resolution
+func supportsResolution(config ModelConfig, target string) bool {
+	want, _ := resolutionLines(target)
+	have, ok := resolutionLines(maxResolution(config))
+	return ok && have >= want
+}
+
+// maxResolution returns the highest resolution preset a model produces
+func maxResolution(config ModelConfig) string {
+	best, bestLines := config.DefaultRes, 0
+	for _, res := range append([]string{config.DefaultRes}, config.Resolutions...) {
+		if lines, ok := resolutionLines(res); ok && lines > bestLines {
+			best, bestLines = res, lines
+		}
+	}
+	return best
+}
+
+// resolutionLines parses a preset such as "720p"
+func resolutionLines(resolution string) (int, bool) {
+	lines, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(resolution), "p"))
+	return lines, err == nil && lines > 0
+}
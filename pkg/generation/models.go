@@ -1,23 +1,44 @@
 package generation
 
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
 // ModelConfig holds configuration for a video model
 type ModelConfig struct {
-	ID          string
-	Name        string
-	Type        string // "t2v", "i2v", or "both"
-	DefaultRes  string
-	MaxDuration int
-	Features    []string
+	ID            string
+	Version       string // Pinned version hash; empty uses Replicate's latest
+	Name          string
+	Type          string // "t2v", "i2v", or "both"
+	DefaultRes    string
+	Resolutions   []string // Other resolution presets the model accepts
+	MaxDuration   int
+	TypicalWait   int     // Typical seconds from creation to completion
+	Sync          bool    // Often done within a minute, so predictions are created waiting for the result
+	Cost          float64 // Estimated USD per generation at default settings
+	CostPerSecond float64 // Estimated USD per second of video, for models priced by duration
+	Features      []string
+	Inputs        InputMapping // How VideoParams map to the model's input keys
 }
 
 // ModelAliases maps short aliases to full model names
 var ModelAliases = map[string]string{
-	"wan-t2v-fast": "wan-video/wan-2.2-t2v-fast",
-	"wan-i2v-fast": "wan-video/wan-2.2-i2v-fast",
-	"veo3":         "google/veo-3",
-	"kling-master": "kwaivgi/kling-v2.1-master",
-	"wan-i2v-full": "wan-video/wan-2.2-i2v-a14b",
-	"kling":        "kwaivgi/kling-v2.1",
+	"wan-t2v-fast":   "wan-video/wan-2.2-t2v-fast",
+	"wan-i2v-fast":   "wan-video/wan-2.2-i2v-fast",
+	"veo3":           "google/veo-3",
+	"kling-master":   "kwaivgi/kling-v2.1-master",
+	"wan-i2v-full":   "wan-video/wan-2.2-i2v-a14b",
+	"kling-standard": "kwaivgi/kling-v2.1",
+	"kling-pro":      "kwaivgi/kling-v2.1",
+	"ltx":            "lightricks/ltx-video",
+	"hunyuan":        "tencent/hunyuan-video",
+	"hailuo":         "minimax/video-01",
+	"veo3.1":         "google/veo-3.1",
+	"veo3-fast":      "google/veo-3-fast",
+	"veo2":           "google/veo-2",
+	"pixverse":       "pixverse/pixverse-v4.5",
 }
 
 // ModelConfigs holds configuration for each model
@@ -27,32 +48,329 @@ var ModelConfigs = map[string]ModelConfig{
 		Name:        "Wan 2.2 Fast Text-to-Video",
 		Type:        "t2v",
 		DefaultRes:  "480p",
+		Resolutions: []string{"720p"},
 		MaxDuration: 0, // Uses frames instead
-		Features:    []string{"fast", "affordable", "go_fast"},
+		TypicalWait: 60,
+		Sync:        true,
+		Cost:        0.05,
+		Features:    []string{"fast", "affordable", "go_fast", "frame_control", "sample_shift", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:      "resolution",
+			AspectRatio:     "aspect_ratio",
+			NumFrames:       "num_frames",
+			FramesPerSecond: "frames_per_second",
+			Frames:          Range{Min: 81, Max: 121, Default: 81},
+			FPS:             Range{Min: 5, Max: 30, Default: 16},
+			GoFast:          "go_fast",
+			DefaultGoFast:   true,
+			SampleShift:     "sample_shift",
+			Shift:           FloatRange{Min: 1, Max: 20, Default: 12},
+			NegativePrompt:  "negative_prompt",
+			CameraPrompt:    true,
+			Seed:            "seed",
+			Fixed: map[string]interface{}{
+				"optimize_prompt": false,
+			},
+		},
 	},
 	"wan-i2v-fast": {
 		ID:          "wan-video/wan-2.2-i2v-fast",
 		Name:        "Wan 2.2 Fast Image-to-Video",
 		Type:        "i2v",
 		DefaultRes:  "480p",
+		Resolutions: []string{"720p"},
+		MaxDuration: 0, // Uses frames instead
+		TypicalWait: 60,
+		Sync:        true,
+		Cost:        0.05,
+		Features:    []string{"fast", "affordable", "go_fast", "frame_control", "sample_shift", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:      "resolution",
+			AspectRatio:     "aspect_ratio",
+			Image:           "image",
+			NumFrames:       "num_frames",
+			FramesPerSecond: "frames_per_second",
+			Frames:          Range{Min: 81, Max: 121, Default: 81},
+			FPS:             Range{Min: 5, Max: 30, Default: 16},
+			GoFast:          "go_fast",
+			DefaultGoFast:   true,
+			SampleShift:     "sample_shift",
+			Shift:           FloatRange{Min: 1, Max: 20, Default: 12},
+			NegativePrompt:  "negative_prompt",
+			CameraPrompt:    true,
+			DisableSafety:   "disable_safety_checker",
+			Seed:            "seed",
+		},
+	},
+	"wan-i2v-full": {
+		ID:          "wan-video/wan-2.2-i2v-a14b",
+		Name:        "Wan 2.2 A14B Image-to-Video",
+		Type:        "i2v",
+		DefaultRes:  "480p",
+		Resolutions: []string{"720p"},
 		MaxDuration: 0, // Uses frames instead
-		Features:    []string{"fast", "affordable", "go_fast"},
+		TypicalWait: 180,
+		Cost:        0.45,
+		Features:    []string{"high_quality", "frame_control", "sample_shift", "inference_steps", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:      "resolution",
+			Image:           "image",
+			NumFrames:       "num_frames",
+			FramesPerSecond: "frames_per_second",
+			Frames:          Range{Min: 81, Max: 121, Default: 81},
+			FPS:             Range{Min: 5, Max: 30, Default: 16},
+			GoFast:          "go_fast",
+			DefaultGoFast:   false,
+			SampleShift:     "sample_shift",
+			Shift:           FloatRange{Min: 1, Max: 20, Default: 5},
+			InferenceSteps:  "sample_steps",
+			Steps:           Range{Min: 1, Max: 40, Default: 30},
+			CameraPrompt:    true,
+			DisableSafety:   "disable_safety_checker",
+			Seed:            "seed",
+		},
+	},
+	"ltx": {
+		ID:          "lightricks/ltx-video",
+		Name:        "LTX Video",
+		Type:        "both",
+		MaxDuration: 0,
+		TypicalWait: 40,
+		Cost:        0.05,
+		Features:    []string{"fast", "negative_prompt", "guidance_scale", "inference_steps", "camera_motion"},
+		Inputs: InputMapping{
+			AspectRatio:    "aspect_ratio",
+			Image:          "image",
+			NegativePrompt: "negative_prompt",
+			GuidanceScale:  "cfg",
+			Guidance:       FloatRange{Min: 1, Max: 20, Default: 3},
+			InferenceSteps: "steps",
+			Steps:          Range{Min: 1, Max: 50, Default: 30},
+			CameraPrompt:   true,
+			Seed:           "seed",
+		},
+	},
+	"hunyuan": {
+		ID:          "tencent/hunyuan-video",
+		Name:        "HunyuanVideo",
+		Type:        "t2v",
+		DefaultRes:  "480p",
+		Resolutions: []string{"720p"},
+		MaxDuration: 0,
+		TypicalWait: 300,
+		Cost:        1.00,
+		Features:    []string{"high_quality", "guidance_scale", "inference_steps", "camera_motion"},
+		Inputs: InputMapping{
+			Width:          "width",
+			Height:         "height",
+			GuidanceScale:  "embedded_guidance_scale",
+			Guidance:       FloatRange{Min: 1, Max: 10, Default: 6},
+			InferenceSteps: "infer_steps",
+			Steps:          Range{Min: 1, Max: 50, Default: 50},
+			CameraPrompt:   true,
+			Seed:           "seed",
+		},
+	},
+	"hailuo": {
+		ID:          "minimax/video-01",
+		Name:        "Hailuo Video-01 (MiniMax)",
+		Type:        "both",
+		DefaultRes:  "720p",
+		MaxDuration: 6,
+		TypicalWait: 240,
+		Cost:        0.50,
+		Features:    []string{"subject_reference", "camera_motion"},
+		Inputs: InputMapping{
+			Image:        "first_frame_image",
+			StyleImage:   "subject_reference",
+			CameraPrompt: true,
+		},
 	},
 	"veo3": {
 		ID:          "google/veo-3",
 		Name:        "Google Veo 3",
 		Type:        "both",
 		DefaultRes:  "720p",
+		Resolutions: []string{"1080p"},
 		MaxDuration: 0,
-		Features:    []string{"premium", "audio", "style_preservation", "negative_prompt"},
+		TypicalWait: 180,
+		Cost:        6.00,
+		Features:    []string{"premium", "audio", "style_preservation", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:     "resolution",
+			AspectRatio:    "aspect_ratio",
+			Image:          "image",
+			NegativePrompt: "negative_prompt",
+			CameraPrompt:   true,
+			Seed:           "seed",
+		},
+	},
+	"veo3.1": {
+		ID:          "google/veo-3.1",
+		Name:        "Google Veo 3.1",
+		Type:        "both",
+		DefaultRes:  "720p",
+		Resolutions: []string{"1080p"},
+		MaxDuration: 0,
+		TypicalWait: 180,
+		Cost:        3.20,
+		Features:    []string{"premium", "audio", "reference_images", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:     "resolution",
+			AspectRatio:    "aspect_ratio",
+			Image:          "image",
+			Images:         "reference_images",
+			MaxImages:      3,
+			NegativePrompt: "negative_prompt",
+			CameraPrompt:   true,
+			Seed:           "seed",
+		},
+	},
+	"veo3-fast": {
+		ID:          "google/veo-3-fast",
+		Name:        "Google Veo 3 Fast",
+		Type:        "both",
+		DefaultRes:  "720p",
+		Resolutions: []string{"1080p"},
+		MaxDuration: 0,
+		TypicalWait: 90,
+		Cost:        1.20,
+		Features:    []string{"high_quality", "fast", "audio", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:     "resolution",
+			AspectRatio:    "aspect_ratio",
+			Image:          "image",
+			NegativePrompt: "negative_prompt",
+			CameraPrompt:   true,
+			Seed:           "seed",
+		},
+	},
+	"veo2": {
+		ID:            "google/veo-2",
+		Name:          "Google Veo 2",
+		Type:          "both",
+		DefaultRes:    "720p", // The only resolution; there is no input for it
+		MaxDuration:   8,
+		TypicalWait:   150,
+		Cost:          2.50,
+		CostPerSecond: 0.50,
+		Features:      []string{"high_quality", "duration_control", "camera_motion"},
+		Inputs: InputMapping{
+			AspectRatio:     "aspect_ratio",
+			Image:           "image",
+			Duration:        "duration",
+			DefaultDuration: 5,
+			MinDuration:     5,
+			CameraPrompt:    true,
+			Seed:            "seed",
+		},
 	},
 	"kling-master": {
-		ID:          "kwaivgi/kling-v2.1-master",
-		Name:        "Kling 2.1 Master",
+		ID:            "kwaivgi/kling-v2.1-master",
+		Name:          "Kling 2.1 Master",
+		Type:          "both",
+		DefaultRes:    "1080p",
+		MaxDuration:   10,
+		TypicalWait:   240,
+		Cost:          1.40,
+		CostPerSecond: 0.28,
+		Features:      []string{"high_quality", "duration_control", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:      "resolution",
+			AspectRatio:     "aspect_ratio",
+			Image:           "start_image",
+			Duration:        "duration",
+			DefaultDuration: 5,
+			Durations:       []int{5, 10},
+			NegativePrompt:  "negative_prompt",
+			CameraPrompt:    true,
+		},
+	},
+	// Kling 2.1 takes its quality tier as a mode, so each mode is registered
+	// as a model of its own with the mode fixed
+	"kling-standard": {
+		ID:            "kwaivgi/kling-v2.1",
+		Name:          "Kling 2.1 Standard",
+		Type:          "i2v",
+		DefaultRes:    "720p",
+		MaxDuration:   10,
+		TypicalWait:   180,
+		Cost:          0.25,
+		CostPerSecond: 0.05,
+		Features:      []string{"affordable", "duration_control", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Image:           "start_image",
+			Duration:        "duration",
+			DefaultDuration: 5,
+			Durations:       []int{5, 10},
+			NegativePrompt:  "negative_prompt",
+			CameraPrompt:    true,
+			Fixed: map[string]interface{}{
+				"mode": "standard",
+			},
+		},
+	},
+	"kling-pro": {
+		ID:            "kwaivgi/kling-v2.1",
+		Name:          "Kling 2.1 Pro",
+		Type:          "i2v",
+		DefaultRes:    "1080p",
+		MaxDuration:   10,
+		TypicalWait:   240,
+		Cost:          0.45,
+		CostPerSecond: 0.09,
+		Features:      []string{"high_quality", "duration_control", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Image:           "start_image",
+			Duration:        "duration",
+			DefaultDuration: 5,
+			Durations:       []int{5, 10},
+			NegativePrompt:  "negative_prompt",
+			CameraPrompt:    true,
+			Fixed: map[string]interface{}{
+				"mode": "pro",
+			},
+		},
+	},
+	"pixverse": {
+		ID:          "pixverse/pixverse-v4.5",
+		Name:        "PixVerse v4.5",
 		Type:        "both",
-		DefaultRes:  "1080p",
-		MaxDuration: 10,
-		Features:    []string{"high_quality", "duration_control", "negative_prompt"},
+		DefaultRes:  "540p",
+		Resolutions: []string{"360p", "720p", "1080p"},
+		MaxDuration: 8,
+		TypicalWait: 60,
+		Cost:        0.30,
+		Features:    []string{"affordable", "effects", "duration_control", "negative_prompt", "camera_motion"},
+		Inputs: InputMapping{
+			Resolution:      "quality",
+			AspectRatio:     "aspect_ratio",
+			Image:           "image",
+			Duration:        "duration",
+			DefaultDuration: 5,
+			Durations:       []int{5, 8},
+			NegativePrompt:  "negative_prompt",
+			CameraPrompt:    true,
+			Seed:            "seed",
+			Effect:          "effect",
+			Effects: map[string]string{
+				"anything_robot":  "Anything, Robot",
+				"emergency_beat":  "Emergency Beat",
+				"evil_trigger":    "Evil Trigger",
+				"ghibli_live":     "Ghibli Live!",
+				"kungfu_club":     "Kungfu Club",
+				"mega_dive":       "Mega Dive",
+				"microwave_360":   "360° Microwave",
+				"mint_in_box":     "Mint in Box",
+				"muscle_surge":    "Muscle Surge",
+				"retro_anime_pop": "Retro Anime Pop",
+				"subject_3_fever": "Subject 3 Fever",
+				"suit_swagger":    "Suit Swagger",
+				"vogue_walk":      "Vogue Walk",
+				"warmth_of_jesus": "Warmth of Jesus",
+				"ymca":            "Let's YMCA!",
+			},
+		},
 	},
 }
 
@@ -65,6 +383,125 @@ func GetModelID(alias string) string {
 	return alias
 }
 
+// FindModelAlias returns the alias of the registered model with the given
+// Replicate model ID. Where several share the ID, such as kling-standard and
+// kling-pro, the first by alias is returned; FindModelAliasForInput tells
+// them apart.
+func FindModelAlias(modelID string) (string, bool) {
+	return FindModelAliasForInput(modelID, nil)
+}
+
+// FindModelAliasForInput returns the alias of the registered model with the
+// given Replicate model ID whose fixed inputs, such as Kling's mode, match
+// a prediction's input
+func FindModelAliasForInput(modelID string, input map[string]interface{}) (string, bool) {
+	aliases := make([]string, 0, len(ModelConfigs))
+	for alias, config := range ModelConfigs {
+		if config.ID == modelID {
+			aliases = append(aliases, alias)
+		}
+	}
+	if len(aliases) == 0 {
+		return "", false
+	}
+	sort.Strings(aliases)
+	for _, alias := range aliases {
+		if fixedInputsMatch(ModelConfigs[alias].Inputs.Fixed, input) {
+			return alias, true
+		}
+	}
+	return aliases[0], true
+}
+
+// fixedInputsMatch reports whether input has every fixed input. Inputs read
+// back from metadata may have changed type, so values are compared as text.
+func fixedInputsMatch(fixed, input map[string]interface{}) bool {
+	for key, value := range fixed {
+		got, ok := input[key]
+		if !ok || fmt.Sprint(got) != fmt.Sprint(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// PinVersion pins a registered model to a specific Replicate version
+func PinVersion(alias, version string) error {
+	config, ok := ModelConfigs[alias]
+	if !ok {
+		return fmt.Errorf("unknown model: %s", alias)
+	}
+	if !validVersion(version) {
+		return fmt.Errorf("invalid version for %s: %q", alias, version)
+	}
+	config.Version = version
+	ModelConfigs[alias] = config
+	return nil
+}
+
+// PinVersions pins several models at once, keyed by alias
+func PinVersions(versions map[string]string) error {
+	for alias, version := range versions {
+		if err := PinVersion(alias, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ModelRef returns the reference passed to Replicate: "owner/model" for the
+// latest version, or "owner/model:version" when a version is given or pinned.
+// override takes precedence over the pinned version.
+func (c ModelConfig) ModelRef(override string) string {
+	version := c.Version
+	if override != "" {
+		version = override
+	}
+	if version == "" {
+		return c.ID
+	}
+	return c.ID + ":" + version
+}
+
+// validVersion reports whether s looks like a Replicate version ID
+func validVersion(s string) bool {
+	if s == "" || len(s) > 128 {
+		return false
+	}
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// EffectNames returns the effect templates a model accepts, sorted
+func (c ModelConfig) EffectNames() []string {
+	names := make([]string, 0, len(c.Inputs.Effects))
+	for name := range c.Inputs.Effects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// EstimateCost returns the estimated USD cost of a generation of duration
+// seconds, 0 for the model's default. Models priced by the second cost in
+// proportion to the duration; others cost Cost whatever it is.
+func (c ModelConfig) EstimateCost(duration int) float64 {
+	if c.CostPerSecond <= 0 || c.Inputs.Duration == "" {
+		return c.Cost
+	}
+	if duration <= 0 {
+		duration = c.Inputs.DefaultDuration
+	}
+	if duration <= 0 {
+		return c.Cost
+	}
+	return math.Round(c.CostPerSecond*float64(duration)*100) / 100 // To the cent
+}
+
 // GetModelConfig returns the configuration for a model
 func GetModelConfig(alias string) (ModelConfig, bool) {
 	config, ok := ModelConfigs[alias]
@@ -85,4 +522,4 @@ func IsImageToVideoModel(alias string) bool {
 		return config.Type == "i2v" || config.Type == "both"
 	}
 	return false
-}
\ No newline at end of file
+}
@@ -0,0 +1,48 @@
+package generation
+
+import "testing"
+
+func TestFindModelAliasForInput(t *testing.T) {
+	tests := []struct {
+		name    string
+		modelID string
+		input   map[string]interface{}
+		want    string
+		wantOK  bool
+	}{
+		{"single alias", "google/veo-3", nil, "veo3", true},
+		{"standard mode", "kwaivgi/kling-v2.1", map[string]interface{}{"mode": "standard"}, "kling-standard", true},
+		{"pro mode", "kwaivgi/kling-v2.1", map[string]interface{}{"mode": "pro"}, "kling-pro", true},
+		{"no input", "kwaivgi/kling-v2.1", nil, "kling-pro", true},
+		{"unknown model", "owner/unknown", nil, "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := FindModelAliasForInput(tt.modelID, tt.input)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("%s: FindModelAliasForInput(%q) = %q, %v, want %q, %v", tt.name, tt.modelID, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestEstimateCost(t *testing.T) {
+	tests := []struct {
+		model    string
+		duration int
+		want     float64
+	}{
+		{"kling-pro", 0, 0.45}, // The default duration
+		{"kling-pro", 10, 0.90},
+		{"kling-standard", 10, 0.50},
+		{"kling-master", 10, 2.80},
+		{"veo2", 8, 4.00},
+		{"hailuo", 10, 0.50}, // Priced per generation
+	}
+
+	for _, tt := range tests {
+		config, _ := GetModelConfig(tt.model)
+		if got := config.EstimateCost(tt.duration); got != tt.want {
+			t.Errorf("%s.EstimateCost(%d) = %g, want %g", tt.model, tt.duration, got, tt.want)
+		}
+	}
+}
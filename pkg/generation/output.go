@@ -0,0 +1,200 @@
+package generation
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// OutputFile is one file in a prediction's output
+type OutputFile struct {
+	Name string // Where it is in the output: a key, an index, or both, e.g. "video" or "previews_1"
+	URL  string
+}
+
+// videoKeys name the video in object outputs, in order of preference
+var videoKeys = []string{"video", "output", "url"}
+
+// videoExtensions are the output files that can be the video
+var videoExtensions = map[string]bool{".mp4": true, ".webm": true, ".mov": true, ".gif": true}
+
+// outputFiles lists the files in a prediction's output, which is a single
+// URL for the registered models but may be a list of URLs or an object of
+// named URLs, possibly nested, for other models. The video comes first:
+// the file under a videoKeys key, else the first with a video extension,
+// else the first file.
+func outputFiles(output interface{}) []OutputFile {
+	var files []OutputFile
+	collectOutputFiles(output, "", &files)
+	if len(files) == 0 {
+		return nil
+	}
+
+	video := -1
+	for _, key := range videoKeys {
+		for i, f := range files {
+			if video < 0 && (f.Name == key || strings.HasPrefix(f.Name, key+"_")) {
+				video = i
+			}
+		}
+	}
+	for i, f := range files {
+		if video < 0 && videoExtensions[urlExtension(f.URL)] {
+			video = i
+		}
+	}
+	if video > 0 {
+		first := files[video]
+		copy(files[1:video+1], files[:video])
+		files[0] = first
+	}
+	return files
+}
+
+// collectOutputFiles appends the URLs in output to files, named by where
+// they are. Object keys are visited in sorted order, so names and order are
+// stable.
+func collectOutputFiles(output interface{}, name string, files *[]OutputFile) {
+	join := func(part string) string {
+		if name == "" {
+			return part
+		}
+		return name + "_" + part
+	}
+	switch v := output.(type) {
+	case string:
+		if isOutputURL(v) {
+			*files = append(*files, OutputFile{Name: name, URL: v})
+		}
+	case []interface{}:
+		for i, item := range v {
+			collectOutputFiles(item, join(strconv.Itoa(i)), files)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			collectOutputFiles(v[key], join(key), files)
+		}
+	}
+}
+
+// isOutputURL reports whether a string in the output is a file to download
+// rather than, say, a caption or seed
+func isOutputURL(s string) bool {
+	return strings.HasPrefix(s, "https://") || strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "file://")
+}
+
+// urlExtension returns the lowercased extension of a URL's path, e.g.
+// ".mp4", or "" when it has none
+func urlExtension(rawURL string) string {
+	p := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		p = u.Path
+	}
+	return strings.ToLower(path.Ext(p))
+}
+
+// contentTypeExtensions give output files without an extension in their
+// URL one from the type the server sends
+var contentTypeExtensions = map[string]string{
+	"video/mp4":            ".mp4",
+	"video/webm":           ".webm",
+	"video/quicktime":      ".mov",
+	"image/gif":            ".gif",
+	"image/jpeg":           ".jpg",
+	"image/png":            ".png",
+	"image/webp":           ".webp",
+	"audio/mp4":            ".m4a",
+	"audio/x-m4a":          ".m4a",
+	"audio/mpeg":           ".mp3",
+	"audio/wav":            ".wav",
+	"audio/x-wav":          ".wav",
+	"application/json":     ".json",
+	"text/vtt":             ".vtt",
+	"application/x-subrip": ".srt",
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// extraOutputFilename names the file an extra output is saved as: its name
+// in the output with the URL's extension, e.g. audio.m4a, or output_1.mp4
+// for list items
+func extraOutputFilename(f OutputFile) string {
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(f.Name, "_"), "_")
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "output_" + name
+	}
+	ext := urlExtension(f.URL)
+	if ext == "" || len(ext) > 6 {
+		ext = ".bin"
+	}
+	return strings.TrimSuffix(name, "_") + ext
+}
+
+// saveExtraOutputs downloads the output files besides the video, adds each
+// to paths under its filename without the extension, e.g. "audio", and
+// returns a record of each for metadata. A file that fails to download is
+// recorded with its error rather than failing the generation.
+func (g *Generator) saveExtraOutputs(ctx context.Context, storageID string, files []OutputFile, paths map[string]interface{}) []interface{} {
+	var records []interface{}
+	used := map[string]bool{"metadata.yaml": true, "thumbnail.jpg": true}
+	for _, p := range paths {
+		if name, ok := p.(string); ok {
+			used[name] = true
+		}
+	}
+	unique := func(filename string) string {
+		ext := path.Ext(filename)
+		base := strings.TrimSuffix(filename, ext)
+		for i := 2; used[filename] || paths[strings.TrimSuffix(filename, ext)] != nil; i++ {
+			filename = base + "_" + strconv.Itoa(i) + ext
+		}
+		used[filename] = true
+		return filename
+	}
+
+	for _, f := range files {
+		filename := unique(extraOutputFilename(f))
+
+		record := map[string]interface{}{
+			"name": f.Name,
+			"url":  f.URL,
+		}
+		download, err := g.storage.SaveVideoFromURL(ctx, f.URL, storageID, filename)
+		if err != nil {
+			logging.Warn("failed to download extra output", "storage_id", storageID, "name", f.Name, "error", err)
+			record["error"] = err.Error()
+		} else {
+			// Name files without an extension after their content type
+			if ext, ok := contentTypeExtensions[download.ContentType]; ok && path.Ext(filename) == ".bin" {
+				renamed := unique(strings.TrimSuffix(filename, ".bin") + ext)
+				folder := filepath.Dir(download.Path)
+				if err := os.Rename(download.Path, filepath.Join(folder, renamed)); err == nil {
+					delete(used, filename)
+					filename = renamed
+				}
+			}
+			record["file"] = filename
+			record["file_size"] = download.Size
+			record["sha256"] = download.SHA256
+			if download.ContentType != "" {
+				record["content_type"] = download.ContentType
+			}
+			paths[strings.TrimSuffix(filename, path.Ext(filename))] = filename
+		}
+		records = append(records, record)
+	}
+	return records
+}
@@ -0,0 +1,449 @@
+package generation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// Range bounds an integer parameter and gives its default
+type Range struct {
+	Min, Max, Default int
+}
+
+// contains reports whether v is within the range
+func (r Range) contains(v int) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// FloatRange bounds a fractional parameter and gives its default
+type FloatRange struct {
+	Min, Max, Default float64
+}
+
+// contains reports whether v is within the range
+func (r FloatRange) contains(v float64) bool {
+	return v >= r.Min && v <= r.Max
+}
+
+// validDuration reports whether d is one of the durations a model accepts
+func validDuration(durations []int, d int) bool {
+	for _, allowed := range durations {
+		if allowed == d {
+			return true
+		}
+	}
+	return false
+}
+
+// durationChoices lists the durations a model accepts, such as "5 or 10"
+func durationChoices(durations []int) string {
+	choices := make([]string, len(durations))
+	for i, d := range durations {
+		choices[i] = strconv.Itoa(d)
+	}
+	if len(choices) < 2 {
+		return strings.Join(choices, "")
+	}
+	return strings.Join(choices[:len(choices)-1], ", ") + " or " + choices[len(choices)-1]
+}
+
+// InputMapping declares how the canonical VideoParams map onto a model's
+// native input keys. An empty key means the model doesn't take that
+// parameter, so it is dropped.
+type InputMapping struct {
+	Resolution      string                 // Key for a resolution preset such as "720p"
+	Width           string                 // Keys for pixel dimensions, derived from the
+	Height          string                 // resolution and aspect ratio when set
+	AspectRatio     string                 // Key for the aspect ratio
+	AspectRatios    map[string]string      // Canonical ratio ("16:9") -> native spelling, when different
+	Image           string                 // Key for the image-to-video input image
+	StyleImage      string                 // Key for a style/reference image in text-to-video
+	Images          string                 // Key for an array of keyframe or reference images
+	MaxImages       int                    // Most images the Images input accepts
+	Duration        string                 // Key for the duration in seconds
+	DefaultDuration int                    // Sent when no duration is requested
+	Durations       []int                  // Durations the model accepts, when only some are
+	MinDuration     int                    // Shortest duration otherwise; ModelConfig.MaxDuration is the longest
+	NegativePrompt  string                 // Key for the negative prompt
+	NumFrames       string                 // Key for the number of frames
+	FramesPerSecond string                 // Key for the frame rate
+	Frames          Range                  // Allowed and default num_frames
+	FPS             Range                  // Allowed and default frames per second
+	GoFast          string                 // Key for the speed optimization toggle
+	DefaultGoFast   bool                   // Sent when go_fast isn't requested
+	SampleShift     string                 // Key for the sampling shift
+	Shift           FloatRange             // Allowed and default sample_shift
+	DisableSafety   string                 // Key for disabling the safety checker
+	GuidanceScale   string                 // Key for the guidance (CFG) scale
+	Guidance        FloatRange             // Allowed and default guidance scale
+	InferenceSteps  string                 // Key for the number of denoising steps
+	Steps           Range                  // Allowed and default inference steps
+	CameraMotion    string                 // Key for a camera movement input
+	CameraMotions   map[string]string      // Canonical movement -> native value for CameraMotion
+	CameraPrompt    bool                   // Describe the camera movement in the prompt instead
+	MotionStrength  string                 // Key for motion strength
+	Strength        FloatRange             // Allowed and default motion strength
+	Effect          string                 // Key for an effect template
+	Effects         map[string]string      // Effect name -> native value for Effect
+	Seed            string                 // Key for the random seed
+	Fixed           map[string]interface{} // Inputs sent unchanged with every request
+}
+
+// inputImages holds the data URLs of the images sent with a request
+type inputImages struct {
+	Image  string   // Image-to-video input image
+	Style  string   // Text-to-video style reference image
+	Images []string // Keyframe or reference images for the Images input
+}
+
+// buildInput translates params into the model's native input parameters,
+// with images given as data URLs
+func (g *Generator) buildInput(params VideoParams, config ModelConfig, images inputImages) map[string]interface{} {
+	mapping := config.Inputs
+	input := make(map[string]interface{})
+	input["prompt"] = params.Prompt
+
+	for key, value := range mapping.Fixed {
+		input[key] = value
+	}
+
+	if images.Image != "" && mapping.Image != "" {
+		input[mapping.Image] = images.Image
+	}
+	if images.Style != "" && mapping.StyleImage != "" {
+		input[mapping.StyleImage] = images.Style
+	}
+	if len(images.Images) > 0 && mapping.Images != "" {
+		input[mapping.Images] = images.Images
+	}
+
+	resolution := params.Resolution
+	if resolution == "" {
+		resolution = resolutionFor(params.Model, config)
+	}
+	if mapping.Resolution != "" && resolution != "" {
+		input[mapping.Resolution] = resolution
+	}
+	if mapping.Width != "" && mapping.Height != "" {
+		if width, height, ok := dimensionsFor(resolution, params.AspectRatio); ok {
+			input[mapping.Width] = width
+			input[mapping.Height] = height
+		}
+	}
+
+	if mapping.AspectRatio != "" && params.AspectRatio != "" {
+		if native, ok := mapping.AspectRatios[params.AspectRatio]; ok {
+			input[mapping.AspectRatio] = native
+		} else {
+			input[mapping.AspectRatio] = params.AspectRatio
+		}
+	}
+
+	if mapping.Duration != "" {
+		if params.Duration > 0 {
+			input[mapping.Duration] = params.Duration
+		} else if mapping.DefaultDuration > 0 {
+			input[mapping.Duration] = mapping.DefaultDuration
+		}
+	}
+
+	if mapping.NegativePrompt != "" && params.NegativePrompt != "" {
+		input[mapping.NegativePrompt] = params.NegativePrompt
+	}
+
+	if mapping.NumFrames != "" {
+		if params.NumFrames > 0 {
+			input[mapping.NumFrames] = params.NumFrames
+		} else if mapping.Frames.Default > 0 {
+			input[mapping.NumFrames] = mapping.Frames.Default
+		}
+	}
+
+	if mapping.FramesPerSecond != "" {
+		if params.FramesPerSecond > 0 {
+			input[mapping.FramesPerSecond] = params.FramesPerSecond
+		} else if mapping.FPS.Default > 0 {
+			input[mapping.FramesPerSecond] = mapping.FPS.Default
+		}
+	}
+
+	if mapping.GoFast != "" {
+		if params.GoFast != nil {
+			input[mapping.GoFast] = *params.GoFast
+		} else {
+			input[mapping.GoFast] = mapping.DefaultGoFast
+		}
+	}
+
+	if mapping.SampleShift != "" {
+		if params.SampleShift > 0 {
+			input[mapping.SampleShift] = params.SampleShift
+		} else if mapping.Shift.Default > 0 {
+			input[mapping.SampleShift] = mapping.Shift.Default
+		}
+	}
+
+	if mapping.DisableSafety != "" {
+		input[mapping.DisableSafety] = !safetyCheckerEnabled(params)
+	}
+
+	if mapping.GuidanceScale != "" {
+		if params.GuidanceScale > 0 {
+			input[mapping.GuidanceScale] = params.GuidanceScale
+		} else if mapping.Guidance.Default > 0 {
+			input[mapping.GuidanceScale] = mapping.Guidance.Default
+		}
+	}
+
+	if mapping.InferenceSteps != "" {
+		if params.InferenceSteps > 0 {
+			input[mapping.InferenceSteps] = params.InferenceSteps
+		} else if mapping.Steps.Default > 0 {
+			input[mapping.InferenceSteps] = mapping.Steps.Default
+		}
+	}
+
+	if params.CameraMotion != "" {
+		if mapping.CameraMotion != "" {
+			if native, ok := mapping.CameraMotions[params.CameraMotion]; ok {
+				input[mapping.CameraMotion] = native
+			} else {
+				input[mapping.CameraMotion] = params.CameraMotion
+			}
+		} else if mapping.CameraPrompt {
+			input["prompt"] = withCameraDirection(params.Prompt, promptCameraPhrases[params.CameraMotion])
+		}
+	}
+
+	if mapping.Effect != "" && params.Effect != "" {
+		input[mapping.Effect] = mapping.Effects[params.Effect]
+	}
+
+	if mapping.Seed != "" && params.Seed != nil {
+		input[mapping.Seed] = *params.Seed
+	}
+
+	if mapping.MotionStrength != "" {
+		if params.MotionStrength > 0 {
+			input[mapping.MotionStrength] = params.MotionStrength
+		} else if mapping.Strength.Default > 0 {
+			input[mapping.MotionStrength] = mapping.Strength.Default
+		}
+	}
+
+	return input
+}
+
+// ValidateParams checks model-specific parameters against the model's
+// input mapping, so unsupported or out-of-range values are rejected before
+// a prediction is created
+func ValidateParams(params VideoParams) error {
+	config, ok := GetModelConfig(params.Model)
+	if !ok {
+		return fmt.Errorf("unknown model: %s", params.Model)
+	}
+	mapping := config.Inputs
+
+	if params.Version != "" && !validVersion(params.Version) {
+		return fmt.Errorf("invalid model_version %q: expected a Replicate version ID", params.Version)
+	}
+
+	if params.Filename != "" {
+		if _, err := storage.CleanFilename(params.Filename); err != nil {
+			return err
+		}
+	}
+
+	if params.Duration > 0 && len(mapping.Durations) > 0 && !validDuration(mapping.Durations, params.Duration) {
+		return fmt.Errorf("duration must be %s seconds for %s", durationChoices(mapping.Durations), params.Model)
+	}
+	if params.Duration > 0 && mapping.Duration != "" && len(mapping.Durations) == 0 && config.MaxDuration > 0 &&
+		(params.Duration < mapping.MinDuration || params.Duration > config.MaxDuration) {
+		return fmt.Errorf("duration must be between %d and %d seconds for %s", mapping.MinDuration, config.MaxDuration, params.Model)
+	}
+
+	if params.NumFrames > 0 {
+		if mapping.NumFrames == "" {
+			return fmt.Errorf("model %s does not support num_frames", params.Model)
+		}
+		if !mapping.Frames.contains(params.NumFrames) {
+			return fmt.Errorf("num_frames must be between %d and %d for %s", mapping.Frames.Min, mapping.Frames.Max, params.Model)
+		}
+	}
+
+	if params.FramesPerSecond > 0 {
+		if mapping.FramesPerSecond == "" {
+			return fmt.Errorf("model %s does not support fps", params.Model)
+		}
+		if !mapping.FPS.contains(params.FramesPerSecond) {
+			return fmt.Errorf("fps must be between %d and %d for %s", mapping.FPS.Min, mapping.FPS.Max, params.Model)
+		}
+	}
+
+	if params.GoFast != nil && mapping.GoFast == "" {
+		return fmt.Errorf("model %s does not support go_fast", params.Model)
+	}
+
+	if params.SampleShift != 0 {
+		if mapping.SampleShift == "" {
+			return fmt.Errorf("model %s does not support sample_shift", params.Model)
+		}
+		if !mapping.Shift.contains(params.SampleShift) {
+			return fmt.Errorf("sample_shift must be between %g and %g for %s", mapping.Shift.Min, mapping.Shift.Max, params.Model)
+		}
+	}
+
+	if params.SafetyChecker != nil && mapping.DisableSafety == "" {
+		return fmt.Errorf("model %s does not support safety_checker", params.Model)
+	}
+
+	if len(params.ImagePaths) > 0 {
+		if mapping.Images == "" {
+			return fmt.Errorf("model %s does not support image_paths", params.Model)
+		}
+		if len(params.ImagePaths) > mapping.MaxImages {
+			return fmt.Errorf("model %s accepts at most %d image_paths", params.Model, mapping.MaxImages)
+		}
+	}
+
+	if params.StyleImagePath != "" && mapping.StyleImage == "" {
+		return fmt.Errorf("model %s does not support style_image_path", params.Model)
+	}
+
+	if params.GuidanceScale != 0 {
+		if mapping.GuidanceScale == "" {
+			return fmt.Errorf("model %s does not support guidance_scale", params.Model)
+		}
+		if !mapping.Guidance.contains(params.GuidanceScale) {
+			return fmt.Errorf("guidance_scale must be between %g and %g for %s", mapping.Guidance.Min, mapping.Guidance.Max, params.Model)
+		}
+	}
+
+	if params.InferenceSteps != 0 {
+		if mapping.InferenceSteps == "" {
+			return fmt.Errorf("model %s does not support num_inference_steps", params.Model)
+		}
+		if !mapping.Steps.contains(params.InferenceSteps) {
+			return fmt.Errorf("num_inference_steps must be between %d and %d for %s", mapping.Steps.Min, mapping.Steps.Max, params.Model)
+		}
+	}
+
+	if params.CameraMotion != "" {
+		if !validCameraMotion(params.CameraMotion) {
+			return fmt.Errorf("invalid camera_motion %q (expected one of: %s)", params.CameraMotion, strings.Join(CameraMotions, ", "))
+		}
+		if mapping.CameraMotion == "" && !mapping.CameraPrompt {
+			return fmt.Errorf("model %s does not support camera_motion", params.Model)
+		}
+	}
+
+	if params.MotionStrength != 0 {
+		if mapping.MotionStrength == "" {
+			return fmt.Errorf("model %s does not support motion_strength", params.Model)
+		}
+		if !mapping.Strength.contains(params.MotionStrength) {
+			return fmt.Errorf("motion_strength must be between %g and %g for %s", mapping.Strength.Min, mapping.Strength.Max, params.Model)
+		}
+	}
+
+	if params.Effect != "" {
+		if mapping.Effect == "" {
+			return fmt.Errorf("model %s does not support effect", params.Model)
+		}
+		if _, ok := mapping.Effects[params.Effect]; !ok {
+			return fmt.Errorf("invalid effect %q for %s (expected one of: %s)", params.Effect, params.Model, strings.Join(config.EffectNames(), ", "))
+		}
+	}
+
+	if err := validateRetry(params.Retry, params.Model, config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// IgnoredParams returns a warning for each parameter in params that the
+// model has no input for. Unlike the parameters ValidateParams rejects, these
+// are common to most models, so the request still goes ahead without them.
+func IgnoredParams(params VideoParams) []string {
+	config, ok := GetModelConfig(params.Model)
+	if !ok {
+		return nil
+	}
+	mapping := config.Inputs
+
+	var ignored []string
+	if params.NegativePrompt != "" && mapping.NegativePrompt == "" {
+		ignored = append(ignored, "negative_prompt")
+	}
+	if params.Duration > 0 && mapping.Duration == "" {
+		ignored = append(ignored, "duration")
+	}
+	if params.Resolution != "" && mapping.Resolution == "" && mapping.Width == "" {
+		ignored = append(ignored, "resolution")
+	}
+	if params.AspectRatio != "" && mapping.AspectRatio == "" && mapping.Width == "" {
+		ignored = append(ignored, "aspect_ratio")
+	}
+	if params.Seed != nil && mapping.Seed == "" {
+		ignored = append(ignored, "seed")
+	}
+
+	warnings := make([]string, 0, len(ignored))
+	for _, name := range ignored {
+		warnings = append(warnings, fmt.Sprintf("model %s does not support %s; it was ignored", params.Model, name))
+	}
+	return warnings
+}
+
+// safetyCheckerEnabled reports whether the safety checker stays on; it is
+// enabled unless explicitly turned off
+func safetyCheckerEnabled(params VideoParams) bool {
+	return params.SafetyChecker == nil || *params.SafetyChecker
+}
+
+// seedSetting returns the seed recorded in metadata, or nil when none was
+// requested
+func seedSetting(params VideoParams) interface{} {
+	if params.Seed == nil {
+		return nil
+	}
+	return *params.Seed
+}
+
+// safetyCheckerSetting returns the safety checker choice recorded in
+// metadata, or nil when the model has no safety checker parameter
+func safetyCheckerSetting(params VideoParams, config ModelConfig) interface{} {
+	if config.Inputs.DisableSafety == "" {
+		return nil
+	}
+	return safetyCheckerEnabled(params)
+}
+
+// dimensionsFor converts a resolution preset ("480p", "720p", "1080p") and
+// aspect ratio ("16:9", defaulting to 16:9) into even pixel dimensions, with
+// the preset giving the shorter side
+func dimensionsFor(resolution, aspectRatio string) (int, int, bool) {
+	short, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(resolution), "p"))
+	if err != nil || short <= 0 {
+		return 0, 0, false
+	}
+
+	w, h := 16, 9
+	if parts := strings.Split(aspectRatio, ":"); len(parts) == 2 {
+		pw, errW := strconv.Atoi(parts[0])
+		ph, errH := strconv.Atoi(parts[1])
+		if errW == nil && errH == nil && pw > 0 && ph > 0 {
+			w, h = pw, ph
+		}
+	}
+
+	even := func(v int) int { return v + v%2 }
+	if w >= h {
+		return even(short * w / h), short, true
+	}
+	return short, even(short * h / w), true
+}
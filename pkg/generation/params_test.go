@@ -0,0 +1,121 @@
+package generation
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuildInput(t *testing.T) {
+	config := ModelConfig{
+		DefaultRes: "720p",
+		Inputs: InputMapping{
+			Resolution:      "quality",
+			AspectRatio:     "ratio",
+			AspectRatios:    map[string]string{"16:9": "landscape"},
+			Image:           "start_image",
+			Duration:        "seconds",
+			DefaultDuration: 5,
+			Fixed:           map[string]interface{}{"mode": "pro"},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		params VideoParams
+		config ModelConfig
+		images inputImages
+		want   map[string]interface{}
+	}{
+		{
+			name:   "defaults",
+			params: VideoParams{Prompt: "a cat"},
+			config: config,
+			want:   map[string]interface{}{"prompt": "a cat", "mode": "pro", "quality": "720p", "seconds": 5},
+		},
+		{
+			name:   "native keys and spellings",
+			params: VideoParams{Prompt: "a cat", Resolution: "1080p", AspectRatio: "16:9", Duration: 10},
+			config: config,
+			images: inputImages{Image: "data:image/png;base64,AA=="},
+			want: map[string]interface{}{
+				"prompt": "a cat", "mode": "pro", "quality": "1080p", "ratio": "landscape",
+				"seconds": 10, "start_image": "data:image/png;base64,AA==",
+			},
+		},
+		{
+			name:   "unmapped params are dropped",
+			params: VideoParams{Prompt: "a cat", AspectRatio: "9:16", Duration: 10, NegativePrompt: "blur"},
+			config: ModelConfig{DefaultRes: "480p", Inputs: InputMapping{Width: "width", Height: "height"}},
+			want:   map[string]interface{}{"prompt": "a cat", "width": 480, "height": 854},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&Generator{}).buildInput(tt.params, tt.config, tt.images)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildInput() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateParamsDuration(t *testing.T) {
+	tests := []struct {
+		model    string
+		duration int
+		wantErr  string
+	}{
+		{"kling-pro", 0, ""},
+		{"kling-pro", 10, ""},
+		{"kling-pro", 7, "duration must be 5 or 10 seconds for kling-pro"},
+		{"kling-master", 8, "duration must be 5 or 10 seconds for kling-master"},
+		{"pixverse", 8, ""},
+		{"pixverse", 10, "duration must be 5 or 8 seconds for pixverse"},
+		{"veo2", 8, ""},
+		{"veo2", 4, "duration must be between 5 and 8 seconds for veo2"},
+		{"veo2", 9, "duration must be between 5 and 8 seconds for veo2"},
+	}
+
+	for _, tt := range tests {
+		err := ValidateParams(VideoParams{Model: tt.model, Duration: tt.duration})
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("ValidateParams(%s, %d) error = %v", tt.model, tt.duration, err)
+		}
+		if tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+			t.Errorf("ValidateParams(%s, %d) error = %v, want %q", tt.model, tt.duration, err, tt.wantErr)
+		}
+	}
+}
+
+func TestEffect(t *testing.T) {
+	tests := []struct {
+		model   string
+		effect  string
+		want    string
+		wantErr string
+	}{
+		{"pixverse", "ymca", "Let's YMCA!", ""},
+		{"pixverse", "moonwalk", "", `invalid effect "moonwalk" for pixverse`},
+		{"veo3", "ymca", "", "model veo3 does not support effect"},
+	}
+
+	for _, tt := range tests {
+		params := VideoParams{Model: tt.model, Prompt: "a robot", Effect: tt.effect}
+		err := ValidateParams(params)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateParams(%s, %s) error = %v, want %q", tt.model, tt.effect, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("ValidateParams(%s, %s) error = %v", tt.model, tt.effect, err)
+		}
+		config, _ := GetModelConfig(tt.model)
+		if got := (&Generator{}).buildInput(params, config, inputImages{})["effect"]; got != tt.want {
+			t.Errorf("buildInput() effect = %v, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package generation
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/notify"
+)
+
+// Many models log tqdm progress bars, e.g. " 45%|████▌     | 23/50 [00:10<00:12]";
+// the last percentage, or failing that the last step count, is the progress
+var (
+	percentPattern = regexp.MustCompile(`(\d{1,3})%\|`)
+	stepsPattern   = regexp.MustCompile(`(\d+)/(\d+) \[`)
+)
+
+// LogProgress returns the percent complete shown in a prediction's logs, or
+// 0 when they show none
+func LogProgress(logs string) float64 {
+	if matches := percentPattern.FindAllStringSubmatch(logs, -1); len(matches) > 0 {
+		percent, _ := strconv.ParseFloat(matches[len(matches)-1][1], 64)
+		return min(percent, 100)
+	}
+	if matches := stepsPattern.FindAllStringSubmatch(logs, -1); len(matches) > 0 {
+		last := matches[len(matches)-1]
+		done, _ := strconv.ParseFloat(last[1], 64)
+		total, _ := strconv.ParseFloat(last[2], 64)
+		if total > 0 && done <= total {
+			return float64(int(done / total * 100))
+		}
+	}
+	return 0
+}
+
+// NotifyProgress sends a progress event for a generation that is still
+// running, when a notifier subscribes to them. percent is 0 when unknown.
+func (g *Generator) NotifyProgress(storageID, predictionID, status string, percent float64) {
+	if !g.notifier.HasNotifiers(notify.EventProgress) {
+		return
+	}
+
+	event := notify.Event{
+		Type:         notify.EventProgress,
+		StorageID:    storageID,
+		PredictionID: predictionID,
+		Message:      fmt.Sprintf("Video %s %s", storageID, status),
+		Details: map[string]interface{}{
+			"status": status,
+		},
+	}
+	if percent > 0 {
+		event.Message += fmt.Sprintf(", %.0f%%", percent)
+		event.Details["percent"] = percent
+	}
+	g.describeEvent(&event)
+	if event.Model != "" {
+		event.Message += fmt.Sprintf(" (%s)", event.Model)
+	}
+
+	g.notifier.Dispatch(event)
+}
@@ -0,0 +1,273 @@
+package generation
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// StatusQueued marks a generation waiting in the queue for a free slot; it
+// has no prediction yet
+const StatusQueued = "queued"
+
+// Queue priorities, highest first
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+)
+
+// priorityRanks orders the priorities; queued generations that are due
+// start in rank order, oldest first within a rank
+var priorityRanks = map[string]int{PriorityHigh: 0, PriorityNormal: 1, PriorityLow: 2}
+
+// ValidPriority reports whether p is a queue priority
+func ValidPriority(p string) bool {
+	_, ok := priorityRanks[p]
+	return ok
+}
+
+// QueuedJob is a generation request waiting in the queue. The queue lives
+// in the metadata of each queued generation, so it survives restarts and is
+// listed from the storage index.
+type QueuedJob struct {
+	StorageID string
+	Tool      string                 // The generation tool the request was made to
+	Args      map[string]interface{} // The tool's arguments, replayed when it starts
+	Priority  string
+	Order     int64 // Position within its priority; lower starts first
+	QueuedAt  time.Time
+	Model     string
+	Prompt    string
+
+	// A deferred job waits until StartAfter, which comes from Schedule when
+	// the request gave a cron schedule
+	StartAfter time.Time
+	Schedule   string
+}
+
+// Due reports whether a queued job may start at now
+func (j QueuedJob) Due(now time.Time) bool {
+	return !now.Before(j.StartAfter)
+}
+
+// Enqueue records a generation request to start once a slot frees up, and
+// not before startAfter when it is set. It gets its storage ID (and folder,
+// for a project or output directory) now, and params.StorageID is set to it
+// when the job starts. schedule is the cron expression startAfter came
+// from, if any, kept for display.
+func (g *Generator) Enqueue(tool string, args map[string]interface{}, params VideoParams, priority string, startAfter time.Time, schedule string) (*QueuedJob, error) {
+	if priority == "" {
+		priority = PriorityNormal
+	}
+	if !ValidPriority(priority) {
+		return nil, fmt.Errorf("invalid priority %q (expected %s, %s, or %s)", priority, PriorityHigh, PriorityNormal, PriorityLow)
+	}
+
+	// Arguments are kept as JSON, so numbers come back as the float64s tool
+	// calls use rather than the ints YAML would give
+	request, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record request: %w", err)
+	}
+
+	storageID := g.storage.GenerateStorageID()
+	if err := g.placeStorage(storageID, params); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	job := &QueuedJob{
+		StorageID: storageID,
+		Tool:      tool,
+		Args:      args,
+		Priority:  priority,
+		Order:     now.UnixNano(),
+		QueuedAt:  now,
+		Model:     params.Model,
+		Prompt:    params.Prompt,
+
+		StartAfter: startAfter,
+		Schedule:   schedule,
+	}
+
+	modelName := params.Model
+	if config, ok := GetModelConfig(params.Model); ok {
+		modelName = config.Name
+	}
+	metadata := map[string]interface{}{
+		"status":     StatusQueued,
+		"storage_id": storageID,
+		"session_id": params.SessionID,
+		"output_dir": params.OutputDir,
+		"project":    params.Project,
+		"created_at": now.Format(time.RFC3339),
+		"model": map[string]interface{}{
+			"alias": params.Model,
+			"name":  modelName,
+		},
+		"parameters": map[string]interface{}{
+			"prompt": params.Prompt,
+		},
+		"queue": map[string]interface{}{
+			"tool":      tool,
+			"request":   string(request),
+			"priority":  priority,
+			"order":     job.Order,
+			"queued_at": now.Format(time.RFC3339),
+		},
+	}
+	if !startAfter.IsZero() {
+		queue := metadata["queue"].(map[string]interface{})
+		queue["start_after"] = startAfter.Format(time.RFC3339)
+		if schedule != "" {
+			queue["schedule"] = schedule
+		}
+	}
+	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
+		return nil, fmt.Errorf("failed to save queued generation: %w", err)
+	}
+
+	logging.Info("generation queued", "storage_id", storageID, "tool", tool, "model", params.Model, "priority", priority, "start_after", startAfter)
+	return job, nil
+}
+
+// QueuedJobs returns the queued generations in the order they will start:
+// those that are due by priority, then deferred ones by start time
+func (g *Generator) QueuedJobs() []QueuedJob {
+	records := g.storage.ListRecords(storage.Filter{Status: StatusQueued})
+	jobs := make([]QueuedJob, 0, len(records))
+	for _, record := range records {
+		job, ok := queuedJob(record.StorageID, record.Metadata)
+		if !ok {
+			logging.Warn("skipping queued generation with no request", "storage_id", record.StorageID)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	sortJobs(jobs, time.Now())
+	return jobs
+}
+
+// QueuedJob returns a queued generation by storage ID
+func (g *Generator) QueuedJob(storageID string) (QueuedJob, bool) {
+	metadata, err := g.storage.LoadMetadata(storageID)
+	if err != nil || metadata["status"] != StatusQueued {
+		return QueuedJob{}, false
+	}
+	return queuedJob(storageID, metadata)
+}
+
+// Reorder changes a queued generation's priority, when priority is set,
+// and moves it to the first or last place within its priority, when
+// position is "first" or "last"
+func (g *Generator) Reorder(storageID, priority, position string) error {
+	if priority != "" && !ValidPriority(priority) {
+		return fmt.Errorf("invalid priority %q (expected %s, %s, or %s)", priority, PriorityHigh, PriorityNormal, PriorityLow)
+	}
+	if position != "" && position != "first" && position != "last" {
+		return fmt.Errorf("invalid position %q (expected first or last)", position)
+	}
+	jobs := g.QueuedJobs()
+
+	return g.storage.UpdateMetadata(storageID, func(metadata map[string]interface{}) error {
+		queue, _ := metadata["queue"].(map[string]interface{})
+		if metadata["status"] != StatusQueued || queue == nil {
+			return fmt.Errorf("storage ID %s is not queued", storageID)
+		}
+		if priority == "" {
+			priority, _ = queue["priority"].(string)
+		}
+		queue["priority"] = priority
+
+		if position != "" {
+			var first, last int64
+			for _, job := range jobs {
+				if job.StorageID == storageID || job.Priority != priority {
+					continue
+				}
+				if first == 0 || job.Order < first {
+					first = job.Order
+				}
+				if job.Order > last {
+					last = job.Order
+				}
+			}
+			switch {
+			case position == "first" && first != 0:
+				queue["order"] = first - 1
+			case position == "last" && last != 0:
+				queue["order"] = last + 1
+			}
+		}
+		logging.Info("queued generation reordered", "storage_id", storageID, "priority", priority, "position", position)
+		return nil
+	})
+}
+
+// queuedJob reads a queued generation from its metadata
+func queuedJob(storageID string, metadata map[string]interface{}) (QueuedJob, bool) {
+	queue, _ := metadata["queue"].(map[string]interface{})
+	request, _ := queue["request"].(string)
+	tool, _ := queue["tool"].(string)
+	if request == "" || tool == "" {
+		return QueuedJob{}, false
+	}
+	var args map[string]interface{}
+	if err := json.Unmarshal([]byte(request), &args); err != nil {
+		return QueuedJob{}, false
+	}
+
+	job := QueuedJob{StorageID: storageID, Tool: tool, Args: args, Priority: PriorityNormal}
+	if priority, _ := queue["priority"].(string); ValidPriority(priority) {
+		job.Priority = priority
+	}
+	switch order := queue["order"].(type) {
+	case int:
+		job.Order = int64(order)
+	case int64:
+		job.Order = order
+	case float64:
+		job.Order = int64(order)
+	}
+	if queuedAt, _ := queue["queued_at"].(string); queuedAt != "" {
+		job.QueuedAt, _ = time.Parse(time.RFC3339, queuedAt)
+	}
+	if startAfter, _ := queue["start_after"].(string); startAfter != "" {
+		job.StartAfter, _ = time.Parse(time.RFC3339, startAfter)
+	}
+	job.Schedule, _ = queue["schedule"].(string)
+	if model, ok := metadata["model"].(map[string]interface{}); ok {
+		job.Model, _ = model["alias"].(string)
+	}
+	if params, ok := metadata["parameters"].(map[string]interface{}); ok {
+		job.Prompt, _ = params["prompt"].(string)
+	}
+	return job, true
+}
+
+// sortJobs orders queued generations that are due at now by priority, then
+// by order, followed by deferred ones by start time
+func sortJobs(jobs []QueuedJob, now time.Time) {
+	sort.SliceStable(jobs, func(i, j int) bool {
+		di, dj := jobs[i].Due(now), jobs[j].Due(now)
+		if di != dj {
+			return di
+		}
+		if !di && !jobs[i].StartAfter.Equal(jobs[j].StartAfter) {
+			return jobs[i].StartAfter.Before(jobs[j].StartAfter)
+		}
+		ri, rj := priorityRanks[jobs[i].Priority], priorityRanks[jobs[j].Priority]
+		if ri != rj {
+			return ri < rj
+		}
+		if jobs[i].Order != jobs[j].Order {
+			return jobs[i].Order < jobs[j].Order
+		}
+		return jobs[i].StorageID < jobs[j].StorageID
+	})
+}
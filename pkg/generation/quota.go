@@ -0,0 +1,248 @@
+package generation
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// ErrQuotaExceeded is wrapped by every QuotaError
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Limits reported in QuotaError
+const (
+	LimitConcurrent = "max_concurrent"
+	LimitPerHour    = "max_per_hour"
+	LimitDailySpend = "max_daily_spend"
+)
+
+// budgetWarningRatio is the share of the daily spend limit at which a
+// budget_warning event is sent
+const budgetWarningRatio = 0.8
+
+// QuotaError reports a prediction refused by a configured limit
+type QuotaError struct {
+	Limit   string    // Which limit was hit, e.g. max_per_hour
+	ResetAt time.Time // When the limit allows a new prediction; zero for max_concurrent
+	Message string
+}
+
+func (e *QuotaError) Error() string {
+	return e.Message
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrQuotaExceeded
+}
+
+// quotaEntry is one prediction counted against the limits
+type quotaEntry struct {
+	storageID string
+	startedAt time.Time
+	cost      float64
+	running   bool
+}
+
+// Quota enforces the prediction limits of one server instance. Usage is kept
+// in memory, so it starts over when the server restarts. A nil Quota allows
+// everything.
+type Quota struct {
+	mu      sync.Mutex
+	limits  config.LimitsConfig
+	entries []*quotaEntry
+	warned  string // Day a budget warning was last sent for
+	now     func() time.Time
+}
+
+// NewQuota creates a quota for the configured limits, or nil if none are set
+func NewQuota(limits config.LimitsConfig) *Quota {
+	if !limits.Enabled() {
+		return nil
+	}
+	return &Quota{limits: limits, now: time.Now}
+}
+
+// QuotaUsage is a snapshot of the counted usage
+type QuotaUsage struct {
+	Running    int
+	LastHour   int
+	SpentToday float64
+}
+
+// Usage returns the current usage
+func (q *Quota) Usage() QuotaUsage {
+	if q == nil {
+		return QuotaUsage{}
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.prune(now)
+	var usage QuotaUsage
+	dayStart := startOfDay(now)
+	for _, e := range q.entries {
+		if e.running {
+			usage.Running++
+		}
+		if now.Sub(e.startedAt) < time.Hour {
+			usage.LastHour++
+		}
+		if !e.startedAt.Before(dayStart) {
+			usage.SpentToday += e.cost
+		}
+	}
+	return usage
+}
+
+// acquire counts a prediction about to be created against the limits, or
+// returns a *QuotaError if it would exceed one. The second result reports
+// whether this prediction crossed the budget warning threshold.
+func (q *Quota) acquire(storageID string, cost float64) (bool, error) {
+	if q == nil {
+		return false, nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	q.prune(now)
+
+	running, lastHour := 0, 0
+	var oldestInHour time.Time
+	var spent float64
+	dayStart := startOfDay(now)
+	for _, e := range q.entries {
+		if e.running {
+			running++
+		}
+		if now.Sub(e.startedAt) < time.Hour {
+			if lastHour == 0 {
+				oldestInHour = e.startedAt
+			}
+			lastHour++
+		}
+		if !e.startedAt.Before(dayStart) {
+			spent += e.cost
+		}
+	}
+
+	limits := q.limits
+	if limits.MaxConcurrent > 0 && running >= limits.MaxConcurrent {
+		return false, &QuotaError{
+			Limit:   LimitConcurrent,
+			Message: fmt.Sprintf("quota exceeded: %d predictions are already running (limit %d); wait for one to finish", running, limits.MaxConcurrent),
+		}
+	}
+	if limits.MaxPerHour > 0 && lastHour >= limits.MaxPerHour {
+		resetAt := oldestInHour.Add(time.Hour)
+		return false, &QuotaError{
+			Limit:   LimitPerHour,
+			ResetAt: resetAt,
+			Message: fmt.Sprintf("quota exceeded: %d predictions started in the last hour (limit %d); next slot at %s", lastHour, limits.MaxPerHour, resetAt.Format(time.RFC3339)),
+		}
+	}
+	if limits.MaxDailySpend > 0 && spent+cost > limits.MaxDailySpend {
+		resetAt := dayStart.AddDate(0, 0, 1)
+		return false, &QuotaError{
+			Limit:   LimitDailySpend,
+			ResetAt: resetAt,
+			Message: fmt.Sprintf("quota exceeded: estimated spend today is $%.2f and this generation costs about $%.2f (limit $%.2f); resets at %s", spent, cost, limits.MaxDailySpend, resetAt.Format(time.RFC3339)),
+		}
+	}
+
+	q.entries = append(q.entries, &quotaEntry{storageID: storageID, startedAt: now, cost: cost, running: true})
+
+	warn := false
+	if limits.MaxDailySpend > 0 && spent+cost >= limits.MaxDailySpend*budgetWarningRatio {
+		day := dayStart.Format("2006-01-02")
+		if q.warned != day {
+			q.warned = day
+			warn = true
+		}
+	}
+	return warn, nil
+}
+
+// abort removes a prediction that could not be created, so it counts
+// against no limit. Finished predictions of the same generation, e.g. one
+// being retried, still count.
+func (q *Quota) abort(storageID string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, e := range q.entries {
+		if e.storageID == storageID && e.running {
+			q.entries = append(q.entries[:i], q.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+// release marks a prediction as finished. It keeps counting towards the
+// hourly and daily limits.
+func (q *Quota) release(storageID string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, e := range q.entries {
+		if e.storageID == storageID && e.running {
+			e.running = false
+			logging.Debug("released quota slot", "storage_id", storageID)
+			return
+		}
+	}
+}
+
+// prune drops finished entries that no longer count towards any limit
+func (q *Quota) prune(now time.Time) {
+	dayStart := startOfDay(now)
+	kept := q.entries[:0]
+	for _, e := range q.entries {
+		if e.running || now.Sub(e.startedAt) < time.Hour || !e.startedAt.Before(dayStart) {
+			kept = append(kept, e)
+		}
+	}
+	q.entries = kept
+}
+
+// startOfDay returns local midnight of t's day
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
+
+// EstimatedCost returns the estimated USD cost of a stored generation from
+// the cost table of the model it used. Custom models have no estimate.
+func (g *Generator) EstimatedCost(storageID string) (float64, bool) {
+	metadata, err := g.storage.LoadMetadata(storageID)
+	if err != nil {
+		return 0, false
+	}
+	return recordCost(storage.Record{StorageID: storageID, Metadata: metadata})
+}
+
+// recordCost returns the estimated USD cost of a generation from its model
+// and, for models priced by the second, the duration it was sent
+func recordCost(record storage.Record) (float64, bool) {
+	alias, ok := recordModelAlias(record)
+	if !ok {
+		return 0, false
+	}
+	config := ModelConfigs[alias]
+	params, _ := record.Metadata["parameters"].(map[string]interface{})
+	input, _ := params["raw_input"].(map[string]interface{})
+	cost := config.EstimateCost(metadataInt(input[config.Inputs.Duration]))
+	return cost, cost > 0
+}
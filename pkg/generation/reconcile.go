@@ -0,0 +1,88 @@
+package generation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// reconcileWait bounds how long reconciliation waits to download a
+// prediction that has already succeeded
+const reconcileWait = 30 * time.Second
+
+// ReconcileReport summarizes a startup reconciliation pass
+type ReconcileReport struct {
+	Completed []string // Storage IDs downloaded after finishing while the server was down
+	Failed    []string // Storage IDs marked failed or canceled
+	Pending   []string // Storage IDs still running on Replicate
+}
+
+// Reconcile checks every generation left in "starting" or "processing" (for
+// example because the server restarted mid-generation) against Replicate.
+// Predictions that have since succeeded are downloaded, and ones that failed
+// or were canceled are marked as such.
+func (g *Generator) Reconcile(ctx context.Context) ReconcileReport {
+	var report ReconcileReport
+
+	for _, record := range g.storage.ListRecords(storage.Filter{}) {
+		if ctx.Err() != nil {
+			break
+		}
+
+		status := record.String("status")
+		if status != types.StatusStarting && status != types.StatusProcessing {
+			continue
+		}
+		predictionID := record.String("prediction_id")
+		if predictionID == "" {
+			continue
+		}
+
+		prediction, err := g.client.GetPrediction(ctx, predictionID)
+		if err != nil {
+			logging.Warn("failed to reconcile prediction", "storage_id", record.StorageID, "prediction_id", predictionID, "error", err)
+			continue
+		}
+
+		switch prediction.Status {
+		case types.StatusSucceeded:
+			if _, err := g.ContinueGeneration(ctx, predictionID, record.StorageID, reconcileWait); err != nil {
+				logging.Warn("failed to download reconciled video", "storage_id", record.StorageID, "prediction_id", predictionID, "error", err)
+				continue
+			}
+			report.Completed = append(report.Completed, record.StorageID)
+
+		case types.StatusFailed, types.StatusCanceled:
+			g.recordFailure(record.StorageID, predictionID, prediction.Status, predictionError(prediction), prediction.Logs)
+			report.Failed = append(report.Failed, record.StorageID)
+
+		default:
+			report.Pending = append(report.Pending, record.StorageID)
+		}
+	}
+
+	logging.Info("reconciled pending generations",
+		"completed", len(report.Completed),
+		"failed", len(report.Failed),
+		"pending", len(report.Pending))
+	return report
+}
+
+// predictionError extracts a readable error message from a prediction
+func predictionError(prediction *types.ReplicatePredictionResponse) string {
+	switch e := prediction.Error.(type) {
+	case string:
+		if e != "" {
+			return e
+		}
+	case map[string]interface{}:
+		if msg, ok := e["message"]; ok {
+			return fmt.Sprintf("%v", msg)
+		}
+	}
+	return fmt.Sprintf("generation %s", prediction.Status)
+}
@@ -0,0 +1,96 @@
+package generation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// RecoverPrediction adopts a prediction this server has no record of, e.g.
+// one whose storage folder was wiped or that another client made. It fetches
+// the prediction from Replicate and saves a metadata record rebuilt from its
+// model, input, and timestamps under a new storage ID, so ContinueGeneration
+// can then download the video like any other. A prediction that is already
+// stored returns its existing record. Only SessionID, Project, and OutputDir
+// are used from params.
+func (g *Generator) RecoverPrediction(ctx context.Context, predictionID string, params VideoParams) (*VideoResult, error) {
+	if storageID, ok := g.storage.FindByPrediction(predictionID); ok {
+		logging.Debug("prediction already stored", "prediction_id", predictionID, "storage_id", storageID)
+		return &VideoResult{ID: storageID, PredictionID: predictionID}, nil
+	}
+
+	prediction, err := g.client.GetPrediction(ctx, predictionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch prediction: %w", err)
+	}
+
+	// Registered models keep their name, so timing hints and listings work
+	modelName := prediction.Model
+	modelAlias := ""
+	if alias, ok := FindModelAliasForInput(prediction.Model, prediction.Input); ok {
+		modelAlias = alias
+		modelName = ModelConfigs[alias].Name
+	}
+
+	storageID := g.storage.GenerateStorageID()
+	if err := g.placeStorage(storageID, params); err != nil {
+		return nil, err
+	}
+
+	// Replicate's timestamps have fractional seconds; metadata uses RFC 3339
+	createdAt := time.Now().Format(time.RFC3339)
+	if t, err := time.Parse(time.RFC3339Nano, prediction.CreatedAt); err == nil {
+		createdAt = t.Format(time.RFC3339)
+	}
+	prompt, _ := prediction.Input["prompt"].(string)
+
+	metadata := map[string]interface{}{
+		"operation":     "recovered",
+		"status":        prediction.Status,
+		"prediction_id": prediction.ID,
+		"storage_id":    storageID,
+		"session_id":    params.SessionID,
+		"output_dir":    params.OutputDir,
+		"project":       params.Project,
+		"created_at":    createdAt,
+		"recovered_at":  time.Now().Format(time.RFC3339),
+
+		// Model information
+		"model": map[string]interface{}{
+			"id":      prediction.Model,
+			"name":    modelName,
+			"version": prediction.Version,
+		},
+
+		// Parameters as Replicate recorded them
+		"parameters": map[string]interface{}{
+			"prompt":    prompt,
+			"raw_input": prediction.Input,
+		},
+
+		// Metrics (will be updated on completion)
+		"metrics": map[string]interface{}{
+			"generation_type": "recovered",
+		},
+
+		// Paths will be added on completion
+		"paths": map[string]interface{}{},
+	}
+
+	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
+		return nil, fmt.Errorf("failed to save metadata: %w", err)
+	}
+
+	logging.Info("recovered prediction", "prediction_id", predictionID, "storage_id", storageID, "model", prediction.Model, "status", prediction.Status)
+
+	return &VideoResult{
+		ID:           storageID,
+		Model:        modelAlias,
+		ModelName:    modelName,
+		PredictionID: prediction.ID,
+		Parameters:   prediction.Input,
+		Status:       prediction.Status,
+	}, nil
+}
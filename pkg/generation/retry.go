@@ -0,0 +1,218 @@
+package generation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// Strategies for resubmitting a failed generation
+const (
+	RetrySame    = "same"     // Resubmit the same input
+	RetryNewSeed = "new_seed" // Resubmit with a new random seed
+)
+
+// MaxAutoRetries is the most times a failed generation is resubmitted
+const MaxAutoRetries = 3
+
+// retryBackoff is how long the first resubmission waits; each later one
+// waits twice as long as the one before
+const retryBackoff = 5 * time.Second
+
+// RetryPolicy resubmits a generation whose prediction fails with a
+// transient model error (see client.ErrTransient)
+type RetryPolicy struct {
+	Count    int    // Resubmissions allowed; 0 turns retrying off
+	Strategy string // RetrySame or RetryNewSeed; empty is RetrySame
+}
+
+// validateRetry checks a retry policy for a model
+func validateRetry(policy RetryPolicy, model string, config ModelConfig) error {
+	if policy.Count < 0 || policy.Count > MaxAutoRetries {
+		return fmt.Errorf("auto_retry must be between 0 and %d", MaxAutoRetries)
+	}
+	switch policy.Strategy {
+	case "", RetrySame:
+	case RetryNewSeed:
+		if config.Inputs.Seed == "" {
+			return fmt.Errorf("model %s does not support retry_strategy %s", model, RetryNewSeed)
+		}
+	default:
+		return fmt.Errorf("invalid retry_strategy %q (expected %s or %s)", policy.Strategy, RetrySame, RetryNewSeed)
+	}
+	return nil
+}
+
+// retrySetting returns the retry policy recorded in metadata, or nil when
+// retrying is off
+func retrySetting(policy RetryPolicy) interface{} {
+	if policy.Count == 0 {
+		return nil
+	}
+	strategy := policy.Strategy
+	if strategy == "" {
+		strategy = RetrySame
+	}
+	return map[string]interface{}{
+		"count":    policy.Count,
+		"strategy": strategy,
+	}
+}
+
+// latestPrediction returns the prediction a generation is now waiting on,
+// following resubmissions, so a continue for a prediction that failed and
+// was retried polls its replacement
+func (g *Generator) latestPrediction(storageID, predictionID string) string {
+	metadata, err := g.storage.LoadMetadata(storageID)
+	if err != nil || len(Attempts(metadata)) == 0 {
+		return predictionID
+	}
+	if current, _ := metadata["prediction_id"].(string); current != "" && current != predictionID {
+		for _, attempt := range Attempts(metadata) {
+			if attempt["prediction_id"] == predictionID {
+				logging.Debug("following retried prediction", "storage_id", storageID, "prediction_id", predictionID, "retry", current)
+				return current
+			}
+		}
+	}
+	return predictionID
+}
+
+// retryFailed resubmits a generation whose prediction failed with a
+// transient error, when its retry policy allows another attempt, after
+// waiting out the backoff. The failed prediction is added to the attempts
+// in metadata and the new one replaces it. It returns the new prediction,
+// or nil when the failure should be recorded instead.
+func (g *Generator) retryFailed(ctx context.Context, storageID string, failed *types.ReplicatePredictionResponse, failure error) *types.ReplicatePredictionResponse {
+	if !errors.Is(failure, client.ErrTransient) {
+		return nil
+	}
+	metadata, err := g.storage.LoadMetadata(storageID)
+	if err != nil {
+		return nil
+	}
+	policy, ok := metadata["auto_retry"].(map[string]interface{})
+	attempts := Attempts(metadata)
+	if !ok || len(attempts) >= metadataInt(policy["count"]) {
+		return nil
+	}
+
+	backoff := retryBackoff << len(attempts)
+	logging.Info("retrying failed prediction", "storage_id", storageID, "prediction_id", failed.ID, "attempt", len(attempts)+2, "backoff", backoff, "error", failure)
+	select {
+	case <-time.After(backoff):
+	case <-ctx.Done():
+		return nil
+	}
+
+	// Hold the storage lock so concurrent continues resubmit once
+	unlock := g.storage.Lock(storageID)
+	defer unlock()
+	metadata, err = g.storage.LoadMetadata(storageID)
+	if err != nil {
+		return nil
+	}
+	if current, _ := metadata["prediction_id"].(string); current != failed.ID {
+		// Another continue already resubmitted it
+		prediction, err := g.client.GetPrediction(ctx, current)
+		if err != nil {
+			return nil
+		}
+		return prediction
+	}
+
+	model, _ := metadata["model"].(map[string]interface{})
+	alias, ok := recordModelAlias(storage.Record{StorageID: storageID, Metadata: metadata})
+	if !ok {
+		return nil
+	}
+	modelConfig := ModelConfigs[alias]
+	version, _ := model["version"].(string)
+	if version == "latest" {
+		version = ""
+	}
+
+	params, _ := metadata["parameters"].(map[string]interface{})
+	rawInput, _ := params["raw_input"].(map[string]interface{})
+	input := make(map[string]interface{}, len(rawInput))
+	for key, value := range rawInput {
+		input[key] = value
+	}
+	strategy, _ := policy["strategy"].(string)
+	if strategy == RetryNewSeed && modelConfig.Inputs.Seed != "" {
+		input[modelConfig.Inputs.Seed] = rand.Intn(1_000_000)
+	}
+
+	if err := g.reserveQuota(storageID, modelConfig.Name, modelConfig.EstimateCost(metadataInt(rawInput[modelConfig.Inputs.Duration]))); err != nil {
+		return nil
+	}
+	prediction, err := g.client.CreatePrediction(ctx, modelConfig.ModelRef(version), input, 0)
+	if err != nil {
+		g.quota.abort(storageID)
+		logging.Warn("failed to resubmit prediction", "storage_id", storageID, "prediction_id", failed.ID, "error", err)
+		return nil
+	}
+
+	attempt := map[string]interface{}{
+		"prediction_id": failed.ID,
+		"status":        failed.Status,
+		"error":         failure.Error(),
+		"failed_at":     time.Now().Format(time.RFC3339),
+	}
+	if seed, ok := rawInput[modelConfig.Inputs.Seed]; ok && modelConfig.Inputs.Seed != "" {
+		attempt["seed"] = seed
+	}
+	history := make([]interface{}, 0, len(attempts)+1)
+	for _, a := range attempts {
+		history = append(history, a)
+	}
+	metadata["attempts"] = append(history, attempt)
+	metadata["prediction_id"] = prediction.ID
+	metadata["status"] = prediction.Status
+	if params != nil {
+		params["raw_input"] = input
+		if strategy == RetryNewSeed {
+			params["seed"] = input[modelConfig.Inputs.Seed]
+		}
+	}
+	if err := g.storage.SaveMetadata(storageID, metadata); err != nil {
+		logging.Warn("failed to update metadata", "storage_id", storageID, "error", err)
+	}
+
+	logging.Info("resubmitted failed prediction", "storage_id", storageID, "failed", failed.ID, "prediction_id", prediction.ID, "strategy", strategy)
+	return prediction
+}
+
+// Attempts returns the failed predictions a generation was resubmitted
+// after, oldest first
+func Attempts(metadata map[string]interface{}) []map[string]interface{} {
+	list, _ := metadata["attempts"].([]interface{})
+	attempts := make([]map[string]interface{}, 0, len(list))
+	for _, item := range list {
+		if attempt, ok := item.(map[string]interface{}); ok {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return attempts
+}
+
+// metadataInt reads a number from metadata, which YAML decodes as int and
+// JSON as float64
+func metadataInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
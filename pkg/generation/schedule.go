@@ -0,0 +1,166 @@
+package generation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxDeferral is how far ahead a queued generation can be scheduled
+const MaxDeferral = 30 * 24 * time.Hour
+
+// cronAliases are the cron shorthands accepted in a schedule
+var cronAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+// cronSchedule is a parsed five-field cron expression, each field a set of
+// allowed values
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	anyDom, anyDow                bool // The day fields were "*"
+}
+
+// ParseStartAfter parses a start_after value: an RFC 3339 timestamp, or a
+// delay from now such as "8h" or "90m"
+func ParseStartAfter(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid start_after %q (expected an RFC 3339 time such as 2025-01-02T01:00:00Z, or a delay such as 8h)", value)
+}
+
+// NextScheduled returns the first time after now that matches a cron
+// expression ("minute hour day-of-month month day-of-week", in local time,
+// or @hourly, @daily, @weekly, @monthly). Fields take *, values, ranges
+// (1-5), steps (*/15, 0-30/10), and lists of these.
+func NextScheduled(expr string, now time.Time) (time.Time, error) {
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	// Skip whole months, days and hours that can't match
+	t := now.Truncate(time.Minute).Add(time.Minute)
+	limit := now.Add(MaxDeferral)
+	for !t.After(limit) {
+		switch {
+		case !inCronSet(schedule.month, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !schedule.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !inCronSet(schedule.hour, t.Hour()):
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case !inCronSet(schedule.minute, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("schedule %q has no time in the next %d days", expr, int(MaxDeferral.Hours()/24))
+}
+
+// parseCron parses a cron expression or shorthand
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if alias, ok := cronAliases[expr]; ok {
+		expr = alias
+	}
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid schedule %q (expected five fields: minute hour day-of-month month day-of-week)", expr)
+	}
+
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid schedule minute: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid schedule hour: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid schedule day of month: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid schedule month: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid schedule day of week: %w", err)
+	}
+	// Sunday is 0 or 7
+	if inCronSet(s.dow, 7) {
+		s.dow |= 1
+	}
+	s.anyDom = strings.HasPrefix(fields[2], "*")
+	s.anyDow = strings.HasPrefix(fields[4], "*")
+	return &s, nil
+}
+
+// parseCronField parses one cron field into a set of values
+func parseCronField(field string, min, max int) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("bad step in %q", part)
+			}
+			step = n
+		}
+
+		lo, hi := min, max
+		if rangePart != "*" {
+			loPart, hiPart, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if lo, err = strconv.Atoi(loPart); err != nil {
+				return 0, fmt.Errorf("bad value in %q", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiPart); err != nil {
+					return 0, fmt.Errorf("bad value in %q", part)
+				}
+			} else if hasStep {
+				hi = max
+			}
+		}
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("%q is outside %d-%d", part, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+// matchesDay checks the day fields; as in cron, when both are restricted a
+// day matching either one matches
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	dom := inCronSet(s.dom, t.Day())
+	dow := inCronSet(s.dow, int(t.Weekday()))
+	switch {
+	case s.anyDom && s.anyDow:
+		return true
+	case s.anyDom:
+		return dow
+	case s.anyDow:
+		return dom
+	}
+	return dom || dow
+}
+
+func inCronSet(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
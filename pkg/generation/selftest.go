@@ -0,0 +1,102 @@
+package generation
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// healthCheckTimeout limits each check that talks to Replicate
+const healthCheckTimeout = 15 * time.Second
+
+// HealthCheck runs the self-test: whether api.replicate.com can be reached,
+// whether the API token is valid, whether the storage root is writable, and
+// whether ffmpeg and ffprobe are installed. It reports each check and which
+// features work.
+func (g *Generator) HealthCheck(ctx context.Context) types.HealthReport {
+	report := types.HealthReport{Healthy: true}
+	status := make(map[string]string)
+	run := func(name string, check func() (string, string)) {
+		start := time.Now()
+		result, message := check()
+		report.Checks = append(report.Checks, types.HealthCheck{
+			Name:       name,
+			Status:     result,
+			Message:    message,
+			DurationMs: time.Since(start).Milliseconds(),
+		})
+		status[name] = result
+		if result == types.HealthError {
+			report.Healthy = false
+		}
+	}
+
+	run("network", func() (string, string) {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		start := time.Now()
+		if err := g.client.Ping(checkCtx); err != nil {
+			return types.HealthError, err.Error()
+		}
+		return types.HealthOK, fmt.Sprintf("api.replicate.com reachable in %dms", time.Since(start).Milliseconds())
+	})
+
+	run("api_token", func() (string, string) {
+		checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+		account, err := g.client.CheckAccount(checkCtx)
+		switch {
+		case err != nil:
+			return types.HealthError, fmt.Sprintf("could not check the token: %v", err)
+		case !account.TokenValid:
+			return types.HealthError, account.Message
+		case account.BillingStatus == types.BillingIssue:
+			return types.HealthWarning, fmt.Sprintf("token is valid, but the account has a billing issue: %s", account.BillingDetail)
+		case account.Account != nil:
+			return types.HealthOK, fmt.Sprintf("token is valid for %s", account.Account.Username)
+		}
+		return types.HealthOK, "token is valid"
+	})
+
+	run("storage", func() (string, string) {
+		root := g.storage.Stats().RootFolder
+		if err := g.storage.CheckWritable(); err != nil {
+			return types.HealthError, fmt.Sprintf("%s is not writable: %v", root, err)
+		}
+		return types.HealthOK, fmt.Sprintf("%s is writable", root)
+	})
+
+	tools := storage.DetectMediaTools()
+	run("ffmpeg", func() (string, string) {
+		return toolCheck(tools.FFmpeg, "thumbnails and video editing tools are unavailable")
+	})
+
+	run("ffprobe", func() (string, string) {
+		return toolCheck(tools.FFprobe, "video duration and resolution are only read from MP4 and QuickTime files")
+	})
+
+	online := status["network"] != types.HealthError && status["api_token"] != types.HealthError
+	report.Capabilities = map[string]bool{
+		"generate":       online && status["storage"] == types.HealthOK,
+		"store_videos":   status["storage"] == types.HealthOK,
+		"thumbnails":     status["ffmpeg"] == types.HealthOK,
+		"video_editing":  status["ffmpeg"] == types.HealthOK,
+		"video_metadata": status["ffprobe"] == types.HealthOK,
+	}
+	return report
+}
+
+// toolCheck reports whether an external tool was found at startup, with
+// its version; a missing tool only disables the features it provides
+func toolCheck(tool storage.MediaTool, missing string) (string, string) {
+	if !tool.Available() {
+		return types.HealthWarning, fmt.Sprintf("%v: %s", tool.Err, missing)
+	}
+	if tool.Version == "" {
+		return types.HealthOK, tool.Path
+	}
+	return types.HealthOK, fmt.Sprintf("%s (%s)", tool.Version, tool.Path)
+}
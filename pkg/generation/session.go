@@ -0,0 +1,128 @@
+package generation
+
+import (
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// SessionSummary recaps the generations made since a time, such as the
+// start of the server
+type SessionSummary struct {
+	Since         time.Time
+	Generations   int
+	Completed     int
+	Failed        int // Failed or canceled
+	Pending       int // Starting or processing
+	Queued        int
+	Retries       int           // Predictions resubmitted by auto_retry
+	EstimatedCost float64       // USD, counting retried predictions but not queued ones; custom models have no estimate
+	WaitTime      time.Duration // From creation to completion, summed over finished generations
+	Models        []SessionModel
+	Videos        []SessionVideo // Completed, oldest first
+	Failures      []SessionVideo // Oldest first
+}
+
+// SessionModel is one model's share of a session
+type SessionModel struct {
+	Name          string
+	Generations   int
+	Completed     int
+	Failed        int
+	EstimatedCost float64
+}
+
+// SessionVideo is a completed or failed generation of a session
+type SessionVideo struct {
+	StorageID string
+	Model     string
+	Prompt    string
+	Status    string
+	Error     string
+	Path      string // The video, for completed generations
+	CreatedAt string
+	WaitTime  time.Duration
+}
+
+// SessionSummary recaps the generations created at or after since, only
+// those of one conversation when sessionID is set. Edits such as captions
+// and reframes are counted under their operation.
+func (g *Generator) SessionSummary(since time.Time, sessionID string) SessionSummary {
+	summary := SessionSummary{Since: since}
+	since = since.Truncate(time.Second) // created_at has second precision
+
+	records := g.storage.ListRecords(storage.Filter{SessionID: sessionID}) // Newest first
+	models := make(map[string]*SessionModel)
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		created, err := time.Parse(time.RFC3339, record.String("created_at"))
+		if err != nil || created.Before(since) {
+			continue
+		}
+
+		name := record.ModelName()
+		if name == "" {
+			name = record.String("operation")
+		}
+		model, ok := models[name]
+		if !ok {
+			model = &SessionModel{Name: name}
+			models[name] = model
+		}
+		model.Generations++
+		summary.Generations++
+
+		retries := len(Attempts(record.Metadata))
+		summary.Retries += retries
+		if cost, ok := recordCost(record); ok && record.String("status") != StatusQueued {
+			cost *= float64(1 + retries)
+			model.EstimatedCost += cost
+			summary.EstimatedCost += cost
+		}
+
+		video := SessionVideo{
+			StorageID: record.StorageID,
+			Model:     name,
+			Prompt:    record.Parameter("prompt"),
+			Status:    record.String("status"),
+			CreatedAt: record.String("created_at"),
+		}
+		if completed, err := time.Parse(time.RFC3339, record.String("completed_at")); err == nil && completed.After(created) {
+			video.WaitTime = completed.Sub(created)
+			summary.WaitTime += video.WaitTime
+		}
+
+		switch video.Status {
+		case "completed":
+			summary.Completed++
+			model.Completed++
+			if output := record.Paths()["output"]; output != "" {
+				video.Path = filepath.Join(g.storage.GetStoragePath(record.StorageID), output)
+			}
+			summary.Videos = append(summary.Videos, video)
+		case types.StatusStarting, types.StatusProcessing:
+			summary.Pending++
+		case StatusQueued:
+			summary.Queued++
+		default:
+			summary.Failed++
+			model.Failed++
+			video.Error = record.String("error")
+			summary.Failures = append(summary.Failures, video)
+		}
+	}
+
+	for _, model := range models {
+		summary.Models = append(summary.Models, *model)
+	}
+	sort.Slice(summary.Models, func(i, j int) bool {
+		if summary.Models[i].Generations != summary.Models[j].Generations {
+			return summary.Models[i].Generations > summary.Models[j].Generations
+		}
+		return summary.Models[i].Name < summary.Models[j].Name
+	})
+	return summary
+}
@@ -0,0 +1,63 @@
+package generation
+
+import (
+	"context"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// ShutdownReport summarizes what happened to unfinished generations when the
+// server stopped
+type ShutdownReport struct {
+	Pending  []string // Prediction IDs left running on Replicate
+	Canceled []string // Prediction IDs canceled before exit
+}
+
+// Shutdown handles generations that are still running when the server exits.
+// Each one is marked in metadata with pending_at_shutdown so it can be picked
+// up later with continue_operation. When cancel is set the predictions are
+// canceled on Replicate instead, so they stop billing.
+func (g *Generator) Shutdown(ctx context.Context, cancel bool) ShutdownReport {
+	var report ShutdownReport
+	now := time.Now().Format(time.RFC3339)
+
+	for _, record := range g.storage.ListRecords(storage.Filter{}) {
+		status := record.String("status")
+		if status != types.StatusStarting && status != types.StatusProcessing {
+			continue
+		}
+		predictionID := record.String("prediction_id")
+		if predictionID == "" {
+			continue
+		}
+
+		if cancel {
+			err := g.client.CancelPrediction(ctx, predictionID)
+			if err == nil {
+				g.recordFailure(record.StorageID, predictionID, types.StatusCanceled, "canceled on server shutdown", "")
+				report.Canceled = append(report.Canceled, predictionID)
+				logging.Info("canceled prediction on shutdown", "storage_id", record.StorageID, "prediction_id", predictionID)
+				continue
+			}
+			logging.Warn("failed to cancel prediction on shutdown", "storage_id", record.StorageID, "prediction_id", predictionID, "error", err)
+		}
+
+		err := g.storage.UpdateMetadata(record.StorageID, func(metadata map[string]interface{}) error {
+			metadata["pending_at_shutdown"] = now
+			return nil
+		})
+		if err != nil {
+			logging.Warn("failed to update metadata", "storage_id", record.StorageID, "error", err)
+			continue
+		}
+		report.Pending = append(report.Pending, predictionID)
+	}
+
+	if len(report.Pending) > 0 {
+		logging.Warn("predictions still running on Replicate at shutdown", "count", len(report.Pending), "prediction_ids", report.Pending)
+	}
+	return report
+}
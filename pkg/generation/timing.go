@@ -0,0 +1,148 @@
+package generation
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+const (
+	// MinContinueWait is the shortest continue_operation wait
+	MinContinueWait = 5 * time.Second
+
+	// DefaultMaxContinueWait is the longest continue_operation wait unless
+	// configured otherwise
+	DefaultMaxContinueWait = 5 * time.Minute
+
+	// defaultExpectedDuration is used for models without any timing data
+	defaultExpectedDuration = 60 * time.Second
+
+	// historySampleSize is how many recent completions are considered
+	historySampleSize = 20
+)
+
+// maxContinueWait is the configured longest continue_operation wait
+var maxContinueWait = DefaultMaxContinueWait
+
+// SetMaxContinueWait configures the longest a single continue_operation call
+// may wait
+func SetMaxContinueWait(d time.Duration) {
+	if d >= MinContinueWait {
+		maxContinueWait = d
+	}
+}
+
+// MaxContinueWait returns the longest a single continue_operation call may wait
+func MaxContinueWait() time.Duration {
+	return maxContinueWait
+}
+
+// ExpectedDuration returns how long a generation with the given model alias
+// typically takes from creation to completion. It uses the median of recent
+// completed generations in storage, falling back to the model's TypicalWait.
+func (g *Generator) ExpectedDuration(model string) time.Duration {
+	config, ok := GetModelConfig(model)
+	if !ok {
+		return defaultExpectedDuration
+	}
+
+	var samples []time.Duration
+	for _, record := range g.storage.ListRecords(storage.Filter{Status: "completed"}) {
+		if recordModelID(record) != config.ID {
+			continue
+		}
+		created, err1 := time.Parse(time.RFC3339, record.String("created_at"))
+		completed, err2 := time.Parse(time.RFC3339, record.String("completed_at"))
+		if err1 != nil || err2 != nil || !completed.After(created) {
+			continue
+		}
+		samples = append(samples, completed.Sub(created))
+		if len(samples) >= historySampleSize {
+			break // Records are newest first
+		}
+	}
+
+	if len(samples) > 0 {
+		sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+		return samples[len(samples)/2].Round(time.Second)
+	}
+
+	if config.TypicalWait > 0 {
+		return time.Duration(config.TypicalWait) * time.Second
+	}
+	return defaultExpectedDuration
+}
+
+// SuggestedWait returns how long a continue_operation call should wait given
+// the expected total duration and the time already elapsed, clamped to the
+// allowed range for a single call.
+func SuggestedWait(expected, elapsed time.Duration) time.Duration {
+	remaining := expected - elapsed
+	switch {
+	case elapsed > expected:
+		// Overdue generations are checked back periodically
+		remaining = 15 * time.Second
+	case remaining < MinContinueWait:
+		remaining = MinContinueWait
+	case remaining > maxContinueWait:
+		remaining = maxContinueWait
+	}
+	return remaining.Round(time.Second)
+}
+
+// ContinueHints returns the suggested wait for the next continue call and
+// the remaining time the generation is expected to need, based on the model's
+// historical completion times. Unknown generations default to 30 seconds.
+func (g *Generator) ContinueHints(storageID string) (wait time.Duration, remaining time.Duration) {
+	model, createdAt, ok := g.ModelForStorage(storageID)
+	if !ok {
+		return 30 * time.Second, 0
+	}
+
+	expected := g.ExpectedDuration(model)
+	var elapsed time.Duration
+	if !createdAt.IsZero() {
+		elapsed = time.Since(createdAt)
+	}
+
+	remaining = expected - elapsed
+	if remaining < 0 {
+		remaining = 0
+	}
+	return SuggestedWait(expected, elapsed), remaining.Round(time.Second)
+}
+
+// ModelForStorage returns the model alias and creation time recorded for a
+// storage ID, if known
+func (g *Generator) ModelForStorage(storageID string) (string, time.Time, bool) {
+	metadata, err := g.storage.LoadMetadata(storageID)
+	if err != nil {
+		return "", time.Time{}, false
+	}
+	record := storage.Record{StorageID: storageID, Metadata: metadata}
+
+	alias, ok := recordModelAlias(record)
+	if !ok {
+		return "", time.Time{}, false
+	}
+	created, _ := time.Parse(time.RFC3339, record.String("created_at"))
+	return alias, created, true
+}
+
+// recordModelAlias returns the registered model recorded in metadata, told
+// apart from others sharing its Replicate model ID by the input it was sent
+func recordModelAlias(record storage.Record) (string, bool) {
+	params, _ := record.Metadata["parameters"].(map[string]interface{})
+	input, _ := params["raw_input"].(map[string]interface{})
+	return FindModelAliasForInput(recordModelID(record), input)
+}
+
+// recordModelID returns the Replicate model ID recorded in metadata
+func recordModelID(record storage.Record) string {
+	if model, ok := record.Metadata["model"].(map[string]interface{}); ok {
+		id, _ := model["id"].(string)
+		return id
+	}
+	return ""
+}
@@ -3,24 +3,59 @@ package generation
 // VideoParams holds parameters for video generation
 type VideoParams struct {
 	// Common parameters
-	Prompt      string
-	Model       string
-	Resolution  string
-	AspectRatio string
-	Filename    string
+	Prompt       string
+	Model        string
+	Version      string // Overrides the model's pinned version for this request
+	Resolution   string
+	AspectRatio  string
+	Filename     string
+	SessionID    string // Groups generations from one conversation
+	OutputDir    string // Validated per-request storage root override
+	Project      string // Groups generations under <root>/<project>/
+	ComparisonID string // Groups the generations of one compare_models call
+	StorageID    string // Set when a queued generation starts, to reuse its storage ID
+
+	// Prompt variations
+	VariationSetID string            // Groups the generations of one generate_variations call
+	Variation      map[string]string // Substitutions this generation was expanded with
+
+	// Storyboards
+	StoryboardID string // Groups the scenes of one generate_storyboard call
+	Scene        int    // 1-based position of this scene in its storyboard
 
 	// Text-to-video specific
 	NegativePrompt string
-	Duration       int // For Kling
+	Duration       int    // For Kling
+	StyleImagePath string // Reference image for models with a style/subject input
 
 	// Image-to-video specific
 	ImagePath       string
+	ImagePaths      []string // Further keyframes or references, for models with an image array input
 	NumFrames       int // For Wan
 	FramesPerSecond int
 
 	// Model-specific optimizations
-	GoFast      bool    // For Wan fast models
-	SampleShift float64 // For Wan tuning
+	GoFast      *bool   // For Wan fast models; nil uses the model default
+	SampleShift float64 // For Wan tuning; 0 uses the model default
+	Seed        *int    // Random seed for reproducible results; nil lets the model pick
+
+	// Quality tuning; zero values use the model default
+	GuidanceScale  float64 // How closely to follow the prompt
+	InferenceSteps int     // Denoising steps; more is slower and usually cleaner
+
+	// Motion control
+	CameraMotion   string  // One of CameraMotions; empty leaves the camera to the model
+	MotionStrength float64 // 0-1 for models with a motion strength input; 0 uses the model default
+
+	// Effect template, one of the model's EffectNames; empty for none
+	Effect string
+
+	// Resubmission of predictions that fail with a transient model error
+	Retry RetryPolicy
+
+	// Safety checker override (requires REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE);
+	// nil keeps the model's checker enabled
+	SafetyChecker *bool
 }
 
 // VideoResult holds the result of video generation
@@ -33,6 +68,8 @@ type VideoResult struct {
 	Parameters   map[string]interface{}
 	Metrics      VideoMetrics
 	Status       string
+	Logs         string  // Prediction logs, kept for failed generations
+	Progress     float64 // Percent complete from the logs while pending; 0 when they don't say
 }
 
 // VideoMetrics holds metrics about the generated video
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/audit"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// Audit log query limits
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// recordCall appends a tool call and its outcome to the audit log. The
+// outcome is read back from the JSON response.
+func (h *ReplicateVideoHandler) recordCall(req *protocol.CallToolRequest, resp *protocol.CallToolResponse, callErr error, started time.Time) {
+	if h.audit == nil {
+		return
+	}
+
+	record := types.AuditRecord{
+		Timestamp:  started,
+		Tool:       req.Name,
+		Namespace:  h.namespace,
+		Parameters: req.Arguments,
+		Status:     "success",
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+
+	var result struct {
+		Success      bool    `json:"success"`
+		Status       string  `json:"status"`
+		PredictionID string  `json:"prediction_id"`
+		StorageID    string  `json:"storage_id"`
+		TotalCost    float64 `json:"total_estimated_cost"`
+		Error        struct {
+			Type string `json:"type"`
+		} `json:"error"`
+	}
+	switch {
+	case callErr != nil:
+		record.Status = "error"
+		record.ErrorType = "internal_error"
+	case resp != nil && len(resp.Content) > 0:
+		if err := json.Unmarshal([]byte(resp.Content[0].Text), &result); err == nil {
+			record.PredictionID = result.PredictionID
+			record.StorageID = result.StorageID
+			if result.Status == "processing" || result.Status == "starting" || result.Status == "queued" {
+				record.Status = "processing"
+			}
+		}
+		if resp.IsError {
+			record.Status = "error"
+			record.ErrorType = result.Error.Type
+		}
+	}
+
+	// Only starting a generation costs money
+	switch req.Name {
+	case "generate_video_from_text", "generate_video_from_image", "run_custom_video_model":
+		if record.Status != "error" && record.StorageID != "" {
+			record.EstimatedCost, _ = h.generator.EstimatedCost(record.StorageID)
+		}
+	case "compare_models":
+		record.EstimatedCost = result.TotalCost
+	}
+
+	if err := h.audit.Append(record); err != nil {
+		logging.Warn("failed to write audit record", "tool", req.Name, "error", err)
+	}
+}
+
+// handleGetAuditLog lists recent tool calls of the caller's namespace from
+// the audit log
+func (h *ReplicateVideoHandler) handleGetAuditLog(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	if h.audit == nil {
+		return h.errorResponse("get_audit_log", "audit_disabled", "the audit log is disabled (REPLICATE_VIDEO_AUDIT_LOG=off)", nil)
+	}
+
+	query := audit.Query{Namespace: h.namespace, Limit: defaultAuditLimit}
+	filters := map[string]interface{}{}
+	if limit, ok := args["limit"].(float64); ok && limit > 0 {
+		query.Limit = int(limit)
+		if query.Limit > maxAuditLimit {
+			query.Limit = maxAuditLimit
+		}
+	}
+	if tool, ok := args["tool"].(string); ok && tool != "" {
+		query.Tool = tool
+		filters["tool"] = tool
+	}
+	if status, ok := args["status"].(string); ok && status != "" {
+		switch status {
+		case "success", "processing", "error":
+		default:
+			return h.errorResponse("get_audit_log", "invalid_parameters", "status must be success, processing, or error", nil)
+		}
+		query.Status = status
+		filters["status"] = status
+	}
+	if since, ok := args["since"].(string); ok && since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return h.errorResponse("get_audit_log", "invalid_parameters", "since must be an RFC 3339 timestamp, e.g. 2025-01-31T09:00:00Z", nil)
+		}
+		query.Since = t
+		filters["since"] = since
+	}
+
+	records, err := h.audit.Recent(query)
+	if err != nil {
+		return h.errorResponse("get_audit_log", "audit_error", err.Error(), nil)
+	}
+
+	return h.successResponse(responses.BuildAuditLogResponse(h.audit.Path(), records, filters))
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// maxConcurrentPolls limits how many predictions are polled at once
+const maxConcurrentPolls = 8
+
+// allPending is the special prediction_id value that selects every pending generation
+const allPending = "all_pending"
+
+// handleContinueMany polls several predictions concurrently within one wait
+// window and reports each one's status
+func (h *ReplicateVideoHandler) handleContinueMany(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	predictionIDs := stringSliceArg(args, "prediction_ids")
+	if len(predictionIDs) == 0 || (len(predictionIDs) == 1 && predictionIDs[0] == allPending) {
+		predictionIDs = h.pendingPredictionIDs(sessionIDArg(args))
+	}
+
+	if len(predictionIDs) == 0 {
+		return h.successResponse(responses.BuildBatchStatusResponse("continue_operation", nil))
+	}
+
+	waitTime := 30 * time.Second
+	if wt, ok := args["wait_time"].(float64); ok {
+		waitTime = time.Duration(wt) * time.Second
+		if waitTime < generation.MinContinueWait {
+			waitTime = generation.MinContinueWait
+		}
+		if waitTime > generation.MaxContinueWait() {
+			waitTime = generation.MaxContinueWait()
+		}
+	}
+
+	results := make([]types.PredictionStatus, len(predictionIDs))
+	sem := make(chan struct{}, maxConcurrentPolls)
+	var wg sync.WaitGroup
+
+	for i, predictionID := range predictionIDs {
+		wg.Add(1)
+		go func(i int, predictionID string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = h.pollPrediction(ctx, predictionID, waitTime)
+		}(i, predictionID)
+	}
+	wg.Wait()
+
+	return h.successResponse(responses.BuildBatchStatusResponse("continue_operation", results))
+}
+
+// pollPrediction waits for a single prediction and summarizes the outcome
+func (h *ReplicateVideoHandler) pollPrediction(ctx context.Context, predictionID string, waitTime time.Duration) types.PredictionStatus {
+	target := h.predictionTarget(predictionID)
+	storageID := target.StorageID
+
+	status := types.PredictionStatus{
+		PredictionID: predictionID,
+		StorageID:    storageID,
+	}
+
+	// Share the background poll with single continue calls
+	_, result, _, err := h.longPoll(ctx, predictionID, storageID, waitTime)
+	switch {
+	case err == nil && result.Status == "completed":
+		status.Status = "completed"
+		status.Paths = map[string]string{"output": result.FilePath}
+		if metadata, err := h.storage.LoadMetadata(storageID); err == nil {
+			record := storage.Record{StorageID: storageID, Metadata: metadata}
+			for name, rel := range record.Paths() {
+				status.Paths[name] = filepath.Join(h.storage.GetStoragePath(storageID), rel)
+			}
+		}
+	case result != nil && (result.Status == "starting" || result.Status == "processing"):
+		status.Status = result.Status
+	case result != nil:
+		status.Status = result.Status
+		if err != nil {
+			status.Error = err.Error()
+			status.ErrorType = client.ErrorType(err, "")
+		}
+		if result.Logs != "" {
+			status.LogsTail = storage.LogTail(result.Logs)
+		}
+	default:
+		status.Status = "error"
+		if err != nil {
+			status.Error = err.Error()
+			status.ErrorType = client.ErrorType(err, "")
+		}
+	}
+
+	return status
+}
+
+// pendingPredictionIDs returns prediction IDs of generations that have not
+// finished, optionally limited to one session
+func (h *ReplicateVideoHandler) pendingPredictionIDs(sessionID string) []string {
+	var ids []string
+	for _, record := range h.storage.ListRecords(storage.Filter{SessionID: sessionID}) {
+		status := record.String("status")
+		if status != "starting" && status != "processing" {
+			continue
+		}
+		if predictionID := record.String("prediction_id"); predictionID != "" {
+			ids = append(ids, predictionID)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// maxCompareModels limits how many models one comparison may run
+const maxCompareModels = 6
+
+// handleCompareModels runs one prompt across several models concurrently
+// and groups the generations under a comparison ID. Called with only a
+// comparison_id, it reports that comparison's generations instead.
+func (h *ReplicateVideoHandler) handleCompareModels(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	waitTime := waitTimeArg(args)
+
+	if comparisonID, ok := args["comparison_id"].(string); ok && comparisonID != "" {
+		if _, ok := args["prompt"]; !ok {
+			return h.comparisonStatus(ctx, comparisonID, waitTime)
+		}
+	}
+
+	models := stringSliceArg(args, "models")
+	if len(models) < 2 || len(models) > maxCompareModels {
+		return h.errorResponse("compare_models", "invalid_parameters",
+			fmt.Sprintf("models must list 2 to %d models", maxCompareModels), nil)
+	}
+	seen := make(map[string]bool)
+	for _, model := range models {
+		if seen[model] {
+			return h.errorResponse("compare_models", "invalid_parameters", fmt.Sprintf("model %s is listed twice", model), nil)
+		}
+		seen[model] = true
+	}
+
+	// Image-to-video when an image is given, text-to-video otherwise
+	tool := "generate_video_from_text"
+	if imagePath, _ := args["image_path"].(string); imagePath != "" {
+		tool = "generate_video_from_image"
+	}
+
+	comparisonID := "cmp-" + h.storage.GenerateStorageID()
+
+	// Validate every model's parameters before starting any paid prediction
+	type run struct {
+		args   map[string]interface{}
+		params generation.VideoParams
+	}
+	runs := make([]run, len(models))
+	for i, model := range models {
+		if model == generation.AutoModel {
+			return h.errorResponse("compare_models", "invalid_parameters", "model auto can't be compared; name the models", nil)
+		}
+		modelArgs := make(map[string]interface{}, len(args))
+		for key, value := range args {
+			switch key {
+			case "models", "wait_time":
+				continue
+			}
+			modelArgs[key] = value
+		}
+		modelArgs["model"] = model
+		modelArgs["comparison_id"] = comparisonID // Never coalesced with another comparison
+
+		var params generation.VideoParams
+		var err error
+		if tool == "generate_video_from_text" {
+			params, err = h.extractTextToVideoParams(modelArgs)
+		} else {
+			params, err = h.extractImageToVideoParams(modelArgs)
+		}
+		if err != nil {
+			return h.errorResponse("compare_models", "invalid_parameters", err.Error(), map[string]interface{}{
+				"model": model,
+			})
+		}
+		params.ComparisonID = comparisonID
+		runs[i] = run{args: modelArgs, params: params}
+	}
+
+	// Start every generation at once; one model failing doesn't stop the others
+	entries := make([]types.ComparisonEntry, len(runs))
+	var wg sync.WaitGroup
+	for i, r := range runs {
+		wg.Add(1)
+		go func(i int, r run) {
+			defer wg.Done()
+			target, err := h.startGeneration(ctx, tool, r.args, func() (*generation.VideoResult, error) {
+				if tool == "generate_video_from_text" {
+					return h.generator.GenerateTextToVideo(ctx, r.params)
+				}
+				return h.generator.GenerateImageToVideo(ctx, r.params)
+			})
+			if err != nil {
+				entries[i] = types.ComparisonEntry{
+					Model:     r.params.Model,
+					Status:    "error",
+					Error:     err.Error(),
+					ErrorType: generationErrorType(err),
+				}
+				return
+			}
+			status := types.PredictionStatus{PredictionID: target.PredictionID, StorageID: target.StorageID}
+			if waitTime > 0 {
+				status = h.pollPrediction(ctx, target.PredictionID, waitTime)
+			}
+			entries[i] = h.comparisonEntry(target.StorageID, status)
+			entries[i].Model = r.params.Model
+			entries[i].OperationID = target.OperationID
+		}(i, r)
+	}
+	wg.Wait()
+
+	logging.Info("comparison started", "comparison_id", comparisonID, "models", models)
+	prompt, _ := args["prompt"].(string)
+	return h.successResponse(responses.BuildComparisonResponse(comparisonID, prompt, entries))
+}
+
+// comparisonStatus reports the generations of an earlier comparison,
+// waiting up to waitTime for those still running
+func (h *ReplicateVideoHandler) comparisonStatus(ctx context.Context, comparisonID string, waitTime time.Duration) (*protocol.CallToolResponse, error) {
+	records := h.storage.ListRecords(storage.Filter{ComparisonID: comparisonID})
+	if len(records) == 0 {
+		return h.errorResponse("compare_models", "not_found", fmt.Sprintf("no generations found for comparison %s", comparisonID), nil)
+	}
+
+	entries := make([]types.ComparisonEntry, len(records))
+	var wg sync.WaitGroup
+	for i, record := range records {
+		wg.Add(1)
+		go func(i int, record storage.Record) {
+			defer wg.Done()
+			status := types.PredictionStatus{PredictionID: record.String("prediction_id"), StorageID: record.StorageID}
+			if waitTime > 0 && isPendingStatus(record.String("status")) && status.PredictionID != "" {
+				status = h.pollPrediction(ctx, status.PredictionID, waitTime)
+			}
+			entries[i] = h.comparisonEntry(record.StorageID, status)
+		}(i, record)
+	}
+	wg.Wait()
+
+	return h.successResponse(responses.BuildComparisonResponse(comparisonID, records[0].Parameter("prompt"), entries))
+}
+
+// comparisonEntry summarizes one generation of a comparison from its
+// metadata, with the error of a failed poll
+func (h *ReplicateVideoHandler) comparisonEntry(storageID string, status types.PredictionStatus) types.ComparisonEntry {
+	entry := types.ComparisonEntry{
+		StorageID:    storageID,
+		PredictionID: status.PredictionID,
+		Status:       status.Status,
+		Error:        status.Error,
+		ErrorType:    status.ErrorType,
+	}
+
+	metadata, err := h.storage.LoadMetadata(storageID)
+	if err != nil {
+		if entry.Status == "" {
+			entry.Status = "error"
+			entry.Error = err.Error()
+		}
+		return entry
+	}
+	record := storage.Record{StorageID: storageID, Metadata: metadata}
+	if entry.Status == "" {
+		entry.Status = record.String("status")
+	}
+	if entry.Error == "" {
+		entry.Error = record.String("error")
+	}
+	entry.ModelName = record.ModelName()
+	if model, _, ok := h.generator.ModelForStorage(storageID); ok {
+		entry.Model = model
+	}
+	entry.EstimatedCost, _ = h.generator.EstimatedCost(storageID)
+
+	if created, err := time.Parse(time.RFC3339, record.String("created_at")); err == nil {
+		end := time.Now()
+		if completed, err := time.Parse(time.RFC3339, record.String("completed_at")); err == nil {
+			end = completed
+		}
+		entry.ElapsedSeconds = int(end.Sub(created).Seconds())
+	}
+
+	if entry.Status == "completed" {
+		entry.Paths = make(map[string]string)
+		for name, rel := range record.Paths() {
+			entry.Paths[name] = filepath.Join(h.storage.GetStoragePath(storageID), rel)
+		}
+		entry.URLs = h.fileURLs(storageID, entry.Paths)
+	}
+	return entry
+}
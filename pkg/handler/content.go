@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"os"
+	"reflect"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+)
+
+// structuredContentField is the StructuredContent field of
+// protocol.CallToolResponse, which MCP library versions implementing the
+// 2025-06-18 protocol revision have. It is looked up rather than named so
+// the server builds against older versions too, which return text only.
+var structuredContentField, hasStructuredContent = reflect.TypeOf(protocol.CallToolResponse{}).FieldByName("StructuredContent")
+
+// withStructuredContent adds a response's JSON object as structured
+// content, when the MCP library supports it. The text block stays, as the
+// protocol asks, for clients that only read text.
+func withStructuredContent(resp *protocol.CallToolResponse) {
+	if !hasStructuredContent || len(resp.Content) == 0 || resp.Content[0].Type != "text" {
+		return
+	}
+	text := resp.Content[0].Text
+	structured, ok := responses.Structured(text)
+	if !ok {
+		return
+	}
+
+	field := reflect.ValueOf(resp).Elem().FieldByIndex(structuredContentField.Index)
+	value := reflect.ValueOf(structured)
+	switch {
+	case value.Type().AssignableTo(field.Type()):
+		field.Set(value)
+	case field.Type() == reflect.TypeOf(json.RawMessage{}):
+		field.Set(reflect.ValueOf(json.RawMessage(text)))
+	default:
+		logging.Debug("unsupported structured content field", "type", field.Type().String())
+	}
+}
+
+// thumbnailContent returns a video's thumbnail as image content, or false
+// when it has none
+func thumbnailContent(paths map[string]string) (protocol.ToolContent, bool) {
+	path := paths["thumbnail"]
+	if path == "" {
+		return protocol.ToolContent{}, false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logging.Warn("failed to read thumbnail", "path", path, "error", err)
+		return protocol.ToolContent{}, false
+	}
+	return protocol.ToolContent{
+		Type:     "image",
+		Data:     base64.StdEncoding.EncodeToString(data),
+		MimeType: "image/jpeg",
+	}, true
+}
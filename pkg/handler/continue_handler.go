@@ -3,62 +3,96 @@ package handler
 import (
 	"context"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
 	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
 )
 
 // handleContinueOperation handles the continue_operation tool
 func (h *ReplicateVideoHandler) handleContinueOperation(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
-	// Note: Debug logging disabled in MCP mode
-	
-	// Extract parameters - support both prediction_id (for backward compatibility) and operation_id
-	var operationID string
+	// Several predictions (or all pending ones) are polled concurrently
+	if len(stringSliceArg(args, "prediction_ids")) > 0 || args["prediction_id"] == allPending {
+		return h.handleContinueMany(ctx, args)
+	}
 	
-	// First try prediction_id (for backward compatibility)
+	// Continue by operation handle, or by prediction ID for older clients
+	var target pollTarget
 	if predID, ok := args["prediction_id"].(string); ok && predID != "" {
-		operationID = predID
+		target = h.predictionTarget(predID)
 	} else if opID, ok := args["operation_id"].(string); ok && opID != "" {
-		// Fall back to operation_id
-		operationID = opID
+		if t, ok := h.lookupPoll(opID); ok {
+			target = t
+		} else {
+			// Earlier versions returned prediction IDs as operation IDs
+			target = h.predictionTarget(opID)
+		}
+	} else if storageID, ok := args["storage_id"].(string); ok && storageID != "" {
+		// A queued generation has no prediction yet; report its place
+		if job := h.queuePosition(storageID); job != nil {
+			return h.queuedResponse("continue_operation", job)
+		}
+		
+		// Clients often keep the short storage ID rather than the prediction ID
+		predID, err := h.predictionForStorage(storageID)
+		if err != nil {
+			return h.errorResponse("continue_operation", "invalid_parameters", err.Error(), map[string]interface{}{
+				"storage_id": storageID,
+			})
+		}
+		target = h.predictionTarget(predID)
+		if target.OperationID == "" {
+			target.StorageID = storageID
+		}
 	} else {
-		return h.errorResponse("continue_operation", "invalid_parameters", "prediction_id or operation_id is required", nil)
+		return h.errorResponse("continue_operation", "invalid_parameters", "operation_id, prediction_id, storage_id, or prediction_ids is required", nil)
 	}
+	operationID := target.PredictionID
+	storageID := target.StorageID
 	
-	waitTime := 30 * time.Second
+	// Default the wait from the model's typical completion time
+	waitTime, _ := h.generator.ContinueHints(storageID)
 	if wt, ok := args["wait_time"].(float64); ok {
 		waitTime = time.Duration(wt) * time.Second
-		if waitTime < 5*time.Second {
-			waitTime = 5 * time.Second
+		if waitTime < generation.MinContinueWait {
+			waitTime = generation.MinContinueWait
 		}
-		if waitTime > 60*time.Second {
-			waitTime = 60 * time.Second
+		if waitTime > generation.MaxContinueWait() {
+			waitTime = generation.MaxContinueWait()
 		}
 	}
 	
-	// Since we don't have a built-in async executor yet, let's handle this directly
-	// by calling the generator's ContinueGeneration method
-	
-	// Find existing storage ID for this prediction ID
-	storageID, err := h.findStorageIDForPrediction(operationID)
-	if err != nil || storageID == "" {
-		// If we can't find existing storage ID, generate a new one
-		storageID = h.generateStorageID()
+	// Long-poll through the async executor; the poll continues in the
+	// background if the generation outlasts this call
+	var result *generation.VideoResult
+	var heartbeat *types.Heartbeat
+	var err error
+	if target.OperationID != "" {
+		target, result, heartbeat, err = h.waitPoll(ctx, target, waitTime)
+	} else {
+		target, result, heartbeat, err = h.longPoll(ctx, operationID, storageID, waitTime)
 	}
-	
-	result, err := h.generator.ContinueGeneration(ctx, operationID, storageID, waitTime)
 	if err != nil {
 		// Check if it's still processing
-		if result != nil && result.Status == "processing" {
+		if result != nil && isPendingStatus(result.Status) {
 			// Return processing response
-			response := responses.BuildProcessingResponse(
+			nextWait, remaining := h.generator.ContinueHints(storageID)
+			response := responses.BuildOperationResponse(
 				"continue_operation",
+				target.OperationID,
 				operationID,
 				result.ID,
-				int(waitTime.Seconds()),
+				int(nextWait.Seconds()),
+				int(remaining.Seconds()),
+				nil,
+				nil,
 			)
 			return &protocol.CallToolResponse{
 				Content: []protocol.ToolContent{
@@ -67,20 +101,36 @@ func (h *ReplicateVideoHandler) handleContinueOperation(ctx context.Context, arg
 			}, nil
 		}
 		
-		return h.errorResponse("continue_operation", "operation_failed", err.Error(), map[string]interface{}{
+		details := map[string]interface{}{
+			"operation_id":  target.OperationID,
 			"prediction_id": operationID,
-		})
+		}
+		if result != nil && result.Logs != "" {
+			details["logs_tail"] = storage.LogTail(result.Logs)
+			details["logs_path"] = filepath.Join(h.storage.GetStoragePath(storageID), storage.LogsFile)
+		}
+		if metadata, err := h.storage.LoadMetadata(storageID); err == nil {
+			if attempts := generation.Attempts(metadata); len(attempts) > 0 {
+				details["attempts"] = attempts
+			}
+		}
+		return h.errorResponse("continue_operation", client.ErrorType(err, "operation_failed"), err.Error(), details)
 	}
 	
 	// Handle the result based on status
 	switch result.Status {
-	case "processing":
+	case "starting", "processing":
 		// Still processing - return processing response
-		response := responses.BuildProcessingResponse(
+		nextWait, remaining := h.generator.ContinueHints(storageID)
+		response := responses.BuildOperationResponse(
 			"continue_operation",
+			target.OperationID,
 			operationID,
 			result.ID,
-			int(waitTime.Seconds()),
+			int(nextWait.Seconds()),
+			int(remaining.Seconds()),
+			heartbeat,
+			nil,
 		)
 		
 		return &protocol.CallToolResponse{
@@ -97,17 +147,31 @@ func (h *ReplicateVideoHandler) handleContinueOperation(ctx context.Context, arg
 			metadata = make(map[string]interface{})
 		}
 		
+		// Adopt the caller's session if the generation wasn't tagged with one
+		if sessionID := sessionIDArg(args); sessionID != "" {
+			if existing, _ := metadata["session_id"].(string); existing == "" {
+				metadata["session_id"] = sessionID
+				err := h.storage.UpdateMetadata(storageID, func(current map[string]interface{}) error {
+					current["session_id"] = sessionID
+					return nil
+				})
+				if err != nil {
+					logging.Warn("failed to record session", "storage_id", storageID, "error", err)
+				}
+			}
+		}
+		
 		// Build paths with absolute paths from relative paths in metadata
 		paths := make(map[string]string)
 		basePath := h.storage.GetStoragePath(storageID)
 		
 		// Convert relative paths to absolute
 		if metadataPaths, ok := metadata["paths"].(map[string]interface{}); ok {
-			if output, ok := metadataPaths["output"].(string); ok {
-				paths["output"] = filepath.Join(basePath, output)
-			}
-			if thumbnail, ok := metadataPaths["thumbnail"].(string); ok {
-				paths["thumbnail"] = filepath.Join(basePath, thumbnail)
+			// Every file: the video, its thumbnail, and any other outputs
+			for name, rel := range metadataPaths {
+				if rel, ok := rel.(string); ok && rel != "" {
+					paths[name] = filepath.Join(basePath, rel)
+				}
 			}
 		} else {
 			// Fallback for old format
@@ -167,23 +231,31 @@ func (h *ReplicateVideoHandler) handleContinueOperation(ctx context.Context, arg
 		if format, ok := metadata["format"].(string); ok {
 			metrics["format"] = format
 		}
+		if quality, ok := metadata["quality"].(map[string]interface{}); ok {
+			metrics["quality"] = quality
+		}
 		
 		// Operation completed - build success response
 		response := responses.BuildSuccessResponse(
 			"continue_operation",
 			result.ID,
 			paths,
+			h.fileURLs(result.ID, paths),
 			modelInfo,
 			parameters,
 			metrics,
 			result.PredictionID,
+			stringSliceArg(metadata, "warnings"),
+			generation.Attempts(metadata),
 		)
 		
-		return &protocol.CallToolResponse{
-			Content: []protocol.ToolContent{
-				{Type: "text", Text: response},
-			},
-		}, nil
+		content := []protocol.ToolContent{{Type: "text", Text: response}}
+		if include, _ := args["include_thumbnail"].(bool); include {
+			if thumbnail, ok := thumbnailContent(paths); ok {
+				content = append(content, thumbnail)
+			}
+		}
+		return &protocol.CallToolResponse{Content: content}, nil
 		
 	default:
 		return h.errorResponse("continue_operation", "unexpected_status", 
@@ -195,44 +267,98 @@ func (h *ReplicateVideoHandler) handleContinueOperation(ctx context.Context, arg
 	}
 }
 
-// generateStorageID creates a unique storage ID for continue operations
-func (h *ReplicateVideoHandler) generateStorageID() string {
-	return h.storage.GenerateStorageID()
-}
-
-// findStorageIDForPrediction searches for existing storage ID with given prediction ID
-func (h *ReplicateVideoHandler) findStorageIDForPrediction(predictionID string) (string, error) {
-	// Get the root videos folder
-	videosDir := h.storage.GetStoragePath("")
+// handleRecoverPrediction adopts a prediction with no local record into
+// storage, then continues it like continue_operation, downloading the video
+// if it already finished
+func (h *ReplicateVideoHandler) handleRecoverPrediction(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	predictionID, _ := args["prediction_id"].(string)
+	predictionID = strings.TrimSpace(predictionID)
+	if predictionID == "" || predictionID == allPending {
+		return h.errorResponse("recover_prediction", "invalid_parameters", "prediction_id is required", nil)
+	}
 	
-	// Read all subdirectories (storage IDs)
-	entries, err := os.ReadDir(videosDir)
+	var params generation.VideoParams
+	var err error
+	params.SessionID = sessionIDArg(args)
+	params.Project, err = projectArg(args)
 	if err != nil {
-		return "", fmt.Errorf("failed to read videos directory: %w", err)
+		return h.errorResponse("recover_prediction", "invalid_parameters", err.Error(), nil)
 	}
-	
-	// Search through each storage directory
-	for _, entry := range entries {
-		if !entry.IsDir() {
-			continue
-		}
-		
-		storageID := entry.Name()
-		metadata, err := h.storage.LoadMetadata(storageID)
+	if outputDir, ok := args["output_dir"].(string); ok && outputDir != "" {
+		dir, err := storage.ValidateOutputDir(outputDir, h.config.AllowedOutputDirs)
 		if err != nil {
-			continue // Skip if can't load metadata
+			return h.errorResponse("recover_prediction", "invalid_parameters", err.Error(), nil)
 		}
-		
-		// Check if this metadata matches the prediction ID
-		if metaPredID, ok := metadata["prediction_id"].(string); ok && metaPredID == predictionID {
-			return storageID, nil
+		params.OutputDir = dir
+	}
+	
+	result, err := h.generator.RecoverPrediction(ctx, predictionID, params)
+	if err != nil {
+		return h.errorResponse("recover_prediction", client.ErrorType(err, "recover_failed"), err.Error(), map[string]interface{}{
+			"prediction_id": predictionID,
+		})
+	}
+	
+	continueArgs := map[string]interface{}{"prediction_id": result.PredictionID}
+	for _, key := range []string{"wait_time", "session_id"} {
+		if v, ok := args[key]; ok {
+			continueArgs[key] = v
 		}
 	}
+	return h.handleContinueOperation(ctx, continueArgs)
+}
+
+// predictionTarget resolves a prediction ID to the operation polling it (or
+// the retained one that already saved it), or to its storage folder when
+// there is none. Predictions this server has no record of (e.g. made by
+// another client) get a new storage ID.
+func (h *ReplicateVideoHandler) predictionTarget(predictionID string) pollTarget {
+	h.pollMu.Lock()
+	operationID, ok := h.polls[pollKey(h.namespace, predictionID)]
+	target := h.pollTargets[operationID]
+	if !ok {
+		target, ok = h.completedPoll(predictionID)
+	}
+	h.pollMu.Unlock()
+	if ok {
+		logging.Debug("continue attached to existing operation", "prediction_id", predictionID, "operation_id", target.OperationID)
+		return target
+	}
+	
+	storageID, err := h.findStorageIDForPrediction(predictionID)
+	if err != nil {
+		storageID = h.storage.GenerateStorageID()
+	}
+	return pollTarget{PredictionID: predictionID, StorageID: storageID}
+}
+
+// predictionForStorage returns the prediction ID recorded for a storage ID
+func (h *ReplicateVideoHandler) predictionForStorage(storageID string) (string, error) {
+	if err := storage.ValidateStorageID(storageID); err != nil {
+		return "", err
+	}
+	metadata, err := h.storage.LoadMetadata(storageID)
+	if err != nil {
+		return "", fmt.Errorf("no generation found with storage ID %s", storageID)
+	}
+	predictionID, _ := metadata["prediction_id"].(string)
+	if predictionID == "" {
+		return "", fmt.Errorf("storage ID %s has no prediction to continue (e.g. an imported video)", storageID)
+	}
+	return predictionID, nil
+}
+
+// findStorageIDForPrediction searches for existing storage ID with given prediction ID
+func (h *ReplicateVideoHandler) findStorageIDForPrediction(predictionID string) (string, error) {
+	// The storage index covers the root and project folders
+	if storageID, ok := h.storage.FindByPrediction(predictionID); ok {
+		return storageID, nil
+	}
 	
 	return "", fmt.Errorf("storage ID not found for prediction %s", predictionID)
 }
 
-// Helper functions to extract values from result map (for future async executor integration)
+// Helper functions to extract values from async operation results
 func getStringValue(m map[string]interface{}, key string) string {
 	if v, ok := m[key].(string); ok {
 		return v
@@ -251,6 +377,9 @@ func getIntValue(m map[string]interface{}, key string) int64 {
 	if v, ok := m[key].(int64); ok {
 		return v
 	}
+	if v, ok := m[key].(int); ok {
+		return int64(v)
+	}
 	if v, ok := m[key].(float64); ok {
 		return int64(v)
 	}
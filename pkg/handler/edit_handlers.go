@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// handleBurnCaptions renders a captioned copy of a completed video from
+// caption text or an SRT file
+func (h *ReplicateVideoHandler) handleBurnCaptions(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("burn_captions", "invalid_parameters", err.Error(), nil)
+	}
+
+	opts := storage.CaptionOptions{}
+	opts.Text, _ = args["text"].(string)
+	opts.Text = strings.TrimSpace(opts.Text)
+	if srtPath, ok := args["srt_path"].(string); ok && srtPath != "" {
+		path, err := h.inputPath("srt_path", srtPath)
+		if err != nil {
+			return h.errorResponse("burn_captions", "invalid_parameters", err.Error(), nil)
+		}
+		opts.SRTPath = path
+	}
+	opts.Font, _ = args["font"].(string)
+	if size, ok := args["font_size"].(float64); ok {
+		opts.FontSize = int(size)
+	}
+	opts.Color, _ = args["color"].(string)
+	opts.Position, _ = args["position"].(string)
+	opts.Start, _ = args["start"].(float64)
+	opts.End, _ = args["end"].(float64)
+
+	output, err := h.storage.BurnCaptions(ctx, storageID, opts)
+	if err != nil {
+		return h.errorResponse("burn_captions", "edit_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	parameters := map[string]interface{}{
+		"font_size": opts.FontSize,
+		"position":  opts.Position,
+	}
+	if opts.SRTPath != "" {
+		parameters["srt_path"] = opts.SRTPath
+	} else {
+		parameters["text"] = opts.Text
+	}
+	return h.editResponse("burn_captions", storageID, "captioned", output, parameters)
+}
+
+// handleWatermarkVideo renders a copy of a completed video with a PNG logo
+// in one corner
+func (h *ReplicateVideoHandler) handleWatermarkVideo(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("watermark_video", "invalid_parameters", err.Error(), nil)
+	}
+
+	logoPath, ok := args["logo_path"].(string)
+	if !ok || logoPath == "" {
+		return h.errorResponse("watermark_video", "invalid_parameters", "logo_path parameter is required", nil)
+	}
+	logoPath, err = h.inputPath("logo_path", logoPath)
+	if err != nil {
+		return h.errorResponse("watermark_video", "invalid_parameters", err.Error(), nil)
+	}
+
+	opts := storage.WatermarkOptions{LogoPath: logoPath}
+	opts.Corner, _ = args["corner"].(string)
+	opts.Opacity, _ = args["opacity"].(float64)
+	opts.Scale, _ = args["scale"].(float64)
+
+	output, err := h.storage.Watermark(ctx, storageID, opts)
+	if err != nil {
+		return h.errorResponse("watermark_video", "edit_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	return h.editResponse("watermark_video", storageID, "watermarked", output, map[string]interface{}{
+		"logo_path": logoPath,
+		"corner":    opts.Corner,
+		"opacity":   opts.Opacity,
+		"scale":     opts.Scale,
+	})
+}
+
+// handleReframeVideo renders a copy of a completed video in another aspect
+// ratio
+func (h *ReplicateVideoHandler) handleReframeVideo(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("reframe_video", "invalid_parameters", err.Error(), nil)
+	}
+
+	opts := storage.ReframeOptions{}
+	opts.AspectRatio, _ = args["aspect_ratio"].(string)
+	if opts.AspectRatio == "" {
+		return h.errorResponse("reframe_video", "invalid_parameters", "aspect_ratio parameter is required", nil)
+	}
+	opts.Mode, _ = args["mode"].(string)
+	if focus, ok := args["focus"].(float64); ok {
+		opts.Focus = &focus
+	}
+
+	output, err := h.storage.Reframe(ctx, storageID, opts)
+	if err != nil {
+		return h.errorResponse("reframe_video", "edit_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	parameters := map[string]interface{}{
+		"aspect_ratio": opts.AspectRatio,
+		"mode":         opts.Mode,
+	}
+	if opts.Focus != nil {
+		parameters["focus"] = *opts.Focus
+	}
+	variant := strings.TrimSuffix(filepath.Base(output), filepath.Ext(output))
+	return h.editResponse("reframe_video", storageID, variant, output, parameters)
+}
+
+// handleInterpolateFrames renders a copy of a completed video at a higher
+// frame rate, optionally slowed down
+func (h *ReplicateVideoHandler) handleInterpolateFrames(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("interpolate_frames", "invalid_parameters", err.Error(), nil)
+	}
+
+	opts := storage.InterpolateOptions{}
+	if fps, ok := args["fps"].(float64); ok {
+		opts.FPS = int(fps)
+	}
+	opts.Method, _ = args["method"].(string)
+	opts.SlowMotion, _ = args["slow_motion"].(float64)
+
+	output, err := h.storage.Interpolate(ctx, storageID, opts)
+	if err != nil {
+		return h.errorResponse("interpolate_frames", "edit_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	return h.editResponse("interpolate_frames", storageID, "interpolated", output, map[string]interface{}{
+		"fps":         opts.FPS,
+		"method":      opts.Method,
+		"slow_motion": opts.SlowMotion,
+	})
+}
+
+// handleMakeLoop renders a seamlessly looping copy of a completed video
+func (h *ReplicateVideoHandler) handleMakeLoop(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("make_loop", "invalid_parameters", err.Error(), nil)
+	}
+
+	opts := storage.LoopOptions{}
+	opts.Style, _ = args["style"].(string)
+	if count, ok := args["count"].(float64); ok {
+		opts.Count = int(count)
+	}
+	opts.Crossfade, _ = args["crossfade"].(float64)
+
+	output, err := h.storage.MakeLoop(ctx, storageID, opts)
+	if err != nil {
+		return h.errorResponse("make_loop", "edit_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	return h.editResponse("make_loop", storageID, "loop", output, map[string]interface{}{
+		"style":     opts.Style,
+		"count":     opts.Count,
+		"crossfade": opts.Crossfade,
+	})
+}
+
+// handleExtractFrames writes frames of a completed video as images and
+// lists their paths
+func (h *ReplicateVideoHandler) handleExtractFrames(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("extract_frames", "invalid_parameters", err.Error(), nil)
+	}
+
+	opts := storage.FrameOptions{}
+	opts.Format, _ = args["format"].(string)
+	if every, ok := args["every"].(float64); ok {
+		opts.Every = int(every)
+	}
+	if items, ok := args["timestamps"].([]interface{}); ok {
+		for _, item := range items {
+			t, ok := item.(float64)
+			if !ok {
+				return h.errorResponse("extract_frames", "invalid_parameters", "timestamps must be numbers of seconds", nil)
+			}
+			opts.Timestamps = append(opts.Timestamps, t)
+		}
+	}
+	if opts.Every > 1 && len(opts.Timestamps) > 0 {
+		return h.errorResponse("extract_frames", "invalid_parameters", "use either every or timestamps, not both", nil)
+	}
+
+	frames, err := h.storage.ExtractFrames(ctx, storageID, opts)
+	if err != nil {
+		return h.errorResponse("extract_frames", "extract_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	infos := make([]types.FrameInfo, 0, len(frames))
+	for _, frame := range frames {
+		info := types.FrameInfo{Path: frame.Path}
+		if len(opts.Timestamps) > 0 {
+			timestamp := frame.Timestamp
+			info.Timestamp = &timestamp
+		}
+		infos = append(infos, info)
+	}
+
+	format := "png"
+	if len(frames) > 0 {
+		format = strings.TrimPrefix(filepath.Ext(frames[0].Path), ".")
+	}
+	folder := filepath.Join(h.storage.GetStoragePath(storageID), "frames")
+	return h.successResponse(responses.BuildFramesResponse(storageID, folder, format, infos))
+}
+
+// editResponse reports a rendered variant of a stored video alongside the
+// original it was made from
+func (h *ReplicateVideoHandler) editResponse(operation, storageID, variant, output string, parameters map[string]interface{}) (*protocol.CallToolResponse, error) {
+	paths := map[string]string{variant: output}
+	if source, err := h.storage.OutputPath(storageID); err == nil {
+		paths["source"] = source
+	}
+	metrics := map[string]interface{}{}
+	if info, err := os.Stat(output); err == nil {
+		metrics["file_size"] = info.Size()
+	}
+
+	response := responses.BuildSuccessResponse(
+		operation,
+		storageID,
+		paths,
+		h.fileURLs(storageID, paths),
+		map[string]string{},
+		parameters,
+		metrics,
+		"",
+		nil,
+		nil,
+	)
+	return h.successResponse(response)
+}
@@ -2,42 +2,56 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
 	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/moderation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
 )
 
 // handleGenerateVideoFromText handles text-to-video generation
 func (h *ReplicateVideoHandler) handleGenerateVideoFromText(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
-	// Note: Debug logging disabled in MCP mode
-	
 	// Extract and validate parameters
 	params, err := h.extractTextToVideoParams(args)
 	if err != nil {
 		return h.errorResponse("generate_video_from_text", "invalid_parameters", err.Error(), nil)
 	}
 	
-	// Generate video (async by default)
-	result, err := h.generator.GenerateTextToVideo(ctx, params)
+	// Generate video; the executor polls the prediction in the background
+	target, queued, err := h.startOrQueue(ctx, "generate_video_from_text", args, params, func() (*generation.VideoResult, error) {
+		return h.generator.GenerateTextToVideo(ctx, params)
+	})
 	if err != nil {
-		return h.errorResponse("generate_video_from_text", "generation_failed", err.Error(), nil)
+		return h.generationFailed("generate_video_from_text", err, nil)
+	}
+	if queued != nil {
+		return h.queuedResponse("generate_video_from_text", queued)
 	}
 	
-	// Return processing response (async)
+	// Fast models may finish while the prediction is created; return the
+	// video once the background poll has saved it
+	if target.Finished {
+		return h.finishedResponse(ctx, target)
+	}
+	
+	// Return processing response (async) with a wait based on the model's history
+	expected := h.generator.ExpectedDuration(params.Model)
 	return h.processingResponse(
 		"generate_video_from_text",
-		result.PredictionID,
-		result.ID,
-		30,
+		target,
+		int(generation.SuggestedWait(expected, 0).Seconds()),
+		int(expected.Seconds()),
+		autoModelWarnings(args, params),
 	)
 }
 
 // handleGenerateVideoFromImage handles image-to-video generation
 func (h *ReplicateVideoHandler) handleGenerateVideoFromImage(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
-	// Note: Debug logging disabled in MCP mode
-	
 	// Extract and validate parameters
 	params, err := h.extractImageToVideoParams(args)
 	if err != nil {
@@ -50,21 +64,99 @@ func (h *ReplicateVideoHandler) handleGenerateVideoFromImage(ctx context.Context
 			fmt.Sprintf("Image file not found: %s", params.ImagePath), nil)
 	}
 	
-	// Generate video (async by default)
-	result, err := h.generator.GenerateImageToVideo(ctx, params)
+	// Generate video; the executor polls the prediction in the background
+	target, queued, err := h.startOrQueue(ctx, "generate_video_from_image", args, params, func() (*generation.VideoResult, error) {
+		return h.generator.GenerateImageToVideo(ctx, params)
+	})
 	if err != nil {
-		return h.errorResponse("generate_video_from_image", "generation_failed", err.Error(), nil)
+		return h.generationFailed("generate_video_from_image", err, nil)
+	}
+	if queued != nil {
+		return h.queuedResponse("generate_video_from_image", queued)
+	}
+	
+	// Fast models may finish while the prediction is created; return the
+	// video once the background poll has saved it
+	if target.Finished {
+		return h.finishedResponse(ctx, target)
 	}
 	
-	// Return processing response (async)
+	// Return processing response (async) with a wait based on the model's history
+	expected := h.generator.ExpectedDuration(params.Model)
 	return h.processingResponse(
 		"generate_video_from_image",
-		result.PredictionID,
-		result.ID,
-		30,
+		target,
+		int(generation.SuggestedWait(expected, 0).Seconds()),
+		int(expected.Seconds()),
+		autoModelWarnings(args, params),
+	)
+}
+
+// finishedResponse returns a generation whose prediction had already
+// succeeded when it was created, as continue_operation would
+func (h *ReplicateVideoHandler) finishedResponse(ctx context.Context, target pollTarget) (*protocol.CallToolResponse, error) {
+	return h.handleContinueOperation(ctx, map[string]interface{}{
+		"operation_id": target.OperationID,
+	})
+}
+
+// handleRunCustomVideoModel runs an arbitrary Replicate model with a raw input object
+func (h *ReplicateVideoHandler) handleRunCustomVideoModel(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	model, _ := args["model"].(string)
+	modelRef, err := generation.ParseCustomModel(model)
+	if err != nil {
+		return h.errorResponse("run_custom_video_model", "invalid_parameters", err.Error(), nil)
+	}
+	
+	input, ok := args["input"].(map[string]interface{})
+	if !ok {
+		return h.errorResponse("run_custom_video_model", "invalid_parameters", "input must be an object of the model's input parameters", nil)
+	}
+	
+	var params generation.VideoParams
+	params.SessionID = sessionIDArg(args)
+	params.Project, err = projectArg(args)
+	if err != nil {
+		return h.errorResponse("run_custom_video_model", "invalid_parameters", err.Error(), nil)
+	}
+	if outputDir, ok := args["output_dir"].(string); ok && outputDir != "" {
+		dir, err := storage.ValidateOutputDir(outputDir, h.config.AllowedOutputDirs)
+		if err != nil {
+			return h.errorResponse("run_custom_video_model", "invalid_parameters", err.Error(), nil)
+		}
+		params.OutputDir = dir
+	}
+	
+	target, err := h.startGeneration(ctx, "run_custom_video_model", args, func() (*generation.VideoResult, error) {
+		return h.generator.GenerateCustom(ctx, modelRef, input, params)
+	})
+	if err != nil {
+		return h.generationFailed("run_custom_video_model", err, map[string]interface{}{
+			"model": modelRef,
+		})
+	}
+	
+	// Unregistered models have no timing history, so use the default wait
+	expected := h.generator.ExpectedDuration(modelRef)
+	return h.processingResponse(
+		"run_custom_video_model",
+		target,
+		int(generation.SuggestedWait(expected, 0).Seconds()),
+		int(expected.Seconds()),
+		nil,
 	)
 }
 
+// autoModelWarnings returns the ignored-parameter warnings, noting the
+// model picked when the request asked for model "auto"
+func autoModelWarnings(args map[string]interface{}, params generation.VideoParams) []string {
+	warnings := generation.IgnoredParams(params)
+	if model, _ := args["model"].(string); model == generation.AutoModel {
+		warnings = append(warnings, fmt.Sprintf("model auto selected %s", params.Model))
+	}
+	return warnings
+}
+
 // extractTextToVideoParams extracts and validates T2V parameters
 func (h *ReplicateVideoHandler) extractTextToVideoParams(args map[string]interface{}) (generation.VideoParams, error) {
 	var params generation.VideoParams
@@ -76,11 +168,20 @@ func (h *ReplicateVideoHandler) extractTextToVideoParams(args map[string]interfa
 	}
 	params.Prompt = prompt
 	
-	// Optional: model (default: wan-t2v-fast)
+	// Optional: model (default: wan-t2v-fast, or the configured default)
 	if model, ok := args["model"].(string); ok && model != "" {
 		params.Model = model
 	} else {
-		params.Model = "wan-t2v-fast"
+		params.Model = generation.DefaultTextModel()
+	}
+	
+	// "auto" picks the best model for the constraints
+	if params.Model == generation.AutoModel {
+		model, err := h.autoModel("t2v", args)
+		if err != nil {
+			return params, err
+		}
+		params.Model = model
 	}
 	
 	// Validate model supports T2V
@@ -88,6 +189,11 @@ func (h *ReplicateVideoHandler) extractTextToVideoParams(args map[string]interfa
 		return params, fmt.Errorf("model %s does not support text-to-video generation", params.Model)
 	}
 	
+	// Optional: model_version (overrides the pinned version)
+	if version, ok := args["model_version"].(string); ok && version != "" {
+		params.Version = version
+	}
+	
 	// Optional: resolution
 	if resolution, ok := args["resolution"].(string); ok && resolution != "" {
 		params.Resolution = resolution
@@ -98,25 +204,108 @@ func (h *ReplicateVideoHandler) extractTextToVideoParams(args map[string]interfa
 		params.AspectRatio = aspectRatio
 	}
 	
-	// Optional: duration (for Kling)
+	// Optional: duration, checked against the model by ValidateParams
 	if durationFloat, ok := args["duration"].(float64); ok {
-		duration := int(durationFloat)
-		if duration < 5 || duration > 10 {
-			return params, fmt.Errorf("duration must be between 5 and 10 seconds")
-		}
-		params.Duration = duration
+		params.Duration = int(durationFloat)
 	}
 	
-	// Optional: negative_prompt (for Veo3, Kling)
+	// Optional: negative_prompt (for Wan, Veo3, Kling)
 	if negativePrompt, ok := args["negative_prompt"].(string); ok {
 		params.NegativePrompt = negativePrompt
 	}
 	
+	// Optional: style_image_path (for Hailuo)
+	if styleImagePath, ok := args["style_image_path"].(string); ok && styleImagePath != "" {
+		path, err := h.inputPath("style_image_path", styleImagePath)
+		if err != nil {
+			return params, err
+		}
+		params.StyleImagePath = path
+	}
+	
+	// Optional: num_frames and fps (for Wan)
+	if numFrames, ok := args["num_frames"].(float64); ok {
+		params.NumFrames = int(numFrames)
+	}
+	if fps, ok := args["fps"].(float64); ok {
+		params.FramesPerSecond = int(fps)
+	}
+	
+	// Optional: go_fast and sample_shift (for Wan)
+	if goFast, ok := args["go_fast"].(bool); ok {
+		params.GoFast = &goFast
+	}
+	if sampleShift, ok := args["sample_shift"].(float64); ok {
+		params.SampleShift = sampleShift
+	}
+	
+	// Optional: seed, for reproducible results
+	if seed, ok := args["seed"].(float64); ok {
+		value := int(seed)
+		params.Seed = &value
+	}
+	
+	// Optional: auto_retry and retry_strategy
+	params.Retry = retryArg(args)
+	
+	// Optional: priority and start_after or schedule, for requests that may
+	// be queued
+	if _, err := priorityArg(args); err != nil {
+		return params, err
+	}
+	if _, _, err := startAfterArg(args); err != nil {
+		return params, err
+	}
+	
+	// Optional: guidance_scale and num_inference_steps (for LTX, Hunyuan, Wan full)
+	if guidanceScale, ok := args["guidance_scale"].(float64); ok {
+		params.GuidanceScale = guidanceScale
+	}
+	if steps, ok := args["num_inference_steps"].(float64); ok {
+		params.InferenceSteps = int(steps)
+	}
+	
+	// Optional: camera_motion, and motion_strength for models that declare it
+	if cameraMotion, ok := args["camera_motion"].(string); ok {
+		params.CameraMotion = cameraMotion
+	}
+	if motionStrength, ok := args["motion_strength"].(float64); ok {
+		params.MotionStrength = motionStrength
+	}
+	
+	// Optional: effect, for models with effect templates
+	if effect, ok := args["effect"].(string); ok {
+		params.Effect = effect
+	}
+	
 	// Optional: filename
 	if filename, ok := args["filename"].(string); ok {
 		params.Filename = filename
 	}
 	
+	// Optional: session_id
+	params.SessionID = sessionIDArg(args)
+	
+	// Optional: project
+	project, err := projectArg(args)
+	if err != nil {
+		return params, err
+	}
+	params.Project = project
+	
+	// Optional: output_dir
+	if outputDir, ok := args["output_dir"].(string); ok && outputDir != "" {
+		dir, err := storage.ValidateOutputDir(outputDir, h.config.AllowedOutputDirs)
+		if err != nil {
+			return params, err
+		}
+		params.OutputDir = dir
+	}
+	
+	if err := generation.ValidateParams(params); err != nil {
+		return params, err
+	}
+	
 	return params, nil
 }
 
@@ -129,8 +318,21 @@ func (h *ReplicateVideoHandler) extractImageToVideoParams(args map[string]interf
 	if !ok || imagePath == "" {
 		return params, fmt.Errorf("image_path parameter is required and must be a non-empty string")
 	}
+	imagePath, err := h.inputPath("image_path", imagePath)
+	if err != nil {
+		return params, err
+	}
 	params.ImagePath = imagePath
 	
+	// Optional: image_paths (further keyframes or references)
+	for _, path := range stringSliceArg(args, "image_paths") {
+		path, err := h.inputPath("image_paths", path)
+		if err != nil {
+			return params, err
+		}
+		params.ImagePaths = append(params.ImagePaths, path)
+	}
+	
 	// Required: prompt
 	prompt, ok := args["prompt"].(string)
 	if !ok || prompt == "" {
@@ -138,11 +340,20 @@ func (h *ReplicateVideoHandler) extractImageToVideoParams(args map[string]interf
 	}
 	params.Prompt = prompt
 	
-	// Optional: model (default: wan-i2v-fast)
+	// Optional: model (default: wan-i2v-fast, or the configured default)
 	if model, ok := args["model"].(string); ok && model != "" {
 		params.Model = model
 	} else {
-		params.Model = "wan-i2v-fast"
+		params.Model = generation.DefaultImageModel()
+	}
+	
+	// "auto" picks the best model for the constraints
+	if params.Model == generation.AutoModel {
+		model, err := h.autoModel("i2v", args)
+		if err != nil {
+			return params, err
+		}
+		params.Model = model
 	}
 	
 	// Validate model supports I2V
@@ -150,29 +361,175 @@ func (h *ReplicateVideoHandler) extractImageToVideoParams(args map[string]interf
 		return params, fmt.Errorf("model %s does not support image-to-video generation", params.Model)
 	}
 	
+	// Optional: model_version (overrides the pinned version)
+	if version, ok := args["model_version"].(string); ok && version != "" {
+		params.Version = version
+	}
+	
 	// Optional: resolution
 	if resolution, ok := args["resolution"].(string); ok && resolution != "" {
 		params.Resolution = resolution
 	}
 	
-	// Optional: duration (for Kling)
+	// Optional: duration, checked against the model by ValidateParams
 	if durationFloat, ok := args["duration"].(float64); ok {
-		duration := int(durationFloat)
-		if duration < 5 || duration > 10 {
-			return params, fmt.Errorf("duration must be between 5 and 10 seconds")
-		}
-		params.Duration = duration
+		params.Duration = int(durationFloat)
 	}
 	
-	// Optional: negative_prompt (for Veo3, Kling)
+	// Optional: negative_prompt (for Wan, Veo3, Kling)
 	if negativePrompt, ok := args["negative_prompt"].(string); ok {
 		params.NegativePrompt = negativePrompt
 	}
 	
+	// Optional: num_frames and fps (for Wan)
+	if numFrames, ok := args["num_frames"].(float64); ok {
+		params.NumFrames = int(numFrames)
+	}
+	if fps, ok := args["fps"].(float64); ok {
+		params.FramesPerSecond = int(fps)
+	}
+	
+	// Optional: go_fast and sample_shift (for Wan)
+	if goFast, ok := args["go_fast"].(bool); ok {
+		params.GoFast = &goFast
+	}
+	if sampleShift, ok := args["sample_shift"].(float64); ok {
+		params.SampleShift = sampleShift
+	}
+	
+	// Optional: seed, for reproducible results
+	if seed, ok := args["seed"].(float64); ok {
+		value := int(seed)
+		params.Seed = &value
+	}
+	
+	// Optional: auto_retry and retry_strategy
+	params.Retry = retryArg(args)
+	
+	// Optional: priority and start_after or schedule, for requests that may
+	// be queued
+	if _, err := priorityArg(args); err != nil {
+		return params, err
+	}
+	if _, _, err := startAfterArg(args); err != nil {
+		return params, err
+	}
+	
+	// Optional: guidance_scale and num_inference_steps (for LTX, Hunyuan, Wan full)
+	if guidanceScale, ok := args["guidance_scale"].(float64); ok {
+		params.GuidanceScale = guidanceScale
+	}
+	if steps, ok := args["num_inference_steps"].(float64); ok {
+		params.InferenceSteps = int(steps)
+	}
+	
+	// Optional: camera_motion, and motion_strength for models that declare it
+	if cameraMotion, ok := args["camera_motion"].(string); ok {
+		params.CameraMotion = cameraMotion
+	}
+	if motionStrength, ok := args["motion_strength"].(float64); ok {
+		params.MotionStrength = motionStrength
+	}
+	
+	// Optional: effect, for models with effect templates
+	if effect, ok := args["effect"].(string); ok {
+		params.Effect = effect
+	}
+	
+	// Optional: safety_checker (for Wan I2V, requires opt-in)
+	if safetyChecker, ok := args["safety_checker"].(bool); ok {
+		if !h.config.AllowSafetyOverride {
+			return params, fmt.Errorf("safety_checker can only be changed when REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE=true")
+		}
+		params.SafetyChecker = &safetyChecker
+	}
+	
 	// Optional: filename
 	if filename, ok := args["filename"].(string); ok {
 		params.Filename = filename
 	}
 	
+	// Optional: session_id
+	params.SessionID = sessionIDArg(args)
+	
+	// Optional: project
+	project, err := projectArg(args)
+	if err != nil {
+		return params, err
+	}
+	params.Project = project
+	
+	// Optional: output_dir
+	if outputDir, ok := args["output_dir"].(string); ok && outputDir != "" {
+		dir, err := storage.ValidateOutputDir(outputDir, h.config.AllowedOutputDirs)
+		if err != nil {
+			return params, err
+		}
+		params.OutputDir = dir
+	}
+	
+	if err := generation.ValidateParams(params); err != nil {
+		return params, err
+	}
+	
 	return params, nil
-}
\ No newline at end of file
+}
+
+// retryArg extracts the optional resubmission of transient failures
+func retryArg(args map[string]interface{}) generation.RetryPolicy {
+	var policy generation.RetryPolicy
+	if count, ok := args["auto_retry"].(float64); ok {
+		policy.Count = int(count)
+	}
+	if strategy, ok := args["retry_strategy"].(string); ok {
+		policy.Strategy = strategy
+	}
+	return policy
+}
+
+// generationFailed reports a generation that could not be started. Requests
+// refused by a limit are reported as quota_exceeded errors with the reset
+// time, and prompts rejected by moderation as content_policy errors with the
+// reason; Replicate failures get their classified error type.
+func (h *ReplicateVideoHandler) generationFailed(operation string, err error, details map[string]interface{}) (*protocol.CallToolResponse, error) {
+	if details == nil {
+		details = make(map[string]interface{})
+	}
+	
+	var quotaErr *generation.QuotaError
+	if errors.As(err, &quotaErr) {
+		details["limit"] = quotaErr.Limit
+		if !quotaErr.ResetAt.IsZero() {
+			details["reset_at"] = quotaErr.ResetAt.Format(time.RFC3339)
+		}
+		return h.errorResponse(operation, "quota_exceeded", err.Error(), details)
+	}
+	
+	var violation *moderation.Violation
+	if !errors.As(err, &violation) {
+		return h.errorResponse(operation, client.ErrorType(err, "generation_failed"), err.Error(), details)
+	}
+	
+	details["source"] = violation.Source
+	if violation.Rule != "" {
+		details["rule"] = violation.Rule
+	}
+	if len(violation.Categories) > 0 {
+		details["categories"] = violation.Categories
+	}
+	return h.errorResponse(operation, "content_policy", err.Error(), details)
+}
+
+// generationErrorType returns the error type generationFailed reports for err
+func generationErrorType(err error) string {
+	var quotaErr *generation.QuotaError
+	var violation *moderation.Violation
+	switch {
+	case errors.As(err, &quotaErr):
+		return "quota_exceeded"
+	case errors.As(err, &violation):
+		return "content_policy"
+	default:
+		return client.ErrorType(err, "generation_failed")
+	}
+}
@@ -3,63 +3,373 @@ package handler
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sync"
 	"time"
 
 	"github.com/gomcpgo/mcp/pkg/async"
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/audit"
 	"github.com/gomcpgo/replicate_video_ai/pkg/client"
 	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/fileserver"
 	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/moderation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/notify"
 	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
 	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
 )
 
-// ReplicateVideoHandler handles MCP requests for video operations
+// ReplicateVideoHandler handles MCP requests for video operations. Every
+// namespace has its own handler with its own storage and generator; the
+// rest of the server state is shared.
 type ReplicateVideoHandler struct {
+	*shared
 	generator *generation.Generator
 	storage   *storage.Storage
+	namespace string // Empty for the default namespace
+	
+	// queueWake asks the namespace's queue dispatcher to check the queue
+	queueWake chan struct{}
+}
+
+// shared is the server state common to all namespaces
+type shared struct {
 	client    client.Client
+	transport *http.Transport // Proxy and TLS settings for downloads
 	executor  *async.OperationExecutor
+	notifier  *notify.Dispatcher
+	moderator *moderation.Checker
+	files     *fileserver.Server // nil unless REPLICATE_VIDEO_HTTP_ADDR is set
+	audit     *audit.Log         // nil when REPLICATE_VIDEO_AUDIT_LOG=off
+	config    *config.Config
 	timeouts  config.TimeoutConfig
 	debug     bool
+	startedAt time.Time // When the server started, where session_summary begins
+	
+	// shutdownCtx is canceled on Shutdown to interrupt in-flight polls
+	shutdownCtx context.Context
+	stopPolls   context.CancelFunc
+	
+	// polls maps prediction IDs (prefixed with their namespace) to the
+	// executor operation polling them, and pollTargets operation IDs to the
+	// prediction they poll
+	pollMu      sync.Mutex
+	polls       map[string]string
+	pollTargets map[string]pollTarget
+	
+	// generations holds recent generation requests for coalescing
+	genMu       sync.Mutex
+	generations map[string]*generationCall
+	
+	// variationSets holds variation sets by namespace and ID while their
+	// variations wait for concurrency slots
+	varMu         sync.Mutex
+	variationSets map[string]*variationSet
+	
+	// storyboards holds storyboards by namespace and ID while their scenes
+	// are generated and joined
+	sbMu        sync.Mutex
+	storyboards map[string]*storyboard
+	
+	// tenants holds the handler of each namespace used so far
+	tenantMu sync.Mutex
+	tenants  map[string]*ReplicateVideoHandler
 }
 
 // NewReplicateVideoHandler creates a new handler instance
-func NewReplicateVideoHandler(apiKey string, rootFolder string, debug bool) (*ReplicateVideoHandler, error) {
-	// Initialize storage
-	store := storage.NewStorage(rootFolder, debug)
+func NewReplicateVideoHandler(cfg *config.Config) (*ReplicateVideoHandler, error) {
+	debug := cfg.DebugMode
+	
+	// Check the filename template once for all namespaces; empty uses the
+	// default
+	if cfg.FilenameTemplate == "" {
+		cfg.FilenameTemplate = storage.DefaultFilenameTemplate
+	}
+	if err := storage.ValidateFilenameTemplate(cfg.FilenameTemplate); err != nil {
+		return nil, fmt.Errorf("invalid REPLICATE_VIDEO_FILENAME_TEMPLATE: %w", err)
+	}
 	
-	// Initialize Replicate client
-	replicateClient := client.NewReplicateClient(apiKey, debug)
+	// Proxy and TLS settings for the API and downloads
+	transport, err := client.NewTransport(cfg.HTTP)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Initialize Replicate client (or the offline mock)
+	var replicateClient client.Client
+	if cfg.Mock.Enabled {
+		mockClient, err := client.NewMockClient(cfg.Mock.Delay, cfg.Mock.Output)
+		if err != nil {
+			return nil, err
+		}
+		replicateClient = mockClient
+	} else {
+		apiClient := client.NewReplicateClient(cfg.ReplicateAPIToken, debug)
+		apiClient.SetTimeout(cfg.HTTP.APITimeout)
+		apiClient.SetTransport(transport)
+		if cfg.HTTP.Cassette != "" {
+			recorder, err := client.NewRecorder(cfg.HTTP.Cassette, cfg.HTTP.CassetteMode, transport)
+			if err != nil {
+				return nil, err
+			}
+			apiClient.SetTransport(recorder)
+		}
+		replicateClient = apiClient
+	}
+	
+	// Apply the configured defaults, and model overrides from models.yaml
+	if err := generation.Configure(cfg.Defaults, cfg.Models); err != nil {
+		return nil, fmt.Errorf("invalid models config: %w", err)
+	}
+	if err := responses.Configure(cfg.Locale, cfg.Messages); err != nil {
+		return nil, fmt.Errorf("invalid REPLICATE_VIDEO_LOCALE or messages config: %w", err)
+	}
 	
-	// Initialize generator
-	gen := generation.NewGenerator(replicateClient, store, debug)
+	// Locate ffmpeg and ffprobe once, and bound their runs; features needing
+	// them are disabled when they're missing
+	storage.SetMediaToolPaths(cfg.FFmpegPath, cfg.FFprobePath)
+	storage.SetMediaLimits(cfg.Media.ProbeTimeout, cfg.Media.RenderTimeout, cfg.Media.MaxConcurrent)
+	tools := storage.DetectMediaTools()
+	for _, tool := range []storage.MediaTool{tools.FFmpeg, tools.FFprobe} {
+		if !tool.Available() {
+			logging.Warn("media tool unavailable", "error", tool.Err)
+		}
+	}
+	
+	// Initialize notification channels
+	notifier, err := notify.NewDispatcherFromConfig(cfg.Notifications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure notifications: %w", err)
+	}
+	
+	// Screen prompts when a blocklist or moderation API is configured
+	moderator, err := moderation.NewChecker(cfg.Moderation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure moderation: %w", err)
+	}
 	
 	// Load timeout configuration
-	timeouts := config.LoadTimeouts()
+	timeouts, err := config.LoadTimeouts()
+	if err != nil {
+		return nil, err
+	}
+	generation.SetMaxContinueWait(timeouts.MaxContinueWait)
 	
-	// Initialize async executor
+	// Initialize async executor, which polls every generation in the
+	// background from the moment its prediction is created
 	executorConfig := async.ExecutorConfig{
 		DefaultTimeout:  timeouts.InitialWait,
-		MaxLifetime:     10 * time.Minute,
-		RetentionPeriod: 5 * time.Minute,
+		MaxLifetime:     timeouts.TotalTimeout,
+		RetentionPeriod: operationRetention,
 		CleanupInterval: 1 * time.Minute,
 	}
 	executor := async.NewExecutor(executorConfig)
 	
-	return &ReplicateVideoHandler{
-		generator: gen,
-		storage:   store,
+	// Record every tool call for shared deployments
+	var auditLog *audit.Log
+	if cfg.AuditLog != "" {
+		auditLog, err = audit.Open(cfg.AuditLog)
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	shutdownCtx, stopPolls := context.WithCancel(context.Background())
+	
+	s := &shared{
 		client:    replicateClient,
+		transport: transport,
 		executor:  executor,
+		notifier:  notifier,
+		moderator: moderator,
+		audit:     auditLog,
+		config:    cfg,
 		timeouts:  timeouts,
 		debug:     debug,
-	}, nil
+		startedAt: time.Now(),
+		
+		shutdownCtx: shutdownCtx,
+		stopPolls:   stopPolls,
+		polls:       make(map[string]string),
+		pollTargets: make(map[string]pollTarget),
+		generations: make(map[string]*generationCall),
+		tenants:     make(map[string]*ReplicateVideoHandler),
+		
+		variationSets: make(map[string]*variationSet),
+		storyboards:   make(map[string]*storyboard),
+	}
+	
+	// The configured namespace (default: none) serves resources and the
+	// file server, and tool calls that don't select another
+	h, err := s.tenant(cfg.Namespace)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REPLICATE_VIDEO_NAMESPACE: %w", err)
+	}
+	
+	// Serve results over HTTP when enabled
+	if cfg.FileServer.Enabled {
+		files, err := fileserver.New(cfg.FileServer, h.storage)
+		if err != nil {
+			return nil, err
+		}
+		if err := files.Start(); err != nil {
+			return nil, err
+		}
+		s.files = files
+	}
+	
+	return h, nil
 }
 
-// CallTool handles execution of video tools
+// tenant returns the handler of a namespace, creating its storage and
+// generator on first use. Each namespace has its own quota.
+func (s *shared) tenant(namespace string) (*ReplicateVideoHandler, error) {
+	s.tenantMu.Lock()
+	defer s.tenantMu.Unlock()
+	
+	if h, ok := s.tenants[namespace]; ok {
+		return h, nil
+	}
+	
+	rootFolder, err := storage.NamespaceRoot(s.config.VideosRootFolder, namespace)
+	if err != nil {
+		return nil, err
+	}
+	store := storage.NewStorage(rootFolder, s.debug)
+	store.SetDownloadIdleTimeout(s.config.HTTP.DownloadIdleTimeout)
+	store.SetDownloadTransport(s.transport)
+	store.SetImageLimits(s.config.Images.MaxBytes, s.config.Images.Types)
+	if err := store.SetFilenameTemplate(s.config.FilenameTemplate); err != nil {
+		return nil, fmt.Errorf("invalid REPLICATE_VIDEO_FILENAME_TEMPLATE: %w", err)
+	}
+	
+	gen := generation.NewGenerator(s.client, store, s.debug)
+	gen.SetNotifier(s.notifier)
+	gen.SetQualityReport(s.config.QualityReport)
+	gen.SetSyncWait(s.timeouts.SyncWait)
+	gen.SetModerator(s.moderator)
+	gen.SetQuota(generation.NewQuota(s.config.Limits))
+	
+	h := &ReplicateVideoHandler{
+		shared:    s,
+		generator: gen,
+		storage:   store,
+		namespace: namespace,
+		queueWake: make(chan struct{}, 1),
+	}
+	s.tenants[namespace] = h
+	
+	// Start queued generations as slots free up, including those queued
+	// before a restart
+	go h.runQueue()
+	
+	// Fetch videos that finished (or failed) while the server was down
+	if s.config.ReconcileOnStartup {
+		go gen.Reconcile(s.shutdownCtx)
+	}
+
+	// Delete generations past their retention period
+	if s.config.Retention.Enabled() {
+		go store.ApplyRetention(s.config.Retention.Failed, s.config.Retention.Completed)
+	}
+	
+	logging.Debug("namespace ready", "namespace", namespace, "root", rootFolder)
+	return h, nil
+}
+
+// forRequest returns the handler of the namespace a tool call selects with
+// its namespace (or user_id) argument. When REPLICATE_VIDEO_NAMESPACE is
+// set, calls are confined to that namespace.
+func (h *ReplicateVideoHandler) forRequest(args map[string]interface{}) (*ReplicateVideoHandler, error) {
+	namespace, _ := args["namespace"].(string)
+	if namespace == "" {
+		namespace, _ = args["user_id"].(string)
+	}
+	if namespace == "" || namespace == h.namespace {
+		return h, nil
+	}
+	if h.config.Namespace != "" {
+		return nil, fmt.Errorf("this server is limited to namespace %q", h.config.Namespace)
+	}
+	if err := storage.ValidateNamespace(namespace); err != nil {
+		return nil, err
+	}
+	return h.tenant(namespace)
+}
+
+// CallTool handles execution of video tools in the namespace the call
+// selects, recording each call in the audit log and compacting the response
+// when asked
 func (h *ReplicateVideoHandler) CallTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	logging.Debug("tool call", "tool", req.Name)
+	
+	started := time.Now()
+	format, err := h.responseFormat(req.Arguments)
+	if err != nil {
+		resp, _ := h.errorResponse(req.Name, "invalid_parameters", err.Error(), nil)
+		h.recordCall(req, resp, nil, started)
+		return resp, nil
+	}
+	tenant, err := h.forRequest(req.Arguments)
+	if err != nil {
+		resp, _ := h.errorResponse(req.Name, "invalid_parameters", err.Error(), nil)
+		h.recordCall(req, resp, nil, started)
+		return resp, nil
+	}
+	
+	resp, err := tenant.callTool(ctx, req)
+	tenant.recordCall(req, resp, err, started)
+	if resp != nil && h.compactRequested(req.Arguments) {
+		for i, content := range resp.Content {
+			if content.Type == "text" {
+				resp.Content[i].Text = responses.Compact(content.Text)
+			}
+		}
+	}
+	if resp != nil && h.config.StructuredContent {
+		withStructuredContent(resp)
+	}
+	if resp != nil && format != responses.FormatJSON {
+		for i, content := range resp.Content {
+			if content.Type == "text" {
+				resp.Content[i].Text = responses.Format(content.Text, format)
+			}
+		}
+	}
+	return resp, err
+}
+
+// responseFormat returns the format a call's response is rendered in: its
+// response_format argument, or REPLICATE_VIDEO_RESPONSE_FORMAT
+func (h *ReplicateVideoHandler) responseFormat(args map[string]interface{}) (string, error) {
+	format, _ := args["response_format"].(string)
+	if format == "" {
+		return h.config.ResponseFormat, nil
+	}
+	if !responses.ValidFormat(format) {
+		return "", fmt.Errorf("invalid response_format %q (expected json, yaml, or markdown)", format)
+	}
+	return format, nil
+}
+
+// compactRequested reports whether a call's response should be compacted:
+// its compact_response argument, or REPLICATE_VIDEO_COMPACT_RESPONSES
+func (h *ReplicateVideoHandler) compactRequested(args map[string]interface{}) bool {
+	if compact, ok := args["compact_response"].(bool); ok {
+		return compact
+	}
+	return h.config.CompactResponses
+}
+
+// callTool dispatches a tool call to its handler
+func (h *ReplicateVideoHandler) callTool(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResponse, error) {
+	// Interrupt long polls when the server shuts down
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(h.shutdownCtx, cancel)
+	defer stop()
 	
 	switch req.Name {
 	// Generation tools
@@ -67,10 +377,83 @@ func (h *ReplicateVideoHandler) CallTool(ctx context.Context, req *protocol.Call
 		return h.handleGenerateVideoFromText(ctx, req.Arguments)
 	case "generate_video_from_image":
 		return h.handleGenerateVideoFromImage(ctx, req.Arguments)
+	case "run_custom_video_model":
+		return h.handleRunCustomVideoModel(ctx, req.Arguments)
+	case "compare_models":
+		return h.handleCompareModels(ctx, req.Arguments)
+	case "generate_variations":
+		return h.handleGenerateVariations(ctx, req.Arguments)
+	case "generate_storyboard":
+		return h.handleGenerateStoryboard(ctx, req.Arguments)
 		
 	// Async operation management
 	case "continue_operation":
 		return h.handleContinueOperation(ctx, req.Arguments)
+	case "recover_prediction":
+		return h.handleRecoverPrediction(ctx, req.Arguments)
+	case "queue_status":
+		return h.handleQueueStatus(ctx, req.Arguments)
+	case "reorder_queue":
+		return h.handleReorderQueue(ctx, req.Arguments)
+		
+	// Library management
+	case "search_videos":
+		return h.handleSearchVideos(ctx, req.Arguments)
+	case "list_videos":
+		return h.handleListVideos(ctx, req.Arguments)
+	case "tag_video":
+		return h.handleTagVideo(ctx, req.Arguments)
+	case "list_tags":
+		return h.handleListTags(ctx, req.Arguments)
+	case "import_video":
+		return h.handleImportVideo(ctx, req.Arguments)
+	case "export_videos":
+		return h.handleExportVideos(ctx, req.Arguments)
+	case "find_duplicates":
+		return h.handleFindDuplicates(ctx, req.Arguments)
+	case "storage_stats":
+		return h.handleStorageStats(ctx, req.Arguments)
+	case "session_summary":
+		return h.handleSessionSummary(ctx, req.Arguments)
+	case "delete_videos":
+		return h.handleDeleteVideos(ctx, req.Arguments)
+		
+	// Media tools
+	case "get_video_info":
+		return h.handleGetVideoInfo(ctx, req.Arguments)
+	case "get_thumbnail":
+		return h.handleGetThumbnail(ctx, req.Arguments)
+	case "burn_captions":
+		return h.handleBurnCaptions(ctx, req.Arguments)
+	case "watermark_video":
+		return h.handleWatermarkVideo(ctx, req.Arguments)
+	case "reframe_video":
+		return h.handleReframeVideo(ctx, req.Arguments)
+	case "interpolate_frames":
+		return h.handleInterpolateFrames(ctx, req.Arguments)
+	case "make_loop":
+		return h.handleMakeLoop(ctx, req.Arguments)
+	case "extract_frames":
+		return h.handleExtractFrames(ctx, req.Arguments)
+		
+	// Server information
+	case "server_capabilities":
+		return h.handleServerCapabilities(ctx, req.Arguments)
+	case "get_response_schema":
+		return h.handleGetResponseSchema(ctx, req.Arguments)
+		
+	case "check_account":
+		return h.handleCheckAccount(ctx, req.Arguments)
+	case "health_check":
+		return h.handleHealthCheck(ctx, req.Arguments)
+	case "get_model_versions":
+		return h.handleGetModelVersions(ctx, req.Arguments)
+	case "list_deployments":
+		return h.handleListDeployments(ctx, req.Arguments)
+	case "recommend_model":
+		return h.handleRecommendModel(ctx, req.Arguments)
+	case "get_audit_log":
+		return h.handleGetAuditLog(ctx, req.Arguments)
 		
 	default:
 		return nil, fmt.Errorf("unknown tool: %s", req.Name)
@@ -84,6 +467,51 @@ func (h *ReplicateVideoHandler) Stop() {
 	}
 }
 
+// EnableMCPNotifications sends generation events (progress, completion,
+// failure, budget warnings) to the MCP client as they happen, for servers
+// that can send notifications
+func (h *ReplicateVideoHandler) EnableMCPNotifications(sender notify.NotificationSender) {
+	h.notifier.RegisterAll(notify.NewMCPNotifier(sender))
+	logging.Info("sending generation events to the MCP client")
+}
+
+// Shutdown interrupts in-flight polls, records (or, with
+// REPLICATE_CANCEL_ON_SHUTDOWN, cancels) predictions that are still running,
+// and waits for pending notifications before the server exits
+func (h *ReplicateVideoHandler) Shutdown(ctx context.Context) {
+	h.stopPolls()
+	
+	h.tenantMu.Lock()
+	tenants := make([]*ReplicateVideoHandler, 0, len(h.tenants))
+	for _, tenant := range h.tenants {
+		tenants = append(tenants, tenant)
+	}
+	h.tenantMu.Unlock()
+	for _, tenant := range tenants {
+		report := tenant.generator.Shutdown(ctx, h.config.CancelOnShutdown)
+		logging.Info("handler shut down", "namespace", tenant.namespace, "pending", len(report.Pending), "canceled", len(report.Canceled))
+	}
+	
+	h.Stop()
+	h.notifier.Wait()
+	
+	if h.files != nil {
+		if err := h.files.Shutdown(ctx); err != nil {
+			logging.Warn("file server shutdown", "error", err)
+		}
+	}
+}
+
+// fileURLs returns signed HTTP URLs for a video's paths, or nil when the file
+// server is disabled. The file server only serves the server's own
+// namespace.
+func (h *ReplicateVideoHandler) fileURLs(storageID string, paths map[string]string) map[string]string {
+	if h.files == nil || h.namespace != h.config.Namespace {
+		return nil
+	}
+	return h.files.URLs(storageID, paths)
+}
+
 // Helper methods for building responses
 
 // errorResponse creates an error response
@@ -106,9 +534,10 @@ func (h *ReplicateVideoHandler) successResponse(response string) (*protocol.Call
 	}, nil
 }
 
-// processingResponse creates a processing response
-func (h *ReplicateVideoHandler) processingResponse(operation, predictionID, storageID string, waitTime int) (*protocol.CallToolResponse, error) {
-	response := responses.BuildProcessingResponse(operation, predictionID, storageID, waitTime)
+// processingResponse creates a processing response for a prediction polled by
+// a background operation
+func (h *ReplicateVideoHandler) processingResponse(operation string, target pollTarget, waitTime int, estimatedTime int, warnings []string) (*protocol.CallToolResponse, error) {
+	response := responses.BuildOperationResponse(operation, target.OperationID, target.PredictionID, target.StorageID, waitTime, estimatedTime, nil, warnings)
 	return &protocol.CallToolResponse{
 		Content: []protocol.ToolContent{
 			{Type: "text", Text: response},
@@ -0,0 +1,560 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// handleSearchVideos handles full-text search over stored prompts
+func (h *ReplicateVideoHandler) handleSearchVideos(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	query, ok := args["query"].(string)
+	if !ok || query == "" {
+		return h.errorResponse("search_videos", "invalid_parameters", "query parameter is required and must be a non-empty string", nil)
+	}
+
+	limit := 10
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	filter := filterArg(args)
+
+	results := h.storage.Search(query, filter, limit)
+
+	videos := make([]types.VideoSummary, 0, len(results))
+	for _, result := range results {
+		summary := h.storage.Summary(result.Record)
+		summary.Score = result.Score
+		summary.MatchedTerms = result.MatchedTerms
+		videos = append(videos, summary)
+	}
+
+	filters := filter.Summary()
+	filters["query"] = query
+	filters["limit"] = limit
+
+	response := responses.BuildListResponse("search_videos", videos, filters)
+	return h.successResponse(response)
+}
+
+// handleListVideos lists stored videos, newest first
+func (h *ReplicateVideoHandler) handleListVideos(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	filter := filterArg(args)
+
+	limit := 20
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	records := h.storage.ListRecords(filter)
+	if len(records) > limit {
+		records = records[:limit]
+	}
+
+	videos := make([]types.VideoSummary, 0, len(records))
+	for _, record := range records {
+		videos = append(videos, h.storage.Summary(record))
+	}
+
+	filters := filter.Summary()
+	filters["limit"] = limit
+
+	response := responses.BuildListResponse("list_videos", videos, filters)
+	return h.successResponse(response)
+}
+
+// handleDeleteVideos deletes stored videos by storage ID or session
+func (h *ReplicateVideoHandler) handleDeleteVideos(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageIDs := stringSliceArg(args, "storage_ids")
+	sessionID := sessionIDArg(args)
+
+	if len(storageIDs) == 0 && sessionID == "" {
+		return h.errorResponse("delete_videos", "invalid_parameters", "storage_ids or session_id is required", nil)
+	}
+
+	if len(storageIDs) == 0 {
+		for _, record := range h.storage.ListRecords(storage.Filter{SessionID: sessionID}) {
+			storageIDs = append(storageIDs, record.StorageID)
+		}
+	}
+
+	deleted := make([]types.VideoSummary, 0, len(storageIDs))
+	failures := make(map[string]interface{})
+	for _, storageID := range storageIDs {
+		if err := h.storage.DeleteStorage(storageID); err != nil {
+			failures[storageID] = err.Error()
+			continue
+		}
+		deleted = append(deleted, types.VideoSummary{StorageID: storageID, SessionID: sessionID, Status: "deleted"})
+	}
+
+	filters := map[string]interface{}{}
+	if sessionID != "" {
+		filters["session_id"] = sessionID
+	}
+	if len(failures) > 0 {
+		filters["failures"] = failures
+	}
+
+	response := responses.BuildListResponse("delete_videos", deleted, filters)
+	return h.successResponse(response)
+}
+
+// handleGetVideoInfo returns the stored metadata for a video, optionally with
+// a contact sheet of evenly spaced frames for judging motion quality
+func (h *ReplicateVideoHandler) handleGetVideoInfo(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("get_video_info", "invalid_parameters", err.Error(), nil)
+	}
+
+	metadata, err := h.storage.LoadMetadata(storageID)
+	if err != nil {
+		return h.errorResponse("get_video_info", "metadata_error", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+	record := storage.Record{StorageID: storageID, Metadata: metadata}
+	summary := h.storage.Summary(record)
+
+	model := make(map[string]string)
+	if m, ok := metadata["model"].(map[string]interface{}); ok {
+		for k, v := range m {
+			model[k] = fmt.Sprintf("%v", v)
+		}
+	}
+
+	parameters := getMapValue(metadata, "parameters")
+	delete(parameters, "raw_input") // May contain base64 image data
+	metrics := getMapValue(metadata, "metrics")
+
+	content := []protocol.ToolContent{}
+
+	includeSheet, _ := args["include_contact_sheet"].(bool)
+	if includeSheet {
+		videoPath, ok := summary.Paths["output"]
+		if !ok {
+			return h.errorResponse("get_video_info", "not_completed", "contact sheets are only available for completed videos", map[string]interface{}{
+				"storage_id": storageID,
+			})
+		}
+
+		frames := 9
+		if f, ok := args["frames"].(float64); ok && f > 0 {
+			frames = int(f)
+		}
+
+		sheetPath, err := h.storage.GenerateContactSheet(ctx, storageID, videoPath, frames)
+		if err != nil {
+			return h.errorResponse("get_video_info", "contact_sheet_failed", err.Error(), map[string]interface{}{
+				"storage_id": storageID,
+			})
+		}
+
+		err = h.storage.UpdateMetadata(storageID, func(metadata map[string]interface{}) error {
+			metaPaths := getMapValue(metadata, "paths")
+			metaPaths["contact_sheet"] = filepath.Base(sheetPath)
+			metadata["paths"] = metaPaths
+			return nil
+		})
+		if err != nil {
+			return h.errorResponse("get_video_info", "metadata_error", err.Error(), nil)
+		}
+		summary.Paths["contact_sheet"] = sheetPath
+
+		data, err := os.ReadFile(sheetPath)
+		if err != nil {
+			return h.errorResponse("get_video_info", "file_error", fmt.Sprintf("failed to read contact sheet: %v", err), nil)
+		}
+		content = append(content, protocol.ToolContent{
+			Type:     "image",
+			Data:     base64.StdEncoding.EncodeToString(data),
+			MimeType: "image/jpeg",
+		})
+	}
+
+	response := responses.BuildInfoResponse(
+		"get_video_info",
+		storageID,
+		summary.Status,
+		summary.Paths,
+		h.fileURLs(storageID, summary.Paths),
+		model,
+		parameters,
+		metrics,
+		summary.PredictionID,
+	)
+
+	if includeThumbnail, _ := args["include_thumbnail"].(bool); includeThumbnail {
+		if thumbnail, ok := thumbnailContent(summary.Paths); ok {
+			content = append(content, thumbnail)
+		}
+	}
+
+	content = append([]protocol.ToolContent{{Type: "text", Text: response}}, content...)
+	return &protocol.CallToolResponse{Content: content}, nil
+}
+
+// handleTagVideo adds or removes tags on a stored video and sets its
+// favorite flag
+func (h *ReplicateVideoHandler) handleTagVideo(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("tag_video", "invalid_parameters", err.Error(), nil)
+	}
+
+	add := stringSliceArg(args, "tags")
+	remove := stringSliceArg(args, "remove_tags")
+	var favorite *bool
+	if f, ok := args["favorite"].(bool); ok {
+		favorite = &f
+	}
+	if len(add) == 0 && len(remove) == 0 && favorite == nil {
+		return h.errorResponse("tag_video", "invalid_parameters", "tags, remove_tags, or favorite is required", nil)
+	}
+
+	record, err := h.storage.UpdateTags(storageID, add, remove, favorite)
+	if err != nil {
+		return h.errorResponse("tag_video", "tag_failed", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	return h.successResponse(responses.BuildTagResponse(storageID, record.Tags(), record.Favorite()))
+}
+
+// handleListTags lists the tags in use, most used first
+func (h *ReplicateVideoHandler) handleListTags(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	filter := storage.Filter{
+		SessionID: sessionIDArg(args),
+	}
+	if project, ok := args["project"].(string); ok {
+		filter.Project = project
+	}
+
+	counts := make(map[string]int)
+	favorites := 0
+	for _, record := range h.storage.ListRecords(filter) {
+		for _, tag := range record.Tags() {
+			counts[tag]++
+		}
+		if record.Favorite() {
+			favorites++
+		}
+	}
+
+	tags := make([]types.TagCount, 0, len(counts))
+	for tag, count := range counts {
+		tags = append(tags, types.TagCount{Tag: tag, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Tag < tags[j].Tag
+	})
+
+	response := responses.BuildTagListResponse(tags, favorites, filter.Summary())
+	return h.successResponse(response)
+}
+
+// handleImportVideo copies an existing video file into storage so it can be
+// managed alongside generations
+func (h *ReplicateVideoHandler) handleImportVideo(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	path, ok := args["path"].(string)
+	if !ok || strings.TrimSpace(path) == "" {
+		return h.errorResponse("import_video", "invalid_parameters", "path parameter is required", nil)
+	}
+	path, err := h.inputPath("path", path)
+	if err != nil {
+		return h.errorResponse("import_video", "invalid_parameters", err.Error(), nil)
+	}
+
+	project, err := projectArg(args)
+	if err != nil {
+		return h.errorResponse("import_video", "invalid_parameters", err.Error(), nil)
+	}
+	description, _ := args["description"].(string)
+
+	storageID, metadata, err := h.storage.ImportLocalVideo(ctx, strings.TrimSpace(path), storage.ImportOptions{
+		Description: description,
+		SessionID:   sessionIDArg(args),
+		Project:     project,
+		Tags:        stringSliceArg(args, "tags"),
+	})
+	if err != nil {
+		return h.errorResponse("import_video", "import_failed", err.Error(), map[string]interface{}{
+			"path": path,
+		})
+	}
+
+	summary := h.storage.Summary(storage.Record{StorageID: storageID, Metadata: metadata})
+	parameters := map[string]interface{}{
+		"source_path": metadata["source_path"],
+	}
+	if description != "" {
+		parameters["description"] = description
+	}
+
+	response := responses.BuildSuccessResponse(
+		"import_video",
+		storageID,
+		summary.Paths,
+		h.fileURLs(storageID, summary.Paths),
+		map[string]string{"id": "imported", "name": summary.Model},
+		parameters,
+		getMapValue(metadata, "metrics"),
+		"",
+		nil,
+		nil,
+	)
+	return h.successResponse(response)
+}
+
+// handleExportVideos bundles stored videos into a zip archive or a folder
+// with a manifest, for handing off a finished project
+func (h *ReplicateVideoHandler) handleExportVideos(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageIDs := stringSliceArg(args, "storage_ids")
+	if len(storageIDs) == 0 {
+		// Select by filter, defaulting to completed videos
+		filter := filterArg(args)
+		if filter == (storage.Filter{}) {
+			return h.errorResponse("export_videos", "invalid_parameters", "storage_ids or a filter (project, tag, favorite, session_id) is required", nil)
+		}
+		if filter.Status == "" {
+			filter.Status = "completed"
+		}
+		for _, record := range h.storage.ListRecords(filter) {
+			storageIDs = append(storageIDs, record.StorageID)
+		}
+		if len(storageIDs) == 0 {
+			return h.errorResponse("export_videos", "no_videos", "no videos match the filter", filter.Summary())
+		}
+	}
+
+	format := storage.ExportZip
+	if f, ok := args["format"].(string); ok && f != "" {
+		format = f
+	}
+	name, _ := args["name"].(string)
+
+	var dir string
+	if destination, ok := args["destination"].(string); ok && destination != "" {
+		validated, err := storage.ValidateOutputDir(destination, h.config.AllowedOutputDirs)
+		if err != nil {
+			return h.errorResponse("export_videos", "invalid_parameters", err.Error(), nil)
+		}
+		dir = validated
+	}
+
+	result, err := h.storage.Export(storageIDs, dir, strings.TrimSpace(name), format)
+	if err != nil {
+		return h.errorResponse("export_videos", "export_failed", err.Error(), nil)
+	}
+
+	exported := make([]string, 0, len(result.Manifest.Videos))
+	for _, video := range result.Manifest.Videos {
+		exported = append(exported, video.StorageID)
+	}
+
+	return h.successResponse(responses.BuildExportResponse(result.Format, result.Path, result.Size, exported))
+}
+
+// handleFindDuplicates reports stored videos whose video or input image
+// files are byte-identical
+func (h *ReplicateVideoHandler) handleFindDuplicates(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	filter := storage.Filter{
+		SessionID: sessionIDArg(args),
+	}
+	if project, ok := args["project"].(string); ok {
+		filter.Project = project
+	}
+	backfill := true
+	if b, ok := args["backfill"].(bool); ok {
+		backfill = b
+	}
+
+	duplicates, backfilled := h.storage.FindDuplicates(filter, backfill)
+
+	groups := make([]types.DuplicateGroup, 0, len(duplicates))
+	for _, d := range duplicates {
+		groups = append(groups, types.DuplicateGroup{
+			Kind:        d.Kind,
+			Hash:        d.Hash,
+			Size:        d.Size,
+			WastedBytes: d.Wasted(),
+			StorageIDs:  d.StorageIDs,
+		})
+	}
+
+	response := responses.BuildDuplicatesResponse(groups, backfilled, filter.Summary())
+	return h.successResponse(response)
+}
+
+// handleStorageStats reports disk usage of the storage root from the index
+func (h *ReplicateVideoHandler) handleStorageStats(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	stats := h.storage.Stats()
+
+	response := responses.BuildStorageStatsResponse(types.StorageStatsResponse{
+		RootFolder:  stats.RootFolder,
+		TotalBytes:  stats.TotalBytes,
+		VideoBytes:  stats.VideoBytes,
+		LogBytes:    stats.LogBytes,
+		ExportBytes: stats.ExportBytes,
+		VideoCount:  stats.VideoCount,
+		ByModel:     usageBuckets(stats.ByModel),
+		ByProject:   usageBuckets(stats.ByProject),
+		ByStatus:    usageBuckets(stats.ByStatus),
+		Oldest:      statsEntry(stats.Oldest),
+		Newest:      statsEntry(stats.Newest),
+	})
+	return h.successResponse(response)
+}
+
+// handleSessionSummary recaps the generations made since the server started,
+// or since a given time, for an agent closing a conversation
+func (h *ReplicateVideoHandler) handleSessionSummary(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	since := h.startedAt
+	if value, ok := args["since"].(string); ok && value != "" {
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return h.errorResponse("session_summary", "invalid_parameters", fmt.Sprintf("invalid since %q (expected an RFC 3339 time)", value), nil)
+		}
+		since = t
+	}
+	sessionID := sessionIDArg(args)
+	summary := h.generator.SessionSummary(since, sessionID)
+
+	response := types.SessionSummaryResponse{
+		Since:         summary.Since.Format(time.RFC3339),
+		SessionID:     sessionID,
+		Generations:   summary.Generations,
+		Completed:     summary.Completed,
+		Failed:        summary.Failed,
+		Pending:       summary.Pending,
+		Queued:        summary.Queued,
+		Retries:       summary.Retries,
+		EstimatedCost: summary.EstimatedCost,
+		WaitSeconds:   int(summary.WaitTime.Seconds()),
+		Videos:        sessionVideos(summary.Videos),
+		Failures:      sessionVideos(summary.Failures),
+	}
+	if finished := summary.Completed + summary.Failed; finished > 0 {
+		response.AverageWaitSeconds = response.WaitSeconds / finished
+	}
+	for _, m := range summary.Models {
+		response.Models = append(response.Models, types.SessionModel{
+			Model:         m.Name,
+			Generations:   m.Generations,
+			Completed:     m.Completed,
+			Failed:        m.Failed,
+			EstimatedCost: m.EstimatedCost,
+		})
+	}
+	return h.successResponse(responses.BuildSessionSummaryResponse(response))
+}
+
+func sessionVideos(videos []generation.SessionVideo) []types.SessionVideo {
+	entries := make([]types.SessionVideo, len(videos))
+	for i, v := range videos {
+		entries[i] = types.SessionVideo{
+			StorageID:   v.StorageID,
+			Model:       v.Model,
+			Prompt:      v.Prompt,
+			Path:        v.Path,
+			Error:       v.Error,
+			CreatedAt:   v.CreatedAt,
+			WaitSeconds: int(v.WaitTime.Seconds()),
+		}
+	}
+	return entries
+}
+
+// filterArg builds the library filter shared by search_videos and list_videos
+func filterArg(args map[string]interface{}) storage.Filter {
+	filter := storage.Filter{
+		SessionID: sessionIDArg(args),
+	}
+	if status, ok := args["status"].(string); ok {
+		filter.Status = status
+	}
+	if project, ok := args["project"].(string); ok {
+		filter.Project = project
+	}
+	if tag, ok := args["tag"].(string); ok {
+		filter.Tag = storage.NormalizeTag(tag)
+	}
+	if favorite, ok := args["favorite"].(bool); ok {
+		filter.Favorite = favorite
+	}
+	return filter
+}
+
+// sessionIDArg extracts the optional session_id accepted by every tool
+func sessionIDArg(args map[string]interface{}) string {
+	if sessionID, ok := args["session_id"].(string); ok {
+		return sessionID
+	}
+	return ""
+}
+
+// projectArg extracts and validates the optional project name
+func projectArg(args map[string]interface{}) (string, error) {
+	project, _ := args["project"].(string)
+	project = strings.TrimSpace(project)
+	if project == "" {
+		return "", nil
+	}
+	if err := storage.ValidateProjectName(project); err != nil {
+		return "", err
+	}
+	return project, nil
+}
+
+// stringSliceArg extracts an array of strings from tool arguments
+func stringSliceArg(args map[string]interface{}, key string) []string {
+	var values []string
+	if items, ok := args[key].([]interface{}); ok {
+		for _, item := range items {
+			if s, ok := item.(string); ok && s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// usageBuckets converts storage usage buckets for responses
+func usageBuckets(buckets []storage.UsageBucket) []types.UsageBucket {
+	usage := make([]types.UsageBucket, 0, len(buckets))
+	for _, b := range buckets {
+		usage = append(usage, types.UsageBucket{Name: b.Name, Count: b.Count, Bytes: b.Bytes})
+	}
+	return usage
+}
+
+// statsEntry converts a storage stats entry for responses
+func statsEntry(entry *storage.StatsEntry) *types.StatsEntry {
+	if entry == nil {
+		return nil
+	}
+	return &types.StatsEntry{
+		StorageID: entry.StorageID,
+		CreatedAt: entry.CreatedAt,
+		Model:     entry.Model,
+		Prompt:    entry.Prompt,
+		Bytes:     entry.Bytes,
+	}
+}
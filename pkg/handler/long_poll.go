@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/async"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// operationRetention is how long finished operations stay available to
+// continue_operation
+const operationRetention = 5 * time.Minute
+
+// pollTarget identifies the prediction an executor operation polls
+type pollTarget struct {
+	OperationID  string
+	PredictionID string
+	StorageID    string
+	Namespace    string
+	StartedAt    time.Time
+	Completed    bool // The operation finished and saved the video
+	Finished     bool // The prediction had already succeeded when it was created
+}
+
+// longPoll waits up to waitTime for a prediction. The prediction is polled by
+// a background executor operation, so polling (and the download once it
+// succeeds) continues after this call returns, and repeated calls for the
+// same prediction share one poll. A heartbeat is returned while the
+// prediction is still running.
+func (h *ReplicateVideoHandler) longPoll(ctx context.Context, predictionID, storageID string, waitTime time.Duration) (pollTarget, *generation.VideoResult, *types.Heartbeat, error) {
+	return h.waitPoll(ctx, h.startPoll(predictionID, storageID), waitTime)
+}
+
+// waitPoll waits up to waitTime for the operation polling target. A poll that
+// outlived the executor's lifetime is restarted under a new operation, so
+// the returned target may carry a different operation ID.
+func (h *ReplicateVideoHandler) waitPoll(ctx context.Context, target pollTarget, waitTime time.Duration) (pollTarget, *generation.VideoResult, *types.Heartbeat, error) {
+	deadline := time.Now().Add(waitTime)
+
+	op, err := h.executor.Wait(ctx, target.OperationID, waitTime)
+	if err != nil {
+		// The executor dropped the operation after its retention period;
+		// poll the prediction again
+		if ctx.Err() == nil {
+			h.forgetPoll(target)
+			return h.longPoll(ctx, target.PredictionID, target.StorageID, time.Until(deadline))
+		}
+		return target, nil, nil, err
+	}
+
+	switch op.Status {
+	case async.StatusRunning:
+		heartbeat := &types.Heartbeat{
+			PredictionStatus: getStringValue(op.ProgressData, "prediction_status"),
+			Elapsed:          int(time.Since(op.StartedAt).Seconds()),
+			Polls:            int(getIntValue(op.ProgressData, "polls")),
+			Progress:         getFloatValue(op.ProgressData, "progress"),
+		}
+		if heartbeat.PredictionStatus == "" {
+			heartbeat.PredictionStatus = types.StatusStarting
+		}
+		if !op.UpdatedAt.IsZero() {
+			heartbeat.UpdatedAt = op.UpdatedAt.Format(time.RFC3339)
+		}
+		return target, &generation.VideoResult{
+			ID:           target.StorageID,
+			PredictionID: target.PredictionID,
+			Status:       "processing",
+		}, heartbeat, nil
+
+	case async.StatusCancelled:
+		// The poll reached the executor's lifetime; keep polling unless the
+		// server is shutting down
+		if ctx.Err() == nil && h.shutdownCtx.Err() == nil {
+			h.forgetPoll(target)
+			return h.longPoll(ctx, target.PredictionID, target.StorageID, time.Until(deadline))
+		}
+	}
+
+	result, _ := op.Result["result"].(*generation.VideoResult)
+	if result == nil && op.Error == nil {
+		return target, nil, nil, fmt.Errorf("poll for prediction %s ended without a result", target.PredictionID)
+	}
+	return target, result, nil, op.Error
+}
+
+// startPoll returns the executor operation polling a prediction, starting one
+// if none is running
+func (h *ReplicateVideoHandler) startPoll(predictionID, storageID string) pollTarget {
+	h.pollMu.Lock()
+	defer h.pollMu.Unlock()
+
+	if operationID, ok := h.polls[pollKey(h.namespace, predictionID)]; ok {
+		logging.Debug("attached to running poll", "prediction_id", predictionID, "operation_id", operationID)
+		return h.pollTargets[operationID]
+	}
+	h.pruneTargets()
+
+	operationID := h.executor.Start("continue_operation", func(ctx context.Context, progress async.ProgressFunc) (res map[string]interface{}, err error) {
+		defer func() {
+			result, _ := res["result"].(*generation.VideoResult)
+			h.finishPoll(predictionID, err == nil && result != nil && result.Status == "completed")
+		}()
+
+		// Stop polling when the server shuts down
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(h.shutdownCtx, cancel)
+		defer stop()
+
+		start := time.Now()
+		var lastStatus string
+		var lastProgress float64
+		for polls := 1; ; polls++ {
+			result, err := h.generator.ContinueGeneration(ctx, predictionID, storageID, h.timeouts.HeartbeatInterval)
+			if result != nil && isPendingStatus(result.Status) && ctx.Err() == nil {
+				elapsed := time.Since(start).Round(time.Second)
+				logging.Debug("continue heartbeat", "prediction_id", predictionID, "status", result.Status, "progress", result.Progress, "elapsed", elapsed, "polls", polls)
+				progress(fmt.Sprintf("prediction %s after %s", result.Status, elapsed), map[string]interface{}{
+					"prediction_status": result.Status,
+					"progress":          result.Progress,
+					"polls":             int64(polls),
+				})
+
+				// Tell subscribers (such as the MCP client) when the status
+				// or the logged progress moves on
+				if result.Status != lastStatus || result.Progress != lastProgress {
+					h.generator.NotifyProgress(storageID, result.PredictionID, result.Status, result.Progress)
+					lastStatus, lastProgress = result.Status, result.Progress
+				}
+				continue
+			}
+			return map[string]interface{}{"result": result}, err
+		}
+	})
+
+	target := pollTarget{
+		OperationID:  operationID,
+		PredictionID: predictionID,
+		StorageID:    storageID,
+		Namespace:    h.namespace,
+		StartedAt:    time.Now(),
+	}
+	h.polls[pollKey(h.namespace, predictionID)] = operationID
+	h.pollTargets[operationID] = target
+	return target
+}
+
+// lookupPoll returns the prediction an operation ID polls, while the
+// operation is running or retained. Operations of other namespaces are not
+// found.
+func (h *ReplicateVideoHandler) lookupPoll(operationID string) (pollTarget, bool) {
+	h.pollMu.Lock()
+	defer h.pollMu.Unlock()
+	target, ok := h.pollTargets[operationID]
+	if !ok || target.Namespace != h.namespace {
+		return pollTarget{}, false
+	}
+	return target, true
+}
+
+// pollKey keys polls by namespace and prediction ID, so namespaces never
+// attach to each other's polls
+func pollKey(namespace, predictionID string) string {
+	return namespace + "\x00" + predictionID
+}
+
+// finishPoll marks a prediction's background poll as finished and wakes the
+// queue. The operation stays available by ID until it expires; if it saved
+// the video, later continues for the prediction attach to it, otherwise they
+// start a new poll.
+func (h *ReplicateVideoHandler) finishPoll(predictionID string, completed bool) {
+	h.pollMu.Lock()
+	operationID := h.polls[pollKey(h.namespace, predictionID)]
+	delete(h.polls, pollKey(h.namespace, predictionID))
+	if target, ok := h.pollTargets[operationID]; ok && completed {
+		target.Completed = true
+		h.pollTargets[operationID] = target
+	}
+	h.pollMu.Unlock()
+
+	// A slot may have freed up for a queued generation
+	h.wakeQueue()
+}
+
+// completedPoll returns the retained operation that saved a prediction's
+// video, if any. Callers hold pollMu.
+func (h *ReplicateVideoHandler) completedPoll(predictionID string) (pollTarget, bool) {
+	var found pollTarget
+	for _, target := range h.pollTargets {
+		if target.Completed && target.Namespace == h.namespace && target.PredictionID == predictionID && target.StartedAt.After(found.StartedAt) {
+			found = target
+		}
+	}
+	return found, found.OperationID != ""
+}
+
+// forgetPoll drops an operation that will not be waited on again
+func (h *ReplicateVideoHandler) forgetPoll(target pollTarget) {
+	h.pollMu.Lock()
+	if h.polls[pollKey(target.Namespace, target.PredictionID)] == target.OperationID {
+		delete(h.polls, pollKey(target.Namespace, target.PredictionID))
+	}
+	delete(h.pollTargets, target.OperationID)
+	h.pollMu.Unlock()
+}
+
+// pruneTargets drops operations past their lifetime and retention. Callers
+// hold pollMu.
+func (h *ReplicateVideoHandler) pruneTargets() {
+	expiry := h.timeouts.TotalTimeout + operationRetention
+	for operationID, target := range h.pollTargets {
+		if time.Since(target.StartedAt) > expiry && h.polls[pollKey(target.Namespace, target.PredictionID)] != operationID {
+			delete(h.pollTargets, operationID)
+		}
+	}
+}
+
+// isPendingStatus reports whether a prediction is still running
+func isPendingStatus(status string) bool {
+	return status == types.StatusStarting || status == types.StatusProcessing
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// handleGetThumbnail returns a stored video's thumbnail as inline image content
+func (h *ReplicateVideoHandler) handleGetThumbnail(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, err := h.resolveStorageID(args)
+	if err != nil {
+		return h.errorResponse("get_thumbnail", "invalid_parameters", err.Error(), nil)
+	}
+
+	metadata, err := h.storage.LoadMetadata(storageID)
+	if err != nil {
+		return h.errorResponse("get_thumbnail", "metadata_error", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	basePath := h.storage.GetStoragePath(storageID)
+	metaPaths, _ := metadata["paths"].(map[string]interface{})
+	output, _ := metaPaths["output"].(string)
+	if output == "" {
+		return h.errorResponse("get_thumbnail", "not_completed", "video has not been downloaded yet; use continue_operation first", map[string]interface{}{
+			"storage_id": storageID,
+			"status":     metadata["status"],
+		})
+	}
+	videoPath := filepath.Join(basePath, output)
+
+	// Generate the thumbnail on demand for videos completed without one
+	thumbnailPath := ""
+	if thumbnail, ok := metaPaths["thumbnail"].(string); ok && thumbnail != "" {
+		thumbnailPath = filepath.Join(basePath, thumbnail)
+	}
+	if _, err := os.Stat(thumbnailPath); thumbnailPath == "" || err != nil {
+		thumbnailPath, _ = h.storage.GenerateThumbnail(ctx, storageID, videoPath)
+		if thumbnailPath == "" {
+			return h.errorResponse("get_thumbnail", "thumbnail_unavailable", "no thumbnail available (ffmpeg is required to generate one)", map[string]interface{}{
+				"storage_id": storageID,
+			})
+		}
+		err := h.storage.UpdateMetadata(storageID, func(metadata map[string]interface{}) error {
+			metaPaths := getMapValue(metadata, "paths")
+			metaPaths["thumbnail"] = filepath.Base(thumbnailPath)
+			metadata["paths"] = metaPaths
+			return nil
+		})
+		if err != nil {
+			return h.errorResponse("get_thumbnail", "metadata_error", err.Error(), nil)
+		}
+	}
+
+	data, err := os.ReadFile(thumbnailPath)
+	if err != nil {
+		return h.errorResponse("get_thumbnail", "file_error", fmt.Sprintf("failed to read thumbnail: %v", err), nil)
+	}
+
+	paths := map[string]string{
+		"output":    videoPath,
+		"thumbnail": thumbnailPath,
+	}
+	response := responses.BuildSuccessResponse(
+		"get_thumbnail",
+		storageID,
+		paths,
+		h.fileURLs(storageID, paths),
+		map[string]string{},
+		map[string]interface{}{},
+		map[string]interface{}{
+			"thumbnail_size": len(data),
+		},
+		getStringValue(metadata, "prediction_id"),
+		nil,
+		nil,
+	)
+
+	return &protocol.CallToolResponse{
+		Content: []protocol.ToolContent{
+			{Type: "text", Text: response},
+			{Type: "image", Data: base64.StdEncoding.EncodeToString(data), MimeType: "image/jpeg"},
+		},
+	}, nil
+}
+
+// inputPath checks a file a tool call asks to read, such as image_path,
+// returning its canonical path; arg names the argument in errors
+func (h *ReplicateVideoHandler) inputPath(arg, path string) (string, error) {
+	resolved, err := h.storage.InputPath(path, h.config.AllowedInputDirs)
+	if err != nil {
+		return "", fmt.Errorf("invalid %s: %w", arg, err)
+	}
+	return resolved, nil
+}
+
+// resolveStorageID finds the storage ID from storage_id or prediction_id arguments
+func (h *ReplicateVideoHandler) resolveStorageID(args map[string]interface{}) (string, error) {
+	if storageID, ok := args["storage_id"].(string); ok && storageID != "" {
+		if err := storage.ValidateStorageID(storageID); err != nil {
+			return "", err
+		}
+		if _, err := os.Stat(filepath.Join(h.storage.GetStoragePath(storageID), "metadata.yaml")); err != nil {
+			return "", fmt.Errorf("storage ID not found: %s", storageID)
+		}
+		return storageID, nil
+	}
+
+	if predictionID, ok := args["prediction_id"].(string); ok && predictionID != "" {
+		return h.findStorageIDForPrediction(predictionID)
+	}
+
+	return "", fmt.Errorf("storage_id or prediction_id is required")
+}
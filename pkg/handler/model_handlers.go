@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/client"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// handleGetModelVersions lists an upstream model's versions and the input
+// schema of the latest one
+func (h *ReplicateVideoHandler) handleGetModelVersions(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	model, _ := args["model"].(string)
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return h.errorResponse("get_model_versions", "invalid_parameters", "model is required (alias such as wan-t2v-fast, or owner/name)", nil)
+	}
+
+	limit := 10
+	if l, ok := args["limit"].(float64); ok && l > 0 {
+		limit = int(l)
+	}
+
+	// Resolve registered aliases to their Replicate model ID
+	modelID := model
+	alias := ""
+	var pinned string
+	if config, ok := generation.GetModelConfig(model); ok {
+		modelID = config.ID
+		alias = model
+		pinned = config.Version
+	} else if a, ok := generation.FindModelAlias(model); ok {
+		alias = a
+		pinned = generation.ModelConfigs[a].Version
+	}
+	if strings.Count(modelID, "/") != 1 {
+		return h.errorResponse("get_model_versions", "invalid_parameters",
+			fmt.Sprintf("unknown model %q: use a registered alias or owner/name", model), nil)
+	}
+
+	versions, err := h.client.ListModelVersions(ctx, modelID)
+	if err != nil {
+		return h.errorResponse("get_model_versions", client.ErrorType(err, "api_error"), err.Error(), map[string]interface{}{
+			"model": modelID,
+		})
+	}
+
+	infos := make([]types.ModelVersionInfo, 0, len(versions))
+	for i, version := range versions {
+		if i >= limit {
+			break
+		}
+		infos = append(infos, types.ModelVersionInfo{
+			ID:         version.ID,
+			CreatedAt:  version.CreatedAt,
+			CogVersion: version.CogVersion,
+			Pinned:     pinned != "" && version.ID == pinned,
+		})
+	}
+
+	// Replicate lists versions newest first
+	var inputSchema map[string]interface{}
+	if len(versions) > 0 {
+		inputSchema = inputSchemaOf(versions[0].OpenAPISchema)
+	}
+
+	return h.successResponse(responses.BuildModelVersionsResponse(modelID, alias, infos, inputSchema))
+}
+
+// handleListDeployments lists the account's deployments, which
+// run_custom_video_model runs as deployment:owner/name
+func (h *ReplicateVideoHandler) handleListDeployments(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	deployments, err := h.client.ListDeployments(ctx)
+	if err != nil {
+		return h.errorResponse("list_deployments", client.ErrorType(err, "api_error"), err.Error(), nil)
+	}
+
+	infos := make([]types.DeploymentInfo, 0, len(deployments))
+	for _, deployment := range deployments {
+		release := deployment.CurrentRelease
+		infos = append(infos, types.DeploymentInfo{
+			Model:        client.DeploymentPrefix + deployment.Owner + "/" + deployment.Name,
+			RunsModel:    release.Model,
+			Version:      release.Version,
+			Release:      release.Number,
+			Hardware:     release.Configuration.Hardware,
+			MinInstances: release.Configuration.MinInstances,
+			MaxInstances: release.Configuration.MaxInstances,
+		})
+	}
+
+	return h.successResponse(responses.BuildDeploymentsResponse(infos))
+}
+
+// inputSchemaOf extracts the prediction input schema from a version's
+// OpenAPI schema
+func inputSchemaOf(openAPI map[string]interface{}) map[string]interface{} {
+	components, _ := openAPI["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	input, _ := schemas["Input"].(map[string]interface{})
+	return input
+}
+
+// handleRecommendModel ranks the registered models for the given
+// constraints using their cost and recent success rate and latency
+func (h *ReplicateVideoHandler) handleRecommendModel(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	mode, _ := args["mode"].(string)
+	if mode == "" {
+		mode = "t2v"
+	}
+	if mode != "t2v" && mode != "i2v" {
+		return h.errorResponse("recommend_model", "invalid_parameters", "mode must be t2v or i2v", nil)
+	}
+	constraints, filters, err := modelConstraints(mode, args)
+	if err != nil {
+		return h.errorResponse("recommend_model", "invalid_parameters", err.Error(), nil)
+	}
+
+	candidates, err := h.generator.RecommendModel(constraints)
+	if candidates == nil {
+		return h.errorResponse("recommend_model", "invalid_parameters", err.Error(), nil)
+	}
+	infos := make([]types.ModelRecommendation, 0, len(candidates))
+	for _, candidate := range candidates {
+		infos = append(infos, types.ModelRecommendation{
+			Model:               candidate.Model,
+			Name:                candidate.Name,
+			EstimatedCost:       candidate.Cost,
+			ExpectedWaitSeconds: int(candidate.ExpectedWait.Seconds()),
+			SuccessRate:         candidate.Health.SuccessRate,
+			RecentGenerations:   candidate.Health.Samples,
+			Excluded:            candidate.Excluded,
+		})
+	}
+	if err != nil {
+		return h.errorResponse("recommend_model", "no_matching_model", err.Error(), map[string]interface{}{
+			"candidates": infos,
+		})
+	}
+
+	return h.successResponse(responses.BuildRecommendModelResponse(candidates[0].Model, filters, infos))
+}
+
+// autoModel picks the model for a generation requested with model "auto",
+// from the max_cost, max_wait_seconds, need_audio, and resolution arguments
+func (h *ReplicateVideoHandler) autoModel(mode string, args map[string]interface{}) (string, error) {
+	constraints, _, err := modelConstraints(mode, args)
+	if err != nil {
+		return "", err
+	}
+	candidates, err := h.generator.RecommendModel(constraints)
+	if err != nil {
+		return "", fmt.Errorf("model auto: %w", err)
+	}
+	logging.Info("model auto selected", "model", candidates[0].Model, "mode", mode)
+	return candidates[0].Model, nil
+}
+
+// modelConstraints reads model selection constraints from tool arguments.
+// The second result echoes the constraints that were set.
+func modelConstraints(mode string, args map[string]interface{}) (generation.ModelConstraints, map[string]interface{}, error) {
+	constraints := generation.ModelConstraints{Mode: mode}
+	filters := map[string]interface{}{"mode": mode}
+
+	if maxCost, ok := args["max_cost"].(float64); ok {
+		if maxCost <= 0 {
+			return constraints, nil, fmt.Errorf("max_cost must be positive")
+		}
+		constraints.MaxCost = maxCost
+		filters["max_cost"] = maxCost
+	}
+	if maxWait, ok := args["max_wait_seconds"].(float64); ok {
+		if maxWait <= 0 {
+			return constraints, nil, fmt.Errorf("max_wait_seconds must be positive")
+		}
+		constraints.MaxWait = time.Duration(maxWait) * time.Second
+		filters["max_wait_seconds"] = int(maxWait)
+	}
+	if needAudio, ok := args["need_audio"].(bool); ok && needAudio {
+		constraints.NeedAudio = true
+		filters["need_audio"] = true
+	}
+	if resolution, ok := args["resolution"].(string); ok && resolution != "" {
+		constraints.Resolution = resolution
+		filters["resolution"] = resolution
+	}
+	if duration, ok := args["duration"].(float64); ok && duration > 0 {
+		constraints.Duration = int(duration)
+		filters["duration"] = int(duration)
+	}
+	return constraints, filters, nil
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// coalesceWindow is how long an identical generation request reuses the
+// prediction of the first one instead of creating (and paying for) another.
+// It covers clients that retry a call they think timed out.
+const coalesceWindow = 30 * time.Second
+
+// generationCall is a generation request that later identical requests share
+type generationCall struct {
+	done    chan struct{}
+	target  pollTarget
+	err     error
+	started time.Time
+}
+
+// startGeneration creates a prediction with create and starts the background
+// operation that polls it and saves the video. An identical request (same
+// tool and arguments) made within coalesceWindow returns the first request's
+// operation instead of creating a new prediction.
+func (h *ReplicateVideoHandler) startGeneration(ctx context.Context, tool string, args map[string]interface{}, create func() (*generation.VideoResult, error)) (pollTarget, error) {
+	key := h.namespace + "\x00" + generationKey(tool, args)
+
+	h.genMu.Lock()
+	for k, call := range h.generations {
+		if time.Since(call.started) > coalesceWindow {
+			delete(h.generations, k)
+		}
+	}
+	if call, ok := h.generations[key]; ok {
+		h.genMu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return pollTarget{}, ctx.Err()
+		}
+		if call.err == nil {
+			logging.Info("duplicate generation request coalesced", "tool", tool, "prediction_id", call.target.PredictionID, "operation_id", call.target.OperationID)
+			return call.target, nil
+		}
+		// The first request failed; this one makes its own attempt
+		return h.startGeneration(ctx, tool, args, create)
+	}
+	call := &generationCall{done: make(chan struct{}), started: time.Now()}
+	h.generations[key] = call
+	h.genMu.Unlock()
+
+	result, err := create()
+	if err == nil {
+		call.target = h.startPoll(result.PredictionID, result.ID)
+		call.target.Finished = result.Status == types.StatusSucceeded
+	} else {
+		call.err = err
+		h.genMu.Lock()
+		if h.generations[key] == call {
+			delete(h.generations, key)
+		}
+		h.genMu.Unlock()
+	}
+	close(call.done)
+	return call.target, err
+}
+
+// generationKey identifies identical generation requests. Map keys are
+// marshaled in sorted order, so equal arguments give equal keys.
+func generationKey(tool string, args map[string]interface{}) string {
+	data, err := json.Marshal(args)
+	if err != nil {
+		return tool + "\x00" + time.Now().String() // Never coalesced
+	}
+	return tool + "\x00" + string(data)
+}
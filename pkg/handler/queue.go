@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// queueDispatchInterval is how often the queue is checked for generations
+// that can start, besides whenever a generation finishes
+const queueDispatchInterval = 5 * time.Second
+
+// queueArgs are the tool arguments that control queueing rather than the
+// generation, dropped when a queued request is replayed
+var queueArgs = []string{"queue", "priority", "start_after", "schedule", "namespace", "user_id"}
+
+// priorityArg extracts and validates the optional queue priority
+func priorityArg(args map[string]interface{}) (string, error) {
+	priority, _ := args["priority"].(string)
+	if priority != "" && !generation.ValidPriority(priority) {
+		return "", fmt.Errorf("invalid priority %q (expected %s, %s, or %s)", priority, generation.PriorityHigh, generation.PriorityNormal, generation.PriorityLow)
+	}
+	return priority, nil
+}
+
+// startAfterArg extracts the optional start_after time or cron schedule. It
+// returns when a deferred request may start, zero to start now, and the
+// schedule it came from.
+func startAfterArg(args map[string]interface{}) (time.Time, string, error) {
+	startAfter, _ := args["start_after"].(string)
+	schedule, _ := args["schedule"].(string)
+	now := time.Now()
+	var start time.Time
+	var err error
+	switch {
+	case startAfter != "" && schedule != "":
+		return time.Time{}, "", fmt.Errorf("give start_after or schedule, not both")
+	case startAfter != "":
+		start, err = generation.ParseStartAfter(startAfter, now)
+	case schedule != "":
+		start, err = generation.NextScheduled(schedule, now)
+	default:
+		return time.Time{}, "", nil
+	}
+	if err != nil {
+		return time.Time{}, "", err
+	}
+	if start.Sub(now) > generation.MaxDeferral {
+		return time.Time{}, "", fmt.Errorf("start_after %s is more than %d days away", start.Format(time.RFC3339), int(generation.MaxDeferral.Hours()/24))
+	}
+	if !start.After(now) {
+		return time.Time{}, "", nil
+	}
+	return start, schedule, nil
+}
+
+// startOrQueue starts a generation like startGeneration. With queue: true
+// in args the request is queued instead when generations are already
+// waiting or a limit refuses it, and the queued job is returned. A request
+// with a future start_after or a schedule is always queued.
+func (h *ReplicateVideoHandler) startOrQueue(ctx context.Context, tool string, args map[string]interface{}, params generation.VideoParams, create func() (*generation.VideoResult, error)) (pollTarget, *types.QueuedJob, error) {
+	startAfter, schedule, err := startAfterArg(args)
+	if err != nil {
+		return pollTarget{}, nil, err
+	}
+	if queue, _ := args["queue"].(bool); !queue && startAfter.IsZero() {
+		target, err := h.startGeneration(ctx, tool, args, create)
+		return target, nil, err
+	}
+	priority, err := priorityArg(args)
+	if err != nil {
+		return pollTarget{}, nil, err
+	}
+
+	// Start right away unless deferred or others are waiting their turn
+	if startAfter.IsZero() && len(h.dueJobs()) == 0 {
+		target, err := h.startGeneration(ctx, tool, args, create)
+		var quotaErr *generation.QuotaError
+		if !errors.As(err, &quotaErr) {
+			return target, nil, err
+		}
+		logging.Debug("generation refused by quota; queueing", "tool", tool, "limit", quotaErr.Limit)
+	}
+
+	request := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		request[key] = value
+	}
+	for _, key := range queueArgs {
+		delete(request, key)
+	}
+	job, err := h.generator.Enqueue(tool, request, params, priority, startAfter, schedule)
+	if err != nil {
+		return pollTarget{}, nil, err
+	}
+	h.wakeQueue()
+
+	info := h.queuePosition(job.StorageID)
+	if info == nil {
+		// Already started by the dispatcher
+		info = &types.QueuedJob{StorageID: job.StorageID, Priority: job.Priority, Tool: tool, Model: job.Model, QueuedAt: job.QueuedAt.Format(time.RFC3339)}
+	}
+	return pollTarget{}, info, nil
+}
+
+// queuedResponse reports a queued generation request
+func (h *ReplicateVideoHandler) queuedResponse(operation string, job *types.QueuedJob) (*protocol.CallToolResponse, error) {
+	return h.successResponse(responses.BuildQueuedResponse(operation, *job))
+}
+
+// queuePosition returns a queued generation with its place in the queue, or
+// nil once it has left the queue
+func (h *ReplicateVideoHandler) queuePosition(storageID string) *types.QueuedJob {
+	for _, job := range h.queuedJobs() {
+		if job.StorageID == storageID {
+			return &job
+		}
+	}
+	return nil
+}
+
+// queuedJobs lists the queued generations for responses, in the order they
+// start
+func (h *ReplicateVideoHandler) queuedJobs() []types.QueuedJob {
+	jobs := h.generator.QueuedJobs()
+	infos := make([]types.QueuedJob, len(jobs))
+	for i, job := range jobs {
+		infos[i] = types.QueuedJob{
+			Position:  i + 1,
+			StorageID: job.StorageID,
+			Priority:  job.Priority,
+			Tool:      job.Tool,
+			Model:     job.Model,
+			Prompt:    job.Prompt,
+			QueuedAt:  job.QueuedAt.Format(time.RFC3339),
+			Schedule:  job.Schedule,
+		}
+		if !job.StartAfter.IsZero() {
+			infos[i].StartAfter = job.StartAfter.Format(time.RFC3339)
+		}
+	}
+	return infos
+}
+
+// dueJobs returns the queued generations that may start now, in order
+func (h *ReplicateVideoHandler) dueJobs() []generation.QueuedJob {
+	now := time.Now()
+	var due []generation.QueuedJob
+	for _, job := range h.generator.QueuedJobs() {
+		if job.Due(now) {
+			due = append(due, job)
+		}
+	}
+	return due
+}
+
+// handleQueueStatus lists the queued generations in the order they start
+func (h *ReplicateVideoHandler) handleQueueStatus(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	usage := h.generator.QuotaUsage()
+	return h.successResponse(responses.BuildQueueStatusResponse("queue_status", h.queuedJobs(), usage.Running, h.config.Limits.MaxConcurrent))
+}
+
+// handleReorderQueue changes a queued generation's priority or moves it to
+// the front or back of its priority, then lists the queue
+func (h *ReplicateVideoHandler) handleReorderQueue(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	storageID, _ := args["storage_id"].(string)
+	if storageID == "" {
+		return h.errorResponse("reorder_queue", "invalid_parameters", "storage_id is required", nil)
+	}
+	priority, err := priorityArg(args)
+	if err != nil {
+		return h.errorResponse("reorder_queue", "invalid_parameters", err.Error(), nil)
+	}
+	position, _ := args["position"].(string)
+	if priority == "" && position == "" {
+		return h.errorResponse("reorder_queue", "invalid_parameters", "give a priority, a position, or both", nil)
+	}
+	if _, ok := h.generator.QueuedJob(storageID); !ok {
+		return h.errorResponse("reorder_queue", "not_found", fmt.Sprintf("storage ID %s is not queued", storageID), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+
+	if err := h.generator.Reorder(storageID, priority, position); err != nil {
+		return h.errorResponse("reorder_queue", "invalid_parameters", err.Error(), map[string]interface{}{
+			"storage_id": storageID,
+		})
+	}
+	h.wakeQueue()
+
+	usage := h.generator.QuotaUsage()
+	return h.successResponse(responses.BuildQueueStatusResponse("reorder_queue", h.queuedJobs(), usage.Running, h.config.Limits.MaxConcurrent))
+}
+
+// wakeQueue asks the dispatcher to check the queue now
+func (h *ReplicateVideoHandler) wakeQueue() {
+	select {
+	case h.queueWake <- struct{}{}:
+	default:
+	}
+}
+
+// runQueue starts queued generations as slots free up, until the server
+// shuts down. Queued generations are kept in storage, so those left when
+// the server stopped start after it restarts.
+func (h *ReplicateVideoHandler) runQueue() {
+	ticker := time.NewTicker(queueDispatchInterval)
+	defer ticker.Stop()
+	for {
+		h.dispatchQueue()
+		select {
+		case <-h.shutdownCtx.Done():
+			return
+		case <-ticker.C:
+		case <-h.queueWake:
+		}
+	}
+}
+
+// dispatchQueue starts queued generations that are due in order until a
+// limit refuses one, which stays first in line
+func (h *ReplicateVideoHandler) dispatchQueue() {
+	for _, job := range h.dueJobs() {
+		if h.shutdownCtx.Err() != nil {
+			return
+		}
+		var quotaErr *generation.QuotaError
+		if err := h.startQueued(job); errors.As(err, &quotaErr) {
+			return
+		}
+	}
+}
+
+// startQueued starts a queued generation under its storage ID. The storage
+// lock is held throughout, so another process sharing the storage can't
+// start it too. A request that fails for any reason but a limit is marked
+// failed and leaves the queue.
+func (h *ReplicateVideoHandler) startQueued(job generation.QueuedJob) error {
+	unlock := h.storage.Lock(job.StorageID)
+	defer unlock()
+	metadata, err := h.storage.LoadMetadata(job.StorageID)
+	if err != nil || metadata["status"] != generation.StatusQueued {
+		return nil
+	}
+
+	// The arguments are checked again, since files may have gone and the
+	// configuration changed since the request was queued
+	ctx := h.shutdownCtx
+	var params generation.VideoParams
+	switch job.Tool {
+	case "generate_video_from_text":
+		params, err = h.extractTextToVideoParams(job.Args)
+	case "generate_video_from_image":
+		params, err = h.extractImageToVideoParams(job.Args)
+	default:
+		err = fmt.Errorf("tool %s cannot be queued", job.Tool)
+	}
+	var target pollTarget
+	if err == nil {
+		params.StorageID = job.StorageID
+		args := make(map[string]interface{}, len(job.Args)+1)
+		for key, value := range job.Args {
+			args[key] = value
+		}
+		args["storage_id"] = job.StorageID // Never coalesced with another request
+		target, err = h.startGeneration(ctx, job.Tool, args, func() (*generation.VideoResult, error) {
+			if job.Tool == "generate_video_from_text" {
+				return h.generator.GenerateTextToVideo(ctx, params)
+			}
+			return h.generator.GenerateImageToVideo(ctx, params)
+		})
+	}
+
+	var quotaErr *generation.QuotaError
+	switch {
+	case errors.As(err, &quotaErr):
+		logging.Debug("queued generation waiting for a slot", "storage_id", job.StorageID, "limit", quotaErr.Limit)
+		return err
+	case err != nil:
+		logging.Warn("queued generation failed to start", "storage_id", job.StorageID, "tool", job.Tool, "error", err)
+		metadata["status"] = types.StatusFailed
+		metadata["error"] = err.Error()
+		metadata["completed_at"] = time.Now().Format(time.RFC3339)
+		if err := h.storage.SaveMetadata(job.StorageID, metadata); err != nil {
+			logging.Warn("failed to update metadata", "storage_id", job.StorageID, "error", err)
+		}
+		return err
+	}
+
+	// Keep when and how the generation waited
+	if started, err := h.storage.LoadMetadata(job.StorageID); err == nil {
+		started["queued_at"] = job.QueuedAt.Format(time.RFC3339)
+		started["queue_priority"] = job.Priority
+		if !job.StartAfter.IsZero() {
+			started["start_after"] = job.StartAfter.Format(time.RFC3339)
+		}
+		if job.Schedule != "" {
+			started["schedule"] = job.Schedule
+		}
+		if err := h.storage.SaveMetadata(job.StorageID, started); err != nil {
+			logging.Warn("failed to update metadata", "storage_id", job.StorageID, "error", err)
+		}
+	}
+	logging.Info("queued generation started", "storage_id", job.StorageID, "prediction_id", target.PredictionID, "waited", time.Since(job.QueuedAt).Round(time.Second))
+	return nil
+}
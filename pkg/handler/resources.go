@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+)
+
+// resourceScheme prefixes the URIs of stored videos and thumbnails:
+// replicate-video://<storage_id>/video and replicate-video://<storage_id>/thumbnail
+const resourceScheme = "replicate-video://"
+
+// maxResourceSize caps the files returned by resources/read, since contents
+// are sent base64-encoded in a single message
+const maxResourceSize = 50 << 20
+
+// resourceMimeTypes maps stored file extensions to MIME types
+var resourceMimeTypes = map[string]string{
+	".mp4":  "video/mp4",
+	".m4v":  "video/mp4",
+	".mov":  "video/quicktime",
+	".webm": "video/webm",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+}
+
+// ListResources lists completed videos and their thumbnails as MCP resources,
+// newest first
+func (h *ReplicateVideoHandler) ListResources(ctx context.Context) (*protocol.ListResourcesResponse, error) {
+	resources := []protocol.Resource{}
+	for _, record := range h.storage.ListRecords(storage.Filter{Status: "completed"}) {
+		paths := record.Paths()
+		name := record.Parameter("prompt")
+		if name == "" {
+			name = record.StorageID
+		}
+		description := record.ModelName()
+		if created := record.String("created_at"); created != "" {
+			description += ", " + created
+		}
+
+		for _, kind := range []string{"video", "thumbnail"} {
+			file := paths[resourcePathKey(kind)]
+			if file == "" {
+				continue
+			}
+			resources = append(resources, protocol.Resource{
+				URI:         resourceScheme + record.StorageID + "/" + kind,
+				Name:        fmt.Sprintf("%s (%s)", name, kind),
+				Description: description,
+				MimeType:    resourceMimeType(file),
+			})
+		}
+	}
+
+	return &protocol.ListResourcesResponse{Resources: resources}, nil
+}
+
+// ReadResource returns the bytes of a stored video or thumbnail
+func (h *ReplicateVideoHandler) ReadResource(ctx context.Context, req *protocol.ReadResourceRequest) (*protocol.ReadResourceResponse, error) {
+	storageID, kind, err := parseResourceURI(req.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := h.storage.LoadMetadata(storageID)
+	if err != nil {
+		return nil, fmt.Errorf("resource not found: %s", req.URI)
+	}
+	record := storage.Record{StorageID: storageID, Metadata: metadata}
+	file := record.Paths()[resourcePathKey(kind)]
+	if file == "" {
+		return nil, fmt.Errorf("resource not available: %s", req.URI)
+	}
+	path := filepath.Join(h.storage.GetStoragePath(storageID), file)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", req.URI, err)
+	}
+	if info.Size() > maxResourceSize {
+		return nil, fmt.Errorf("%s is too large to return as a resource (%d MB, limit %d MB); open %s instead",
+			req.URI, info.Size()>>20, maxResourceSize>>20, path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", req.URI, err)
+	}
+
+	return &protocol.ReadResourceResponse{
+		Contents: []protocol.ResourceContent{
+			{
+				URI:      req.URI,
+				MimeType: resourceMimeType(file),
+				Blob:     base64.StdEncoding.EncodeToString(data),
+			},
+		},
+	}, nil
+}
+
+// parseResourceURI splits a resource URI into its storage ID and kind
+func parseResourceURI(uri string) (string, string, error) {
+	rest, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok {
+		return "", "", fmt.Errorf("unknown resource URI: %s", uri)
+	}
+	storageID, kind, ok := strings.Cut(rest, "/")
+	if !ok || storageID == "" || storageID != filepath.Base(storageID) || storageID == "." || storageID == ".." || (kind != "video" && kind != "thumbnail") {
+		return "", "", fmt.Errorf("invalid resource URI %s (expected %s<storage_id>/video or /thumbnail)", uri, resourceScheme)
+	}
+	return storageID, kind, nil
+}
+
+// resourcePathKey maps a resource kind to its key in metadata paths
+func resourcePathKey(kind string) string {
+	if kind == "video" {
+		return "output"
+	}
+	return kind
+}
+
+func resourceMimeType(file string) string {
+	if mimeType, ok := resourceMimeTypes[strings.ToLower(filepath.Ext(file))]; ok {
+		return mimeType
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"sort"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// handleServerCapabilities reports the server version, optional subsystems,
+// registered models, and available tools
+func (h *ReplicateVideoHandler) handleServerCapabilities(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	server := map[string]string{
+		"name":    "replicate-video-ai",
+		"version": config.Version,
+	}
+	if h.config.Profile != "" {
+		server["profile"] = h.config.Profile
+	}
+	server["locale"] = responses.Locale()
+
+	notificationEvents := h.notifier.Events()
+	subsystems := map[string]interface{}{
+		"notifications":       len(notificationEvents) > 0,
+		"notification_events": notificationEvents,
+		"mock_client":         h.config.Mock.Enabled,
+		"ffmpeg":              storage.HasFFmpeg(),
+		"ffprobe":             storage.HasFFprobe(),
+		"file_logging":        h.config.Logging.File != "",
+		"audit_log":           h.audit != nil,
+		"file_server":         h.files != nil,
+		"prompt_moderation":   h.config.Moderation.Enabled(),
+		"quotas":              h.config.Limits.Enabled(),
+		"namespace":           h.namespace,
+		"http_transport":      false,
+		"object_storage":      false,
+	}
+	if h.config.Limits.Enabled() {
+		usage := h.generator.QuotaUsage()
+		subsystems["quota_usage"] = map[string]interface{}{
+			"running":         usage.Running,
+			"last_hour":       usage.LastHour,
+			"spent_today":     usage.SpentToday,
+			"max_concurrent":  h.config.Limits.MaxConcurrent,
+			"max_per_hour":    h.config.Limits.MaxPerHour,
+			"max_daily_spend": h.config.Limits.MaxDailySpend,
+		}
+	}
+	if h.config.HTTP.Cassette != "" && !h.config.Mock.Enabled {
+		subsystems["api_cassette"] = h.config.HTTP.CassetteMode
+	}
+
+	health := h.generator.ModelHealth()
+	models := make([]types.ModelInfo, 0, len(generation.ModelConfigs))
+	for alias, model := range generation.ModelConfigs {
+		models = append(models, types.ModelInfo{
+			Alias:             alias,
+			ID:                model.ID,
+			Name:              model.Name,
+			Version:           model.Version,
+			Type:              model.Type,
+			DefaultResolution: model.DefaultRes,
+			MaxDuration:       model.MaxDuration,
+			Features:          model.Features,
+			Effects:           model.EffectNames(),
+			EstimatedCost:     model.Cost,
+			CostPerSecond:     model.CostPerSecond,
+			RecentGenerations: health[alias].Samples,
+			SuccessRate:       health[alias].SuccessRate,
+			MedianWaitSeconds: int(health[alias].MedianWait.Seconds()),
+		})
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Alias < models[j].Alias })
+
+	var tools []string
+	if list, err := h.ListTools(ctx); err == nil {
+		for _, tool := range list.Tools {
+			tools = append(tools, tool.Name)
+		}
+	}
+
+	return h.successResponse(responses.BuildCapabilitiesResponse(server, subsystems, models, tools))
+}
+
+// handleCheckAccount verifies the API token and reports billing problems
+// without starting a generation
+func (h *ReplicateVideoHandler) handleCheckAccount(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	status, err := h.client.CheckAccount(ctx)
+	if err != nil {
+		return h.errorResponse("check_account", "api_error", err.Error(), nil)
+	}
+
+	return h.successResponse(responses.BuildAccountResponse(*status))
+}
+
+// handleHealthCheck runs the self-test and reports what works
+func (h *ReplicateVideoHandler) handleHealthCheck(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	return h.successResponse(responses.BuildHealthCheckResponse(h.generator.HealthCheck(ctx)))
+}
+
+// handleGetResponseSchema returns JSON Schemas of the response types
+func (h *ReplicateVideoHandler) handleGetResponseSchema(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	names := stringSliceArg(args, "types")
+	if len(names) == 0 {
+		names = responses.DefaultSchemas
+	}
+
+	schemas := make(map[string]interface{}, len(names))
+	for _, name := range names {
+		schema, err := responses.ResponseSchema(name)
+		if err != nil {
+			return h.errorResponse("get_response_schema", "invalid_parameters", err.Error(), nil)
+		}
+		schemas[name] = schema
+	}
+	return h.successResponse(responses.BuildResponseSchemaResponse(schemas))
+}
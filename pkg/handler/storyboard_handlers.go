@@ -0,0 +1,491 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+const (
+	// maxStoryboardScenes limits how many scenes one storyboard may have
+	maxStoryboardScenes = 12
+
+	// defaultCrossfade is the crossfade between joined scenes, in seconds
+	defaultCrossfade = 0.5
+
+	// storyboardRetention is how long a storyboard's in-memory state is
+	// kept; afterwards its status is read from the storage index
+	storyboardRetention = 24 * time.Hour
+)
+
+// sceneParameters are the per-scene arguments that override the storyboard's
+var sceneParameters = []string{"prompt", "model", "duration", "image_path", "negative_prompt", "camera_motion", "seed"}
+
+// storyboardScene is one scene of a storyboard, started like a variation
+type storyboardScene struct {
+	*variation
+	tool    string
+	chained bool // Generated from the last frame of the previous scene
+}
+
+// storyboard tracks the scenes of one generate_storyboard call and the
+// video assembled from them
+type storyboard struct {
+	id          string
+	created     time.Time
+	scenes      []*storyboardScene
+	concatenate bool
+	chain       bool
+	crossfade   float64
+	options     storage.ConcatOptions
+
+	started   sync.WaitGroup // Done once every scene was started or failed to
+	assembled chan struct{}  // Closed once joining finished or was given up
+
+	mu    sync.Mutex
+	video types.StoryboardVideo
+}
+
+// handleGenerateStoryboard generates an ordered list of scenes and, once
+// every scene completed, joins them into one video with crossfades. In chain
+// mode each scene waits for the previous one and animates its last frame.
+// Called with only a storyboard_id, it reports that storyboard's progress
+// instead.
+func (h *ReplicateVideoHandler) handleGenerateStoryboard(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	waitTime := waitTimeArg(args)
+
+	if storyboardID, ok := args["storyboard_id"].(string); ok && storyboardID != "" {
+		if _, ok := args["scenes"]; !ok {
+			return h.storyboardStatus(ctx, storyboardID, args, waitTime)
+		}
+	}
+
+	items, ok := args["scenes"].([]interface{})
+	if !ok || len(items) < 2 || len(items) > maxStoryboardScenes {
+		return h.errorResponse("generate_storyboard", "invalid_parameters",
+			fmt.Sprintf("scenes must list 2 to %d scenes", maxStoryboardScenes), nil)
+	}
+
+	concatenate := storage.HasFFmpeg()
+	if c, ok := args["concatenate"].(bool); ok {
+		concatenate = c
+	}
+	if concatenate && !storage.HasFFmpeg() {
+		return h.errorResponse("generate_storyboard", "ffmpeg_unavailable", "ffmpeg is required to join the scenes; set concatenate to false to only generate them", nil)
+	}
+	chain, _ := args["chain"].(bool)
+	if chain && !storage.HasFFmpeg() {
+		return h.errorResponse("generate_storyboard", "ffmpeg_unavailable", "ffmpeg is required to take the last frame of each scene in chain mode", nil)
+	}
+	crossfade := defaultCrossfade
+	if c, ok := args["crossfade"].(float64); ok {
+		crossfade = c
+	}
+	if crossfade < 0 || crossfade > 2 {
+		return h.errorResponse("generate_storyboard", "invalid_parameters", "crossfade must be between 0 and 2 seconds", nil)
+	}
+
+	sb := &storyboard{
+		id:          "sb-" + h.storage.GenerateStorageID(),
+		created:     time.Now(),
+		concatenate: concatenate,
+		chain:       chain,
+		crossfade:   crossfade,
+		assembled:   make(chan struct{}),
+	}
+	sb.video = types.StoryboardVideo{Status: "waiting", Crossfade: crossfade}
+	sb.options = storage.ConcatOptions{Crossfade: crossfade, StoryboardID: sb.id}
+	sb.options.SessionID, _ = args["session_id"].(string)
+	sb.options.Project, _ = args["project"].(string)
+
+	// Validate every scene before starting any paid prediction
+	var prompts []string
+	for i, item := range items {
+		sceneArgs, err := storyboardSceneArgs(args, item)
+		if err != nil {
+			return h.errorResponse("generate_storyboard", "invalid_parameters", fmt.Sprintf("scene %d: %s", i+1, err), nil)
+		}
+		sceneArgs["storyboard_id"] = sb.id // Never coalesced with another storyboard
+		sceneArgs["storyboard_scene"] = float64(i + 1)
+
+		// Chained scenes animate the previous scene's last frame, which is
+		// stood in for until it exists; scenes with their own image keep it
+		chained := false
+		if imagePath, _ := sceneArgs["image_path"].(string); chain && i > 0 && imagePath == "" {
+			chained = true
+			sceneArgs["image_path"] = fmt.Sprintf("last frame of scene %d", i)
+			if scene, _ := item.(map[string]interface{}); scene["model"] == nil {
+				if model, _ := sceneArgs["model"].(string); model != generation.AutoModel && !generation.IsImageToVideoModel(model) {
+					delete(sceneArgs, "model") // The storyboard's model can't animate an image; use the default
+				}
+			}
+		}
+
+		tool := "generate_video_from_text"
+		var params generation.VideoParams
+		if imagePath, _ := sceneArgs["image_path"].(string); imagePath != "" {
+			tool = "generate_video_from_image"
+			params, err = h.extractImageToVideoParams(sceneArgs)
+		} else {
+			params, err = h.extractTextToVideoParams(sceneArgs)
+		}
+		if err != nil {
+			return h.errorResponse("generate_storyboard", "invalid_parameters", fmt.Sprintf("scene %d: %s", i+1, err), nil)
+		}
+		params.StoryboardID = sb.id
+		params.Scene = i + 1
+		prompts = append(prompts, params.Prompt)
+		status := "queued"
+		if chain && i > 0 {
+			status = "waiting"
+		}
+		sb.scenes = append(sb.scenes, &storyboardScene{
+			variation: &variation{args: sceneArgs, params: params, status: status},
+			tool:      tool,
+			chained:   chained,
+		})
+	}
+	sb.options.Description = strings.Join(prompts, " / ")
+
+	h.sbMu.Lock()
+	for key, old := range h.storyboards {
+		if time.Since(old.created) > storyboardRetention {
+			delete(h.storyboards, key)
+		}
+	}
+	h.storyboards[pollKey(h.namespace, sb.id)] = sb
+	h.sbMu.Unlock()
+
+	// Start the scenes in the background like variations, queueing behind
+	// the concurrency limit; respond once each was tried once, or in chain
+	// mode once the first scene was
+	var attempted sync.WaitGroup
+	sem := make(chan struct{}, maxVariationStarts)
+	if sb.chain {
+		attempted.Add(1)
+		sb.started.Add(1)
+		go func() {
+			defer sb.started.Done()
+			h.runChain(sb, sem, &attempted)
+		}()
+	} else {
+		for _, scene := range sb.scenes {
+			attempted.Add(1)
+			sb.started.Add(1)
+			go func(scene *storyboardScene) {
+				defer sb.started.Done()
+				h.runVariation(scene.tool, scene.variation, sem, &attempted)
+			}(scene)
+		}
+	}
+	attempted.Wait()
+
+	if sb.concatenate {
+		go h.assembleStoryboard(sb)
+	} else {
+		close(sb.assembled)
+	}
+
+	logging.Info("storyboard started", "storyboard_id", sb.id, "scenes", len(sb.scenes), "chain", sb.chain, "concatenate", sb.concatenate)
+	return h.successResponse(h.storyboardResponse(ctx, sb, waitTime))
+}
+
+// storyboardSceneArgs builds a scene's generation arguments from the
+// storyboard's shared arguments and the scene, given as a prompt string or
+// an object with a prompt and optional overrides
+func storyboardSceneArgs(args map[string]interface{}, item interface{}) (map[string]interface{}, error) {
+	sceneArgs := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		switch key {
+		case "scenes", "concatenate", "crossfade", "wait_time", "storyboard_id":
+			continue
+		}
+		sceneArgs[key] = value
+	}
+
+	switch scene := item.(type) {
+	case string:
+		sceneArgs["prompt"] = scene
+	case map[string]interface{}:
+		for key, value := range scene {
+			if !slices.Contains(sceneParameters, key) {
+				return nil, fmt.Errorf("unknown scene parameter %q (use %s)", key, strings.Join(sceneParameters, ", "))
+			}
+			sceneArgs[key] = value
+		}
+	default:
+		return nil, fmt.Errorf("must be a prompt or an object with a prompt")
+	}
+	if prompt, _ := sceneArgs["prompt"].(string); strings.TrimSpace(prompt) == "" {
+		return nil, fmt.Errorf("prompt is required")
+	}
+	return sceneArgs, nil
+}
+
+// runChain starts the scenes one after another, each once the previous one
+// completed, giving chained scenes the previous scene's last frame.
+// attempted is marked done once the first scene was tried. When a scene
+// fails, the scenes after it are not generated.
+func (h *ReplicateVideoHandler) runChain(sb *storyboard, sem chan struct{}, attempted *sync.WaitGroup) {
+	ctx := h.shutdownCtx
+	for i, scene := range sb.scenes {
+		if i == 0 {
+			h.runVariation(scene.tool, scene.variation, sem, attempted)
+			continue
+		}
+
+		previous := sb.scenes[i-1]
+		storageID, err := h.waitForScene(ctx, previous.variation)
+		if err == nil && scene.chained {
+			var frame string
+			if frame, err = h.storage.ExtractLastFrame(ctx, storageID); err == nil {
+				scene.args["image_path"] = frame
+				scene.params.ImagePath = frame
+			}
+		}
+		if err != nil {
+			err = fmt.Errorf("scene %d did not complete, so the chain stopped: %w", i, err)
+			for _, rest := range sb.scenes[i:] {
+				rest.mu.Lock()
+				rest.status, rest.err = "error", err
+				rest.mu.Unlock()
+			}
+			logging.Warn("storyboard chain stopped", "storyboard_id", sb.id, "scene", i+1, "error", err)
+			return
+		}
+
+		scene.mu.Lock()
+		scene.status = "queued"
+		scene.mu.Unlock()
+		var done sync.WaitGroup
+		done.Add(1)
+		h.runVariation(scene.tool, scene.variation, sem, &done)
+	}
+}
+
+// waitForScene waits for a started scene to finish and returns its storage
+// ID, or why it didn't complete
+func (h *ReplicateVideoHandler) waitForScene(ctx context.Context, v *variation) (string, error) {
+	v.mu.Lock()
+	status, target, err := v.status, v.target, v.err
+	v.mu.Unlock()
+	switch {
+	case status != "started" && err != nil:
+		return "", err
+	case status != "started":
+		return "", fmt.Errorf("scene was not started")
+	}
+
+	poll := types.PredictionStatus{Status: types.StatusStarting}
+	for isPendingStatus(poll.Status) {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("server shut down")
+		}
+		poll = h.pollPrediction(ctx, target.PredictionID, generation.MaxContinueWait())
+	}
+	if poll.Status != "completed" {
+		if poll.Error != "" {
+			return "", fmt.Errorf("%s: %s", poll.Status, poll.Error)
+		}
+		return "", fmt.Errorf("%s", poll.Status)
+	}
+	return target.StorageID, nil
+}
+
+// assembleStoryboard waits for every scene to finish and joins the
+// completed scenes into one video. It runs in the background and stops when
+// the server shuts down.
+func (h *ReplicateVideoHandler) assembleStoryboard(sb *storyboard) {
+	defer close(sb.assembled)
+	sb.started.Wait()
+
+	storageIDs := make([]string, len(sb.scenes))
+	for i, scene := range sb.scenes {
+		storageID, err := h.waitForScene(h.shutdownCtx, scene.variation)
+		if err != nil {
+			sb.setVideo(types.StoryboardVideo{Status: "failed", Error: fmt.Sprintf("scene %d did not complete: %v", i+1, err)})
+			return
+		}
+		storageIDs[i] = storageID
+	}
+
+	sb.setVideo(types.StoryboardVideo{Status: "assembling"})
+	h.joinScenes(h.shutdownCtx, sb.id, storageIDs, sb.options, sb.setVideo)
+}
+
+// joinScenes joins completed scenes in order and reports the result to set
+func (h *ReplicateVideoHandler) joinScenes(ctx context.Context, storyboardID string, storageIDs []string, opts storage.ConcatOptions, set func(types.StoryboardVideo)) {
+	storageID, _, err := h.storage.ConcatVideos(ctx, storageIDs, opts)
+	if err != nil {
+		logging.Warn("failed to join storyboard scenes", "storyboard_id", storyboardID, "error", err)
+		set(types.StoryboardVideo{Status: "failed", Error: err.Error()})
+		return
+	}
+	logging.Info("storyboard assembled", "storyboard_id", storyboardID, "storage_id", storageID)
+	set(types.StoryboardVideo{Status: "completed", StorageID: storageID})
+}
+
+// setVideo records the state of the assembled video
+func (sb *storyboard) setVideo(video types.StoryboardVideo) {
+	video.Crossfade = sb.crossfade
+	sb.mu.Lock()
+	sb.video = video
+	sb.mu.Unlock()
+}
+
+// storyboardResponse reports a storyboard's scenes, waiting up to waitTime
+// for running scenes and then for the assembled video
+func (h *ReplicateVideoHandler) storyboardResponse(ctx context.Context, sb *storyboard, waitTime time.Duration) string {
+	deadline := time.Now().Add(waitTime)
+	variations := make([]*variation, len(sb.scenes))
+	for i, scene := range sb.scenes {
+		variations[i] = scene.variation
+	}
+	scenes := h.storyboardScenes(ctx, variations, waitTime)
+
+	if !sb.concatenate {
+		return responses.BuildStoryboardResponse(sb.id, scenes, nil)
+	}
+	if remaining := time.Until(deadline); waitTime > 0 && remaining > 0 {
+		timer := time.NewTimer(remaining)
+		select {
+		case <-sb.assembled:
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		timer.Stop()
+	}
+
+	sb.mu.Lock()
+	video := sb.video
+	sb.mu.Unlock()
+	h.videoPaths(&video)
+	return responses.BuildStoryboardResponse(sb.id, scenes, &video)
+}
+
+// storyboardScenes summarizes every scene, waiting up to waitTime for
+// started ones that are still running
+func (h *ReplicateVideoHandler) storyboardScenes(ctx context.Context, variations []*variation, waitTime time.Duration) []types.StoryboardScene {
+	entries := h.variationEntries(ctx, &variationSet{variations: variations}, waitTime)
+	scenes := make([]types.StoryboardScene, len(entries))
+	for i, entry := range entries {
+		scenes[i] = types.StoryboardScene{
+			Scene:        i + 1,
+			Prompt:       entry.Prompt,
+			Model:        variations[i].params.Model,
+			Status:       entry.Status,
+			StorageID:    entry.StorageID,
+			PredictionID: entry.PredictionID,
+			Paths:        entry.Paths,
+			URLs:         entry.URLs,
+			Error:        entry.Error,
+			ErrorType:    entry.ErrorType,
+		}
+	}
+	return scenes
+}
+
+// videoPaths fills in the paths and URLs of an assembled video
+func (h *ReplicateVideoHandler) videoPaths(video *types.StoryboardVideo) {
+	if video.Status != "completed" || video.StorageID == "" {
+		return
+	}
+	metadata, err := h.storage.LoadMetadata(video.StorageID)
+	if err != nil {
+		return
+	}
+	video.Paths = make(map[string]string)
+	for name, rel := range (storage.Record{StorageID: video.StorageID, Metadata: metadata}).Paths() {
+		video.Paths[name] = filepath.Join(h.storage.GetStoragePath(video.StorageID), rel)
+	}
+	video.URLs = h.fileURLs(video.StorageID, video.Paths)
+}
+
+// storyboardStatus reports an earlier storyboard. When its in-memory state
+// is gone (e.g. after a restart) the scenes are read from the storage index,
+// and with concatenate set, completed scenes that were never joined are
+// joined now.
+func (h *ReplicateVideoHandler) storyboardStatus(ctx context.Context, storyboardID string, args map[string]interface{}, waitTime time.Duration) (*protocol.CallToolResponse, error) {
+	h.sbMu.Lock()
+	sb := h.storyboards[pollKey(h.namespace, storyboardID)]
+	h.sbMu.Unlock()
+	if sb != nil {
+		return h.successResponse(h.storyboardResponse(ctx, sb, waitTime))
+	}
+
+	records := h.storage.ListRecords(storage.Filter{Storyboard: storyboardID})
+	var variations []*variation
+	var video *types.StoryboardVideo
+	var sceneRecords []storage.Record
+	for _, record := range records {
+		if record.String("operation") == "concatenate" {
+			if video == nil { // Newest first
+				video = &types.StoryboardVideo{Status: "completed", StorageID: record.StorageID}
+				if params, ok := record.Metadata["parameters"].(map[string]interface{}); ok {
+					video.Crossfade, _ = params["crossfade"].(float64)
+				}
+			}
+			continue
+		}
+		sceneRecords = append(sceneRecords, record)
+	}
+	if len(sceneRecords) == 0 {
+		return h.errorResponse("generate_storyboard", "not_found", fmt.Sprintf("no scenes found for storyboard %s", storyboardID), nil)
+	}
+	sort.Slice(sceneRecords, func(i, j int) bool {
+		return getIntValue(sceneRecords[i].Metadata, "storyboard_scene") < getIntValue(sceneRecords[j].Metadata, "storyboard_scene")
+	})
+	for _, record := range sceneRecords {
+		v := &variation{status: "started", target: pollTarget{
+			PredictionID: record.String("prediction_id"),
+			StorageID:    record.StorageID,
+		}}
+		v.params.Prompt = record.Parameter("prompt")
+		v.params.Model, _, _ = h.generator.ModelForStorage(record.StorageID)
+		variations = append(variations, v)
+	}
+	scenes := h.storyboardScenes(ctx, variations, waitTime)
+
+	if concatenate, _ := args["concatenate"].(bool); concatenate && video == nil {
+		crossfade := defaultCrossfade
+		if c, ok := args["crossfade"].(float64); ok {
+			crossfade = c
+		}
+		video = &types.StoryboardVideo{Status: "waiting", Crossfade: crossfade}
+		storageIDs := make([]string, 0, len(scenes))
+		for _, scene := range scenes {
+			if scene.Status == "completed" {
+				storageIDs = append(storageIDs, scene.StorageID)
+			}
+		}
+		if len(storageIDs) == len(scenes) {
+			opts := storage.ConcatOptions{
+				Crossfade:    crossfade,
+				StoryboardID: storyboardID,
+				Description:  sceneRecords[0].Parameter("prompt"),
+				SessionID:    sceneRecords[0].String("session_id"),
+				Project:      sceneRecords[0].String("project"),
+			}
+			h.joinScenes(ctx, storyboardID, storageIDs, opts, func(v types.StoryboardVideo) {
+				v.Crossfade = crossfade
+				*video = v
+			})
+		}
+	}
+	if video != nil {
+		h.videoPaths(video)
+	}
+	return h.successResponse(responses.BuildStoryboardResponse(storyboardID, scenes, video))
+}
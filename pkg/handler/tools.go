@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 
 	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
 )
 
 // ListTools returns the available MCP tools
@@ -12,7 +13,7 @@ func (h *ReplicateVideoHandler) ListTools(ctx context.Context) (*protocol.ListTo
 	tools := []protocol.Tool{
 		{
 			Name:        "generate_video_from_text",
-			Description: "Generate a video from a text prompt. Models: wan-t2v-fast (default, fast/cheap), veo3 (premium with audio), kling-master (high quality, supports 5/10s duration)",
+			Description: "Generate a video from a text prompt. Models: wan-t2v-fast (default, fast/cheap), veo3 (premium with audio), kling-master (high quality, supports 5/10s duration), ltx (fast, tunable guidance/steps), hunyuan (high quality, slow), hailuo (accepts a style_image_path subject reference), veo3.1 (premium with audio), veo3-fast (cheaper Veo 3 with audio), veo2 (no audio, 5-8s duration), pixverse (affordable, effect templates)",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -22,12 +23,16 @@ func (h *ReplicateVideoHandler) ListTools(ctx context.Context) (*protocol.ListTo
 					},
 					"model": {
 						"type": "string",
-						"description": "Model to use: wan-t2v-fast, veo3, kling-master",
+						"description": "Model to use: wan-t2v-fast, veo3, veo3.1, veo3-fast, veo2, kling-master, ltx, hunyuan, hailuo, pixverse, or auto to pick the best model for max_cost, max_wait_seconds, need_audio, and resolution",
 						"default": "wan-t2v-fast"
 					},
+					"model_version": {
+						"type": "string",
+						"description": "Optional Replicate version ID to use instead of the model's pinned or latest version"
+					},
 					"duration": {
 						"type": "integer",
-						"description": "Video duration in seconds (5 or 10, only for kling-master)",
+						"description": "Video duration in seconds (kling-master: 5 or 10; veo2: 5-8; pixverse: 5 or 8)",
 						"minimum": 5,
 						"maximum": 10
 					},
@@ -43,11 +48,114 @@ func (h *ReplicateVideoHandler) ListTools(ctx context.Context) (*protocol.ListTo
 					},
 					"negative_prompt": {
 						"type": "string",
-						"description": "What to avoid in the video (supported by veo3, kling-master)"
+						"description": "What to avoid in the video (supported by Wan models, veo3, veo3-fast, kling-master, pixverse; ignored with a warning by others)"
+					},
+					"style_image_path": {
+						"type": "string",
+						"description": "Path to a reference image that guides the subject and look of the video (only for hailuo, where it is used as the subject reference)"
+					},
+					"num_frames": {
+						"type": "integer",
+						"description": "Number of frames (81-121, only for Wan models). More frames make a longer clip",
+						"minimum": 81,
+						"maximum": 121,
+						"default": 81
+					},
+					"fps": {
+						"type": "integer",
+						"description": "Frames per second (5-30, only for Wan models). Lower is longer and choppier, higher is shorter and smoother",
+						"minimum": 5,
+						"maximum": 30,
+						"default": 16
+					},
+					"go_fast": {
+						"type": "boolean",
+						"description": "Speed optimizations (only for Wan models). true is faster and cheaper; false is slower but can give slightly cleaner detail",
+						"default": true
+					},
+					"sample_shift": {
+						"type": "number",
+						"description": "Sampling shift (1-20, only for Wan models). Higher values favor coherent overall structure and motion, lower values favor fine detail",
+						"minimum": 1,
+						"maximum": 20,
+						"default": 12
+					},
+					"guidance_scale": {
+						"type": "number",
+						"description": "Guidance (CFG) scale: how closely to follow the prompt. Higher is more literal, lower is more natural. Ranges: ltx 1-20 (default 3), hunyuan 1-10 (default 6)"
+					},
+					"num_inference_steps": {
+						"type": "integer",
+						"description": "Denoising steps. More steps are slower and usually cleaner. Ranges: ltx 1-50 (default 30), hunyuan 1-50 (default 50), wan-i2v-full 1-40 (default 30)"
+					},
+					"camera_motion": {
+						"type": "string",
+						"description": "Camera movement, sent to the model as a camera direction added to the prompt",
+						"enum": ["static", "pan_left", "pan_right", "tilt_up", "tilt_down", "zoom_in", "zoom_out"]
+					},
+					"effect": {
+						"type": "string",
+						"description": "Effect template for models that have them, such as pixverse's ghibli_live, mint_in_box, or ymca. server_capabilities lists each model's effects; other models reject it"
 					},
 					"filename": {
 						"type": "string",
-						"description": "Optional output filename"
+						"description": "Optional filename for the downloaded video, without directories; the extension is added when missing"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name. The video is stored under <root>/<project>/<storage_id>/ and can be listed by project"
+					},
+					"output_dir": {
+						"type": "string",
+						"description": "Optional absolute directory (e.g. a project folder) to save this video in instead of the default storage root. The video is written to <output_dir>/<storage_id>/"
+					},
+					"seed": {
+						"type": "integer",
+						"description": "Random seed for reproducible results (wan, ltx, hunyuan, veo3, veo3.1, veo3-fast, veo2, pixverse); other models ignore it"
+					},
+					"auto_retry": {
+						"type": "integer",
+						"description": "Times to resubmit the prediction if it fails with a transient model error, e.g. running out of GPU memory, waiting longer before each (0-3, default: 0). The completed result lists the failed attempts",
+						"minimum": 0,
+						"maximum": 3
+					},
+					"retry_strategy": {
+						"type": "string",
+						"description": "How auto_retry resubmits: same (the same input) or new_seed (a new random seed, for models that take one). Default: same",
+						"enum": ["same", "new_seed"]
+					},
+					"queue": {
+						"type": "boolean",
+						"description": "Queue the request instead of failing when a limit (e.g. REPLICATE_VIDEO_MAX_CONCURRENT) refuses it or other requests are already queued. Queued requests start automatically as slots free up, also after a restart; the response has status queued and a storage_id for continue_operation"
+					},
+					"priority": {
+						"type": "string",
+						"description": "Queue priority with queue: true; higher priorities start first (default: normal)",
+						"enum": ["high", "normal", "low"]
+					},
+					"start_after": {
+						"type": "string",
+						"description": "Queue the request to start no earlier than this: an RFC 3339 time (e.g. 2025-01-02T01:00:00Z) or a delay from now (e.g. 8h), at most 30 days ahead. Useful for running expensive jobs overnight once hourly or daily limits reset"
+					},
+					"schedule": {
+						"type": "string",
+						"description": "Queue the request to start at the next time matching a cron expression in server local time (minute hour day-of-month month day-of-week, e.g. '0 2 * * *' for 2am), or @hourly, @daily, @weekly, @monthly. The request runs once; use instead of start_after"
+					},
+					"max_cost": {
+						"type": "number",
+						"description": "With model auto: highest estimated cost in USD"
+					},
+					"max_wait_seconds": {
+						"type": "integer",
+						"description": "With model auto: longest typical generation time in seconds"
+					},
+					"need_audio": {
+						"type": "boolean",
+						"description": "With model auto: only pick models that generate audio"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
 					}
 				},
 				"required": ["prompt"]
@@ -55,7 +163,7 @@ func (h *ReplicateVideoHandler) ListTools(ctx context.Context) (*protocol.ListTo
 		},
 		{
 			Name:        "generate_video_from_image",
-			Description: "Generate a video from an image with motion prompt. Models: wan-i2v-fast (default, fast/cheap), veo3 (preserves style), kling-master (high quality, 5/10s duration)",
+			Description: "Generate a video from an image with motion prompt. Models: wan-i2v-fast (default, fast/cheap), veo3 (preserves style), kling-master (high quality, 5/10s duration), wan-i2v-full (higher quality Wan, tunable steps), ltx (fast, tunable guidance/steps), hailuo (MiniMax, 6s clips), veo3.1 (accepts image_paths reference images), veo3-fast (cheaper Veo 3 with audio), veo2 (no audio, 5-8s duration), kling-standard (720p Kling at a fraction of kling-master's cost), kling-pro (1080p Kling, cheaper than kling-master), pixverse (affordable, effect templates)",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
@@ -67,14 +175,23 @@ func (h *ReplicateVideoHandler) ListTools(ctx context.Context) (*protocol.ListTo
 						"type": "string",
 						"description": "Description of how to animate the image"
 					},
+					"image_paths": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Further images sent with image_path as keyframes or subject references (only for models with a multi-image input: veo3.1 takes up to 3 reference images)"
+					},
 					"model": {
 						"type": "string",
-						"description": "Model to use: wan-i2v-fast, veo3, kling-master",
+						"description": "Model to use: wan-i2v-fast, wan-i2v-full, veo3, kling-master, kling-standard, kling-pro, ltx, hailuo, veo3.1, veo3-fast, veo2, pixverse, or auto to pick the best model for max_cost, max_wait_seconds, need_audio, and resolution",
 						"default": "wan-i2v-fast"
 					},
+					"model_version": {
+						"type": "string",
+						"description": "Optional Replicate version ID to use instead of the model's pinned or latest version"
+					},
 					"duration": {
 						"type": "integer",
-						"description": "Video duration in seconds (only for kling-master: 5 or 10)"
+						"description": "Video duration in seconds (kling-master, kling-standard, kling-pro: 5 or 10; veo2: 5-8; pixverse: 5 or 8)"
 					},
 					"resolution": {
 						"type": "string",
@@ -83,38 +200,1252 @@ func (h *ReplicateVideoHandler) ListTools(ctx context.Context) (*protocol.ListTo
 					},
 					"negative_prompt": {
 						"type": "string",
-						"description": "What to avoid in the video (supported by veo3, kling-master)"
+						"description": "What to avoid in the video (supported by Wan models, veo3, veo3-fast, kling-master, kling-standard, kling-pro, pixverse; ignored with a warning by others)"
+					},
+					"num_frames": {
+						"type": "integer",
+						"description": "Number of frames (81-121, only for Wan models). More frames make a longer clip",
+						"minimum": 81,
+						"maximum": 121,
+						"default": 81
+					},
+					"fps": {
+						"type": "integer",
+						"description": "Frames per second (5-30, only for Wan models). Lower is longer and choppier, higher is shorter and smoother",
+						"minimum": 5,
+						"maximum": 30,
+						"default": 16
+					},
+					"go_fast": {
+						"type": "boolean",
+						"description": "Speed optimizations (only for Wan models). true is faster and cheaper; false is slower but can give slightly cleaner detail",
+						"default": true
+					},
+					"sample_shift": {
+						"type": "number",
+						"description": "Sampling shift (1-20, only for Wan models). Higher values favor coherent overall structure and motion, lower values favor fine detail",
+						"minimum": 1,
+						"maximum": 20,
+						"default": 12
+					},
+					"guidance_scale": {
+						"type": "number",
+						"description": "Guidance (CFG) scale: how closely to follow the prompt. Higher is more literal, lower is more natural. Ranges: ltx 1-20 (default 3), hunyuan 1-10 (default 6)"
+					},
+					"num_inference_steps": {
+						"type": "integer",
+						"description": "Denoising steps. More steps are slower and usually cleaner. Ranges: ltx 1-50 (default 30), hunyuan 1-50 (default 50), wan-i2v-full 1-40 (default 30)"
+					},
+					"camera_motion": {
+						"type": "string",
+						"description": "Camera movement, sent to the model as a camera direction added to the prompt",
+						"enum": ["static", "pan_left", "pan_right", "tilt_up", "tilt_down", "zoom_in", "zoom_out"]
+					},
+					"effect": {
+						"type": "string",
+						"description": "Effect template for models that have them, such as pixverse's ghibli_live, mint_in_box, or ymca. server_capabilities lists each model's effects; other models reject it"
+					},
+					"safety_checker": {
+						"type": "boolean",
+						"description": "Keep the model's safety checker enabled (only for wan-i2v-fast and wan-i2v-full). Setting false requires the server to be started with REPLICATE_VIDEO_ALLOW_SAFETY_OVERRIDE=true",
+						"default": true
 					},
 					"filename": {
 						"type": "string",
-						"description": "Optional output filename"
+						"description": "Optional filename for the downloaded video, without directories; the extension is added when missing"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name. The video is stored under <root>/<project>/<storage_id>/ and can be listed by project"
+					},
+					"output_dir": {
+						"type": "string",
+						"description": "Optional absolute directory (e.g. a project folder) to save this video in instead of the default storage root. The video is written to <output_dir>/<storage_id>/"
+					},
+					"seed": {
+						"type": "integer",
+						"description": "Random seed for reproducible results (wan, ltx, hunyuan, veo3, veo3.1, veo3-fast, veo2, pixverse); other models ignore it"
+					},
+					"auto_retry": {
+						"type": "integer",
+						"description": "Times to resubmit the prediction if it fails with a transient model error, e.g. running out of GPU memory, waiting longer before each (0-3, default: 0). The completed result lists the failed attempts",
+						"minimum": 0,
+						"maximum": 3
+					},
+					"retry_strategy": {
+						"type": "string",
+						"description": "How auto_retry resubmits: same (the same input) or new_seed (a new random seed, for models that take one). Default: same",
+						"enum": ["same", "new_seed"]
+					},
+					"queue": {
+						"type": "boolean",
+						"description": "Queue the request instead of failing when a limit (e.g. REPLICATE_VIDEO_MAX_CONCURRENT) refuses it or other requests are already queued. Queued requests start automatically as slots free up, also after a restart; the response has status queued and a storage_id for continue_operation"
+					},
+					"priority": {
+						"type": "string",
+						"description": "Queue priority with queue: true; higher priorities start first (default: normal)",
+						"enum": ["high", "normal", "low"]
+					},
+					"start_after": {
+						"type": "string",
+						"description": "Queue the request to start no earlier than this: an RFC 3339 time (e.g. 2025-01-02T01:00:00Z) or a delay from now (e.g. 8h), at most 30 days ahead. Useful for running expensive jobs overnight once hourly or daily limits reset"
+					},
+					"schedule": {
+						"type": "string",
+						"description": "Queue the request to start at the next time matching a cron expression in server local time (minute hour day-of-month month day-of-week, e.g. '0 2 * * *' for 2am), or @hourly, @daily, @weekly, @monthly. The request runs once; use instead of start_after"
+					},
+					"max_cost": {
+						"type": "number",
+						"description": "With model auto: highest estimated cost in USD"
+					},
+					"max_wait_seconds": {
+						"type": "integer",
+						"description": "With model auto: longest typical generation time in seconds"
+					},
+					"need_audio": {
+						"type": "boolean",
+						"description": "With model auto: only pick models that generate audio"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
 					}
 				},
 				"required": ["image_path", "prompt"]
 			}`),
 		},
+		{
+			Name:        "run_custom_video_model",
+			Description: "Run any Replicate video model that isn't in the registry, or one of your own deployments, with a raw input object. The video is stored, tracked, and downloaded like other generations; use continue_operation to wait for it. Use get_model_versions to discover the model's input parameters, and list_deployments to find your deployments",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"model": {
+						"type": "string",
+						"description": "Replicate model as owner/model, owner/model:version to pin a version, or deployment:owner/name to run one of your deployments"
+					},
+					"input": {
+						"type": "object",
+						"description": "Input parameters passed to the model as-is. Images must be URLs or data URIs"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name. The video is stored under <root>/<project>/<storage_id>/ and can be listed by project"
+					},
+					"output_dir": {
+						"type": "string",
+						"description": "Optional absolute directory to save this video in instead of the default storage root"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
+					}
+				},
+				"required": ["model", "input"]
+			}`),
+		},
 		{
 			Name:        "continue_operation",
-			Description: "Continue checking status of async video generation",
+			Description: "Continue checking status of async video generation. Pass several prediction_ids (or prediction_id \"all_pending\") to poll a batch concurrently in one call",
 			InputSchema: json.RawMessage(`{
 				"type": "object",
 				"properties": {
+					"operation_id": {
+						"type": "string",
+						"description": "The operation ID from initial generation"
+					},
 					"prediction_id": {
 						"type": "string",
-						"description": "The prediction ID from initial generation"
+						"description": "The prediction ID from initial generation (alternative to operation_id), or \"all_pending\" for every unfinished generation"
+					},
+					"storage_id": {
+						"type": "string",
+						"description": "The storage ID from initial generation (alternative to operation_id); the prediction ID is looked up from its metadata"
+					},
+					"prediction_ids": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Several prediction IDs to check concurrently; returns per-prediction statuses"
 					},
 					"wait_time": {
 						"type": "number",
-						"description": "How long to wait in seconds (5-60)",
-						"default": 30
+						"description": "How long to wait in seconds (at least 5; up to 300 by default, see REPLICATE_VIDEO_MAX_CONTINUE_WAIT). Defaults to the time the model typically still needs, based on recent generations. Polling continues in the background after the call returns"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID; with \"all_pending\", limits the batch to this session"
+					},
+					"include_thumbnail": {
+						"type": "boolean",
+						"description": "Return the video's thumbnail as an image alongside a completed response",
+						"default": false
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "recover_prediction",
+			Description: "Adopt a Replicate prediction this server has no record of, e.g. after the storage folder was wiped or when it was made by another client: rebuild its metadata from Replicate and download the video once it has finished. Returns like continue_operation",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"prediction_id": {
+						"type": "string",
+						"description": "The Replicate prediction ID to recover"
+					},
+					"wait_time": {
+						"type": "number",
+						"description": "How long to wait in seconds for a prediction that is still running, as for continue_operation"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name. The video is stored under <root>/<project>/<storage_id>/ and can be listed by project"
+					},
+					"output_dir": {
+						"type": "string",
+						"description": "Optional absolute directory to save this video in instead of the default storage root"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
 					}
 				},
 				"required": ["prediction_id"]
 			}`),
 		},
-	}
-
-	return &protocol.ListToolsResponse{
-		Tools: tools,
-	}, nil
+		{
+			Name:        "queue_status",
+			Description: "List the generation requests waiting in the queue (made with queue: true, start_after, or schedule), in the order they will start, with how many predictions are running against the concurrency limit. Deferred requests show their start_after",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {}
+			}`),
+		},
+		{
+			Name:        "reorder_queue",
+			Description: "Change a queued generation's priority, or move it to the first or last place within its priority. Returns the reordered queue. Delete a queued generation with delete_videos",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "The storage ID of the queued generation"
+					},
+					"priority": {
+						"type": "string",
+						"description": "New priority",
+						"enum": ["high", "normal", "low"]
+					},
+					"position": {
+						"type": "string",
+						"description": "Move to the first or last place among generations of the same priority",
+						"enum": ["first", "last"]
+					}
+				},
+				"required": ["storage_id"]
+			}`),
+		},
+		{
+			Name:        "search_videos",
+			Description: "Search previously generated videos by prompt text (including negative prompts). Returns ranked matches with storage IDs, file paths, and thumbnails",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"query": {
+						"type": "string",
+						"description": "Words to search for, e.g. 'beach sunset'"
+					},
+					"limit": {
+						"type": "integer",
+						"description": "Maximum number of results",
+						"default": 10
+					},
+					"status": {
+						"type": "string",
+						"description": "Only include videos with this status (e.g. completed, processing)"
+					},
+					"project": {
+						"type": "string",
+						"description": "Only include videos from this project"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Only include videos with this tag"
+					},
+					"favorite": {
+						"type": "boolean",
+						"description": "Only include favorite videos when true"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Only include videos from this conversation/session"
+					}
+				},
+				"required": ["query"]
+			}`),
+		},
+		{
+			Name:        "list_videos",
+			Description: "List previously generated videos, newest first, optionally filtered by session, project, status, tag, or favorite",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Only include videos from this conversation/session"
+					},
+					"status": {
+						"type": "string",
+						"description": "Only include videos with this status (e.g. completed, processing)"
+					},
+					"project": {
+						"type": "string",
+						"description": "Only include videos from this project"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Only include videos with this tag"
+					},
+					"favorite": {
+						"type": "boolean",
+						"description": "Only include favorite videos when true"
+					},
+					"limit": {
+						"type": "integer",
+						"description": "Maximum number of results",
+						"default": 20
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "tag_video",
+			Description: "Add or remove tags on a stored video and mark it as a favorite, to curate the library. Filter by tag or favorite with list_videos",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"tags": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Tags to add, e.g. ['beach', 'final cut']. Tags are case-insensitive"
+					},
+					"remove_tags": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Tags to remove"
+					},
+					"favorite": {
+						"type": "boolean",
+						"description": "Mark (true) or unmark (false) the video as a favorite"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "list_tags",
+			Description: "List the tags in use across stored videos with how many videos carry each, plus the number of favorites",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"project": {
+						"type": "string",
+						"description": "Only count videos from this project"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Only count videos from this conversation/session"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "import_video",
+			Description: "Import an existing video file (e.g. footage not generated here) into storage, so it can be listed, tagged, exported, and used in editing workflows alongside generations",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"path": {
+						"type": "string",
+						"description": "Absolute path of the video file to import (.mp4, .m4v, .mov, or .webm). The file is copied"
+					},
+					"description": {
+						"type": "string",
+						"description": "What the video shows; searchable with search_videos"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name. The video is stored under <root>/<project>/<storage_id>/"
+					},
+					"tags": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Tags to add to the imported video"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group videos"
+					}
+				},
+				"required": ["path"]
+			}`),
+		},
+		{
+			Name:        "export_videos",
+			Description: "Bundle stored videos (video, metadata, thumbnail) into a zip archive or a folder with a manifest.json, to hand off a finished project's assets",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_ids": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Storage IDs to export"
+					},
+					"project": {
+						"type": "string",
+						"description": "Export the completed videos of this project (used when storage_ids is omitted)"
+					},
+					"tag": {
+						"type": "string",
+						"description": "Export the completed videos with this tag (used when storage_ids is omitted)"
+					},
+					"favorite": {
+						"type": "boolean",
+						"description": "Export only favorite videos (used when storage_ids is omitted)"
+					},
+					"format": {
+						"type": "string",
+						"enum": ["zip", "directory"],
+						"description": "Write a zip archive or copy into a folder",
+						"default": "zip"
+					},
+					"destination": {
+						"type": "string",
+						"description": "Absolute directory to write the export to. Defaults to the exports folder in the storage root"
+					},
+					"name": {
+						"type": "string",
+						"description": "Name of the archive or folder (default: replicate-videos-<timestamp>)"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Export the completed videos from this conversation/session (used when storage_ids is omitted)"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "find_duplicates",
+			Description: "Find stored videos whose video files or input images are byte-identical (SHA-256), e.g. from repeated retries, with the disk space the extra copies use. Delete extras with delete_videos",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"project": {
+						"type": "string",
+						"description": "Only check videos from this project"
+					},
+					"backfill": {
+						"type": "boolean",
+						"description": "Hash videos stored before hashing was recorded, saving the hashes to their metadata",
+						"default": true
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Only check videos from this conversation/session"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "session_summary",
+			Description: "Recap everything generated since the server started: counts by status, the models used, estimated total cost, total generation time, the completed videos with their paths, and the failures with their errors. Use it to close a conversation with an accurate summary",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Only generations made with this conversation/session ID"
+					},
+					"since": {
+						"type": "string",
+						"description": "Start the recap at this RFC 3339 time instead of the server start, e.g. to include an earlier run"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "storage_stats",
+			Description: "Report disk usage of stored videos: total size, video count, breakdowns by model, project, and status, and the oldest and newest videos, to decide when to prune",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "delete_videos",
+			Description: "Delete stored videos and their metadata, either by storage ID or every video from a session",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_ids": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "Storage IDs to delete"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Delete all videos from this conversation/session (used when storage_ids is omitted)"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "get_video_info",
+			Description: "Get details for a stored video (status, paths, model, parameters, metrics). Optionally include a contact sheet of evenly spaced frames to judge motion quality without downloading the video",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"include_contact_sheet": {
+						"type": "boolean",
+						"description": "Generate and return a contact sheet image (requires ffmpeg)",
+						"default": false
+					},
+					"frames": {
+						"type": "integer",
+						"description": "Number of frames in the contact sheet (2-36)",
+						"default": 9
+					},
+					"include_thumbnail": {
+						"type": "boolean",
+						"description": "Return the video's thumbnail as an image",
+						"default": false
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "get_thumbnail",
+			Description: "Get the thumbnail of a completed video as an inline JPEG image for previewing in chat",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "burn_captions",
+			Description: "Burn caption text or an SRT subtitle file into a completed video with ffmpeg, saving a captioned copy (captioned.mp4) in the same storage folder. Requires ffmpeg",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"text": {
+						"type": "string",
+						"description": "Caption text to show (use either text or srt_path)"
+					},
+					"srt_path": {
+						"type": "string",
+						"description": "Path to an SRT subtitle file with timed captions (use either text or srt_path)"
+					},
+					"font": {
+						"type": "string",
+						"description": "Font family name (e.g. Arial). Defaults to ffmpeg's font"
+					},
+					"font_size": {
+						"type": "integer",
+						"description": "Font size in pixels (8-200)",
+						"default": 48
+					},
+					"color": {
+						"type": "string",
+						"description": "Text color: white, black, yellow, red, green, blue, or #RRGGBB",
+						"default": "white"
+					},
+					"position": {
+						"type": "string",
+						"description": "Where to place the captions",
+						"enum": ["top", "center", "bottom"],
+						"default": "bottom"
+					},
+					"start": {
+						"type": "number",
+						"description": "Seconds into the video when text appears (text only)"
+					},
+					"end": {
+						"type": "number",
+						"description": "Seconds into the video when text disappears (text only, default: end of video)"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "watermark_video",
+			Description: "Overlay a PNG logo on a completed video at a chosen corner with ffmpeg, saving a branded copy (watermarked.mp4) next to the original. Requires ffmpeg",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"logo_path": {
+						"type": "string",
+						"description": "Path to a PNG logo; transparency is kept"
+					},
+					"corner": {
+						"type": "string",
+						"description": "Corner to place the logo in",
+						"enum": ["top_left", "top_right", "bottom_left", "bottom_right"],
+						"default": "bottom_right"
+					},
+					"opacity": {
+						"type": "number",
+						"description": "Logo opacity (0-1)",
+						"minimum": 0,
+						"maximum": 1,
+						"default": 0.8
+					},
+					"scale": {
+						"type": "number",
+						"description": "Logo width as a fraction of the video width (0.01-1)",
+						"default": 0.15
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				},
+				"required": ["logo_path"]
+			}`),
+		},
+		{
+			Name:        "reframe_video",
+			Description: "Convert a completed video to another aspect ratio (e.g. 16:9 to 9:16 for vertical platforms) by cropping around a focus position or padding with black bars, saving the copy as reframed_<w>x<h>.mp4 next to the original. Requires ffmpeg",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"aspect_ratio": {
+						"type": "string",
+						"description": "Target aspect ratio",
+						"enum": ["9:16", "16:9", "1:1", "4:5"]
+					},
+					"mode": {
+						"type": "string",
+						"description": "crop fills the new frame and cuts off the sides (or top and bottom); pad keeps the whole picture and adds black bars",
+						"enum": ["crop", "pad"],
+						"default": "crop"
+					},
+					"focus": {
+						"type": "number",
+						"description": "Crop position: 0 keeps the left (or top) edge, 0.5 the center, 1 the right (or bottom) edge. Only for crop",
+						"minimum": 0,
+						"maximum": 1,
+						"default": 0.5
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				},
+				"required": ["aspect_ratio"]
+			}`),
+		},
+		{
+			Name:        "interpolate_frames",
+			Description: "Raise the frame rate of a completed video (e.g. 16fps Wan output to 30 or 60fps) by synthesizing in-between frames with ffmpeg, optionally as smooth slow motion. Saves interpolated.mp4 next to the original. Requires ffmpeg; motion interpolation can take several minutes",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"fps": {
+						"type": "integer",
+						"description": "Target frame rate (1-120)",
+						"default": 30
+					},
+					"method": {
+						"type": "string",
+						"description": "minterpolate estimates motion for the smoothest result (slowest); blend crossfades neighbouring frames; duplicate repeats frames and only changes the rate",
+						"enum": ["minterpolate", "blend", "duplicate"],
+						"default": "minterpolate"
+					},
+					"slow_motion": {
+						"type": "number",
+						"description": "Slow the clip down by this factor (1-8) before interpolating. Audio is dropped when slowed",
+						"default": 1
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "make_loop",
+			Description: "Turn a completed video into a seamless loop for background or ambient clips, either as a boomerang (forward then reverse) or by crossfading the tail into the head. Saves loop.mp4 next to the original, without audio. Requires ffmpeg",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"style": {
+						"type": "string",
+						"description": "boomerang plays the clip forward then reversed (twice as long); crossfade blends the last seconds into the opening (shorter by the crossfade)",
+						"enum": ["boomerang", "crossfade"],
+						"default": "boomerang"
+					},
+					"count": {
+						"type": "integer",
+						"description": "How many times the loop repeats in the output (1-20)",
+						"default": 1
+					},
+					"crossfade": {
+						"type": "number",
+						"description": "Crossfade length in seconds, at most half the clip (crossfade style only)",
+						"default": 1
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "extract_frames",
+			Description: "Extract frames from a completed video as PNG or JPEG images into its frames/ folder and list their paths, e.g. to pick a frame and animate it again with generate_video_from_image. Takes all frames, every Nth frame, or frames at given timestamps. Replaces frames from earlier extractions. Requires ffmpeg",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"storage_id": {
+						"type": "string",
+						"description": "Storage ID of the video"
+					},
+					"prediction_id": {
+						"type": "string",
+						"description": "Prediction ID of the video (alternative to storage_id)"
+					},
+					"every": {
+						"type": "integer",
+						"description": "Take every Nth frame (1 takes all frames). Ignored with timestamps",
+						"minimum": 1,
+						"default": 1
+					},
+					"timestamps": {
+						"type": "array",
+						"items": {"type": "number"},
+						"description": "Seconds into the video to take single frames at, instead of every"
+					},
+					"format": {
+						"type": "string",
+						"description": "Image format",
+						"enum": ["png", "jpg"],
+						"default": "png"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "get_audit_log",
+			Description: "List recent tool calls from the audit log, newest first, with their parameters (image data omitted), result status, prediction ID, and estimated cost",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"tool": {
+						"type": "string",
+						"description": "Only calls of this tool, e.g. generate_video_from_text"
+					},
+					"status": {
+						"type": "string",
+						"enum": ["success", "processing", "error"],
+						"description": "Only calls with this result"
+					},
+					"since": {
+						"type": "string",
+						"description": "Only calls at or after this RFC 3339 time, e.g. 2025-01-31T09:00:00Z"
+					},
+					"limit": {
+						"type": "integer",
+						"description": "Maximum number of records (max 500)",
+						"default": 50
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "compare_models",
+			Description: "A/B compare models: run the same prompt (and image, for image-to-video) on 2 to 6 models concurrently. The generations are grouped under a comparison_id; the response lists each model's status, paths, estimated cost, and elapsed time. Call again with only comparison_id (and optionally wait_time) to check on them",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"prompt": {
+						"type": "string",
+						"description": "Text description of the video, sent to every model"
+					},
+					"models": {
+						"type": "array",
+						"items": {"type": "string"},
+						"description": "2 to 6 registered models to compare, e.g. [\"wan-t2v-fast\", \"ltx\", \"veo3\"]"
+					},
+					"image_path": {
+						"type": "string",
+						"description": "Optional input image; compares image-to-video models instead of text-to-video"
+					},
+					"resolution": {
+						"type": "string",
+						"description": "Video resolution for models that accept it (480p, 720p, 1080p)"
+					},
+					"aspect_ratio": {
+						"type": "string",
+						"description": "Aspect ratio for models that accept it (16:9, 9:16, 1:1)"
+					},
+					"negative_prompt": {
+						"type": "string",
+						"description": "What to avoid, for models that accept it"
+					},
+					"comparison_id": {
+						"type": "string",
+						"description": "ID of an earlier comparison to report on instead of starting a new one"
+					},
+					"wait_time": {
+						"type": "integer",
+						"description": "Seconds to wait for the videos before responding (default: 0, respond once started)"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name to store the videos under"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "generate_variations",
+			Description: "Explore prompt variations: expand a base prompt with substitution lists (every combination of the values) and/or a count of seed variations into up to 24 generations of one model, grouped under a variation_set_id. Variations beyond the concurrency limit are queued and started as slots free up. Call again with only variation_set_id (and optionally wait_time) to check on them",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"prompt": {
+						"type": "string",
+						"description": "Base prompt with {placeholders} for the substitutions, e.g. \"a fox running through snow, {style}\""
+					},
+					"substitutions": {
+						"type": "object",
+						"additionalProperties": {"type": "array", "items": {"type": "string"}},
+						"description": "Values for each placeholder, e.g. {\"style\": [\"watercolor\", \"film noir\"]}. The keys camera_motion and aspect_ratio vary that parameter instead of a placeholder"
+					},
+					"count": {
+						"type": "integer",
+						"description": "Seed variations per combination, using consecutive seeds from seed (or a random base) (default: 1)",
+						"default": 1
+					},
+					"seed": {
+						"type": "integer",
+						"description": "Base seed for the seed variations"
+					},
+					"model": {
+						"type": "string",
+						"description": "Model for every variation, or auto (default: wan-t2v-fast, or wan-i2v-fast with image_path)"
+					},
+					"image_path": {
+						"type": "string",
+						"description": "Optional input image; makes image-to-video variations"
+					},
+					"resolution": {
+						"type": "string",
+						"description": "Video resolution for every variation"
+					},
+					"negative_prompt": {
+						"type": "string",
+						"description": "What to avoid, for every variation"
+					},
+					"variation_set_id": {
+						"type": "string",
+						"description": "ID of an earlier variation set to report on instead of starting a new one"
+					},
+					"wait_time": {
+						"type": "integer",
+						"description": "Seconds to wait for the videos before responding (default: 0, respond once started or queued)"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name to store the videos under"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "generate_storyboard",
+			Description: "Generate a short narrative from an ordered list of scenes (2-12), one clip per scene, and once every scene completed join them in order into a single video with crossfades (requires ffmpeg). In chain mode each scene continues from the last frame of the previous one. Scenes beyond the concurrency limit are queued. Call again with only storyboard_id (and optionally wait_time) to check progress and get the assembled video",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"scenes": {
+						"type": "array",
+						"description": "Scenes in order: a prompt string, or an object with a prompt and optional per-scene model, duration, image_path, negative_prompt, camera_motion, or seed",
+						"items": {
+							"oneOf": [
+								{"type": "string"},
+								{
+									"type": "object",
+									"properties": {
+										"prompt": {"type": "string"},
+										"model": {"type": "string"},
+										"duration": {"type": "integer"},
+										"image_path": {"type": "string"},
+										"negative_prompt": {"type": "string"},
+										"camera_motion": {"type": "string"},
+										"seed": {"type": "integer"}
+									},
+									"required": ["prompt"]
+								}
+							]
+						}
+					},
+					"model": {
+						"type": "string",
+						"description": "Model for scenes that don't name one, or auto (default: wan-t2v-fast, or wan-i2v-fast for scenes with image_path)"
+					},
+					"resolution": {
+						"type": "string",
+						"description": "Video resolution for every scene"
+					},
+					"aspect_ratio": {
+						"type": "string",
+						"description": "Aspect ratio for every scene"
+					},
+					"negative_prompt": {
+						"type": "string",
+						"description": "What to avoid, for scenes that don't set their own"
+					},
+					"chain": {
+						"type": "boolean",
+						"description": "Generate the scenes one after another, each after the first animating the last frame of the previous clip (image-to-video) for visual continuity. Scenes with their own image_path keep it. Requires ffmpeg (default: false)",
+						"default": false
+					},
+					"concatenate": {
+						"type": "boolean",
+						"description": "Join the completed scenes into one video (default: true when ffmpeg is available). With storyboard_id, joins an earlier storyboard's scenes that were never joined"
+					},
+					"crossfade": {
+						"type": "number",
+						"description": "Seconds each scene fades into the next, 0-2; 0 cuts (default: 0.5)",
+						"default": 0.5
+					},
+					"storyboard_id": {
+						"type": "string",
+						"description": "ID of an earlier storyboard to report on instead of starting a new one"
+					},
+					"wait_time": {
+						"type": "integer",
+						"description": "Seconds to wait for the scenes and the joined video before responding (default: 0, respond once started or queued)"
+					},
+					"project": {
+						"type": "string",
+						"description": "Optional project name to store the videos under"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID used to group generations"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "get_response_schema",
+			Description: "Get JSON Schemas (draft 2020-12) of this server's tool responses, for automations that validate them. Every response carries schema_version; fields are only added within a major version. Without types, returns SuccessResponse, ProcessingResponse, and ErrorResponse",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"types": {
+						"type": "array",
+						"items": {
+							"type": "string",
+							"enum": ["SuccessResponse", "ProcessingResponse", "ErrorResponse", "QueuedResponse", "QueueStatusResponse", "ListResponse", "BatchStatusResponse", "ComparisonResponse", "VariationSetResponse", "StoryboardResponse", "SessionSummaryResponse"]
+						},
+						"description": "Response types to return schemas for"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "server_capabilities",
+			Description: "Report this server's version, enabled optional subsystems (notifications, ffmpeg, mock client), registered models, and available tools",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "check_account",
+			Description: "Check that the Replicate API token is valid and whether the account has a billing issue (402), without starting a generation",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "health_check",
+			Description: "Diagnose the server: check that api.replicate.com is reachable, the API token is valid, the storage root is writable, and ffmpeg/ffprobe are installed. Returns each check with a message and which features (generate, thumbnails, video editing, ...) work. Use it first when generations or tools fail unexpectedly",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "recommend_model",
+			Description: "Rank the registered models for a generation by quality, recent success rate, cost, and typical wait, given constraints. Recent success rates and completion times come from this server's past generations. Pass model \"auto\" to the generate tools to use the top pick directly",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"mode": {
+						"type": "string",
+						"enum": ["t2v", "i2v"],
+						"description": "t2v for text-to-video, i2v for image-to-video (default: t2v)",
+						"default": "t2v"
+					},
+					"max_cost": {
+						"type": "number",
+						"description": "Highest estimated cost in USD"
+					},
+					"max_wait_seconds": {
+						"type": "integer",
+						"description": "Longest typical generation time in seconds"
+					},
+					"need_audio": {
+						"type": "boolean",
+						"description": "Only models that generate audio"
+					},
+					"resolution": {
+						"type": "string",
+						"description": "Lowest acceptable resolution, e.g. 720p or 1080p"
+					},
+					"duration": {
+						"type": "integer",
+						"description": "Video duration in seconds, so models priced by the second (Kling, veo2) are costed for it"
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+		{
+			Name:        "get_model_versions",
+			Description: "List a Replicate model's available versions with release dates, plus the input schema of the latest version, to discover which parameters the upstream model accepts",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"model": {
+						"type": "string",
+						"description": "Registered model alias (e.g. wan-t2v-fast, kling-master) or a Replicate model as owner/name"
+					},
+					"limit": {
+						"type": "integer",
+						"description": "Maximum number of versions to return (default: 10)",
+						"default": 10
+					},
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				},
+				"required": ["model"]
+			}`),
+		},
+		{
+			Name:        "list_deployments",
+			Description: "List your Replicate deployments: private or fine-tuned models run on dedicated hardware. Run one with run_custom_video_model using the model shown, e.g. deployment:acme/my-wan",
+			InputSchema: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"session_id": {
+						"type": "string",
+						"description": "Optional conversation/session ID"
+					}
+				}
+			}`),
+		},
+	}
+
+	// A server confined by REPLICATE_VIDEO_NAMESPACE takes no namespace argument
+	for i := range tools {
+		if h.config.Namespace == "" {
+			tools[i].InputSchema = withProperty(tools[i].InputSchema, "namespace", namespaceProperty)
+		}
+		tools[i].InputSchema = withProperty(tools[i].InputSchema, "compact_response", compactProperty)
+		tools[i].InputSchema = withProperty(tools[i].InputSchema, "response_format", formatProperty)
+	}
+
+	// Tell agents up front which editing tools can't work here
+	if !storage.HasFFmpeg() {
+		for i := range tools {
+			if ffmpegTools[tools[i].Name] {
+				tools[i].Description += ". Unavailable on this server: ffmpeg was not found"
+			}
+		}
+	}
+
+	return &protocol.ListToolsResponse{
+		Tools: tools,
+	}, nil
+}
+
+// ffmpegTools are the tools that fail without ffmpeg
+var ffmpegTools = map[string]bool{
+	"burn_captions":      true,
+	"watermark_video":    true,
+	"reframe_video":      true,
+	"interpolate_frames": true,
+	"make_loop":          true,
+	"extract_frames":     true,
+}
+
+// namespaceProperty is the schema of the namespace argument every tool accepts
+var namespaceProperty = json.RawMessage(`{
+	"type": "string",
+	"description": "Optional namespace (e.g. a user or agent ID). Generations, projects, searches, and quotas are kept separate per namespace; user_id is accepted as an alias"
+}`)
+
+// compactProperty is the schema of the compact_response argument every tool
+// accepts
+var compactProperty = json.RawMessage(`{
+	"type": "boolean",
+	"description": "Return a compact response: unindented JSON without empty fields, raw model input, or unset parameters, to save tokens"
+}`)
+
+// formatProperty is the schema of the response_format argument every tool
+// accepts
+var formatProperty = json.RawMessage(`{
+	"type": "string",
+	"enum": ["json", "yaml", "markdown"],
+	"description": "Format of the response: json (default), yaml, or markdown, a readable summary with the video's path, model, prompt, and a thumbnail link for clients that show tool output as is"
+}`)
+
+// withProperty adds an argument to a tool's input schema
+func withProperty(schema json.RawMessage, name string, property json.RawMessage) json.RawMessage {
+	var parsed map[string]json.RawMessage
+	if err := json.Unmarshal(schema, &parsed); err != nil {
+		return schema
+	}
+	properties := map[string]json.RawMessage{}
+	if raw, ok := parsed["properties"]; ok {
+		if err := json.Unmarshal(raw, &properties); err != nil {
+			return schema
+		}
+	}
+	properties[name] = property
+	raw, err := json.Marshal(properties)
+	if err != nil {
+		return schema
+	}
+	parsed["properties"] = raw
+	data, err := json.Marshal(parsed)
+	if err != nil {
+		return schema
+	}
+	return data
 }
\ No newline at end of file
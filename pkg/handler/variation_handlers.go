@@ -0,0 +1,419 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/mcp/pkg/protocol"
+	"github.com/gomcpgo/replicate_video_ai/pkg/generation"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/responses"
+	"github.com/gomcpgo/replicate_video_ai/pkg/storage"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+const (
+	// maxVariations limits how many generations one variation set expands to
+	maxVariations = 24
+
+	// maxVariationStarts limits how many predictions a variation set creates
+	// at once
+	maxVariationStarts = 4
+
+	// variationRetryInterval is how often a variation queued behind the
+	// concurrency limit tries again
+	variationRetryInterval = 5 * time.Second
+
+	// variationSetRetention is how long a variation set's queue state is
+	// kept; afterwards its status is read from the storage index
+	variationSetRetention = 24 * time.Hour
+)
+
+// parameterSubstitutions are substitution keys that set a generation
+// parameter instead of filling a {placeholder} in the prompt
+var parameterSubstitutions = map[string]bool{
+	"camera_motion": true,
+	"aspect_ratio":  true,
+}
+
+// variation is one expanded prompt of a variation set
+type variation struct {
+	args   map[string]interface{}
+	params generation.VideoParams
+
+	mu     sync.Mutex
+	status string // queued, started, or error
+	target pollTarget
+	err    error
+}
+
+// variationSet tracks the variations of one generate_variations call while
+// they wait for concurrency slots
+type variationSet struct {
+	id         string
+	model      string
+	basePrompt string
+	created    time.Time
+	variations []*variation
+}
+
+// handleGenerateVariations expands a base prompt with substitution lists
+// and seed variations into several generations, grouped under a variation
+// set ID. Variations refused by the concurrency limit are queued and started
+// as slots free up. Called with only a variation_set_id, it reports that
+// set's generations instead.
+func (h *ReplicateVideoHandler) handleGenerateVariations(ctx context.Context, args map[string]interface{}) (*protocol.CallToolResponse, error) {
+	waitTime := waitTimeArg(args)
+
+	if setID, ok := args["variation_set_id"].(string); ok && setID != "" {
+		if _, ok := args["prompt"]; !ok {
+			return h.variationSetStatus(ctx, setID, waitTime)
+		}
+	}
+
+	basePrompt, _ := args["prompt"].(string)
+	if strings.TrimSpace(basePrompt) == "" {
+		return h.errorResponse("generate_variations", "invalid_parameters", "prompt parameter is required and must be a non-empty string", nil)
+	}
+	substitutions, err := substitutionsArg(args, basePrompt)
+	if err != nil {
+		return h.errorResponse("generate_variations", "invalid_parameters", err.Error(), nil)
+	}
+	count := 1
+	if c, ok := args["count"].(float64); ok {
+		count = int(c)
+		if count < 1 {
+			return h.errorResponse("generate_variations", "invalid_parameters", "count must be at least 1", nil)
+		}
+	}
+
+	combos := expandSubstitutions(substitutions)
+	if total := len(combos) * count; total > maxVariations {
+		return h.errorResponse("generate_variations", "invalid_parameters",
+			fmt.Sprintf("%d variations requested; at most %d are allowed per set", total, maxVariations), nil)
+	}
+	if len(combos)*count < 2 {
+		return h.errorResponse("generate_variations", "invalid_parameters", "give substitutions or a count above 1 to make variations", nil)
+	}
+
+	// Image-to-video when an image is given, text-to-video otherwise
+	tool, mode := "generate_video_from_text", "t2v"
+	if imagePath, _ := args["image_path"].(string); imagePath != "" {
+		tool, mode = "generate_video_from_image", "i2v"
+	}
+
+	// Resolve model "auto" once, so every variation uses the same model
+	baseArgs := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		switch key {
+		case "substitutions", "count", "wait_time", "variation_set_id":
+			continue
+		}
+		baseArgs[key] = value
+	}
+	if model, _ := args["model"].(string); model == generation.AutoModel {
+		model, err := h.autoModel(mode, args)
+		if err != nil {
+			return h.errorResponse("generate_variations", "invalid_parameters", err.Error(), nil)
+		}
+		baseArgs["model"] = model
+	}
+
+	// Seed variations get consecutive seeds from the given or a random base
+	var baseSeed *int
+	if seed, ok := args["seed"].(float64); ok {
+		value := int(seed)
+		baseSeed = &value
+	} else if count > 1 {
+		value := rand.Intn(1_000_000)
+		baseSeed = &value
+	}
+
+	set := &variationSet{
+		id:         "var-" + h.storage.GenerateStorageID(),
+		basePrompt: basePrompt,
+		created:    time.Now(),
+	}
+
+	// Validate every variation before starting any paid prediction
+	for _, combo := range combos {
+		for i := 0; i < count; i++ {
+			variationArgs := make(map[string]interface{}, len(baseArgs)+3)
+			for key, value := range baseArgs {
+				variationArgs[key] = value
+			}
+			prompt := basePrompt
+			for key, value := range combo {
+				if parameterSubstitutions[key] {
+					variationArgs[key] = value
+				} else {
+					prompt = strings.ReplaceAll(prompt, "{"+key+"}", value)
+				}
+			}
+			variationArgs["prompt"] = prompt
+			if baseSeed != nil {
+				variationArgs["seed"] = float64(*baseSeed + i)
+			}
+			variationArgs["variation_set_id"] = set.id // Never coalesced with another set
+
+			var params generation.VideoParams
+			if tool == "generate_video_from_text" {
+				params, err = h.extractTextToVideoParams(variationArgs)
+			} else {
+				params, err = h.extractImageToVideoParams(variationArgs)
+			}
+			if err != nil {
+				return h.errorResponse("generate_variations", "invalid_parameters", err.Error(), map[string]interface{}{
+					"prompt":        prompt,
+					"substitutions": combo,
+				})
+			}
+			params.VariationSetID = set.id
+			if len(combo) > 0 {
+				params.Variation = combo
+			}
+			set.model = params.Model
+			set.variations = append(set.variations, &variation{args: variationArgs, params: params, status: "queued"})
+		}
+	}
+
+	h.varMu.Lock()
+	for key, old := range h.variationSets {
+		if time.Since(old.created) > variationSetRetention {
+			delete(h.variationSets, key)
+		}
+	}
+	h.variationSets[pollKey(h.namespace, set.id)] = set
+	h.varMu.Unlock()
+
+	// Start the variations in the background, since queued ones may wait for
+	// other generations to finish; respond once each was tried once
+	var attempted sync.WaitGroup
+	sem := make(chan struct{}, maxVariationStarts)
+	for _, v := range set.variations {
+		attempted.Add(1)
+		go h.runVariation(tool, v, sem, &attempted)
+	}
+	attempted.Wait()
+
+	logging.Info("variation set started", "variation_set_id", set.id, "model", set.model, "variations", len(set.variations))
+	return h.successResponse(responses.BuildVariationSetResponse(set.id, set.model, set.basePrompt, h.variationEntries(ctx, set, waitTime)))
+}
+
+// runVariation creates a variation's prediction, retrying while the
+// concurrency limit refuses it. attempted is marked done after the first try.
+func (h *ReplicateVideoHandler) runVariation(tool string, v *variation, sem chan struct{}, attempted *sync.WaitGroup) {
+	first := true
+	markAttempted := func() {
+		if first {
+			first = false
+			attempted.Done()
+		}
+	}
+	defer markAttempted()
+
+	ctx := h.shutdownCtx
+	for {
+		sem <- struct{}{}
+		target, err := h.startGeneration(ctx, tool, v.args, func() (*generation.VideoResult, error) {
+			if tool == "generate_video_from_text" {
+				return h.generator.GenerateTextToVideo(ctx, v.params)
+			}
+			return h.generator.GenerateImageToVideo(ctx, v.params)
+		})
+		<-sem
+
+		var quotaErr *generation.QuotaError
+		if errors.As(err, &quotaErr) && quotaErr.Limit == generation.LimitConcurrent {
+			markAttempted() // Reported as queued
+			select {
+			case <-ctx.Done():
+				err = fmt.Errorf("server shut down before the variation could start: %w", err)
+			case <-time.After(variationRetryInterval):
+				continue
+			}
+		}
+
+		v.mu.Lock()
+		if err != nil {
+			v.status, v.err = "error", err
+		} else {
+			v.status, v.target = "started", target
+		}
+		v.mu.Unlock()
+		return
+	}
+}
+
+// variationSetStatus reports the variations of an earlier set, waiting up
+// to waitTime for those still running
+func (h *ReplicateVideoHandler) variationSetStatus(ctx context.Context, setID string, waitTime time.Duration) (*protocol.CallToolResponse, error) {
+	h.varMu.Lock()
+	set := h.variationSets[pollKey(h.namespace, setID)]
+	h.varMu.Unlock()
+	if set != nil {
+		return h.successResponse(responses.BuildVariationSetResponse(set.id, set.model, set.basePrompt, h.variationEntries(ctx, set, waitTime)))
+	}
+
+	// The queue state is gone (e.g. after a restart); report what was stored
+	records := h.storage.ListRecords(storage.Filter{VariationSet: setID})
+	if len(records) == 0 {
+		return h.errorResponse("generate_variations", "not_found", fmt.Sprintf("no generations found for variation set %s", setID), nil)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].String("created_at") < records[j].String("created_at")
+	})
+
+	set = &variationSet{id: setID}
+	set.model, _, _ = h.generator.ModelForStorage(records[0].StorageID)
+	for _, record := range records {
+		v := &variation{status: "started", target: pollTarget{
+			PredictionID: record.String("prediction_id"),
+			StorageID:    record.StorageID,
+		}}
+		v.params.Prompt = record.Parameter("prompt")
+		if params, ok := record.Metadata["parameters"].(map[string]interface{}); ok {
+			if seed, ok := params["seed"].(int); ok {
+				v.params.Seed = &seed
+			}
+		}
+		if combo, ok := record.Metadata["variation"].(map[string]interface{}); ok && len(combo) > 0 {
+			v.params.Variation = make(map[string]string, len(combo))
+			for key, value := range combo {
+				v.params.Variation[key] = fmt.Sprint(value)
+			}
+		}
+		set.variations = append(set.variations, v)
+	}
+	return h.successResponse(responses.BuildVariationSetResponse(set.id, set.model, "", h.variationEntries(ctx, set, waitTime)))
+}
+
+// variationEntries summarizes every variation of a set, waiting up to
+// waitTime for started ones that are still running
+func (h *ReplicateVideoHandler) variationEntries(ctx context.Context, set *variationSet, waitTime time.Duration) []types.VariationEntry {
+	entries := make([]types.VariationEntry, len(set.variations))
+	var wg sync.WaitGroup
+	for i, v := range set.variations {
+		v.mu.Lock()
+		status, target, err := v.status, v.target, v.err
+		v.mu.Unlock()
+
+		entries[i] = types.VariationEntry{
+			Index:         i + 1,
+			Prompt:        v.params.Prompt,
+			Substitutions: v.params.Variation,
+			Seed:          v.params.Seed,
+			Status:        status,
+		}
+		switch status {
+		case "error":
+			entries[i].Error = err.Error()
+			entries[i].ErrorType = generationErrorType(err)
+		case "started":
+			wg.Add(1)
+			go func(i int, target pollTarget) {
+				defer wg.Done()
+				poll := types.PredictionStatus{PredictionID: target.PredictionID, StorageID: target.StorageID}
+				if waitTime > 0 && target.PredictionID != "" {
+					if metadata, err := h.storage.LoadMetadata(target.StorageID); err == nil && isPendingStatus(fmt.Sprint(metadata["status"])) {
+						poll = h.pollPrediction(ctx, target.PredictionID, waitTime)
+					}
+				}
+				summary := h.comparisonEntry(target.StorageID, poll)
+				entries[i].Status = summary.Status
+				entries[i].StorageID = summary.StorageID
+				entries[i].PredictionID = summary.PredictionID
+				entries[i].Paths = summary.Paths
+				entries[i].URLs = summary.URLs
+				entries[i].Error = summary.Error
+				entries[i].ErrorType = summary.ErrorType
+			}(i, target)
+		}
+	}
+	wg.Wait()
+	return entries
+}
+
+// substitutionsArg reads the substitution lists, dropping blank and repeated
+// values. Every key must be a {placeholder} in the prompt or a parameter in
+// parameterSubstitutions.
+func substitutionsArg(args map[string]interface{}, prompt string) (map[string][]string, error) {
+	substitutions := make(map[string][]string)
+	raw, ok := args["substitutions"].(map[string]interface{})
+	if !ok {
+		return substitutions, nil
+	}
+	for key, value := range raw {
+		if !parameterSubstitutions[key] && !strings.Contains(prompt, "{"+key+"}") {
+			return nil, fmt.Errorf("substitution %q has no {%s} placeholder in the prompt", key, key)
+		}
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("substitution %q must be a list of strings", key)
+		}
+		seen := make(map[string]bool)
+		for _, item := range items {
+			s, _ := item.(string)
+			s = strings.TrimSpace(s)
+			if s == "" || seen[s] {
+				continue
+			}
+			seen[s] = true
+			substitutions[key] = append(substitutions[key], s)
+		}
+		if len(substitutions[key]) == 0 {
+			return nil, fmt.Errorf("substitution %q has no values", key)
+		}
+	}
+	return substitutions, nil
+}
+
+// expandSubstitutions returns every combination of the substitution values,
+// in a stable order
+func expandSubstitutions(substitutions map[string][]string) []map[string]string {
+	keys := make([]string, 0, len(substitutions))
+	for key := range substitutions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	combos := []map[string]string{{}}
+	for _, key := range keys {
+		var next []map[string]string
+		for _, combo := range combos {
+			for _, value := range substitutions[key] {
+				expanded := make(map[string]string, len(combo)+1)
+				for k, v := range combo {
+					expanded[k] = v
+				}
+				expanded[key] = value
+				next = append(next, expanded)
+			}
+		}
+		combos = next
+	}
+	return combos
+}
+
+// waitTimeArg reads the optional wait_time in seconds, clamped to the
+// allowed range; zero means don't wait
+func waitTimeArg(args map[string]interface{}) time.Duration {
+	wt, ok := args["wait_time"].(float64)
+	if !ok || wt <= 0 {
+		return 0
+	}
+	waitTime := time.Duration(wt) * time.Second
+	if waitTime < generation.MinContinueWait {
+		waitTime = generation.MinContinueWait
+	}
+	if waitTime > generation.MaxContinueWait() {
+		waitTime = generation.MaxContinueWait()
+	}
+	return waitTime
+}
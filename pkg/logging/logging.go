@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// Options configures the logging subsystem
+type Options struct {
+	Path       string // Log file path; empty disables file logging
+	Level      string // debug, info, warn, error
+	MaxSize    int64  // Rotate after this many bytes
+	MaxBackups int    // Number of rotated files to keep
+	Console    bool   // Also write logs to stderr (terminal mode)
+}
+
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	logger.Store(slog.New(slog.NewJSONHandler(io.Discard, nil)))
+}
+
+// Setup initializes structured JSON logging. Until Setup is called all log
+// output is discarded, so nothing ever reaches stdout in MCP mode.
+func Setup(opts Options) (io.Closer, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var writers []io.Writer
+	var closer io.Closer = nopCloser{}
+
+	if opts.Path != "" {
+		file, err := NewRotatingFile(opts.Path, opts.MaxSize, opts.MaxBackups)
+		if err != nil {
+			return nil, err
+		}
+		writers = append(writers, file)
+		closer = file
+	}
+	if opts.Console {
+		writers = append(writers, os.Stderr)
+	}
+	if len(writers) == 0 {
+		writers = append(writers, io.Discard)
+	}
+
+	handler := slog.NewJSONHandler(io.MultiWriter(writers...), &slog.HandlerOptions{Level: level})
+	logger.Store(slog.New(handler))
+
+	return closer, nil
+}
+
+// ParseLevel converts a level name to a slog.Level
+func ParseLevel(level string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "debug":
+		return slog.LevelDebug, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("invalid log level: %s", level)
+	}
+}
+
+// Logger returns the current structured logger
+func Logger() *slog.Logger {
+	return logger.Load()
+}
+
+// Enabled reports whether messages at level would be written
+func Enabled(level slog.Level) bool {
+	return Logger().Enabled(context.Background(), level)
+}
+
+// Debug logs a debug message with key/value attributes
+func Debug(msg string, args ...any) {
+	Logger().Debug(msg, args...)
+}
+
+// Info logs an informational message with key/value attributes
+func Info(msg string, args ...any) {
+	Logger().Info(msg, args...)
+}
+
+// Warn logs a warning with key/value attributes
+func Warn(msg string, args ...any) {
+	Logger().Warn(msg, args...)
+}
+
+// Error logs an error with key/value attributes
+func Error(msg string, args ...any) {
+	Logger().Error(msg, args...)
+}
+
+type nopCloser struct{}
+
+func (nopCloser) Close() error { return nil }
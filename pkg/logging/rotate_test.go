@@ -0,0 +1,36 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRotatingFileWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "server.log")
+	r, err := NewRotatingFile(path, 1<<20, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("first\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// As a rotation that couldn't reopen the file leaves it
+	r.file.Close()
+	r.file = nil
+	if _, err := r.Write([]byte("second\n")); err != nil {
+		t.Fatalf("Write() after a failed rotation error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "first\nsecond\n" {
+		t.Errorf("log = %q, want %q", data, "first\nsecond\n")
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Write([]byte("third\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write() after Close error = %v, want os.ErrClosed", err)
+	}
+}
@@ -0,0 +1,209 @@
+package moderation
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// ErrContentPolicy is wrapped by every prompt rejection
+var ErrContentPolicy = errors.New("prompt violates the content policy")
+
+// Violation explains why a prompt was rejected
+type Violation struct {
+	Source     string   // "blocklist" or "moderation_api"
+	Rule       string   // The blocklist entry that matched
+	Categories []string // Categories flagged by the moderation API
+}
+
+func (v *Violation) Error() string {
+	if v.Rule != "" {
+		return fmt.Sprintf("%s: matched blocklist entry %q", ErrContentPolicy, v.Rule)
+	}
+	if len(v.Categories) > 0 {
+		return fmt.Sprintf("%s: flagged by moderation (%s)", ErrContentPolicy, strings.Join(v.Categories, ", "))
+	}
+	return fmt.Sprintf("%s: flagged by moderation", ErrContentPolicy)
+}
+
+func (v *Violation) Unwrap() error {
+	return ErrContentPolicy
+}
+
+// rule is one blocklist entry
+type rule struct {
+	text    string
+	pattern *regexp.Regexp
+}
+
+// Checker screens prompts against a blocklist and, optionally, an external
+// moderation API. A nil Checker allows every prompt.
+type Checker struct {
+	rules      []rule
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewChecker creates a checker from the moderation settings. It returns nil
+// when no check is configured.
+func NewChecker(cfg config.ModerationConfig) (*Checker, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	c := &Checker{
+		apiURL:     cfg.APIURL,
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: cfg.Timeout},
+	}
+	if cfg.BlocklistFile != "" {
+		rules, err := loadBlocklist(cfg.BlocklistFile)
+		if err != nil {
+			return nil, err
+		}
+		c.rules = rules
+	}
+
+	logging.Info("prompt moderation enabled", "blocklist_entries", len(c.rules), "moderation_api", c.apiURL != "")
+	return c, nil
+}
+
+// loadBlocklist reads one entry per line. Lines starting with "re:" are
+// regular expressions; other lines are phrases matched as whole words.
+// Matching is case-insensitive, and blank lines and # comments are skipped.
+func loadBlocklist(path string) ([]rule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open blocklist: %w", err)
+	}
+	defer file.Close()
+
+	var rules []rule
+	scanner := bufio.NewScanner(file)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		expr := `\b` + regexp.QuoteMeta(line) + `\b`
+		if strings.HasPrefix(line, "re:") {
+			expr = strings.TrimSpace(strings.TrimPrefix(line, "re:"))
+		}
+		pattern, err := regexp.Compile("(?i)" + expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid blocklist entry on line %d: %w", lineNum, err)
+		}
+		rules = append(rules, rule{text: line, pattern: pattern})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read blocklist: %w", err)
+	}
+	return rules, nil
+}
+
+// Check returns a *Violation if the prompt is disallowed. The blocklist is
+// checked first so obvious cases never reach the moderation API. If the API
+// cannot be reached the prompt is rejected with a plain error, since the
+// check was explicitly requested.
+func (c *Checker) Check(ctx context.Context, prompt string) error {
+	if c == nil || strings.TrimSpace(prompt) == "" {
+		return nil
+	}
+
+	for _, r := range c.rules {
+		if r.pattern.MatchString(prompt) {
+			logging.Info("prompt rejected by blocklist", "rule", r.text)
+			return &Violation{Source: "blocklist", Rule: r.text}
+		}
+	}
+
+	if c.apiURL == "" {
+		return nil
+	}
+	categories, flagged, err := c.moderate(ctx, prompt)
+	if err != nil {
+		return fmt.Errorf("prompt moderation failed: %w", err)
+	}
+	if flagged {
+		logging.Info("prompt rejected by moderation API", "categories", categories)
+		return &Violation{Source: "moderation_api", Categories: categories}
+	}
+	return nil
+}
+
+// moderationResponse is the response of an OpenAI-compatible moderation API
+type moderationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// moderate sends the prompt to the moderation API and returns the flagged
+// categories
+func (c *Checker) moderate(ctx context.Context, prompt string) ([]string, bool, error) {
+	body, err := json.Marshal(map[string]string{"input": prompt})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("moderation API error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+
+	var result moderationResponse
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, false, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	var categories []string
+	flagged := false
+	for _, r := range result.Results {
+		if !r.Flagged {
+			continue
+		}
+		flagged = true
+		for category, hit := range r.Categories {
+			if hit {
+				categories = append(categories, category)
+			}
+		}
+	}
+	sort.Strings(categories)
+	return categories, flagged, nil
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/shell"
+)
+
+// SlackNotifier posts events to a Slack incoming webhook
+type SlackNotifier struct {
+	webhookURL string
+}
+
+// NewSlackNotifier creates a Slack webhook notifier
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{webhookURL: webhookURL}
+}
+
+// Name returns the notifier name
+func (n *SlackNotifier) Name() string { return "slack" }
+
+// Notify posts a short text summary of the event
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	text := fmt.Sprintf("*Replicate Video AI* – %s", event.Message)
+	if event.Path != "" {
+		text += fmt.Sprintf("\n`%s`", event.Path)
+	}
+	if event.Error != "" {
+		text += fmt.Sprintf("\nError: %s", event.Error)
+	}
+	return postJSON(ctx, n.webhookURL, nil, map[string]string{"text": text})
+}
+
+// WebhookNotifier posts the full event as JSON to an arbitrary URL
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+}
+
+// NewWebhookNotifier creates a generic webhook notifier
+func NewWebhookNotifier(url string, headers map[string]string) *WebhookNotifier {
+	return &WebhookNotifier{url: url, headers: headers}
+}
+
+// Name returns the notifier name
+func (n *WebhookNotifier) Name() string { return "webhook" }
+
+// Notify posts the event JSON
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	return postJSON(ctx, n.url, n.headers, event)
+}
+
+// CommandNotifier runs a shell command with the event as JSON on stdin and
+// key fields in REPLICATE_EVENT_* environment variables
+type CommandNotifier struct {
+	command string
+}
+
+// NewCommandNotifier creates a shell command notifier
+func NewCommandNotifier(command string) *CommandNotifier {
+	return &CommandNotifier{command: command}
+}
+
+// Name returns the notifier name
+func (n *CommandNotifier) Name() string { return "command" }
+
+// Notify runs the command
+func (n *CommandNotifier) Notify(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	_, err = shell.Run(ctx, n.command, bytes.NewReader(data),
+		"REPLICATE_EVENT_TYPE="+event.Type,
+		"REPLICATE_EVENT_STORAGE_ID="+event.StorageID,
+		"REPLICATE_EVENT_PREDICTION_ID="+event.PredictionID,
+		"REPLICATE_EVENT_PATH="+event.Path,
+		"REPLICATE_EVENT_MESSAGE="+event.Message,
+		"REPLICATE_EVENT_ERROR="+event.Error,
+	)
+	if err != nil {
+		return fmt.Errorf("command failed: %w", err)
+	}
+	return nil
+}
+
+// DesktopNotifier shows a native desktop notification
+type DesktopNotifier struct{}
+
+// NewDesktopNotifier creates a desktop notifier
+func NewDesktopNotifier() *DesktopNotifier {
+	return &DesktopNotifier{}
+}
+
+// Name returns the notifier name
+func (n *DesktopNotifier) Name() string { return "desktop" }
+
+// Notify shows the notification using the platform's native tool
+func (n *DesktopNotifier) Notify(ctx context.Context, event Event) error {
+	title := "Replicate Video AI"
+	message := event.Message
+
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.CommandContext(ctx, "osascript", "-e", script)
+	case "linux":
+		cmd = exec.CommandContext(ctx, "notify-send", title, message)
+	case "windows":
+		script := fmt.Sprintf("New-BurntToastNotification -Text '%s', '%s'",
+			strings.ReplaceAll(title, "'", "''"), strings.ReplaceAll(message, "'", "''"))
+		cmd = exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script)
+	default:
+		return fmt.Errorf("desktop notifications not supported on %s", runtime.GOOS)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("desktop notification failed: %w, output: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// postJSON sends a JSON body and treats any non-2xx status as an error
+func postJSON(ctx context.Context, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook error (status %d): %s", resp.StatusCode, string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package notify
+
+import (
+	"context"
+)
+
+// mcpLogger names this server in the log notifications it sends
+const mcpLogger = "replicate-video-ai"
+
+// NotificationSender sends server-initiated notifications to the connected
+// MCP client. MCP servers whose transport supports them implement it.
+type NotificationSender interface {
+	SendNotification(method string, params interface{}) error
+}
+
+// MCPNotifier forwards events to the MCP client as notifications/message
+// log notifications, with the event as the data, so the client can follow
+// generations without polling continue_operation
+type MCPNotifier struct {
+	sender NotificationSender
+}
+
+// NewMCPNotifier creates a notifier that sends events to the MCP client
+func NewMCPNotifier(sender NotificationSender) *MCPNotifier {
+	return &MCPNotifier{sender: sender}
+}
+
+// Name returns the notifier name
+func (n *MCPNotifier) Name() string { return "mcp" }
+
+// Notify sends the event, logged at error level for failures and warning
+// level for budget warnings
+func (n *MCPNotifier) Notify(ctx context.Context, event Event) error {
+	level := "info"
+	switch event.Type {
+	case EventFailed:
+		level = "error"
+	case EventBudgetWarning:
+		level = "warning"
+	}
+	return n.sender.SendNotification("notifications/message", map[string]interface{}{
+		"level":  level,
+		"logger": mcpLogger,
+		"data":   event,
+	})
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// Event types that notifiers can subscribe to
+const (
+	EventCompleted     = "completed"
+	EventFailed        = "failed"
+	EventBudgetWarning = "budget_warning"
+	EventProgress      = "progress" // A running generation changed status or logged progress
+)
+
+// eventTypes lists every event type, in the order they're documented
+var eventTypes = []string{EventCompleted, EventFailed, EventBudgetWarning, EventProgress}
+
+// Event describes something worth telling the user about
+type Event struct {
+	Type         string                 `json:"type"`
+	StorageID    string                 `json:"storage_id,omitempty"`
+	PredictionID string                 `json:"prediction_id,omitempty"`
+	Model        string                 `json:"model,omitempty"`
+	Prompt       string                 `json:"prompt,omitempty"`
+	Path         string                 `json:"path,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	Message      string                 `json:"message"`
+	Timestamp    time.Time              `json:"timestamp"`
+	Details      map[string]interface{} `json:"details,omitempty"`
+}
+
+// Notifier delivers events to a single channel
+type Notifier interface {
+	Name() string
+	Notify(ctx context.Context, event Event) error
+}
+
+// Dispatcher routes events to the notifiers configured for their type
+type Dispatcher struct {
+	mu        sync.RWMutex
+	notifiers map[string][]Notifier
+	timeout   time.Duration
+	wg        sync.WaitGroup
+}
+
+// NewDispatcher creates an empty dispatcher
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		notifiers: make(map[string][]Notifier),
+		timeout:   15 * time.Second,
+	}
+}
+
+// NewDispatcherFromConfig builds a dispatcher from per-event channel settings
+func NewDispatcherFromConfig(cfg config.NotificationsConfig) (*Dispatcher, error) {
+	d := NewDispatcher()
+	for eventType, channels := range cfg {
+		if !knownEvent(eventType) {
+			return nil, fmt.Errorf("unknown notification event %q (known: %s)", eventType, strings.Join(eventTypes, ", "))
+		}
+		for _, channel := range channels {
+			n, err := NewNotifier(channel)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s notifier: %w", eventType, err)
+			}
+			d.Register(eventType, n)
+		}
+	}
+	return d, nil
+}
+
+// knownEvent reports whether eventType is one of the event types
+func knownEvent(eventType string) bool {
+	for _, known := range eventTypes {
+		if eventType == known {
+			return true
+		}
+	}
+	return false
+}
+
+// NewNotifier creates a notifier for a channel configuration
+func NewNotifier(channel config.NotifierConfig) (Notifier, error) {
+	switch channel.Type {
+	case "slack":
+		if channel.URL == "" {
+			return nil, fmt.Errorf("slack notifier requires url")
+		}
+		return NewSlackNotifier(channel.URL), nil
+	case "webhook":
+		if channel.URL == "" {
+			return nil, fmt.Errorf("webhook notifier requires url")
+		}
+		return NewWebhookNotifier(channel.URL, channel.Headers), nil
+	case "command":
+		if channel.Command == "" {
+			return nil, fmt.Errorf("command notifier requires command")
+		}
+		return NewCommandNotifier(channel.Command), nil
+	case "desktop":
+		return NewDesktopNotifier(), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier type: %s", channel.Type)
+	}
+}
+
+// RegisterAll subscribes a notifier to every event type
+func (d *Dispatcher) RegisterAll(n Notifier) {
+	for _, eventType := range eventTypes {
+		d.Register(eventType, n)
+	}
+}
+
+// Register subscribes a notifier to an event type
+func (d *Dispatcher) Register(eventType string, n Notifier) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.notifiers[eventType] = append(d.notifiers[eventType], n)
+}
+
+// HasNotifiers reports whether any notifier is subscribed to eventType
+func (d *Dispatcher) HasNotifiers(eventType string) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return len(d.notifiers[eventType]) > 0
+}
+
+// Events returns the event types that have at least one notifier
+func (d *Dispatcher) Events() []string {
+	if d == nil {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	events := make([]string, 0, len(d.notifiers))
+	for eventType, notifiers := range d.notifiers {
+		if len(notifiers) > 0 {
+			events = append(events, eventType)
+		}
+	}
+	sort.Strings(events)
+	return events
+}
+
+// Dispatch delivers an event to its notifiers in the background. Delivery
+// failures are logged and never affect the caller.
+func (d *Dispatcher) Dispatch(event Event) {
+	if d == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	d.mu.RLock()
+	notifiers := append([]Notifier(nil), d.notifiers[event.Type]...)
+	d.mu.RUnlock()
+
+	for _, n := range notifiers {
+		d.wg.Add(1)
+		go func(n Notifier) {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			defer cancel()
+			if err := n.Notify(ctx, event); err != nil {
+				logging.Warn("notification failed", "notifier", n.Name(), "event", event.Type, "error", err)
+			}
+		}(n)
+	}
+}
+
+// Wait blocks until all in-flight notifications have been delivered
+func (d *Dispatcher) Wait() {
+	if d == nil {
+		return
+	}
+	d.wg.Wait()
+}
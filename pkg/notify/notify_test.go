@@ -0,0 +1,39 @@
+package notify
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/config"
+)
+
+func TestNewDispatcherFromConfig(t *testing.T) {
+	desktop := []config.NotifierConfig{{Type: "desktop"}}
+
+	d, err := NewDispatcherFromConfig(config.NotificationsConfig{EventCompleted: desktop, EventProgress: desktop})
+	if err != nil {
+		t.Fatalf("NewDispatcherFromConfig() error = %v", err)
+	}
+	if got, want := d.Events(), []string{EventCompleted, EventProgress}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Events() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		name    string
+		cfg     config.NotificationsConfig
+		wantErr string
+	}{
+		{"misspelled event", config.NotificationsConfig{"complete": desktop}, `unknown notification event "complete"`},
+		{"unknown channel", config.NotificationsConfig{EventFailed: {{Type: "email"}}}, "invalid failed notifier: unknown notifier type: email"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewDispatcherFromConfig(tt.cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("NewDispatcherFromConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
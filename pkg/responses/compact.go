@@ -0,0 +1,100 @@
+package responses
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxEchoedString caps string parameter values echoed back in responses
+const maxEchoedString = 2000
+
+// Sanitize copies a parameter value, replacing data URLs (such as input
+// images sent inline) with their media type and size, and truncating
+// strings longer than maxLen
+func Sanitize(value interface{}, maxLen int) interface{} {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, "data:") {
+			mediaType, _, _ := strings.Cut(strings.TrimPrefix(v, "data:"), ";")
+			return fmt.Sprintf("<%s data, %d bytes>", mediaType, len(v))
+		}
+		if len(v) > maxLen {
+			return v[:maxLen] + "…"
+		}
+		return v
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = Sanitize(item, maxLen)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = Sanitize(item, maxLen)
+		}
+		return copied
+	default:
+		return v
+	}
+}
+
+// sanitizeParameters prepares generation parameters to be echoed in a
+// response
+func sanitizeParameters(parameters map[string]interface{}) map[string]interface{} {
+	if parameters == nil {
+		return nil
+	}
+	sanitized, _ := Sanitize(parameters, maxEchoedString).(map[string]interface{})
+	return sanitized
+}
+
+// Compact shrinks a JSON response for token-constrained clients: no
+// indentation, no empty values, no raw model input, and no parameters left
+// at their zero value. Text that isn't a JSON object is returned unchanged.
+func Compact(text string) string {
+	response, err := decodeObject(text)
+	if err != nil {
+		return text
+	}
+	compactValue(response, false)
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(response); err != nil {
+		return text
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// compactValue removes what Compact leaves out from a decoded value in
+// place, reporting whether anything is left of it. Zero numbers and false
+// are dropped only within parameters, where they mean "not set".
+func compactValue(value interface{}, inParameters bool) bool {
+	switch v := value.(type) {
+	case nil:
+		return false
+	case string:
+		return v != ""
+	case json.Number:
+		return !inParameters || v.String() != "0"
+	case bool:
+		return !inParameters || v
+	case map[string]interface{}:
+		for key, item := range v {
+			if key == "raw_input" || !compactValue(item, inParameters || key == "parameters") {
+				delete(v, key)
+			}
+		}
+		return len(v) > 0
+	case []interface{}:
+		for _, item := range v {
+			compactValue(item, inParameters)
+		}
+		return len(v) > 0
+	}
+	return true
+}
@@ -0,0 +1,28 @@
+package responses
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Structured decodes a JSON response for an MCP client's structured
+// content, which carries the same object as the text. It reports false for
+// text that isn't a JSON object, which is then returned as text only.
+func Structured(text string) (map[string]interface{}, bool) {
+	response, err := decodeObject(text)
+	if err != nil || response == nil {
+		return nil, false
+	}
+	return response, true
+}
+
+// decodeObject decodes a JSON object, keeping numbers exactly as written
+func decodeObject(text string) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+	var response map[string]interface{}
+	if err := decoder.Decode(&response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
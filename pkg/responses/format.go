@@ -0,0 +1,285 @@
+package responses
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Response formats a tool call can ask for with response_format
+const (
+	FormatJSON     = "json"
+	FormatYAML     = "yaml"
+	FormatMarkdown = "markdown"
+)
+
+// ValidFormat reports whether f is a response format
+func ValidFormat(f string) bool {
+	return f == FormatJSON || f == FormatYAML || f == FormatMarkdown
+}
+
+// Format renders a JSON response in a response format: unchanged for json,
+// the same fields for yaml, and a summary for chat clients that show tool
+// output verbatim for markdown. Text that isn't a JSON object is returned
+// unchanged.
+func Format(text, format string) string {
+	switch format {
+	case FormatYAML:
+		return formatYAML(text)
+	case FormatMarkdown:
+		return formatMarkdown(text)
+	}
+	return text
+}
+
+// formatYAML converts a JSON response to block-style YAML, keeping the
+// order of its fields
+func formatYAML(text string) string {
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(text), &node); err != nil || len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+		return text
+	}
+	blockStyle(&node)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return text
+	}
+	if err := encoder.Close(); err != nil {
+		return text
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// blockStyle drops the JSON flow style and quoting from a YAML tree; the
+// encoder quotes strings again where they would read as another type
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// markdownSkipped are the fields the markdown summary leaves out or shows
+// in its header
+var markdownSkipped = map[string]bool{
+	"success": true, "schema_version": true, "operation": true, "status": true, "message": true,
+	"paths": true, "urls": true, "model": true, "storage_id": true, "prediction_id": true,
+	"warnings": true, "raw_input": true,
+}
+
+// formatMarkdown summarizes a JSON response: the outcome, the video with
+// its model, prompt, and thumbnail, then the remaining fields as a list.
+// What Compact leaves out is left out here too.
+func formatMarkdown(text string) string {
+	response, err := decodeObject(text)
+	if err != nil || response == nil {
+		return text
+	}
+	compactValue(response, false)
+	operation := markdownString(response["operation"])
+
+	var b strings.Builder
+	if failure, ok := response["error"].(map[string]interface{}); ok && response["success"] == false {
+		fmt.Fprintf(&b, "**%s** (%s): %s\n", message("markdown_failed", operation), markdownString(failure["type"]), markdownString(failure["message"]))
+		if details, ok := failure["details"].(map[string]interface{}); ok && len(details) > 0 {
+			b.WriteString("\n")
+			writeMarkdownFields(&b, details, 0)
+		}
+		return strings.TrimSuffix(b.String(), "\n")
+	}
+
+	fmt.Fprintf(&b, "**%s**", operation)
+	if status := markdownString(response["status"]); status != "" {
+		fmt.Fprintf(&b, ": %s", status)
+	}
+	b.WriteString("\n")
+	if message := markdownString(response["message"]); message != "" {
+		fmt.Fprintf(&b, "\n%s\n", message)
+	}
+
+	paths, _ := response["paths"].(map[string]interface{})
+	urls, _ := response["urls"].(map[string]interface{})
+	parameters, _ := response["parameters"].(map[string]interface{})
+	var lines []string
+	if video := markdownString(paths["output"]); video != "" {
+		line := fmt.Sprintf("- **%s:** `%s`", message("markdown_video"), video)
+		if link := markdownString(urls["output"]); link != "" {
+			line += fmt.Sprintf(" ([%s](%s))", message("markdown_open"), link)
+		}
+		lines = append(lines, line)
+	}
+	if model, ok := response["model"].(map[string]interface{}); ok {
+		for _, key := range []string{"name", "alias", "id"} {
+			if name := markdownString(model[key]); name != "" {
+				lines = append(lines, fmt.Sprintf("- **%s:** %s", message("markdown_model"), name))
+				break
+			}
+		}
+	}
+	if prompt := markdownString(parameters["prompt"]); prompt != "" {
+		lines = append(lines, fmt.Sprintf("- **%s:** %s", message("markdown_prompt"), prompt))
+	}
+	if thumbnail := markdownString(paths["thumbnail"]); thumbnail != "" {
+		lines = append(lines, fmt.Sprintf("- **%s:** [%s](%s)", message("markdown_thumbnail"), filepath.Base(thumbnail), fileLink(thumbnail, markdownString(urls["thumbnail"]))))
+	}
+	for _, key := range []string{"storage_id", "prediction_id"} {
+		if id := markdownString(response[key]); id != "" {
+			lines = append(lines, fmt.Sprintf("- **%s:** `%s`", markdownLabel(key), id))
+		}
+	}
+	if len(lines) > 0 {
+		b.WriteString("\n" + strings.Join(lines, "\n") + "\n")
+	}
+
+	if warnings, ok := response["warnings"].([]interface{}); ok && len(warnings) > 0 {
+		fmt.Fprintf(&b, "\n**%s:**\n", message("markdown_warnings"))
+		for _, warning := range warnings {
+			fmt.Fprintf(&b, "- %s\n", markdownString(warning))
+		}
+	}
+
+	// The other parameters, the prompt having been shown
+	rest := make(map[string]interface{})
+	for key, value := range response {
+		if !markdownSkipped[key] {
+			rest[key] = value
+		}
+	}
+	if parameters != nil {
+		others := make(map[string]interface{}, len(parameters))
+		for key, value := range parameters {
+			if key != "prompt" {
+				others[key] = value
+			}
+		}
+		rest["parameters"] = others
+	}
+	if len(rest) > 0 {
+		b.WriteString("\n")
+		writeMarkdownFields(&b, rest, 0)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// writeMarkdownFields writes an object's fields as a list, sorted by name,
+// nesting objects and arrays of objects two levels deep
+func writeMarkdownFields(b *strings.Builder, fields map[string]interface{}, depth int) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	indent := strings.Repeat("  ", depth)
+	for _, key := range keys {
+		label := markdownLabel(key)
+		switch value := fields[key].(type) {
+		case map[string]interface{}:
+			if len(value) == 0 {
+				continue
+			}
+			if depth >= 2 {
+				fmt.Fprintf(b, "%s- **%s:** %s\n", indent, label, markdownString(value))
+				continue
+			}
+			fmt.Fprintf(b, "%s- **%s:**\n", indent, label)
+			writeMarkdownFields(b, value, depth+1)
+		case []interface{}:
+			if len(value) == 0 {
+				continue
+			}
+			if _, ok := value[0].(map[string]interface{}); !ok || depth >= 2 {
+				fmt.Fprintf(b, "%s- **%s:** %s\n", indent, label, markdownString(value))
+				continue
+			}
+			fmt.Fprintf(b, "%s- **%s:** %d\n", indent, label, len(value))
+			for i, item := range value {
+				object, ok := item.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				fmt.Fprintf(b, "%s  - **%d.**\n", indent, i+1)
+				writeMarkdownFields(b, object, depth+2)
+			}
+		default:
+			if s := markdownString(value); s != "" {
+				fmt.Fprintf(b, "%s- **%s:** %s\n", indent, label, s)
+			}
+		}
+	}
+}
+
+// markdownLabel turns a field name such as prediction_id into a label
+// such as Prediction ID
+func markdownLabel(key string) string {
+	label := strings.ReplaceAll(key, "_", " ")
+	if strings.HasSuffix(label, " id") || label == "id" {
+		label = strings.TrimSuffix(label, "id") + "ID"
+	}
+	if label == "" {
+		return label
+	}
+	return strings.ToUpper(label[:1]) + label[1:]
+}
+
+// markdownString renders a decoded value on one line: strings as they are,
+// lists and objects of strings comma-separated, and anything else as JSON
+func markdownString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return fmt.Sprintf("%t", v)
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			s, ok := item.(string)
+			if !ok {
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+			items[i] = s
+		}
+		return strings.Join(items, ", ")
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		items := make([]string, len(keys))
+		for i, key := range keys {
+			s, ok := v[key].(string)
+			if !ok {
+				data, _ := json.Marshal(v)
+				return string(data)
+			}
+			items[i] = key + ": " + s
+		}
+		return strings.Join(items, ", ")
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// fileLink links a file by its HTTP URL, when the file server gives it one,
+// or else by file URL
+func fileLink(path, httpURL string) string {
+	if httpURL != "" {
+		return httpURL
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
@@ -0,0 +1,220 @@
+package responses
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultLocale is the locale of the built-in messages, used for any message
+// a locale's catalog lacks
+const DefaultLocale = "en"
+
+// catalogs holds the built-in response messages by locale. Messages are fmt
+// formats whose arguments are numbered, so a translation can reorder them;
+// it should use every argument. Only the human-readable message text is
+// translated: field names, statuses, and error types stay the same in every
+// locale.
+var catalogs = map[string]map[string]string{
+	"en": {
+		"generation_in_progress":          "Video generation in progress. Use continue_operation to check status.",
+		"generation_in_progress_estimate": "Video generation in progress (about %[1]ds remaining). This model usually needs %[2]d continue_operation calls; use continue_operation to check status.",
+		"generation_still_running":        "Video generation still %[1]s after %[2]ds. The server keeps polling in the background and saves the video when it finishes; use continue_operation to get the result.",
+		"batch_pending":                   "%[1]d of %[2]d videos still processing. Use continue_operation again to check the rest.",
+		"comparison_pending":              "%[1]d of %[2]d videos still processing. Call compare_models with comparison_id %[3]s to check again.",
+		"variations_pending":              "%[1]d of %[2]d variations still queued or processing. Call generate_variations with variation_set_id %[3]s to check again.",
+		"storyboard_generating":           "%[1]d of %[2]d scenes completed. Call generate_storyboard with storyboard_id %[3]s to check again.",
+		"storyboard_failed":               "%[1]d of %[2]d scenes failed; regenerate them and join the clips yourself, or start a new storyboard.",
+		"storyboard_join_failed":          "Every scene completed, but joining them failed: %[1]s",
+		"storyboard_assembling":           "Every scene completed; joining them. Call generate_storyboard with storyboard_id %[1]s to check again.",
+		"queued":                          "Generation queued at position %[1]d; it starts automatically when a slot frees up. Use continue_operation with this storage_id, or queue_status, to follow it.",
+		"queued_scheduled":                "Generation scheduled to start after %[1]s, once a slot is free. Use continue_operation with this storage_id, or queue_status, to follow it.",
+		"session_generations_one":         "%[1]d generation",
+		"session_generations_other":       "%[1]d generations",
+		"session_summary":                 "%[1]s since %[2]s: %[3]d completed, %[4]d failed.",
+		"session_summary_running":         "%[1]s since %[2]s: %[3]d completed, %[4]d failed, %[5]d still running or queued.",
+		"session_models":                  "Models: %[1]s.",
+		"session_cost":                    "About $%.2[1]f estimated and %[2]s of generation time.",
+		"markdown_failed":                 "%[1]s failed",
+		"markdown_video":                  "Video",
+		"markdown_model":                  "Model",
+		"markdown_prompt":                 "Prompt",
+		"markdown_thumbnail":              "Thumbnail",
+		"markdown_warnings":               "Warnings",
+		"markdown_open":                   "open",
+	},
+	"es": {
+		"generation_in_progress":          "Generación de video en curso. Usa continue_operation para consultar el estado.",
+		"generation_in_progress_estimate": "Generación de video en curso (quedan unos %[1]ds). Este modelo suele necesitar %[2]d llamadas a continue_operation; usa continue_operation para consultar el estado.",
+		"generation_still_running":        "La generación de video sigue en %[1]s tras %[2]ds. El servidor sigue consultando en segundo plano y guarda el video cuando termina; usa continue_operation para obtener el resultado.",
+		"batch_pending":                   "%[1]d de %[2]d videos siguen en proceso. Vuelve a usar continue_operation para consultar el resto.",
+		"comparison_pending":              "%[1]d de %[2]d videos siguen en proceso. Llama a compare_models con comparison_id %[3]s para volver a consultar.",
+		"variations_pending":              "%[1]d de %[2]d variaciones siguen en cola o en proceso. Llama a generate_variations con variation_set_id %[3]s para volver a consultar.",
+		"storyboard_generating":           "%[1]d de %[2]d escenas completadas. Llama a generate_storyboard con storyboard_id %[3]s para volver a consultar.",
+		"storyboard_failed":               "%[1]d de %[2]d escenas fallaron; vuelve a generarlas y une los clips tú mismo, o empieza un nuevo storyboard.",
+		"storyboard_join_failed":          "Todas las escenas se completaron, pero no se pudieron unir: %[1]s",
+		"storyboard_assembling":           "Todas las escenas se completaron; uniéndolas. Llama a generate_storyboard con storyboard_id %[1]s para volver a consultar.",
+		"queued":                          "Generación en cola en la posición %[1]d; empieza automáticamente cuando quede un hueco libre. Usa continue_operation con este storage_id, o queue_status, para seguirla.",
+		"queued_scheduled":                "Generación programada para empezar después de %[1]s, cuando haya un hueco libre. Usa continue_operation con este storage_id, o queue_status, para seguirla.",
+		"session_generations_one":         "%[1]d generación",
+		"session_generations_other":       "%[1]d generaciones",
+		"session_summary":                 "%[1]s desde %[2]s: %[3]d completadas, %[4]d fallidas.",
+		"session_summary_running":         "%[1]s desde %[2]s: %[3]d completadas, %[4]d fallidas, %[5]d aún en curso o en cola.",
+		"session_models":                  "Modelos: %[1]s.",
+		"session_cost":                    "Unos $%.2[1]f estimados y %[2]s de tiempo de generación.",
+		"markdown_failed":                 "%[1]s falló",
+		"markdown_video":                  "Video",
+		"markdown_model":                  "Modelo",
+		"markdown_prompt":                 "Prompt",
+		"markdown_thumbnail":              "Miniatura",
+		"markdown_warnings":               "Advertencias",
+		"markdown_open":                   "abrir",
+	},
+	"de": {
+		"generation_in_progress":          "Videogenerierung läuft. Den Status mit continue_operation abfragen.",
+		"generation_in_progress_estimate": "Videogenerierung läuft (noch etwa %[1]ds). Dieses Modell braucht meist %[2]d Aufrufe von continue_operation; den Status mit continue_operation abfragen.",
+		"generation_still_running":        "Videogenerierung nach %[2]ds noch im Status %[1]s. Der Server fragt im Hintergrund weiter ab und speichert das Video, sobald es fertig ist; das Ergebnis mit continue_operation abrufen.",
+		"batch_pending":                   "%[1]d von %[2]d Videos werden noch verarbeitet. continue_operation erneut aufrufen, um den Rest abzufragen.",
+		"comparison_pending":              "%[1]d von %[2]d Videos werden noch verarbeitet. compare_models mit comparison_id %[3]s erneut aufrufen.",
+		"variations_pending":              "%[1]d von %[2]d Varianten warten noch oder werden verarbeitet. generate_variations mit variation_set_id %[3]s erneut aufrufen.",
+		"storyboard_generating":           "%[1]d von %[2]d Szenen fertig. generate_storyboard mit storyboard_id %[3]s erneut aufrufen.",
+		"storyboard_failed":               "%[1]d von %[2]d Szenen sind fehlgeschlagen; sie neu generieren und die Clips selbst zusammenfügen, oder ein neues Storyboard beginnen.",
+		"storyboard_join_failed":          "Alle Szenen sind fertig, aber das Zusammenfügen ist fehlgeschlagen: %[1]s",
+		"storyboard_assembling":           "Alle Szenen sind fertig und werden zusammengefügt. generate_storyboard mit storyboard_id %[1]s erneut aufrufen.",
+		"queued":                          "Generierung an Position %[1]d der Warteschlange; sie startet automatisch, sobald ein Platz frei wird. Mit continue_operation und dieser storage_id oder mit queue_status verfolgen.",
+		"queued_scheduled":                "Generierung startet frühestens %[1]s, sobald ein Platz frei ist. Mit continue_operation und dieser storage_id oder mit queue_status verfolgen.",
+		"session_generations_one":         "%[1]d Generierung",
+		"session_generations_other":       "%[1]d Generierungen",
+		"session_summary":                 "%[1]s seit %[2]s: %[3]d fertig, %[4]d fehlgeschlagen.",
+		"session_summary_running":         "%[1]s seit %[2]s: %[3]d fertig, %[4]d fehlgeschlagen, %[5]d laufen noch oder warten.",
+		"session_models":                  "Modelle: %[1]s.",
+		"session_cost":                    "Geschätzt etwa $%.2[1]f und %[2]s Generierungszeit.",
+		"markdown_failed":                 "%[1]s fehlgeschlagen",
+		"markdown_video":                  "Video",
+		"markdown_model":                  "Modell",
+		"markdown_prompt":                 "Prompt",
+		"markdown_thumbnail":              "Vorschaubild",
+		"markdown_warnings":               "Warnungen",
+		"markdown_open":                   "öffnen",
+	},
+	"fr": {
+		"generation_in_progress":          "Génération de la vidéo en cours. Utilisez continue_operation pour vérifier l'état.",
+		"generation_in_progress_estimate": "Génération de la vidéo en cours (environ %[1]ds restantes). Ce modèle nécessite généralement %[2]d appels à continue_operation ; utilisez continue_operation pour vérifier l'état.",
+		"generation_still_running":        "Génération de la vidéo toujours à l'état %[1]s après %[2]ds. Le serveur continue de vérifier en arrière-plan et enregistre la vidéo dès qu'elle est terminée ; utilisez continue_operation pour obtenir le résultat.",
+		"batch_pending":                   "%[1]d vidéos sur %[2]d encore en cours. Utilisez de nouveau continue_operation pour vérifier les autres.",
+		"comparison_pending":              "%[1]d vidéos sur %[2]d encore en cours. Appelez compare_models avec comparison_id %[3]s pour vérifier de nouveau.",
+		"variations_pending":              "%[1]d variantes sur %[2]d encore en file d'attente ou en cours. Appelez generate_variations avec variation_set_id %[3]s pour vérifier de nouveau.",
+		"storyboard_generating":           "%[1]d scènes sur %[2]d terminées. Appelez generate_storyboard avec storyboard_id %[3]s pour vérifier de nouveau.",
+		"storyboard_failed":               "%[1]d scènes sur %[2]d ont échoué ; régénérez-les et assemblez les clips vous-même, ou commencez un nouveau storyboard.",
+		"storyboard_join_failed":          "Toutes les scènes sont terminées, mais leur assemblage a échoué : %[1]s",
+		"storyboard_assembling":           "Toutes les scènes sont terminées ; assemblage en cours. Appelez generate_storyboard avec storyboard_id %[1]s pour vérifier de nouveau.",
+		"queued":                          "Génération en file d'attente, en position %[1]d ; elle démarre automatiquement dès qu'un emplacement se libère. Utilisez continue_operation avec ce storage_id, ou queue_status, pour la suivre.",
+		"queued_scheduled":                "Génération programmée pour démarrer après %[1]s, dès qu'un emplacement est libre. Utilisez continue_operation avec ce storage_id, ou queue_status, pour la suivre.",
+		"session_generations_one":         "%[1]d génération",
+		"session_generations_other":       "%[1]d générations",
+		"session_summary":                 "%[1]s depuis %[2]s : %[3]d terminées, %[4]d en échec.",
+		"session_summary_running":         "%[1]s depuis %[2]s : %[3]d terminées, %[4]d en échec, %[5]d encore en cours ou en attente.",
+		"session_models":                  "Modèles : %[1]s.",
+		"session_cost":                    "Environ %.2[1]f $ estimés et %[2]s de génération.",
+		"markdown_failed":                 "Échec de %[1]s",
+		"markdown_video":                  "Vidéo",
+		"markdown_model":                  "Modèle",
+		"markdown_prompt":                 "Prompt",
+		"markdown_thumbnail":              "Miniature",
+		"markdown_warnings":               "Avertissements",
+		"markdown_open":                   "ouvrir",
+	},
+}
+
+var (
+	messagesMu sync.RWMutex
+	locale     = DefaultLocale
+	messages   = catalogs[DefaultLocale] // The selected locale's messages over the defaults
+)
+
+// Configure selects the locale of response messages, such as es or pt-BR,
+// with custom catalogs (locale to message key to message) added over the
+// built-in ones. A regional locale falls back to its language, and a message
+// missing from both to English. Empty selects English.
+func Configure(selected string, custom map[string]map[string]string) error {
+	added := make(map[string]map[string]string, len(custom))
+	for loc, catalog := range custom {
+		for key := range catalog {
+			if _, ok := catalogs[DefaultLocale][key]; !ok {
+				return fmt.Errorf("unknown message %q in locale %s (known: %s)", key, loc, strings.Join(messageKeys(), ", "))
+			}
+		}
+		added[normalizeLocale(loc)] = catalog
+	}
+
+	selected = normalizeLocale(selected)
+	if selected == "" {
+		selected = DefaultLocale
+	}
+	language, _, _ := strings.Cut(selected, "-")
+	merged := make(map[string]string, len(catalogs[DefaultLocale]))
+	found := selected == DefaultLocale
+	for _, loc := range []string{DefaultLocale, language, selected} {
+		for _, source := range []map[string]map[string]string{catalogs, added} {
+			catalog, ok := source[loc]
+			if !ok {
+				continue
+			}
+			if loc != DefaultLocale {
+				found = true
+			}
+			for key, message := range catalog {
+				merged[key] = message
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("no messages for locale %q; add them to the messages file", selected)
+	}
+
+	messagesMu.Lock()
+	defer messagesMu.Unlock()
+	locale = selected
+	messages = merged
+	return nil
+}
+
+// Locale returns the locale of response messages
+func Locale() string {
+	messagesMu.RLock()
+	defer messagesMu.RUnlock()
+	return locale
+}
+
+// message formats a response message in the configured locale
+func message(key string, args ...interface{}) string {
+	messagesMu.RLock()
+	format, ok := messages[key]
+	messagesMu.RUnlock()
+	if !ok {
+		format = catalogs[DefaultLocale][key]
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// messageKeys returns the keys of the message catalog
+func messageKeys() []string {
+	keys := make([]string, 0, len(catalogs[DefaultLocale]))
+	for key := range catalogs[DefaultLocale] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// normalizeLocale turns locale names such as pt_BR.UTF-8 into pt-BR
+func normalizeLocale(name string) string {
+	name, _, _ = strings.Cut(strings.TrimSpace(name), ".")
+	name = strings.ReplaceAll(name, "_", "-")
+	language, region, hasRegion := strings.Cut(name, "-")
+	if !hasRegion {
+		return strings.ToLower(language)
+	}
+	return strings.ToLower(language) + "-" + strings.ToUpper(region)
+}
@@ -2,13 +2,18 @@ package responses
 
 import (
 	"encoding/json"
-	"log"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
 	"github.com/gomcpgo/replicate_video_ai/pkg/types"
 )
 
-// BuildSuccessResponse creates a success response
-func BuildSuccessResponse(operation, storageID string, paths map[string]string, model map[string]string, parameters map[string]interface{}, metrics map[string]interface{}, predictionID string) string {
+// BuildSuccessResponse creates a success response. urls holds signed HTTP
+// URLs for the paths when the file server is enabled (nil otherwise), and
+// attempts the failed predictions auto_retry resubmitted, if any.
+func BuildSuccessResponse(operation, storageID string, paths map[string]string, urls map[string]string, model map[string]string, parameters map[string]interface{}, metrics map[string]interface{}, predictionID string, warnings []string, attempts []map[string]interface{}) string {
 	response := types.SuccessResponse{
 		Success:      true,
 		Operation:    operation,
@@ -16,35 +21,587 @@ func BuildSuccessResponse(operation, storageID string, paths map[string]string,
 		PredictionID: predictionID,
 		Status:       "completed",
 		Paths:        paths,
+		URLs:         urls,
 		Model:        model,
-		Parameters:   parameters,
+		Parameters:   sanitizeParameters(parameters),
 		Metrics:      metrics,
+		Warnings:     warnings,
+		Attempts:     attempts,
 	}
 
+	response.SchemaVersion = types.SchemaVersion
 	data, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
-		log.Printf("ERROR: Failed to marshal success response: %v", err)
+		logging.Error("failed to marshal success response", "error", err)
 		return `{"success": false, "error": {"message": "Failed to format response"}}`
 	}
 
 	return string(data)
 }
 
-// BuildProcessingResponse creates a processing/async response
-func BuildProcessingResponse(operation, predictionID, storageID string, waitTime int) string {
-	response := types.ProcessingResponse{
+// BuildInfoResponse creates a response describing a stored video in any
+// status (unlike BuildSuccessResponse, which always reports "completed")
+func BuildInfoResponse(operation, storageID, status string, paths map[string]string, urls map[string]string, model map[string]string, parameters map[string]interface{}, metrics map[string]interface{}, predictionID string) string {
+	response := types.SuccessResponse{
 		Success:      true,
-		Status:       "processing",
 		Operation:    operation,
-		PredictionID: predictionID,
 		StorageID:    storageID,
-		Message:      "Video generation in progress. Use continue_operation to check status.",
-		WaitTime:     waitTime,
+		PredictionID: predictionID,
+		Status:       status,
+		Paths:        paths,
+		URLs:         urls,
+		Model:        model,
+		Parameters:   sanitizeParameters(parameters),
+		Metrics:      metrics,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal info response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildProcessingResponse creates a processing/async response. waitTime is
+// the suggested wait for the next continue_operation call and estimatedTime
+// the remaining time the model typically needs (0 if unknown).
+func BuildProcessingResponse(operation, predictionID, storageID string, waitTime int, estimatedTime int) string {
+	return BuildOperationResponse(operation, "", predictionID, storageID, waitTime, estimatedTime, nil, nil)
+}
+
+// BuildOperationResponse creates a processing response for a prediction
+// polled by a background operation, which continue_operation accepts as
+// operation_id. heartbeat is set once the poll has been running for a while.
+func BuildOperationResponse(operation, operationID, predictionID, storageID string, waitTime int, estimatedTime int, heartbeat *types.Heartbeat, warnings []string) string {
+	response := types.ProcessingResponse{
+		Success:       true,
+		Status:        "processing",
+		Operation:     operation,
+		OperationID:   operationID,
+		PredictionID:  predictionID,
+		StorageID:     storageID,
+		Message:       message("generation_in_progress"),
+		WaitTime:      waitTime,
+		EstimatedTime: estimatedTime,
+		Warnings:      warnings,
+	}
+
+	if waitTime > 0 && estimatedTime > waitTime {
+		response.SuggestedContinues = (estimatedTime + waitTime - 1) / waitTime
+		response.Message = message("generation_in_progress_estimate", estimatedTime, response.SuggestedContinues)
+	}
+	if heartbeat != nil {
+		response.Heartbeat = heartbeat
+		response.Message = message("generation_still_running", heartbeat.PredictionStatus, heartbeat.Elapsed)
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal processing response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildListResponse creates a response listing stored videos
+func BuildListResponse(operation string, videos []types.VideoSummary, filters map[string]interface{}) string {
+	if videos == nil {
+		videos = []types.VideoSummary{}
+	}
+	response := types.ListResponse{
+		Success:   true,
+		Operation: operation,
+		Count:     len(videos),
+		Filters:   filters,
+		Videos:    videos,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal list response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildBatchStatusResponse creates a response summarizing several predictions
+func BuildBatchStatusResponse(operation string, results []types.PredictionStatus) string {
+	if results == nil {
+		results = []types.PredictionStatus{}
+	}
+	response := types.BatchStatusResponse{
+		Success:   true,
+		Operation: operation,
+		Count:     len(results),
+		Results:   results,
+	}
+	for _, r := range results {
+		switch r.Status {
+		case "completed":
+			response.Completed++
+		case "starting", "processing":
+			response.Pending++
+		default:
+			response.Failed++
+		}
+	}
+	if response.Pending > 0 {
+		response.Message = message("batch_pending", response.Pending, response.Count)
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal batch status response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildCapabilitiesResponse creates a server capabilities report
+func BuildCapabilitiesResponse(server map[string]string, subsystems map[string]interface{}, models []types.ModelInfo, tools []string) string {
+	response := types.CapabilitiesResponse{
+		Success:    true,
+		Operation:  "server_capabilities",
+		Server:     server,
+		Subsystems: subsystems,
+		Models:     models,
+		Tools:      tools,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal capabilities response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildAccountResponse creates an account status report
+func BuildAccountResponse(status types.AccountStatus) string {
+	response := types.AccountResponse{
+		Success:       true,
+		Operation:     "check_account",
+		AccountStatus: status,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal account response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildHealthCheckResponse creates a self-test report
+func BuildHealthCheckResponse(report types.HealthReport) string {
+	response := types.HealthCheckResponse{
+		Success:      true,
+		Operation:    "health_check",
+		HealthReport: report,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal health check response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildModelVersionsResponse creates a model versions report
+func BuildModelVersionsResponse(model, alias string, versions []types.ModelVersionInfo, inputSchema map[string]interface{}) string {
+	response := types.ModelVersionsResponse{
+		Success:     true,
+		Operation:   "get_model_versions",
+		Model:       model,
+		Alias:       alias,
+		Versions:    versions,
+		InputSchema: inputSchema,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal model versions response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildDeploymentsResponse creates a report of the account's deployments
+func BuildDeploymentsResponse(deployments []types.DeploymentInfo) string {
+	if deployments == nil {
+		deployments = []types.DeploymentInfo{}
+	}
+	response := types.DeploymentsResponse{
+		Success:     true,
+		Operation:   "list_deployments",
+		Count:       len(deployments),
+		Deployments: deployments,
 	}
 
+	response.SchemaVersion = types.SchemaVersion
 	data, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
-		log.Printf("ERROR: Failed to marshal processing response: %v", err)
+		logging.Error("failed to marshal deployments response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildComparisonResponse creates a response reporting the generations of
+// a comparison
+func BuildComparisonResponse(comparisonID, prompt string, entries []types.ComparisonEntry) string {
+	if entries == nil {
+		entries = []types.ComparisonEntry{}
+	}
+	response := types.ComparisonResponse{
+		Success:      true,
+		Operation:    "compare_models",
+		ComparisonID: comparisonID,
+		Prompt:       prompt,
+		Count:        len(entries),
+		Results:      entries,
+	}
+	for _, e := range entries {
+		response.TotalEstimatedCost += e.EstimatedCost
+		switch e.Status {
+		case "completed":
+			response.Completed++
+		case "starting", "processing":
+			response.Pending++
+		default:
+			response.Failed++
+		}
+	}
+	if response.Pending > 0 {
+		response.Message = message("comparison_pending", response.Pending, response.Count, comparisonID)
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal comparison response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildVariationSetResponse creates a response reporting the generations of
+// a variation set
+func BuildVariationSetResponse(setID, model, basePrompt string, entries []types.VariationEntry) string {
+	if entries == nil {
+		entries = []types.VariationEntry{}
+	}
+	response := types.VariationSetResponse{
+		Success:        true,
+		Operation:      "generate_variations",
+		VariationSetID: setID,
+		Model:          model,
+		BasePrompt:     basePrompt,
+		Count:          len(entries),
+		Variations:     entries,
+	}
+	for _, e := range entries {
+		switch e.Status {
+		case "queued":
+			response.Queued++
+		case "completed":
+			response.Completed++
+		case "starting", "processing":
+			response.Pending++
+		default:
+			response.Failed++
+		}
+	}
+	if response.Queued+response.Pending > 0 {
+		response.Message = message("variations_pending", response.Queued+response.Pending, response.Count, setID)
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal variation set response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildStoryboardResponse creates a response reporting a storyboard's
+// scenes and, when requested, the assembled video
+func BuildStoryboardResponse(storyboardID string, scenes []types.StoryboardScene, video *types.StoryboardVideo) string {
+	if scenes == nil {
+		scenes = []types.StoryboardScene{}
+	}
+	response := types.StoryboardResponse{
+		Success:      true,
+		Operation:    "generate_storyboard",
+		StoryboardID: storyboardID,
+		SceneCount:   len(scenes),
+		Scenes:       scenes,
+		Video:        video,
+	}
+	for _, s := range scenes {
+		switch s.Status {
+		case "queued", "waiting":
+			response.Queued++
+		case "completed":
+			response.Completed++
+		case "starting", "processing":
+			response.Pending++
+		default:
+			response.Failed++
+		}
+	}
+	if len(scenes) > 0 {
+		response.Progress = float64(response.Completed) / float64(len(scenes))
+	}
+
+	switch {
+	case response.Queued+response.Pending > 0:
+		response.Status = "generating"
+		response.Message = message("storyboard_generating", response.Completed, response.SceneCount, storyboardID)
+	case response.Failed > 0:
+		response.Status = "failed"
+		response.Message = message("storyboard_failed", response.Failed, response.SceneCount)
+	case video == nil || video.Status == "completed":
+		response.Status = "completed"
+	case video.Status == "failed":
+		response.Status = "failed"
+		response.Message = message("storyboard_join_failed", video.Error)
+	default:
+		response.Status = "assembling"
+		response.Message = message("storyboard_assembling", storyboardID)
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal storyboard response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildRecommendModelResponse creates a response ranking models for a
+// request's constraints
+func BuildRecommendModelResponse(recommended string, constraints map[string]interface{}, candidates []types.ModelRecommendation) string {
+	response := types.RecommendModelResponse{
+		Success:     true,
+		Operation:   "recommend_model",
+		Recommended: recommended,
+		Constraints: constraints,
+		Candidates:  candidates,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal recommend model response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildTagResponse creates a response describing a video's tags
+func BuildTagResponse(storageID string, tags []string, favorite bool) string {
+	if tags == nil {
+		tags = []string{}
+	}
+	response := types.TagResponse{
+		Success:   true,
+		Operation: "tag_video",
+		StorageID: storageID,
+		Tags:      tags,
+		Favorite:  favorite,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal tag response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildTagListResponse creates a response listing tags and their usage
+func BuildTagListResponse(tags []types.TagCount, favorites int, filters map[string]interface{}) string {
+	if tags == nil {
+		tags = []types.TagCount{}
+	}
+	response := types.TagListResponse{
+		Success:   true,
+		Operation: "list_tags",
+		Count:     len(tags),
+		Favorites: favorites,
+		Filters:   filters,
+		Tags:      tags,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal tag list response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildExportResponse creates a response describing an export
+func BuildExportResponse(format, path string, size int64, storageIDs []string) string {
+	response := types.ExportResponse{
+		Success:    true,
+		Operation:  "export_videos",
+		Format:     format,
+		Path:       path,
+		Count:      len(storageIDs),
+		Size:       size,
+		StorageIDs: storageIDs,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal export response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildFramesResponse creates a response listing extracted frames
+func BuildFramesResponse(storageID, folder, format string, frames []types.FrameInfo) string {
+	if frames == nil {
+		frames = []types.FrameInfo{}
+	}
+	response := types.FramesResponse{
+		Success:   true,
+		Operation: "extract_frames",
+		StorageID: storageID,
+		Folder:    folder,
+		Format:    format,
+		Count:     len(frames),
+		Frames:    frames,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal frames response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildDuplicatesResponse creates a response listing duplicate files
+func BuildDuplicatesResponse(groups []types.DuplicateGroup, backfilled int, filters map[string]interface{}) string {
+	if groups == nil {
+		groups = []types.DuplicateGroup{}
+	}
+	response := types.DuplicatesResponse{
+		Success:    true,
+		Operation:  "find_duplicates",
+		Count:      len(groups),
+		Backfilled: backfilled,
+		Filters:    filters,
+		Duplicates: groups,
+	}
+	for _, group := range groups {
+		response.WastedBytes += group.WastedBytes
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal duplicates response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildStorageStatsResponse creates a disk usage report
+func BuildStorageStatsResponse(stats types.StorageStatsResponse) string {
+	stats.Success = true
+	stats.Operation = "storage_stats"
+	for _, buckets := range []*[]types.UsageBucket{&stats.ByModel, &stats.ByProject, &stats.ByStatus} {
+		if *buckets == nil {
+			*buckets = []types.UsageBucket{}
+		}
+	}
+
+	stats.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal storage stats response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildSessionSummaryResponse creates a recap of a session, with a one-line
+// message an agent can pass on
+func BuildSessionSummaryResponse(summary types.SessionSummaryResponse) string {
+	summary.Success = true
+	summary.Operation = "session_summary"
+	if summary.Models == nil {
+		summary.Models = []types.SessionModel{}
+	}
+	if summary.Videos == nil {
+		summary.Videos = []types.SessionVideo{}
+	}
+	if summary.Failures == nil {
+		summary.Failures = []types.SessionVideo{}
+	}
+
+	generations := message("session_generations_other", summary.Generations)
+	if summary.Generations == 1 {
+		generations = message("session_generations_one", summary.Generations)
+	}
+	parts := []string{message("session_summary", generations, summary.Since, summary.Completed, summary.Failed)}
+	if summary.Pending+summary.Queued > 0 {
+		parts[0] = message("session_summary_running", generations, summary.Since, summary.Completed, summary.Failed, summary.Pending+summary.Queued)
+	}
+	if len(summary.Models) > 0 {
+		names := make([]string, len(summary.Models))
+		for i, m := range summary.Models {
+			names[i] = fmt.Sprintf("%s (%d)", m.Model, m.Generations)
+		}
+		parts = append(parts, message("session_models", strings.Join(names, ", ")))
+	}
+	parts = append(parts, message("session_cost", summary.EstimatedCost, time.Duration(summary.WaitSeconds)*time.Second))
+	summary.Message = strings.Join(parts, " ")
+
+	summary.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal session summary response", "error", err)
 		return `{"success": false, "error": {"message": "Failed to format response"}}`
 	}
 
@@ -63,11 +620,84 @@ func BuildErrorResponse(operation, errorType, message string, details map[string
 		},
 	}
 
+	response.SchemaVersion = types.SchemaVersion
 	data, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
-		log.Printf("ERROR: Failed to marshal error response: %v", err)
+		logging.Error("failed to marshal error response", "error", err)
 		return `{"success": false, "error": {"message": "Failed to format error"}}`
 	}
 
 	return string(data)
-}
\ No newline at end of file
+}
+// BuildAuditLogResponse creates a response listing audit log records
+func BuildAuditLogResponse(path string, records []types.AuditRecord, filters map[string]interface{}) string {
+	if records == nil {
+		records = []types.AuditRecord{}
+	}
+	response := types.AuditLogResponse{
+		Success:   true,
+		Operation: "get_audit_log",
+		Path:      path,
+		Count:     len(records),
+		Filters:   filters,
+		Records:   records,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal audit log response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildQueuedResponse creates a response for a generation request that was
+// queued until a slot frees up
+func BuildQueuedResponse(operation string, job types.QueuedJob) string {
+	response := types.QueuedResponse{
+		Success:   true,
+		Status:    "queued",
+		Operation: operation,
+		StorageID: job.StorageID,
+		Queue:     job,
+		Message:   message("queued", job.Position),
+	}
+	if job.StartAfter != "" {
+		response.Message = message("queued_scheduled", job.StartAfter)
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal queued response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
+
+// BuildQueueStatusResponse creates a report of the queued generations
+func BuildQueueStatusResponse(operation string, jobs []types.QueuedJob, running, maxConcurrent int) string {
+	if jobs == nil {
+		jobs = []types.QueuedJob{}
+	}
+	response := types.QueueStatusResponse{
+		Success:       true,
+		Operation:     operation,
+		Running:       running,
+		MaxConcurrent: maxConcurrent,
+		Count:         len(jobs),
+		Queued:        jobs,
+	}
+
+	response.SchemaVersion = types.SchemaVersion
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal queue status response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
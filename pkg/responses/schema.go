@@ -0,0 +1,136 @@
+package responses
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// schemaTypes are the response types get_response_schema publishes. The
+// schemas are generated from the types, so they can't drift from what the
+// tools return.
+var schemaTypes = map[string]reflect.Type{
+	"SuccessResponse":        reflect.TypeOf(types.SuccessResponse{}),
+	"ProcessingResponse":     reflect.TypeOf(types.ProcessingResponse{}),
+	"ErrorResponse":          reflect.TypeOf(types.ErrorResponse{}),
+	"QueuedResponse":         reflect.TypeOf(types.QueuedResponse{}),
+	"QueueStatusResponse":    reflect.TypeOf(types.QueueStatusResponse{}),
+	"ListResponse":           reflect.TypeOf(types.ListResponse{}),
+	"BatchStatusResponse":    reflect.TypeOf(types.BatchStatusResponse{}),
+	"ComparisonResponse":     reflect.TypeOf(types.ComparisonResponse{}),
+	"VariationSetResponse":   reflect.TypeOf(types.VariationSetResponse{}),
+	"StoryboardResponse":     reflect.TypeOf(types.StoryboardResponse{}),
+	"SessionSummaryResponse": reflect.TypeOf(types.SessionSummaryResponse{}),
+}
+
+// DefaultSchemas are the schemas returned when none are named: the
+// responses of generation and continue_operation
+var DefaultSchemas = []string{"SuccessResponse", "ProcessingResponse", "ErrorResponse"}
+
+// SchemaNames returns the names of the published response schemas
+func SchemaNames() []string {
+	names := make([]string, 0, len(schemaTypes))
+	for name := range schemaTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ResponseSchema returns the JSON Schema of a response type by name
+func ResponseSchema(name string) (map[string]interface{}, error) {
+	t, ok := schemaTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown response schema %q (available: %s)", name, strings.Join(SchemaNames(), ", "))
+	}
+	schema := typeSchema(t)
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["$id"] = fmt.Sprintf("https://github.com/gomcpgo/replicate_video_ai/schemas/%s/%s.json", types.SchemaVersion, name)
+	schema["title"] = name
+	return schema, nil
+}
+
+// typeSchema describes a Go type the way encoding/json marshals it
+func typeSchema(t reflect.Type) map[string]interface{} {
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object"}
+		if t.Elem().Kind() != reflect.Interface {
+			schema["additionalProperties"] = typeSchema(t.Elem())
+		}
+		return schema
+	case reflect.Struct:
+		return structSchema(t)
+	}
+	return map[string]interface{}{} // interface{}: any value
+}
+
+// structSchema describes a struct's JSON fields; fields without omitempty
+// are always present, so they are required
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	required := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = typeSchema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			required = append(required, name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// BuildResponseSchemaResponse creates a response with the named schemas
+func BuildResponseSchemaResponse(schemas map[string]interface{}) string {
+	response := types.ResponseSchemaResponse{
+		Success:   true,
+		Operation: "get_response_schema",
+		Schemas:   schemas,
+		Available: SchemaNames(),
+	}
+	response.SchemaVersion = types.SchemaVersion
+
+	data, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		logging.Error("failed to marshal response schema response", "error", err)
+		return `{"success": false, "error": {"message": "Failed to format response"}}`
+	}
+
+	return string(data)
+}
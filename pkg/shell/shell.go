@@ -0,0 +1,46 @@
+// Package shell runs user-configured commands, such as notification hooks
+// and token_command, through the platform shell
+package shell
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Run runs command through the platform shell (sh -c, or cmd /C on Windows)
+// with stdin and extra environment variables, and returns what it printed
+// to stdout. The command is killed when ctx is done. A failure's error
+// includes what the command printed to stderr.
+func Run(ctx context.Context, command string, stdin io.Reader, env ...string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stdin = stdin
+	if len(env) > 0 {
+		cmd.Env = append(os.Environ(), env...)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("timed out: %w", ctx.Err())
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%w: %s", err, message)
+		}
+		return nil, err
+	}
+	return output, nil
+}
@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// tempSuffix marks files being written; they are renamed into place once
+// complete, so a crash never leaves a partial file under its final name
+const tempSuffix = ".tmp"
+
+// staleTempAge is how old a temporary file must be to count as abandoned
+const staleTempAge = time.Hour
+
+// writeFileAtomic writes data to path via a synced temporary file and rename
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	_, err := writeAtomic(path, perm, func(w io.Writer) (int64, error) {
+		n, err := w.Write(data)
+		return int64(n), err
+	})
+	return err
+}
+
+// writeAtomic streams a file to a hidden temporary file next to path, syncs
+// it, and renames it over path. The temporary file is removed on failure.
+func writeAtomic(path string, perm os.FileMode, write func(io.Writer) (int64, error)) (int64, error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*"+tempSuffix)
+	if err != nil {
+		return 0, err
+	}
+	tmpPath := tmp.Name()
+
+	size, err := write(tmp)
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmpPath, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	syncDir(dir)
+	return size, nil
+}
+
+// syncDir flushes a directory entry so a rename survives a crash. Not every
+// platform supports syncing directories, so failures are ignored.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
+
+// removeStaleTemp deletes temporary files a crash left in a folder. Only old
+// files are removed, since a write may be in progress.
+func removeStaleTemp(folder string) {
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if !isTempFile(entry.Name()) {
+			continue
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > staleTempAge {
+			path := filepath.Join(folder, entry.Name())
+			if os.Remove(path) == nil {
+				logging.Info("removed partial file", "path", path)
+			}
+		}
+	}
+}
+
+// isTempFile reports whether a file name is an in-progress atomic write
+func isTempFile(name string) bool {
+	return strings.HasPrefix(name, ".") && strings.HasSuffix(name, tempSuffix)
+}
+
+// VerifyVideo checks that a downloaded video is complete before it is
+// marked completed: the file must be non-empty and, when ffprobe is
+// available, parse as a container with a video stream
+func (s *Storage) VerifyVideo(ctx context.Context, videoPath string) error {
+	info, err := os.Stat(videoPath)
+	if err != nil {
+		return fmt.Errorf("failed to verify video: %w", err)
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("downloaded video is empty")
+	}
+
+	ffprobePath, err := ffprobeBinary()
+	if err != nil {
+		return nil
+	}
+	output, err := mediaCommand(ctx, probeTimeout, ffprobePath,
+		"-v", "error",
+		"-select_streams", "v:0",
+		"-show_entries", "stream=codec_type",
+		"-of", "csv=p=0",
+		videoPath,
+	).CombinedOutput()
+	if err != nil || strings.TrimSpace(string(output)) != "video" {
+		return fmt.Errorf("downloaded video is unreadable (ffprobe: %s)", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// concatFPS is the frame rate clips are converted to before joining, since
+// models produce different rates and xfade needs them equal
+const concatFPS = 24
+
+// maxCrossfade limits the crossfade between joined clips, in seconds
+const maxCrossfade = 2.0
+
+// ConcatOptions describes how stored videos are joined into a new one
+type ConcatOptions struct {
+	Crossfade    float64 // Seconds each clip fades into the next; 0 cuts
+	StoryboardID string  // Recorded so the storyboard finds its assembled video
+	Description  string  // Stored as the prompt so search finds it
+	SessionID    string
+	Project      string
+}
+
+// ConcatVideos joins completed videos in order into a new storage folder
+// (video.mp4), crossfading from each clip into the next. Every clip is
+// scaled and padded to the first clip's size at concatFPS, and the audio is
+// dropped, since not every model produces it. It returns the new storage ID
+// and its metadata.
+func (s *Storage) ConcatVideos(ctx context.Context, storageIDs []string, opts ConcatOptions) (string, map[string]interface{}, error) {
+	ffmpegPath, err := ffmpegBinary()
+	if err != nil {
+		return "", nil, fmt.Errorf("ffmpeg is required to join videos")
+	}
+	if len(storageIDs) < 2 {
+		return "", nil, fmt.Errorf("at least 2 videos are required to join")
+	}
+	if opts.Crossfade < 0 || opts.Crossfade > maxCrossfade {
+		return "", nil, fmt.Errorf("crossfade must be between 0 and %.0f seconds", maxCrossfade)
+	}
+
+	inputs := make([]string, len(storageIDs))
+	durations := make([]float64, len(storageIDs))
+	var width, height int
+	for i, storageID := range storageIDs {
+		path, err := s.OutputPath(storageID)
+		if err != nil {
+			return "", nil, err
+		}
+		duration, resolution, _ := s.ExtractVideoMetadata(ctx, path)
+		if duration <= 0 {
+			return "", nil, fmt.Errorf("could not determine the duration of video %s", storageID)
+		}
+		if opts.Crossfade > 0 && duration <= opts.Crossfade {
+			return "", nil, fmt.Errorf("video %s (%.2fs) is shorter than the crossfade", storageID, duration)
+		}
+		if i == 0 {
+			if n, _ := fmt.Sscanf(resolution, "%dx%d", &width, &height); n != 2 || width <= 0 || height <= 0 {
+				return "", nil, fmt.Errorf("could not determine the resolution of video %s", storageID)
+			}
+		}
+		inputs[i], durations[i] = path, duration
+	}
+
+	storageID := s.GenerateStorageID()
+	if opts.Project != "" {
+		if _, err := s.SetProject(storageID, opts.Project); err != nil {
+			return "", nil, err
+		}
+	}
+	folder, err := s.CreateStorageFolder(storageID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	outputPath := filepath.Join(folder, "video.mp4")
+	tmpPath := filepath.Join(folder, ".video.mp4"+tempSuffix)
+	defer os.Remove(tmpPath)
+
+	args := []string{}
+	for _, input := range inputs {
+		args = append(args, "-i", input)
+	}
+	args = append(args,
+		"-filter_complex", concatFilter(durations, width-width%2, height-height%2, opts.Crossfade),
+		"-map", "[out]",
+		"-an",
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		"-y",
+		tmpPath,
+	)
+	output, err := mediaCommand(ctx, renderTimeout, ffmpegPath, args...).CombinedOutput()
+	if err != nil {
+		logging.Warn("failed to join videos", "storage_ids", storageIDs, "error", err, "output", string(output))
+		os.RemoveAll(folder)
+		return "", nil, fmt.Errorf("ffmpeg failed to join videos: %s", lastLine(string(output)))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.RemoveAll(folder)
+		return "", nil, fmt.Errorf("failed to save joined video: %w", err)
+	}
+
+	paths := map[string]interface{}{
+		"output": filepath.Base(outputPath),
+	}
+	if thumbnailPath, _ := s.GenerateThumbnail(ctx, storageID, outputPath); thumbnailPath != "" {
+		paths["thumbnail"] = filepath.Base(thumbnailPath)
+	}
+	metrics := map[string]interface{}{
+		"format":          "mp4",
+		"generation_type": "concatenated",
+	}
+	if info, err := os.Stat(outputPath); err == nil {
+		metrics["file_size"] = info.Size()
+	}
+	if duration, resolution, _ := s.ExtractVideoMetadata(ctx, outputPath); duration > 0 {
+		metrics["actual_duration"] = duration
+		metrics["actual_resolution"] = resolution
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	metadata := map[string]interface{}{
+		"operation":     "concatenate",
+		"status":        "completed",
+		"storage_id":    storageID,
+		"storyboard_id": opts.StoryboardID,
+		"source_ids":    storageIDs,
+		"session_id":    opts.SessionID,
+		"project":       opts.Project,
+		"created_at":    now,
+		"completed_at":  now,
+		"model": map[string]interface{}{
+			"id":   "concatenated",
+			"name": "Joined clips",
+		},
+		"parameters": map[string]interface{}{
+			"prompt":    opts.Description,
+			"crossfade": opts.Crossfade,
+		},
+		"metrics": metrics,
+		"paths":   paths,
+	}
+	if hash, err := HashFile(outputPath); err == nil {
+		SetHash(metadata, HashVideo, hash)
+	}
+
+	if err := s.SaveMetadata(storageID, metadata); err != nil {
+		os.RemoveAll(folder)
+		return "", nil, err
+	}
+
+	logging.Info("joined videos", "storage_id", storageID, "clips", len(storageIDs), "crossfade", opts.Crossfade)
+	return storageID, metadata, nil
+}
+
+// concatFilter builds the filter graph joining the inputs into [out]. Each
+// clip is normalized first; with a crossfade, every xfade starts crossfade
+// seconds before the joined clips so far end.
+func concatFilter(durations []float64, width, height int, crossfade float64) string {
+	var b strings.Builder
+	for i := range durations {
+		fmt.Fprintf(&b, "[%d:v]scale=%d:%d:force_original_aspect_ratio=decrease,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=black,setsar=1,fps=%d,format=yuv420p,settb=AVTB[v%d];",
+			i, width, height, width, height, concatFPS, i)
+	}
+
+	if crossfade == 0 {
+		for i := range durations {
+			fmt.Fprintf(&b, "[v%d]", i)
+		}
+		fmt.Fprintf(&b, "concat=n=%d:v=1:a=0[out]", len(durations))
+		return b.String()
+	}
+
+	prev, elapsed := "v0", durations[0]
+	for i := 1; i < len(durations); i++ {
+		next := fmt.Sprintf("x%d", i)
+		if i == len(durations)-1 {
+			next = "out"
+		}
+		fmt.Fprintf(&b, "[%s][v%d]xfade=transition=fade:duration=%.3f:offset=%.3f[%s]", prev, i, crossfade, elapsed-crossfade, next)
+		if next != "out" {
+			b.WriteString(";")
+		}
+		prev, elapsed = next, elapsed+durations[i]-crossfade
+	}
+	return b.String()
+}
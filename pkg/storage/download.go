@@ -0,0 +1,275 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// Downloads that fail partway, e.g. truncated by a dropped connection, are
+// retried, waiting downloadRetryDelay longer before each attempt
+const (
+	downloadAttempts   = 3
+	downloadRetryDelay = 2 * time.Second
+)
+
+// defaultDownloadIdle is how long a download may receive no data before it
+// is abandoned, unless SetDownloadIdleTimeout changes it
+const defaultDownloadIdle = 60 * time.Second
+
+// Download describes a video saved from a URL
+type Download struct {
+	Path          string
+	Size          int64
+	SHA256        string // Hex SHA-256 of the saved file
+	ETag          string // As sent by the server, if any
+	ContentLength int64  // As sent by the server; -1 when unknown
+	ContentType   string // As sent by the server, without parameters
+	Attempts      int
+}
+
+// Metadata returns the download details recorded in metadata
+func (d *Download) Metadata() map[string]interface{} {
+	details := map[string]interface{}{
+		"bytes":    d.Size,
+		"attempts": d.Attempts,
+	}
+	if d.ETag != "" {
+		details["etag"] = d.ETag
+	}
+	return details
+}
+
+// SetDownloadIdleTimeout sets how long a download may receive no data, or
+// wait for the server to respond, before it is abandoned and retried
+func (s *Storage) SetDownloadIdleTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		s.downloadIdle = timeout
+	}
+}
+
+// SetDownloadTransport makes downloads go through transport, e.g. one with
+// a custom CA bundle
+func (s *Storage) SetDownloadTransport(transport http.RoundTripper) {
+	s.downloadTransport = transport
+}
+
+// retryableError marks a download failure that another attempt may not hit
+type retryableError struct {
+	err error
+}
+
+func (e *retryableError) Error() string { return e.err.Error() }
+func (e *retryableError) Unwrap() error { return e.err }
+
+// SaveVideoFromURL downloads and saves a video from URL. The download is
+// checked against the Content-Length and, when the server sends one, the
+// Content-MD5 or x-goog-hash checksum; truncated or corrupted downloads and
+// server errors are retried. Canceling ctx stops the download.
+func (s *Storage) SaveVideoFromURL(ctx context.Context, url string, storageID string, filename string) (*Download, error) {
+	// Create storage folder
+	folderPath, err := s.CreateStorageFolder(storageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Determine file extension from URL or default to mp4
+	ext := ".mp4"
+	if strings.Contains(url, ".webm") {
+		ext = ".webm"
+	} else if strings.Contains(url, ".gif") {
+		ext = ".gif"
+	}
+
+	// Use provided filename or the configured template
+	if filename == "" {
+		filename = s.videoFilename(storageID, ext)
+	}
+	if !strings.Contains(filename, ".") {
+		filename = filename + ext
+	}
+
+	outputPath := filepath.Join(folderPath, filename)
+
+	for attempt := 1; ; attempt++ {
+		logging.Debug("downloading video", "storage_id", storageID, "url", url, "path", outputPath, "attempt", attempt)
+		download, err := s.downloadFile(ctx, url, outputPath)
+		if err == nil {
+			download.Attempts = attempt
+			logging.Debug("video saved", "storage_id", storageID, "path", outputPath, "size", download.Size, "etag", download.ETag)
+			return download, nil
+		}
+
+		var retryable *retryableError
+		if attempt >= downloadAttempts || !errors.As(err, &retryable) || ctx.Err() != nil {
+			return nil, err
+		}
+		logging.Warn("video download failed, retrying", "storage_id", storageID, "attempt", attempt, "error", err)
+		select {
+		case <-time.After(time.Duration(attempt) * downloadRetryDelay):
+		case <-ctx.Done():
+			return nil, fmt.Errorf("video download canceled: %w", ctx.Err())
+		}
+	}
+}
+
+// downloadFile makes one attempt at saving url to path
+func (s *Storage) downloadFile(ctx context.Context, url, path string) (*Download, error) {
+	download := &Download{Path: path, ContentLength: -1}
+
+	var body io.ReadCloser
+	var checksum hash.Hash
+	var expected []byte
+	if localPath, ok := strings.CutPrefix(url, "file://"); ok {
+		// Local files (used by the mock client) are copied directly
+		f, err := os.Open(localPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open local video: %w", err)
+		}
+		if info, err := f.Stat(); err == nil {
+			download.ContentLength = info.Size()
+		}
+		body = f
+	} else {
+		// The request is canceled with ctx, or once no data arrives for
+		// downloadIdle
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		idle := &idleTimer{timeout: s.downloadIdle}
+		idle.timer = time.AfterFunc(s.downloadIdle, func() {
+			idle.expired.Store(true)
+			cancel()
+		})
+		defer idle.timer.Stop()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to download video: %w", err)
+		}
+		// No overall timeout, since large videos take minutes; the idle
+		// timer limits each wait for data instead
+		client := &http.Client{Transport: s.downloadTransport}
+		resp, err := client.Do(req)
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			return nil, fmt.Errorf("failed to download video: %w", err)
+		} else if err != nil {
+			return nil, &retryableError{idle.wrap(fmt.Errorf("failed to download video: %w", err))}
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			err := fmt.Errorf("failed to download video: status %d", resp.StatusCode)
+			if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+				return nil, &retryableError{err}
+			}
+			return nil, err
+		}
+		idle.timer.Reset(s.downloadIdle)
+		body = &idleReader{ReadCloser: resp.Body, idle: idle}
+		download.ContentLength = resp.ContentLength
+		download.ETag = resp.Header.Get("ETag")
+		download.ContentType, _, _ = strings.Cut(resp.Header.Get("Content-Type"), ";")
+		expected = contentMD5(resp.Header)
+		if expected != nil {
+			checksum = md5.New()
+		}
+	}
+	defer body.Close()
+
+	// Write to a temporary file and rename it into place, so an interrupted
+	// download never leaves a truncated video under the final name
+	sha := sha256.New()
+	size, err := writeAtomic(path, 0644, func(w io.Writer) (int64, error) {
+		writers := []io.Writer{w, sha}
+		if checksum != nil {
+			writers = append(writers, checksum)
+		}
+		n, err := io.Copy(io.MultiWriter(writers...), body)
+		if err != nil {
+			return n, &retryableError{err}
+		}
+		if download.ContentLength >= 0 && n != download.ContentLength {
+			return n, &retryableError{fmt.Errorf("download incomplete: got %d of %d bytes", n, download.ContentLength)}
+		}
+		if checksum != nil && !bytes.Equal(checksum.Sum(nil), expected) {
+			return n, &retryableError{fmt.Errorf("download corrupted: MD5 %x does not match the server's %x", checksum.Sum(nil), expected)}
+		}
+		return n, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save video: %w", err)
+	}
+
+	download.Size = size
+	download.SHA256 = hex.EncodeToString(sha.Sum(nil))
+	return download, nil
+}
+
+// idleTimer cancels a download once it receives no data for timeout
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	expired atomic.Bool
+}
+
+// wrap explains an error caused by the timer canceling the download
+func (t *idleTimer) wrap(err error) error {
+	if t.expired.Load() {
+		return fmt.Errorf("download stalled: no data received for %s: %w", t.timeout, err)
+	}
+	return err
+}
+
+// idleReader restarts its idle timer whenever data arrives
+type idleReader struct {
+	io.ReadCloser
+	idle *idleTimer
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.idle.timer.Reset(r.idle.timeout)
+	}
+	if err != nil && err != io.EOF {
+		err = r.idle.wrap(err)
+	}
+	return n, err
+}
+
+// contentMD5 returns the MD5 of the body the server declares in Content-MD5
+// or, as Google Cloud Storage sends it, x-goog-hash; nil when it declares
+// none. ETags aren't used, since they are only sometimes an MD5.
+func contentMD5(header http.Header) []byte {
+	values := header.Values("Content-MD5")
+	for _, h := range header.Values("X-Goog-Hash") {
+		for _, part := range strings.Split(h, ",") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(part), "md5="); ok {
+				values = append(values, value)
+			}
+		}
+	}
+	for _, value := range values {
+		if sum, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value)); err == nil && len(sum) == md5.Size {
+			return sum
+		}
+	}
+	return nil
+}
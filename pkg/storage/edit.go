@@ -0,0 +1,527 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// Caption positions accepted by BurnCaptions
+const (
+	CaptionTop    = "top"
+	CaptionCenter = "center"
+	CaptionBottom = "bottom"
+)
+
+// captionColors maps the named caption colors to hex
+var captionColors = map[string]string{
+	"white":  "FFFFFF",
+	"black":  "000000",
+	"yellow": "FFFF00",
+	"red":    "FF0000",
+	"green":  "00FF00",
+	"blue":   "0000FF",
+}
+
+var (
+	hexColorPattern = regexp.MustCompile(`^#?[0-9A-Fa-f]{6}$`)
+	fontNamePattern = regexp.MustCompile(`^[A-Za-z0-9 _-]+$`)
+)
+
+// CaptionOptions describes captions to burn into a video. Exactly one of
+// Text and SRTPath is set.
+type CaptionOptions struct {
+	Text     string  // Caption shown for the whole clip, or from Start to End
+	SRTPath  string  // Subtitle file with its own timing
+	Font     string  // Font family name; empty uses ffmpeg's default
+	FontSize int     // Font size in pixels (default: 48)
+	Color    string  // Color name or #RRGGBB (default: white)
+	Position string  // top, center, or bottom (default: bottom)
+	Start    float64 // Seconds before Text appears
+	End      float64 // Seconds after which Text disappears; 0 shows it to the end
+}
+
+// OutputPath returns the path of a completed video's downloaded file
+func (s *Storage) OutputPath(storageID string) (string, error) {
+	metadata, err := s.LoadMetadata(storageID)
+	if err != nil {
+		return "", err
+	}
+	paths, _ := metadata["paths"].(map[string]interface{})
+	output, _ := paths["output"].(string)
+	if output == "" {
+		return "", fmt.Errorf("video %s has not been downloaded yet; use continue_operation first", storageID)
+	}
+	return filepath.Join(s.folderPath(storageID), output), nil
+}
+
+// BurnCaptions renders a copy of a stored video with captions drawn onto
+// the frames (captioned.mp4 in the storage folder). Text uses the drawtext
+// filter and SRT files the subtitles filter, so both need an ffmpeg built
+// with libfreetype and libass respectively.
+func (s *Storage) BurnCaptions(ctx context.Context, storageID string, opts CaptionOptions) (string, error) {
+	if (opts.Text == "") == (opts.SRTPath == "") {
+		return "", fmt.Errorf("exactly one of text and srt_path is required")
+	}
+	if opts.Font != "" && !fontNamePattern.MatchString(opts.Font) {
+		return "", fmt.Errorf("invalid font name: %s", opts.Font)
+	}
+	if opts.FontSize == 0 {
+		opts.FontSize = 48
+	}
+	if opts.FontSize < 8 || opts.FontSize > 200 {
+		return "", fmt.Errorf("font_size must be between 8 and 200")
+	}
+	switch opts.Position {
+	case "", CaptionTop, CaptionCenter, CaptionBottom:
+	default:
+		return "", fmt.Errorf("invalid position %q (use top, center, or bottom)", opts.Position)
+	}
+	color, err := captionColor(opts.Color)
+	if err != nil {
+		return "", err
+	}
+	if opts.Start < 0 || (opts.End != 0 && opts.End <= opts.Start) {
+		return "", fmt.Errorf("start must not be negative and end must be after start")
+	}
+
+	// Inputs are copied into the storage folder and ffmpeg runs there, so
+	// filters only see plain relative names and need no path escaping
+	folder := s.folderPath(storageID)
+	var filter string
+	if opts.SRTPath != "" {
+		data, err := os.ReadFile(opts.SRTPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read subtitle file: %w", err)
+		}
+		if err := writeFileAtomic(filepath.Join(folder, "captions.srt"), data, 0644); err != nil {
+			return "", fmt.Errorf("failed to save subtitle file: %w", err)
+		}
+		filter = "subtitles=captions.srt:force_style='" + subtitleStyle(opts, color) + "'"
+	} else {
+		if err := writeFileAtomic(filepath.Join(folder, "captions.txt"), []byte(opts.Text), 0644); err != nil {
+			return "", fmt.Errorf("failed to save caption text: %w", err)
+		}
+		filter = drawtextFilter(opts, color)
+	}
+
+	edit := map[string]interface{}{
+		"tool":      "burn_captions",
+		"font_size": opts.FontSize,
+		"color":     "#" + color,
+		"position":  opts.Position,
+	}
+	if opts.SRTPath != "" {
+		edit["srt"] = "captions.srt"
+	} else {
+		edit["text"] = opts.Text
+	}
+	return s.renderVariant(ctx, storageID, "captioned", []string{"-vf", filter}, edit)
+}
+
+// drawtextFilter builds a drawtext filter for plain-text captions
+func drawtextFilter(opts CaptionOptions, color string) string {
+	y := "h-th-h/12"
+	switch opts.Position {
+	case CaptionTop:
+		y = "h/12"
+	case CaptionCenter:
+		y = "(h-th)/2"
+	}
+	filter := fmt.Sprintf("drawtext=textfile=captions.txt:expansion=none:fontsize=%d:fontcolor=0x%s:x=(w-tw)/2:y=%s:box=1:boxcolor=black@0.5:boxborderw=12",
+		opts.FontSize, color, y)
+	if opts.Font != "" {
+		filter += ":font='" + opts.Font + "'"
+	}
+	if opts.Start > 0 || opts.End > 0 {
+		end := "1e9"
+		if opts.End > 0 {
+			end = fmt.Sprintf("%.3f", opts.End)
+		}
+		filter += fmt.Sprintf(":enable='between(t,%.3f,%s)'", opts.Start, end)
+	}
+	return filter
+}
+
+// subtitleStyle builds the ASS force_style for SRT captions. ASS colors are
+// &HBBGGRR and alignments follow the numeric keypad.
+func subtitleStyle(opts CaptionOptions, color string) string {
+	alignment := 2
+	switch opts.Position {
+	case CaptionTop:
+		alignment = 8
+	case CaptionCenter:
+		alignment = 5
+	}
+	style := fmt.Sprintf("FontSize=%d,PrimaryColour=&H%s%s%s,Alignment=%d,BorderStyle=3,BackColour=&H80000000",
+		opts.FontSize, color[4:6], color[2:4], color[0:2], alignment)
+	if opts.Font != "" {
+		style += ",FontName=" + opts.Font
+	}
+	return style
+}
+
+// captionColor resolves a color name or #RRGGBB to uppercase RRGGBB
+func captionColor(color string) (string, error) {
+	if color == "" {
+		return captionColors["white"], nil
+	}
+	if hex, ok := captionColors[strings.ToLower(color)]; ok {
+		return hex, nil
+	}
+	if !hexColorPattern.MatchString(color) {
+		return "", fmt.Errorf("invalid color %q (use white, black, yellow, red, green, blue, or #RRGGBB)", color)
+	}
+	return strings.ToUpper(strings.TrimPrefix(color, "#")), nil
+}
+
+// Watermark corners accepted by Watermark
+const (
+	CornerTopLeft     = "top_left"
+	CornerTopRight    = "top_right"
+	CornerBottomLeft  = "bottom_left"
+	CornerBottomRight = "bottom_right"
+)
+
+// WatermarkOptions describes a logo to overlay on a video
+type WatermarkOptions struct {
+	LogoPath string  // PNG logo, transparency is kept
+	Corner   string  // Corner to place the logo in (default: bottom_right)
+	Opacity  float64 // 0-1 (default: 0.8)
+	Scale    float64 // Logo width as a fraction of the video width (default: 0.15)
+}
+
+// Watermark renders a copy of a stored video with a logo composited in one
+// corner (watermarked.mp4 in the storage folder)
+func (s *Storage) Watermark(ctx context.Context, storageID string, opts WatermarkOptions) (string, error) {
+	if !strings.EqualFold(filepath.Ext(opts.LogoPath), ".png") {
+		return "", fmt.Errorf("logo must be a PNG image")
+	}
+	if opts.Opacity == 0 {
+		opts.Opacity = 0.8
+	}
+	if opts.Opacity < 0 || opts.Opacity > 1 {
+		return "", fmt.Errorf("opacity must be between 0 and 1")
+	}
+	if opts.Scale == 0 {
+		opts.Scale = 0.15
+	}
+	if opts.Scale < 0.01 || opts.Scale > 1 {
+		return "", fmt.Errorf("scale must be between 0.01 and 1")
+	}
+
+	// Margin from the edges is 3% of the video width
+	margin := "main_w*0.03"
+	var x, y string
+	switch opts.Corner {
+	case CornerTopLeft:
+		x, y = margin, margin
+	case CornerTopRight:
+		x, y = "main_w-overlay_w-"+margin, margin
+	case CornerBottomLeft:
+		x, y = margin, "main_h-overlay_h-"+margin
+	case "", CornerBottomRight:
+		x, y = "main_w-overlay_w-"+margin, "main_h-overlay_h-"+margin
+	default:
+		return "", fmt.Errorf("invalid corner %q (use top_left, top_right, bottom_left, or bottom_right)", opts.Corner)
+	}
+
+	data, err := os.ReadFile(opts.LogoPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read logo: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(s.folderPath(storageID), "watermark.png"), data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save logo: %w", err)
+	}
+
+	// Size the logo from the video width when ffprobe can read it
+	scale := ""
+	if videoPath, err := s.OutputPath(storageID); err == nil {
+		if _, resolution, _ := s.ExtractVideoMetadata(ctx, videoPath); resolution != "" {
+			var width, height int
+			if n, _ := fmt.Sscanf(resolution, "%dx%d", &width, &height); n == 2 && width > 0 {
+				scale = fmt.Sprintf("scale=%d:-1,", max(2, int(float64(width)*opts.Scale)))
+			}
+		}
+	}
+
+	filter := fmt.Sprintf("[1:v]%sformat=rgba,colorchannelmixer=aa=%.2f[logo];[0:v][logo]overlay=x=%s:y=%s",
+		scale, opts.Opacity, x, y)
+	return s.renderVariant(ctx, storageID, "watermarked", []string{"-i", "watermark.png", "-filter_complex", filter}, map[string]interface{}{
+		"tool":    "watermark_video",
+		"logo":    "watermark.png",
+		"corner":  opts.Corner,
+		"opacity": opts.Opacity,
+		"scale":   opts.Scale,
+	})
+}
+
+// Reframe modes accepted by Reframe
+const (
+	ReframeCrop = "crop"
+	ReframePad  = "pad"
+)
+
+// reframeRatios holds the aspect ratios Reframe converts to
+var reframeRatios = map[string][2]int{
+	"9:16": {9, 16},
+	"16:9": {16, 9},
+	"1:1":  {1, 1},
+	"4:5":  {4, 5},
+}
+
+// ReframeOptions describes a change of aspect ratio
+type ReframeOptions struct {
+	AspectRatio string   // Target ratio: 9:16, 16:9, 1:1, or 4:5
+	Mode        string   // crop (default) fills the frame, pad letterboxes
+	Focus       *float64 // Crop position from 0 (left/top) to 1 (right/bottom), default 0.5
+}
+
+// Reframe renders a copy of a stored video in another aspect ratio
+// (reframed_<w>x<h>.mp4 in the storage folder), cropping around the focus
+// position or padding with black bars
+func (s *Storage) Reframe(ctx context.Context, storageID string, opts ReframeOptions) (string, error) {
+	ratio, ok := reframeRatios[opts.AspectRatio]
+	if !ok {
+		return "", fmt.Errorf("invalid aspect_ratio %q (use 9:16, 16:9, 1:1, or 4:5)", opts.AspectRatio)
+	}
+	focus := 0.5
+	if opts.Focus != nil {
+		focus = *opts.Focus
+	}
+	if focus < 0 || focus > 1 {
+		return "", fmt.Errorf("focus must be between 0 and 1")
+	}
+
+	// Sizes are rounded down to even numbers, which libx264 requires
+	w, h := ratio[0], ratio[1]
+	var filter string
+	switch opts.Mode {
+	case "", ReframeCrop:
+		// Only one of the offsets is non-zero, so focus moves the crop
+		// horizontally for wider sources and vertically for taller ones
+		filter = fmt.Sprintf("crop=w='trunc(min(iw,ih*%d/%d)/2)*2':h='trunc(min(ih,iw*%d/%d)/2)*2':x='(iw-ow)*%.3f':y='(ih-oh)*%.3f'",
+			w, h, h, w, focus, focus)
+	case ReframePad:
+		filter = fmt.Sprintf("pad=w='trunc(max(iw,ih*%d/%d)/2)*2':h='trunc(max(ih,iw*%d/%d)/2)*2':x='(ow-iw)/2':y='(oh-ih)/2':color=black",
+			w, h, h, w)
+	default:
+		return "", fmt.Errorf("invalid mode %q (use crop or pad)", opts.Mode)
+	}
+
+	return s.renderVariant(ctx, storageID, fmt.Sprintf("reframed_%dx%d", w, h), []string{"-vf", filter + ",setsar=1"}, map[string]interface{}{
+		"tool":         "reframe_video",
+		"aspect_ratio": opts.AspectRatio,
+		"mode":         opts.Mode,
+		"focus":        focus,
+	})
+}
+
+// Interpolation methods accepted by Interpolate
+const (
+	InterpolateMotion    = "minterpolate" // Motion-compensated, smoothest and slowest
+	InterpolateBlend     = "blend"        // Crossfades neighbouring frames
+	InterpolateDuplicate = "duplicate"    // Repeats frames, only changes the rate
+)
+
+// InterpolateOptions describes a frame rate conversion
+type InterpolateOptions struct {
+	FPS        int     // Target frame rate (default: 30)
+	Method     string  // minterpolate (default), blend, or duplicate
+	SlowMotion float64 // Playback slowdown factor, 1-8; 0 or 1 keeps the speed
+}
+
+// Interpolate renders a copy of a stored video at a higher frame rate
+// (interpolated.mp4 in the storage folder), synthesizing the new frames
+// with ffmpeg's minterpolate filter. With SlowMotion the clip is stretched
+// first, so the synthesized frames make the slow motion smooth; the audio
+// is dropped then, since it would no longer match.
+func (s *Storage) Interpolate(ctx context.Context, storageID string, opts InterpolateOptions) (string, error) {
+	if opts.FPS == 0 {
+		opts.FPS = 30
+	}
+	if opts.FPS < 1 || opts.FPS > 120 {
+		return "", fmt.Errorf("fps must be between 1 and 120")
+	}
+	if opts.SlowMotion == 0 {
+		opts.SlowMotion = 1
+	}
+	if opts.SlowMotion < 1 || opts.SlowMotion > 8 {
+		return "", fmt.Errorf("slow_motion must be between 1 and 8")
+	}
+
+	var filter string
+	switch opts.Method {
+	case "", InterpolateMotion:
+		opts.Method = InterpolateMotion
+		filter = fmt.Sprintf("minterpolate=fps=%d:mi_mode=mci:mc_mode=aobmc:me_mode=bidir:vsbmc=1", opts.FPS)
+	case InterpolateBlend:
+		filter = fmt.Sprintf("minterpolate=fps=%d:mi_mode=blend", opts.FPS)
+	case InterpolateDuplicate:
+		filter = fmt.Sprintf("fps=%d", opts.FPS)
+	default:
+		return "", fmt.Errorf("invalid method %q (use minterpolate, blend, or duplicate)", opts.Method)
+	}
+
+	args := []string{}
+	if opts.SlowMotion > 1 {
+		filter = fmt.Sprintf("setpts=%.3f*PTS,%s", opts.SlowMotion, filter)
+		args = append(args, "-an")
+	}
+	args = append(args, "-vf", filter)
+
+	return s.renderVariant(ctx, storageID, "interpolated", args, map[string]interface{}{
+		"tool":        "interpolate_frames",
+		"method":      opts.Method,
+		"fps":         opts.FPS,
+		"slow_motion": opts.SlowMotion,
+	})
+}
+
+// Loop styles accepted by MakeLoop
+const (
+	LoopBoomerang = "boomerang" // Plays forward then in reverse
+	LoopCrossfade = "crossfade" // Fades the tail into the head
+)
+
+// LoopOptions describes a seamless loop
+type LoopOptions struct {
+	Style     string  // boomerang (default) or crossfade
+	Count     int     // Times the loop plays in the output, 1-20 (default: 1)
+	Crossfade float64 // Crossfade seconds, at most half the clip (default: 1)
+}
+
+// MakeLoop renders a copy of a stored video whose last frame flows back into
+// its first (loop.mp4 in the storage folder), repeated Count times. A
+// boomerang plays forward then reversed; a crossfade blends the last seconds
+// into the opening, shortening the clip by the crossfade. Audio is dropped,
+// since it can't loop with the picture.
+func (s *Storage) MakeLoop(ctx context.Context, storageID string, opts LoopOptions) (string, error) {
+	if opts.Count == 0 {
+		opts.Count = 1
+	}
+	if opts.Count < 1 || opts.Count > 20 {
+		return "", fmt.Errorf("count must be between 1 and 20")
+	}
+
+	var filter string
+	switch opts.Style {
+	case "", LoopBoomerang:
+		opts.Style = LoopBoomerang
+		opts.Crossfade = 0
+		filter = "[0:v]split[fwd][back];[back]reverse[rev];[fwd][rev]concat=n=2:v=1:a=0"
+	case LoopCrossfade:
+		if opts.Crossfade == 0 {
+			opts.Crossfade = 1
+		}
+		videoPath, err := s.OutputPath(storageID)
+		if err != nil {
+			return "", err
+		}
+		duration, _, _ := s.ExtractVideoMetadata(ctx, videoPath)
+		if duration <= 0 {
+			return "", fmt.Errorf("could not determine video duration")
+		}
+		if opts.Crossfade <= 0 || opts.Crossfade > duration/2 {
+			return "", fmt.Errorf("crossfade must be between 0 and %.2f seconds (half the clip)", duration/2)
+		}
+		// The body starts crossfade seconds in and fades into the opening
+		// seconds, so the output ends on the frame it starts with
+		filter = fmt.Sprintf("[0:v]split[a][b];[a]trim=start=%[1]f,setpts=PTS-STARTPTS[body];[b]trim=end=%[1]f,setpts=PTS-STARTPTS[head];[body][head]xfade=transition=fade:duration=%[1]f:offset=%[2]f",
+			opts.Crossfade, duration-2*opts.Crossfade)
+	default:
+		return "", fmt.Errorf("invalid style %q (use boomerang or crossfade)", opts.Style)
+	}
+	if opts.Count > 1 {
+		filter += fmt.Sprintf(",loop=loop=%d:size=32767:start=0,setpts=N/FRAME_RATE/TB", opts.Count-1)
+	}
+
+	return s.renderVariant(ctx, storageID, "loop", []string{"-filter_complex", filter, "-an"}, map[string]interface{}{
+		"tool":      "make_loop",
+		"style":     opts.Style,
+		"count":     opts.Count,
+		"crossfade": opts.Crossfade,
+	})
+}
+
+// renderVariant re-encodes a stored video through ffmpeg with filterArgs,
+// which may add further inputs relative to the storage folder, and saves
+// the result as <name>.mp4 next to the original. The file is recorded under
+// paths[name] in metadata and edit, describing how it was made, under
+// edits[name]. ffmpeg runs in the storage folder and writes to a temporary
+// file, so a failed render never replaces an earlier variant.
+func (s *Storage) renderVariant(ctx context.Context, storageID, name string, filterArgs []string, edit map[string]interface{}) (string, error) {
+	ffmpegPath, err := ffmpegBinary()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg is required to edit videos")
+	}
+	videoPath, err := s.OutputPath(storageID)
+	if err != nil {
+		return "", err
+	}
+
+	folder := s.folderPath(storageID)
+	outputPath := filepath.Join(folder, name+".mp4")
+	tmpPath := filepath.Join(folder, "."+name+".mp4"+tempSuffix)
+	defer os.Remove(tmpPath)
+
+	args := []string{"-i", videoPath}
+	args = append(args, filterArgs...)
+	args = append(args,
+		"-c:v", "libx264",
+		"-pix_fmt", "yuv420p",
+		"-c:a", "copy",
+		"-movflags", "+faststart",
+		"-f", "mp4",
+		"-y",
+		tmpPath,
+	)
+	cmd := mediaCommand(ctx, renderTimeout, ffmpegPath, args...)
+	cmd.Dir = folder
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		logging.Warn("failed to render video", "storage_id", storageID, "variant", name, "error", err, "output", string(output))
+		return "", fmt.Errorf("ffmpeg failed to render %s video: %s", name, lastLine(string(output)))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		return "", fmt.Errorf("failed to save %s video: %w", name, err)
+	}
+
+	err = s.UpdateMetadata(storageID, func(metadata map[string]interface{}) error {
+		paths, _ := metadata["paths"].(map[string]interface{})
+		if paths == nil {
+			paths = make(map[string]interface{})
+		}
+		paths[name] = filepath.Base(outputPath)
+		metadata["paths"] = paths
+
+		edits, _ := metadata["edits"].(map[string]interface{})
+		if edits == nil {
+			edits = make(map[string]interface{})
+		}
+		edit["created_at"] = time.Now().Format(time.RFC3339)
+		edits[name] = edit
+		metadata["edits"] = edits
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	logging.Info("rendered video", "storage_id", storageID, "variant", name, "path", outputPath)
+	return outputPath, nil
+}
+
+// lastLine returns the last non-empty line of command output, which for
+// ffmpeg is usually the actual error
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
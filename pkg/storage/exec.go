@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// Limits on ffmpeg and ffprobe runs, unless SetMediaLimits changes them
+var (
+	probeTimeout  = 60 * time.Second
+	renderTimeout = 10 * time.Minute
+	mediaSlots    = make(chan struct{}, 2)
+)
+
+// SetMediaLimits sets how long ffmpeg and ffprobe may run when reading a
+// video (probe) or decoding all of it (render), and how many may run at
+// once. It must be called before the tools are first used.
+func SetMediaLimits(probe, render time.Duration, maxConcurrent int) {
+	if probe > 0 {
+		probeTimeout = probe
+	}
+	if render > 0 {
+		renderTimeout = render
+	}
+	if maxConcurrent > 0 {
+		mediaSlots = make(chan struct{}, maxConcurrent)
+	}
+}
+
+// mediaCmd is an ffmpeg or ffprobe run, stopped with its context or after
+// its timeout. The whole process group is killed, so filters that spawn
+// helpers don't outlive it.
+type mediaCmd struct {
+	*exec.Cmd
+	ctx     context.Context
+	cancel  context.CancelFunc
+	timeout time.Duration
+}
+
+// mediaCommand prepares the tool at path to run with args for at most
+// timeout, or until ctx ends
+func mediaCommand(ctx context.Context, timeout time.Duration, path string, args ...string) *mediaCmd {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	cmd := exec.CommandContext(ctx, path, args...)
+	killProcessGroup(cmd)
+	// Give up on pipes held open by orphaned children after the kill
+	cmd.WaitDelay = 5 * time.Second
+	return &mediaCmd{Cmd: cmd, ctx: ctx, cancel: cancel, timeout: timeout}
+}
+
+// Output runs the command once a slot is free and returns its stdout
+func (c *mediaCmd) Output() ([]byte, error) {
+	return c.run(c.Cmd.Output)
+}
+
+// CombinedOutput runs the command once a slot is free and returns its
+// stdout and stderr
+func (c *mediaCmd) CombinedOutput() ([]byte, error) {
+	return c.run(c.Cmd.CombinedOutput)
+}
+
+func (c *mediaCmd) run(run func() ([]byte, error)) ([]byte, error) {
+	defer c.cancel()
+	select {
+	case mediaSlots <- struct{}{}:
+		defer func() { <-mediaSlots }()
+	case <-c.ctx.Done():
+		return nil, c.explain(c.ctx.Err())
+	}
+	output, err := run()
+	if err != nil {
+		return output, c.explain(err)
+	}
+	return output, nil
+}
+
+// explain reports a run stopped by its timeout as such, rather than as the
+// bare "signal: killed"
+func (c *mediaCmd) explain(err error) error {
+	if errors.Is(c.ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s: %w", c.Path, c.timeout, err)
+	}
+	return err
+}
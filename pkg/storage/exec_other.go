@@ -0,0 +1,9 @@
+//go:build !unix
+
+package storage
+
+import "os/exec"
+
+// killProcessGroup leaves canceling cmd to kill only its process where
+// process groups aren't available
+func killProcessGroup(cmd *exec.Cmd) {}
@@ -0,0 +1,17 @@
+//go:build unix
+
+package storage
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// killProcessGroup starts cmd in its own process group and makes canceling
+// it kill the whole group
+func killProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
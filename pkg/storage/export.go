@@ -0,0 +1,308 @@
+package storage
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// Export formats
+const (
+	ExportZip       = "zip"
+	ExportDirectory = "directory"
+)
+
+// exportsFolder is the default destination under the storage root
+const exportsFolder = "exports"
+
+// manifestFile is written at the top of every export
+const manifestFile = "manifest.json"
+
+// ExportedVideo describes one video in an export manifest
+type ExportedVideo struct {
+	StorageID      string   `json:"storage_id"`
+	PredictionID   string   `json:"prediction_id,omitempty"`
+	Model          string   `json:"model,omitempty"`
+	Prompt         string   `json:"prompt,omitempty"`
+	NegativePrompt string   `json:"negative_prompt,omitempty"`
+	Project        string   `json:"project,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Favorite       bool     `json:"favorite,omitempty"`
+	CreatedAt      string   `json:"created_at,omitempty"`
+	Files          []string `json:"files"` // Relative to the export root
+}
+
+// ExportManifest is written as manifest.json alongside the exported videos
+type ExportManifest struct {
+	Name       string          `json:"name"`
+	ExportedAt string          `json:"exported_at"`
+	Count      int             `json:"count"`
+	Videos     []ExportedVideo `json:"videos"`
+}
+
+// ExportResult describes a finished export
+type ExportResult struct {
+	Path     string
+	Format   string
+	Size     int64
+	Manifest ExportManifest
+}
+
+// exportWriter receives the files of an export, either into a zip archive
+// or a directory
+type exportWriter interface {
+	copyFile(rel, src string) error
+	writeFile(rel string, data []byte) error
+}
+
+// Export bundles the given storage IDs (video, metadata, thumbnail and any
+// other stored files) into <dir>/<name>.zip or the directory <dir>/<name>/,
+// with a manifest.json describing each video. An empty dir exports to
+// <root>/exports.
+func (s *Storage) Export(storageIDs []string, dir, name, format string) (*ExportResult, error) {
+	if len(storageIDs) == 0 {
+		return nil, fmt.Errorf("no videos to export")
+	}
+	if format != ExportZip && format != ExportDirectory {
+		return nil, fmt.Errorf("unsupported export format %q (use %s or %s)", format, ExportZip, ExportDirectory)
+	}
+	if name == "" {
+		name = "replicate-videos-" + time.Now().Format("20060102-150405")
+	}
+	if !projectNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid export name %q: use letters, digits, spaces, '.', '_' or '-' (max 64 characters)", name)
+	}
+	if dir == "" {
+		dir = filepath.Join(s.rootFolder, exportsFolder)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	// Check every video before writing anything
+	manifest := ExportManifest{
+		Name:       name,
+		ExportedAt: time.Now().Format(time.RFC3339),
+	}
+	sources := make(map[string][]string)
+	for _, storageID := range storageIDs {
+		if err := ValidateStorageID(storageID); err != nil {
+			return nil, err
+		}
+		if _, ok := sources[storageID]; ok {
+			continue
+		}
+		files, err := s.exportFiles(storageID)
+		if err != nil {
+			return nil, err
+		}
+		sources[storageID] = files
+
+		metadata, err := s.LoadMetadata(storageID)
+		if err != nil {
+			return nil, err
+		}
+		record := Record{StorageID: storageID, Metadata: metadata}
+		video := ExportedVideo{
+			StorageID:      storageID,
+			PredictionID:   record.String("prediction_id"),
+			Model:          record.ModelName(),
+			Prompt:         record.Parameter("prompt"),
+			NegativePrompt: record.Parameter("negative_prompt"),
+			Project:        record.String("project"),
+			Tags:           record.Tags(),
+			Favorite:       record.Favorite(),
+			CreatedAt:      record.String("created_at"),
+		}
+		for _, file := range files {
+			video.Files = append(video.Files, filepath.ToSlash(filepath.Join(storageID, filepath.Base(file))))
+		}
+		manifest.Videos = append(manifest.Videos, video)
+	}
+	manifest.Count = len(manifest.Videos)
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	result := &ExportResult{Format: format, Manifest: manifest}
+	if format == ExportZip {
+		result.Path = filepath.Join(dir, name+".zip")
+	} else {
+		result.Path = filepath.Join(dir, name)
+	}
+	if _, err := os.Stat(result.Path); err == nil {
+		return nil, fmt.Errorf("export destination already exists: %s", result.Path)
+	}
+
+	write := func(w exportWriter) error {
+		for _, video := range manifest.Videos {
+			for _, src := range sources[video.StorageID] {
+				if err := w.copyFile(filepath.Join(video.StorageID, filepath.Base(src)), src); err != nil {
+					return err
+				}
+			}
+		}
+		return w.writeFile(manifestFile, manifestData)
+	}
+
+	if format == ExportZip {
+		err = writeZip(result.Path, write)
+	} else {
+		err = writeDirectory(result.Path, write)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result.Size = pathSize(result.Path)
+	logging.Info("videos exported", "path", result.Path, "format", format, "count", manifest.Count, "size", result.Size)
+	return result, nil
+}
+
+// exportFiles lists the files stored for a video, sorted by name
+func (s *Storage) exportFiles(storageID string) ([]string, error) {
+	folder := s.folderPath(storageID)
+	if _, err := os.Stat(filepath.Join(folder, "metadata.yaml")); err != nil {
+		return nil, fmt.Errorf("storage ID not found: %s", storageID)
+	}
+
+	entries, err := os.ReadDir(folder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read storage folder: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || entry.Name() == locationFile || isTempFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(folder, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// writeZip writes an export to a zip archive, removing the partial archive
+// on failure
+func writeZip(path string, write func(exportWriter) error) error {
+	tmp := path + ".partial"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create archive: %w", err)
+	}
+
+	zw := zipWriter{zip.NewWriter(f)}
+	err = write(zw)
+	if closeErr := zw.w.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to finish archive: %w", closeErr)
+	}
+	if closeErr := f.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to finish archive: %w", closeErr)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeDirectory copies an export into a new directory, removing it on
+// failure
+func writeDirectory(path string, write func(exportWriter) error) error {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return fmt.Errorf("failed to create export folder: %w", err)
+	}
+	if err := write(dirWriter(path)); err != nil {
+		os.RemoveAll(path)
+		return err
+	}
+	return nil
+}
+
+type zipWriter struct {
+	w *zip.Writer
+}
+
+func (z zipWriter) copyFile(rel, src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", src, err)
+	}
+	header, err := zip.FileInfoHeader(info)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", rel, err)
+	}
+	header.Name = filepath.ToSlash(rel)
+	header.Method = zip.Deflate
+
+	out, err := z.w.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", rel, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		return fmt.Errorf("failed to add %s: %w", rel, err)
+	}
+	return nil
+}
+
+func (z zipWriter) writeFile(rel string, data []byte) error {
+	out, err := z.w.CreateHeader(&zip.FileHeader{
+		Name:     filepath.ToSlash(rel),
+		Method:   zip.Deflate,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add %s: %w", rel, err)
+	}
+	if _, err := out.Write(data); err != nil {
+		return fmt.Errorf("failed to add %s: %w", rel, err)
+	}
+	return nil
+}
+
+type dirWriter string
+
+func (d dirWriter) copyFile(rel, src string) error {
+	dst := filepath.Join(string(d), rel)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(dst), err)
+	}
+	_, err := copyFile(src, dst)
+	return err
+}
+
+func (d dirWriter) writeFile(rel string, data []byte) error {
+	if err := os.WriteFile(filepath.Join(string(d), rel), data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", rel, err)
+	}
+	return nil
+}
+
+// pathSize returns the size of a file, or the total size of a directory
+func pathSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,192 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultFilenameTemplate names every downloaded video "video.<ext>"
+const DefaultFilenameTemplate = "video"
+
+// maxPromptSlug limits how much of the prompt {prompt_slug} includes
+const maxPromptSlug = 40
+
+var (
+	placeholderPattern = regexp.MustCompile(`\{([a-z_]+)\}`)
+	slugUnsafePattern  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// filenamePlaceholders are the values a filename template may use
+var filenamePlaceholders = map[string]func(Record) string{
+	"date": func(r Record) string { return createdAt(r).Format("2006-01-02") },
+	"time": func(r Record) string { return createdAt(r).Format("150405") },
+	"model": func(r Record) string {
+		// The model name without its owner, e.g. "veo-3" for google/veo-3
+		if model, ok := r.Metadata["model"].(map[string]interface{}); ok {
+			if id, ok := model["id"].(string); ok && id != "" {
+				return slugify(id[strings.LastIndex(id, "/")+1:], 0)
+			}
+		}
+		return ""
+	},
+	"prompt_slug":   func(r Record) string { return slugify(r.Parameter("prompt"), maxPromptSlug) },
+	"storage_id":    func(r Record) string { return r.StorageID },
+	"prediction_id": func(r Record) string { return slugify(r.String("prediction_id"), 0) },
+	"project":       func(r Record) string { return slugify(r.String("project"), 0) },
+}
+
+// sampleRecord fills every placeholder when a template is checked
+var sampleRecord = Record{
+	StorageID: "sample",
+	Metadata: map[string]interface{}{
+		"model":         map[string]interface{}{"id": "owner/model"},
+		"parameters":    map[string]interface{}{"prompt": "sample prompt"},
+		"prediction_id": "sample",
+		"project":       "sample",
+	},
+}
+
+// ValidateFilenameTemplate checks that a template only uses known
+// placeholders, names a file rather than a path, and can't name one of the
+// files a storage folder keeps besides the video
+func ValidateFilenameTemplate(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("filename template is empty")
+	}
+	if strings.ContainsAny(template, `/\`) || template == "." || template == ".." {
+		return fmt.Errorf("filename template %q must not contain a path", template)
+	}
+	for _, match := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		if _, ok := filenamePlaceholders[match[1]]; !ok {
+			return fmt.Errorf("unknown placeholder {%s} in filename template (available: %s)", match[1], placeholderList())
+		}
+	}
+	// Whatever extension is added, the name must not be a reserved file's
+	for _, record := range []Record{sampleRecord, {StorageID: "sample"}} {
+		name := strings.ToLower(renderFilename(template, record, ""))
+		for reserved := range reservedFilenames {
+			if name == reserved || name == strings.TrimSuffix(reserved, filepath.Ext(reserved)) {
+				return fmt.Errorf("filename template %q names the reserved file %s", template, reserved)
+			}
+		}
+	}
+	return nil
+}
+
+// reservedFilenames are files in a storage folder that a requested video
+// filename must not replace
+var reservedFilenames = map[string]bool{
+	"metadata.yaml":     true,
+	"thumbnail.jpg":     true,
+	"contact_sheet.jpg": true,
+	LogsFile:            true,
+}
+
+// CleanFilename checks a requested video filename, e.g. from the filename
+// tool parameter, and returns it trimmed. It must name a file in the
+// storage folder: no directories, no "..", no hidden or reserved files.
+func CleanFilename(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	switch {
+	case name == "":
+		return "", fmt.Errorf("filename is empty")
+	case strings.ContainsAny(name, `/\:`) || filepath.Base(name) != name:
+		return "", fmt.Errorf("filename %q must be a file name, not a path", name)
+	case strings.HasPrefix(name, "."):
+		return "", fmt.Errorf("filename %q must not start with a dot", name)
+	case strings.ContainsFunc(name, func(r rune) bool { return r < ' ' || r == 0x7f }):
+		return "", fmt.Errorf("filename %q contains control characters", name)
+	case len(name) > 200:
+		return "", fmt.Errorf("filename is longer than 200 characters")
+	case reservedFilenames[strings.ToLower(name)]:
+		return "", fmt.Errorf("filename %q is reserved", name)
+	}
+	return name, nil
+}
+
+// SetFilenameTemplate sets the template used to name downloaded videos, e.g.
+// "{date}_{model}_{prompt_slug}_{storage_id}"
+func (s *Storage) SetFilenameTemplate(template string) error {
+	if template == "" {
+		template = DefaultFilenameTemplate
+	}
+	if err := ValidateFilenameTemplate(template); err != nil {
+		return err
+	}
+	s.filenameTemplate = template
+	return nil
+}
+
+// videoFilename renders the filename template for a storage ID from its
+// metadata. ext is used unless the template ends with its own extension.
+func (s *Storage) videoFilename(storageID, ext string) string {
+	template := s.filenameTemplate
+	if template == "" {
+		template = DefaultFilenameTemplate
+	}
+
+	record := Record{StorageID: storageID}
+	if placeholderPattern.MatchString(template) {
+		if metadata, err := s.LoadMetadata(storageID); err == nil {
+			record.Metadata = metadata
+		}
+	}
+	return renderFilename(template, record, ext)
+}
+
+// renderFilename fills a filename template's placeholders from a record.
+// ext is added unless the template ends with its own extension.
+func renderFilename(template string, record Record, ext string) string {
+	name := placeholderPattern.ReplaceAllStringFunc(template, func(placeholder string) string {
+		return filenamePlaceholders[strings.Trim(placeholder, "{}")](record)
+	})
+	// Placeholders without a value leave stray separators behind
+	name = strings.Trim(name, "_- .")
+	for _, sep := range []string{"__", "--"} {
+		for strings.Contains(name, sep) {
+			name = strings.ReplaceAll(name, sep, sep[:1])
+		}
+	}
+	if name == "" {
+		name = DefaultFilenameTemplate
+	}
+
+	if filepath.Ext(name) == "" {
+		name += ext
+	}
+	return name
+}
+
+// createdAt returns when a generation was created, or now for new ones
+func createdAt(r Record) time.Time {
+	if t, err := time.Parse(time.RFC3339, r.String("created_at")); err == nil {
+		return t.Local()
+	}
+	return time.Now()
+}
+
+// slugify lowercases s and joins its words with '-', cut at a word boundary
+// to at most max characters (0 for no limit)
+func slugify(s string, max int) string {
+	slug := strings.Trim(slugUnsafePattern.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	if max > 0 && len(slug) > max {
+		slug = slug[:max]
+		if i := strings.LastIndex(slug, "-"); i > 0 {
+			slug = slug[:i]
+		}
+	}
+	return slug
+}
+
+func placeholderList() string {
+	names := make([]string, 0, len(filenamePlaceholders))
+	for name := range filenamePlaceholders {
+		names = append(names, "{"+name+"}")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
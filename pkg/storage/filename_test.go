@@ -0,0 +1,98 @@
+package storage
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateFilenameTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		wantErr  string
+	}{
+		{"video", ""},
+		{"{date}_{model}_{prompt_slug}_{storage_id}", ""},
+		{"{project}-{prediction_id}.mp4", ""},
+		{"  ", "filename template is empty"},
+		{"clips/{storage_id}", "must not contain a path"},
+		{`clips\{storage_id}`, "must not contain a path"},
+		{"..", "must not contain a path"},
+		{"{seed}", "unknown placeholder {seed}"},
+		{"Metadata", "names the reserved file metadata.yaml"},
+		{"logs.txt", "names the reserved file logs.txt"},
+		{"contact_sheet_{project}", "names the reserved file contact_sheet.jpg"}, // Once {project} is empty
+	}
+
+	for _, tt := range tests {
+		err := ValidateFilenameTemplate(tt.template)
+		if tt.wantErr == "" && err != nil {
+			t.Errorf("ValidateFilenameTemplate(%q) error = %v", tt.template, err)
+		}
+		if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+			t.Errorf("ValidateFilenameTemplate(%q) error = %v, want %q", tt.template, err, tt.wantErr)
+		}
+	}
+}
+
+func TestVideoFilename(t *testing.T) {
+	s := NewStorage(t.TempDir(), false)
+	err := s.SaveMetadata("a1b2c3d4", map[string]interface{}{
+		"model":         map[string]interface{}{"id": "google/veo-3"},
+		"parameters":    map[string]interface{}{"prompt": "A Cat, surfing at sunset! On a very tall wave near the beach"},
+		"prediction_id": "abc123",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"", "video.mp4"},
+		{"{model}_{storage_id}", "veo-3_a1b2c3d4.mp4"},
+		{"{prompt_slug}", "a-cat-surfing-at-sunset-on-a-very-tall.mp4"}, // Cut at a word boundary
+		{"{prediction_id}.mov", "abc123.mov"},                           // The template's own extension
+		{"{model}__{project}__{storage_id}", "veo-3_a1b2c3d4.mp4"},      // Empty placeholders leave no separators
+		{"{project}", "video.mp4"},
+	}
+
+	for _, tt := range tests {
+		if err := s.SetFilenameTemplate(tt.template); err != nil {
+			t.Fatal(err)
+		}
+		if got := s.videoFilename("a1b2c3d4", ".mp4"); got != tt.want {
+			t.Errorf("videoFilename() with %q = %q, want %q", tt.template, got, tt.want)
+		}
+	}
+}
+
+func TestCleanFilename(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr string
+	}{
+		{"  clip.mp4 ", "clip.mp4", ""},
+		{"", "", "filename is empty"},
+		{"../clip.mp4", "", "must be a file name, not a path"},
+		{"c:clip.mp4", "", "must be a file name, not a path"},
+		{".hidden.mp4", "", "must not start with a dot"},
+		{"clip\n.mp4", "", "contains control characters"},
+		{strings.Repeat("a", 201), "", "longer than 200 characters"},
+		{"METADATA.yaml", "", "is reserved"},
+	}
+
+	for _, tt := range tests {
+		got, err := CleanFilename(tt.name)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CleanFilename(%q) error = %v, want %q", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("CleanFilename(%q) = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
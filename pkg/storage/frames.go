@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// framesFolder is the subfolder of a storage folder that extracted frames
+// are written to
+const framesFolder = "frames"
+
+// maxFrames caps how many frames one extraction writes
+const maxFrames = 500
+
+// FrameOptions selects the frames to extract from a video. With Timestamps
+// set, one frame is taken at each; otherwise every Every-th frame is.
+type FrameOptions struct {
+	Every      int       // Take every Nth frame; 1 (default) takes all frames
+	Timestamps []float64 // Seconds into the video to take single frames at
+	Format     string    // png (default) or jpg
+}
+
+// Frame is one extracted frame
+type Frame struct {
+	Path      string
+	Timestamp float64 // Seconds into the video, when taken at a timestamp
+}
+
+// ExtractFrames writes frames of a completed video as images into the
+// frames/ subfolder of its storage folder, replacing frames from earlier
+// extractions, and returns them in order. At most 500 frames are written.
+func (s *Storage) ExtractFrames(ctx context.Context, storageID string, opts FrameOptions) ([]Frame, error) {
+	ffmpegPath, err := ffmpegBinary()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg is required to extract frames")
+	}
+	videoPath, err := s.OutputPath(storageID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch opts.Format {
+	case "", "png":
+		opts.Format = "png"
+	case "jpg", "jpeg":
+		opts.Format = "jpg"
+	default:
+		return nil, fmt.Errorf("invalid format %q (use png or jpg)", opts.Format)
+	}
+	if opts.Every == 0 {
+		opts.Every = 1
+	}
+	if opts.Every < 1 {
+		return nil, fmt.Errorf("every must be at least 1")
+	}
+	if len(opts.Timestamps) > maxFrames {
+		return nil, fmt.Errorf("at most %d timestamps can be extracted at once", maxFrames)
+	}
+	for _, t := range opts.Timestamps {
+		if t < 0 {
+			return nil, fmt.Errorf("timestamps must not be negative")
+		}
+	}
+
+	unlock := s.Lock(storageID)
+	defer unlock()
+
+	folder := filepath.Join(s.folderPath(storageID), framesFolder)
+	if err := os.RemoveAll(folder); err != nil {
+		return nil, fmt.Errorf("failed to clear frames folder: %w", err)
+	}
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create frames folder: %w", err)
+	}
+
+	var frames []Frame
+	if len(opts.Timestamps) > 0 {
+		for i, t := range opts.Timestamps {
+			path := filepath.Join(folder, fmt.Sprintf("frame_%04d.%s", i+1, opts.Format))
+			// Seeking before -i is fast and exact when re-encoding
+			output, err := mediaCommand(ctx, probeTimeout, ffmpegPath,
+				"-ss", fmt.Sprintf("%.3f", t),
+				"-i", videoPath,
+				"-frames:v", "1",
+				"-q:v", "2",
+				"-y",
+				path,
+			).CombinedOutput()
+			if err != nil {
+				logging.Warn("failed to extract frame", "storage_id", storageID, "timestamp", t, "error", err, "output", string(output))
+				return nil, fmt.Errorf("failed to extract frame at %.3fs: %s", t, lastLine(string(output)))
+			}
+			if _, err := os.Stat(path); err != nil {
+				return nil, fmt.Errorf("no frame at %.3fs (past the end of the video?)", t)
+			}
+			frames = append(frames, Frame{Path: path, Timestamp: t})
+		}
+	} else {
+		output, err := mediaCommand(ctx, renderTimeout, ffmpegPath,
+			"-i", videoPath,
+			"-vf", fmt.Sprintf("select='not(mod(n,%d))'", opts.Every),
+			"-vsync", "vfr",
+			"-frames:v", fmt.Sprintf("%d", maxFrames),
+			"-q:v", "2",
+			"-y",
+			filepath.Join(folder, "frame_%04d."+opts.Format),
+		).CombinedOutput()
+		if err != nil {
+			logging.Warn("failed to extract frames", "storage_id", storageID, "error", err, "output", string(output))
+			return nil, fmt.Errorf("failed to extract frames: %s", lastLine(string(output)))
+		}
+		entries, err := os.ReadDir(folder)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list frames: %w", err)
+		}
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Name(), "frame_") {
+				frames = append(frames, Frame{Path: filepath.Join(folder, entry.Name())})
+			}
+		}
+		sort.Slice(frames, func(i, j int) bool { return frames[i].Path < frames[j].Path })
+	}
+
+	// Record the folder so the frames show up with the video's other files
+	metadata, err := s.LoadMetadata(storageID)
+	if err != nil {
+		return nil, err
+	}
+	paths, _ := metadata["paths"].(map[string]interface{})
+	if paths == nil {
+		paths = make(map[string]interface{})
+	}
+	paths[framesFolder] = framesFolder
+	metadata["paths"] = paths
+	if err := s.SaveMetadata(storageID, metadata); err != nil {
+		return nil, err
+	}
+
+	logging.Info("extracted frames", "storage_id", storageID, "count", len(frames), "folder", folder)
+	return frames, nil
+}
+
+// lastFrameName is the file ExtractLastFrame writes in the storage folder
+const lastFrameName = "last_frame.png"
+
+// ExtractLastFrame writes the final frame of a completed video as
+// last_frame.png in its storage folder, recorded under paths["last_frame"],
+// and returns its path
+func (s *Storage) ExtractLastFrame(ctx context.Context, storageID string) (string, error) {
+	ffmpegPath, err := ffmpegBinary()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg is required to extract frames")
+	}
+	videoPath, err := s.OutputPath(storageID)
+	if err != nil {
+		return "", err
+	}
+
+	// Decode only the last second; each frame overwrites the previous one,
+	// leaving the final frame
+	path := filepath.Join(s.folderPath(storageID), lastFrameName)
+	output, err := mediaCommand(ctx, probeTimeout, ffmpegPath,
+		"-sseof", "-1",
+		"-i", videoPath,
+		"-update", "1",
+		"-y",
+		path,
+	).CombinedOutput()
+	if err != nil {
+		logging.Warn("failed to extract last frame", "storage_id", storageID, "error", err, "output", string(output))
+		return "", fmt.Errorf("failed to extract last frame: %s", lastLine(string(output)))
+	}
+	if _, err := os.Stat(path); err != nil {
+		return "", fmt.Errorf("no last frame could be extracted from video %s", storageID)
+	}
+
+	err = s.UpdateMetadata(storageID, func(metadata map[string]interface{}) error {
+		paths, _ := metadata["paths"].(map[string]interface{})
+		if paths == nil {
+			paths = make(map[string]interface{})
+		}
+		paths["last_frame"] = lastFrameName
+		metadata["paths"] = paths
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return path, nil
+}
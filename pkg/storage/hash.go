@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// Hashed file kinds recorded in the metadata hashes section
+const (
+	HashVideo      = "video"
+	HashInputImage = "input_image"
+)
+
+// HashFile returns the hex SHA-256 of a file's contents
+func HashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// SetHash records the hash of a stored file in metadata, keeping hashes of
+// other kinds
+func SetHash(metadata map[string]interface{}, kind, hash string) {
+	hashes, ok := metadata["hashes"].(map[string]interface{})
+	if !ok {
+		hashes = make(map[string]interface{})
+	}
+	hashes[kind] = hash
+	metadata["hashes"] = hashes
+}
+
+// Hash returns the recorded hash of a stored file kind
+func (r Record) Hash(kind string) string {
+	if hashes, ok := r.Metadata["hashes"].(map[string]interface{}); ok {
+		v, _ := hashes[kind].(string)
+		return v
+	}
+	return ""
+}
+
+// hashedFiles returns the relative paths of the files that are hashed, by
+// kind
+func (r Record) hashedFiles() map[string]string {
+	files := make(map[string]string)
+	if output := r.Paths()["output"]; output != "" {
+		files[HashVideo] = output
+	}
+	if image := r.Parameter("input_image"); image != "" {
+		files[HashInputImage] = image
+	}
+	return files
+}
+
+// DuplicateGroup is a set of stored videos sharing an identical file
+type DuplicateGroup struct {
+	Kind       string
+	Hash       string
+	Size       int64 // Size of one copy
+	StorageIDs []string
+}
+
+// FindDuplicates groups videos matching filter whose video or input image
+// files have identical contents, largest waste first. With backfill, hashes
+// missing from older metadata are computed and saved first; the number of
+// videos updated is returned.
+func (s *Storage) FindDuplicates(filter Filter, backfill bool) ([]DuplicateGroup, int) {
+	records := s.ListRecords(filter)
+
+	hashed := 0
+	if backfill {
+		for i, record := range records {
+			updated, ok := s.backfillHashes(record.StorageID)
+			if ok {
+				records[i] = updated
+				hashed++
+			}
+		}
+	}
+
+	// Oldest first, so the original comes first in each group
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].String("created_at") < records[j].String("created_at")
+	})
+
+	type key struct{ kind, hash string }
+	groups := make(map[key]*DuplicateGroup)
+	var order []key
+	for _, record := range records {
+		for _, kind := range []string{HashVideo, HashInputImage} {
+			hash := record.Hash(kind)
+			if hash == "" {
+				continue
+			}
+			k := key{kind, hash}
+			group, ok := groups[k]
+			if !ok {
+				group = &DuplicateGroup{Kind: kind, Hash: hash}
+				if rel := record.hashedFiles()[kind]; rel != "" {
+					if info, err := os.Stat(filepath.Join(s.folderPath(record.StorageID), rel)); err == nil {
+						group.Size = info.Size()
+					}
+				}
+				groups[k] = group
+				order = append(order, k)
+			}
+			group.StorageIDs = append(group.StorageIDs, record.StorageID)
+		}
+	}
+
+	var duplicates []DuplicateGroup
+	for _, k := range order {
+		if group := groups[k]; len(group.StorageIDs) > 1 {
+			duplicates = append(duplicates, *group)
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Wasted() > duplicates[j].Wasted()
+	})
+	return duplicates, hashed
+}
+
+// Wasted is the space taken by all copies but one
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Size * int64(len(g.StorageIDs)-1)
+}
+
+// backfillHashes computes and saves hashes missing from a video's metadata,
+// reporting whether anything was added
+func (s *Storage) backfillHashes(storageID string) (Record, bool) {
+	unlock := s.Lock(storageID)
+	defer unlock()
+
+	metadata, err := s.LoadMetadata(storageID)
+	if err != nil || len(metadata) == 0 {
+		return Record{}, false
+	}
+	record := Record{StorageID: storageID, Metadata: metadata}
+
+	changed := false
+	folder := s.folderPath(storageID)
+	for kind, rel := range record.hashedFiles() {
+		if record.Hash(kind) != "" {
+			continue
+		}
+		hash, err := HashFile(filepath.Join(folder, rel))
+		if err != nil {
+			logging.Debug("skipping hash backfill", "storage_id", storageID, "kind", kind, "error", err)
+			continue
+		}
+		SetHash(metadata, kind, hash)
+		changed = true
+	}
+	if !changed {
+		return Record{}, false
+	}
+
+	if err := s.SaveMetadata(storageID, metadata); err != nil {
+		logging.Warn("failed to save backfilled hashes", "storage_id", storageID, "error", err)
+		return Record{}, false
+	}
+	return record, true
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Input images are limited to defaultMaxImageBytes and defaultImageTypes
+// unless SetImageLimits changes them
+const defaultMaxImageBytes = 25 << 20
+
+var defaultImageTypes = []string{"image/jpeg", "image/png", "image/webp", "image/gif"}
+
+// SetImageLimits sets the largest input image accepted and the MIME types
+// allowed, which are detected from each file's content
+func (s *Storage) SetImageLimits(maxBytes int64, types []string) {
+	if maxBytes > 0 {
+		s.maxImageBytes = maxBytes
+	}
+	if len(types) > 0 {
+		s.imageTypes = types
+	}
+}
+
+// openImage opens an input image once it is known to be no larger than the
+// limit and, from its first bytes, to be an allowed image type, so a wrong
+// path to a large or non-image file is rejected before it is read into
+// memory. It returns the open file, its size and its MIME type.
+func (s *Storage) openImage(imagePath string) (*os.File, int64, string, error) {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return nil, 0, "", fmt.Errorf("failed to read image file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, "", fmt.Errorf("failed to read image file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, 0, "", fmt.Errorf("%s is not a regular file", imagePath)
+	}
+	if info.Size() > s.maxImageBytes {
+		f.Close()
+		return nil, 0, "", fmt.Errorf("image %s is %.1f MB, larger than the %.1f MB limit (REPLICATE_VIDEO_MAX_IMAGE_MB)",
+			imagePath, float64(info.Size())/(1<<20), float64(s.maxImageBytes)/(1<<20))
+	}
+
+	// DetectContentType looks at no more than the first 512 bytes
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		f.Close()
+		return nil, 0, "", fmt.Errorf("failed to read image file: %w", err)
+	}
+	mimeType, _, _ := strings.Cut(http.DetectContentType(head[:n]), ";")
+	if !s.allowedImageType(mimeType) {
+		f.Close()
+		return nil, 0, "", fmt.Errorf("%s is %s, not an allowed image type (%s)", imagePath, mimeType, strings.Join(s.imageTypes, ", "))
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		f.Close()
+		return nil, 0, "", fmt.Errorf("failed to read image file: %w", err)
+	}
+	return f, info.Size(), mimeType, nil
+}
+
+// copyImage copies an image opened with openImage to w, failing if it grew
+// past the limit after it was checked
+func (s *Storage) copyImage(w io.Writer, f *os.File) (int64, error) {
+	n, err := io.Copy(w, io.LimitReader(f, s.maxImageBytes+1))
+	if err != nil {
+		return n, fmt.Errorf("failed to read image file: %w", err)
+	}
+	if n > s.maxImageBytes {
+		return n, fmt.Errorf("image %s is larger than the %.1f MB limit (REPLICATE_VIDEO_MAX_IMAGE_MB)", f.Name(), float64(s.maxImageBytes)/(1<<20))
+	}
+	return n, nil
+}
+
+func (s *Storage) allowedImageType(mimeType string) bool {
+	for _, t := range s.imageTypes {
+		if t == mimeType {
+			return true
+		}
+	}
+	return false
+}
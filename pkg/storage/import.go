@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// importExtensions are the video formats accepted by ImportLocalVideo
+var importExtensions = map[string]bool{
+	".mp4":  true,
+	".m4v":  true,
+	".mov":  true,
+	".webm": true,
+}
+
+// ImportOptions describes how an imported video is recorded
+type ImportOptions struct {
+	Description string // Stored as the prompt so search finds it
+	SessionID   string
+	Project     string
+	Tags        []string
+}
+
+// ImportLocalVideo copies an existing video file into a new storage folder,
+// extracts its metadata and a thumbnail when ffmpeg is available, and
+// records it as an imported (not generated) video. It returns the new
+// storage ID and its metadata.
+func (s *Storage) ImportLocalVideo(ctx context.Context, srcPath string, opts ImportOptions) (string, map[string]interface{}, error) {
+	if srcPath == "~" || strings.HasPrefix(srcPath, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to expand ~: %w", err)
+		}
+		srcPath = filepath.Join(home, strings.TrimPrefix(srcPath, "~"))
+	}
+	if !filepath.IsAbs(srcPath) {
+		return "", nil, fmt.Errorf("video path must be absolute: %s", srcPath)
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to access video: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", nil, fmt.Errorf("not a regular file: %s", srcPath)
+	}
+	ext := strings.ToLower(filepath.Ext(srcPath))
+	if !importExtensions[ext] {
+		return "", nil, fmt.Errorf("unsupported video format %q (supported: %s)", ext, strings.Join(sortedKeys(importExtensions), ", "))
+	}
+
+	storageID := s.GenerateStorageID()
+	if opts.Project != "" {
+		if _, err := s.SetProject(storageID, opts.Project); err != nil {
+			return "", nil, err
+		}
+	}
+	folder, err := s.CreateStorageFolder(storageID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	videoName := "video" + ext
+	videoPath := filepath.Join(folder, videoName)
+	size, err := copyFile(srcPath, videoPath)
+	if err != nil {
+		os.RemoveAll(folder)
+		return "", nil, err
+	}
+
+	// Reject files ffprobe can't read as video, when ffprobe is available
+	duration, resolution, _ := s.ExtractVideoMetadata(ctx, videoPath)
+	if HasFFprobe() && resolution == "" {
+		os.RemoveAll(folder)
+		return "", nil, fmt.Errorf("no video stream found in %s", srcPath)
+	}
+	thumbnailPath, _ := s.GenerateThumbnail(ctx, storageID, videoPath)
+
+	now := time.Now().Format(time.RFC3339)
+	paths := map[string]interface{}{
+		"output": videoName,
+	}
+	if thumbnailPath != "" {
+		paths["thumbnail"] = filepath.Base(thumbnailPath)
+	}
+	metrics := map[string]interface{}{
+		"file_size":       size,
+		"format":          strings.TrimPrefix(ext, "."),
+		"generation_type": "imported",
+	}
+	if duration > 0 {
+		metrics["actual_duration"] = duration
+	}
+	if resolution != "" {
+		metrics["actual_resolution"] = resolution
+	}
+
+	metadata := map[string]interface{}{
+		"operation":    "import",
+		"status":       "completed",
+		"imported":     true,
+		"source_path":  srcPath,
+		"storage_id":   storageID,
+		"session_id":   opts.SessionID,
+		"project":      opts.Project,
+		"created_at":   now,
+		"completed_at": now,
+		"model": map[string]interface{}{
+			"id":   "imported",
+			"name": "Imported video",
+		},
+		"parameters": map[string]interface{}{
+			"prompt": opts.Description,
+		},
+		"metrics": metrics,
+		"paths":   paths,
+	}
+
+	if hash, err := HashFile(videoPath); err == nil {
+		SetHash(metadata, HashVideo, hash)
+	}
+
+	var tags []string
+	for _, tag := range opts.Tags {
+		if tag = NormalizeTag(tag); tag != "" && len(tag) <= maxTagLength {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) > 0 {
+		sort.Strings(tags)
+		metadata["tags"] = tags
+	}
+
+	if err := s.SaveMetadata(storageID, metadata); err != nil {
+		os.RemoveAll(folder)
+		return "", nil, err
+	}
+
+	logging.Info("video imported", "storage_id", storageID, "source", srcPath, "size", size)
+	return storageID, metadata, nil
+}
+
+// copyFile copies src to dst atomically, returning the number of bytes
+// written
+func copyFile(src, dst string) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	size, err := writeAtomic(dst, 0644, func(w io.Writer) (int64, error) {
+		return io.Copy(w, in)
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return size, nil
+}
+
+// sortedKeys returns a set's keys in order
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,455 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+	"github.com/gomcpgo/replicate_video_ai/pkg/types"
+)
+
+// Record is an indexed copy of one storage folder's metadata
+type Record struct {
+	StorageID string
+	Metadata  map[string]interface{}
+	Size      int64 // Bytes on disk, set for records returned by the index
+}
+
+// String returns a top-level string metadata field
+func (r Record) String(key string) string {
+	v, _ := r.Metadata[key].(string)
+	return v
+}
+
+// Parameter returns a string field from the metadata parameters section
+func (r Record) Parameter(key string) string {
+	if params, ok := r.Metadata["parameters"].(map[string]interface{}); ok {
+		v, _ := params[key].(string)
+		return v
+	}
+	return ""
+}
+
+// ModelName returns the model display name (or ID) recorded in metadata
+func (r Record) ModelName() string {
+	if model, ok := r.Metadata["model"].(map[string]interface{}); ok {
+		if name, ok := model["name"].(string); ok && name != "" {
+			return name
+		}
+		if id, ok := model["id"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
+// Paths returns the relative paths recorded in metadata
+func (r Record) Paths() map[string]string {
+	paths := make(map[string]string)
+	if metaPaths, ok := r.Metadata["paths"].(map[string]interface{}); ok {
+		for k, v := range metaPaths {
+			if s, ok := v.(string); ok {
+				paths[k] = s
+			}
+		}
+	}
+	return paths
+}
+
+// Summary converts an indexed record into a list/search result with
+// absolute paths
+func (s *Storage) Summary(record Record) types.VideoSummary {
+	basePath := s.GetStoragePath(record.StorageID)
+	paths := make(map[string]string)
+	for name, rel := range record.Paths() {
+		paths[name] = filepath.Join(basePath, rel)
+	}
+
+	return types.VideoSummary{
+		StorageID:      record.StorageID,
+		PredictionID:   record.String("prediction_id"),
+		Operation:      record.String("operation"),
+		SessionID:      record.String("session_id"),
+		Project:        record.String("project"),
+		Tags:           record.Tags(),
+		Favorite:       record.Favorite(),
+		Status:         record.String("status"),
+		Model:          record.ModelName(),
+		Prompt:         record.Parameter("prompt"),
+		NegativePrompt: record.Parameter("negative_prompt"),
+		CreatedAt:      record.String("created_at"),
+		Paths:          paths,
+	}
+}
+
+// index caches metadata for every storage folder plus an inverted index of
+// prompt terms, so listing and searching don't re-read every YAML file
+type index struct {
+	mu      sync.RWMutex
+	loaded  bool
+	records map[string]map[string]interface{}
+	sizes   map[string]int64              // storageID -> bytes on disk
+	terms   map[string]map[string]float64 // term -> storageID -> weight
+}
+
+func newIndex() *index {
+	return &index{
+		records: make(map[string]map[string]interface{}),
+		sizes:   make(map[string]int64),
+		terms:   make(map[string]map[string]float64),
+	}
+}
+
+// ensureIndex builds the index from disk on first use
+func (s *Storage) ensureIndex() {
+	s.index.mu.RLock()
+	loaded := s.index.loaded
+	s.index.mu.RUnlock()
+	if loaded {
+		return
+	}
+
+	entries, err := os.ReadDir(s.rootFolder)
+	if err != nil && !os.IsNotExist(err) {
+		logging.Warn("failed to read storage root for index", "error", err)
+	}
+
+	records := make(map[string]map[string]interface{})
+	sizes := make(map[string]int64)
+	for _, entry := range entries {
+		if !entry.IsDir() || reservedFolders[entry.Name()] {
+			continue
+		}
+		folder := filepath.Join(s.rootFolder, entry.Name())
+		if isStorageFolder(folder) {
+			s.loadRecord(records, sizes, entry.Name())
+			continue
+		}
+
+		// Otherwise it's a project folder holding storage folders
+		projectEntries, err := os.ReadDir(folder)
+		if err != nil {
+			logging.Warn("failed to read project folder for index", "folder", folder, "error", err)
+			continue
+		}
+		for _, projectEntry := range projectEntries {
+			storageFolder := filepath.Join(folder, projectEntry.Name())
+			if !projectEntry.IsDir() || !isStorageFolder(storageFolder) {
+				continue
+			}
+			s.setLocation(projectEntry.Name(), storageFolder)
+			s.loadRecord(records, sizes, projectEntry.Name())
+		}
+	}
+
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	if s.index.loaded {
+		return
+	}
+	for storageID, metadata := range records {
+		s.index.put(storageID, metadata, sizes[storageID])
+	}
+	s.index.loaded = true
+	logging.Debug("storage index loaded", "records", len(records))
+}
+
+// loadRecord reads a storage folder's metadata and size into records and
+// sizes, skipping folders without metadata
+func (s *Storage) loadRecord(records map[string]map[string]interface{}, sizes map[string]int64, storageID string) {
+	folder := s.folderPath(storageID)
+	if _, err := os.Stat(filepath.Join(folder, "metadata.yaml")); err != nil {
+		return // Not a storage folder
+	}
+	metadata, err := s.LoadMetadata(storageID)
+	if err != nil {
+		logging.Warn("skipping unreadable metadata", "storage_id", storageID, "error", err)
+		return
+	}
+	removeStaleTemp(folder)
+	records[storageID] = metadata
+	sizes[storageID] = pathSize(folder)
+}
+
+// indexRecord updates the index after metadata is written
+func (s *Storage) indexRecord(storageID string, metadata map[string]interface{}) {
+	s.index.mu.RLock()
+	loaded := s.index.loaded
+	s.index.mu.RUnlock()
+	if !loaded {
+		return // Picked up when the index is first built
+	}
+
+	// Measure the folder outside the lock
+	size := pathSize(s.folderPath(storageID))
+
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	s.index.put(storageID, copyMap(metadata), size)
+}
+
+// put replaces a record, its size, and its terms. Caller must hold mu.
+func (idx *index) put(storageID string, metadata map[string]interface{}, size int64) {
+	idx.remove(storageID)
+	idx.records[storageID] = metadata
+	idx.sizes[storageID] = size
+
+	record := Record{StorageID: storageID, Metadata: metadata}
+	for _, term := range tokenize(record.Parameter("prompt")) {
+		idx.addTerm(term, storageID, 1.0)
+	}
+	for _, term := range tokenize(record.Parameter("negative_prompt")) {
+		idx.addTerm(term, storageID, 0.5)
+	}
+}
+
+// remove drops a record and its terms. Caller must hold mu.
+func (idx *index) remove(storageID string) {
+	if _, ok := idx.records[storageID]; !ok {
+		return
+	}
+	delete(idx.records, storageID)
+	delete(idx.sizes, storageID)
+	for term, postings := range idx.terms {
+		delete(postings, storageID)
+		if len(postings) == 0 {
+			delete(idx.terms, term)
+		}
+	}
+}
+
+func (idx *index) addTerm(term, storageID string, weight float64) {
+	postings, ok := idx.terms[term]
+	if !ok {
+		postings = make(map[string]float64)
+		idx.terms[term] = postings
+	}
+	postings[storageID] += weight
+}
+
+// Filter selects records in ListRecords and Search. Empty fields match
+// everything.
+type Filter struct {
+	SessionID    string
+	Status       string
+	Project      string
+	Tag          string
+	Favorite     bool   // Only favorites when set
+	ComparisonID string // Only generations of one compare_models call
+	VariationSet string // Only generations of one generate_variations call
+	Storyboard   string // Only scenes and the assembled video of one generate_storyboard call
+}
+
+// Summary echoes the active filters back in list responses
+func (f Filter) Summary() map[string]interface{} {
+	filters := make(map[string]interface{})
+	if f.SessionID != "" {
+		filters["session_id"] = f.SessionID
+	}
+	if f.Status != "" {
+		filters["status"] = f.Status
+	}
+	if f.Project != "" {
+		filters["project"] = f.Project
+	}
+	if f.Tag != "" {
+		filters["tag"] = f.Tag
+	}
+	if f.Favorite {
+		filters["favorite"] = true
+	}
+	return filters
+}
+
+// Matches reports whether a record passes the filter
+func (f Filter) Matches(r Record) bool {
+	if f.SessionID != "" && r.String("session_id") != f.SessionID {
+		return false
+	}
+	if f.ComparisonID != "" && r.String("comparison_id") != f.ComparisonID {
+		return false
+	}
+	if f.VariationSet != "" && r.String("variation_set_id") != f.VariationSet {
+		return false
+	}
+	if f.Storyboard != "" && r.String("storyboard_id") != f.Storyboard {
+		return false
+	}
+	if f.Status != "" && r.String("status") != f.Status {
+		return false
+	}
+	if f.Project != "" && r.String("project") != f.Project {
+		return false
+	}
+	if f.Tag != "" && !r.HasTag(f.Tag) {
+		return false
+	}
+	if f.Favorite && !r.Favorite() {
+		return false
+	}
+	return true
+}
+
+// FindByPrediction returns the storage ID of the generation with the given
+// prediction ID, including failed predictions it was resubmitted after
+func (s *Storage) FindByPrediction(predictionID string) (string, bool) {
+	s.ensureIndex()
+
+	s.index.mu.RLock()
+	defer s.index.mu.RUnlock()
+	for storageID, metadata := range s.index.records {
+		if id, _ := metadata["prediction_id"].(string); id == predictionID {
+			return storageID, true
+		}
+		attempts, _ := metadata["attempts"].([]interface{})
+		for _, attempt := range attempts {
+			if attempt, ok := attempt.(map[string]interface{}); ok && attempt["prediction_id"] == predictionID {
+				return storageID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// ListRecords returns indexed records matching filter, newest first
+func (s *Storage) ListRecords(filter Filter) []Record {
+	s.ensureIndex()
+
+	s.index.mu.RLock()
+	records := make([]Record, 0, len(s.index.records))
+	for storageID, metadata := range s.index.records {
+		record := Record{StorageID: storageID, Metadata: metadata, Size: s.index.sizes[storageID]}
+		if filter.Matches(record) {
+			records = append(records, record)
+		}
+	}
+	s.index.mu.RUnlock()
+
+	sort.Slice(records, func(i, j int) bool {
+		ci, cj := records[i].String("created_at"), records[j].String("created_at")
+		if ci != cj {
+			return ci > cj
+		}
+		return records[i].StorageID < records[j].StorageID
+	})
+	return records
+}
+
+// forget removes a record from the index
+func (s *Storage) forget(storageID string) {
+	s.index.mu.Lock()
+	defer s.index.mu.Unlock()
+	s.index.remove(storageID)
+}
+
+// SearchResult is a ranked search match
+type SearchResult struct {
+	Record
+	Score        float64
+	MatchedTerms []string
+}
+
+// Search performs full-text search over prompts and negative prompts.
+// Query terms also match indexed terms they are a prefix of ("beach" matches
+// "beaches"). Results are ranked by matched terms, score, then recency.
+func (s *Storage) Search(query string, filter Filter, limit int) []SearchResult {
+	s.ensureIndex()
+
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 {
+		return nil
+	}
+	phrase := strings.ToLower(strings.TrimSpace(query))
+
+	s.index.mu.RLock()
+	scores := make(map[string]float64)
+	matched := make(map[string][]string)
+	for _, qt := range queryTerms {
+		hits := make(map[string]float64)
+		for term, postings := range s.index.terms {
+			if !strings.HasPrefix(term, qt) {
+				continue
+			}
+			// Exact term matches rank above prefix matches
+			factor := 0.75
+			if term == qt {
+				factor = 1.0
+			}
+			for storageID, weight := range postings {
+				if w := weight * factor; w > hits[storageID] {
+					hits[storageID] = w
+				}
+			}
+		}
+		for storageID, w := range hits {
+			scores[storageID] += w
+			matched[storageID] = append(matched[storageID], qt)
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for storageID, score := range scores {
+		record := Record{StorageID: storageID, Metadata: s.index.records[storageID], Size: s.index.sizes[storageID]}
+		if !filter.Matches(record) {
+			continue
+		}
+		// Bonus for the whole query appearing verbatim in the prompt
+		if len(queryTerms) > 1 && strings.Contains(strings.ToLower(record.Parameter("prompt")), phrase) {
+			score += float64(len(queryTerms))
+		}
+		results = append(results, SearchResult{
+			Record:       record,
+			Score:        score,
+			MatchedTerms: matched[storageID],
+		})
+	}
+	s.index.mu.RUnlock()
+
+	sort.Slice(results, func(i, j int) bool {
+		if len(results[i].MatchedTerms) != len(results[j].MatchedTerms) {
+			return len(results[i].MatchedTerms) > len(results[j].MatchedTerms)
+		}
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].String("created_at") > results[j].String("created_at")
+	})
+
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// tokenize splits text into lowercase alphanumeric terms, dropping
+// single-character terms and duplicates
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	seen := make(map[string]bool, len(fields))
+	terms := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if len([]rune(f)) < 2 || seen[f] {
+			continue
+		}
+		seen[f] = true
+		terms = append(terms, f)
+	}
+	return terms
+}
+
+// copyMap makes a shallow copy so callers mutating their metadata map after
+// saving don't change the indexed copy
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	s := NewStorage(t.TempDir(), false)
+	save := func(storageID, createdAt, prompt, negativePrompt string) {
+		t.Helper()
+		err := s.SaveMetadata(storageID, map[string]interface{}{
+			"created_at": createdAt,
+			"parameters": map[string]interface{}{"prompt": prompt, "negative_prompt": negativePrompt},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	save("aaaa1111", "2025-01-01T10:00:00Z", "waves on a beach", "")
+	s.ListRecords(Filter{}) // Build the index; the next save updates it
+	save("bbbb2222", "2025-01-02T10:00:00Z", "a beach at night", "waves")
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"beach", []string{"bbbb2222", "aaaa1111"}}, // Ties go to the newest
+		{"beac", []string{"bbbb2222", "aaaa1111"}},  // Prefix match
+		{"waves", []string{"aaaa1111", "bbbb2222"}}, // Negative prompts weigh less
+		{"night beach", []string{"bbbb2222", "aaaa1111"}},
+		{"forest", []string{}},
+		{"a", []string{}}, // Single characters aren't indexed
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got := []string{}
+			for _, result := range s.Search(tt.query, Filter{}, 0) {
+				got = append(got, result.StorageID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListRecordsBySession(t *testing.T) {
+	s := NewStorage(t.TempDir(), false)
+	for storageID, metadata := range map[string]map[string]interface{}{
+		"aaaa1111": {"created_at": "2025-01-01T10:00:00Z", "session_id": "s1", "status": "succeeded"},
+		"bbbb2222": {"created_at": "2025-01-02T10:00:00Z", "session_id": "s1", "status": "failed"},
+		"cccc3333": {"created_at": "2025-01-03T10:00:00Z", "session_id": "s2", "status": "succeeded"},
+	} {
+		if err := s.SaveMetadata(storageID, metadata); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.DeleteStorage("bbbb2222"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		filter Filter
+		want   []string
+	}{
+		{Filter{}, []string{"cccc3333", "aaaa1111"}},
+		{Filter{SessionID: "s1"}, []string{"aaaa1111"}},
+		{Filter{Status: "succeeded"}, []string{"cccc3333", "aaaa1111"}},
+		{Filter{SessionID: "s2", Status: "failed"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		got := []string{}
+		for _, record := range s.ListRecords(tt.filter) {
+			got = append(got, record.StorageID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ListRecords(%+v) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// locationFile marks a storage folder whose contents live in a per-request
+// output directory. It holds the absolute path of the real folder.
+const locationFile = "location"
+
+// folderPath returns the folder holding a storage ID's files, following the
+// location pointer for generations written to an output_dir override and
+// looking inside project folders
+func (s *Storage) folderPath(storageID string) string {
+	folder := filepath.Join(s.rootFolder, storageID)
+	if storageID == "" {
+		return folder
+	}
+
+	data, err := os.ReadFile(filepath.Join(folder, locationFile))
+	if err == nil {
+		if target := strings.TrimSpace(string(data)); filepath.IsAbs(target) {
+			return target
+		}
+		return folder
+	}
+
+	// Generations grouped by project live in <root>/<project>/<storageID>
+	if _, err := os.Stat(folder); err != nil {
+		if projectFolder, ok := s.projectFolder(storageID); ok {
+			return projectFolder
+		}
+	}
+	return folder
+}
+
+// SetOutputDir places a storage ID's files in <dir>/<storageID> instead of
+// the storage root. The root keeps a small pointer folder so the video can
+// still be found by storage ID. dir must already be validated.
+func (s *Storage) SetOutputDir(storageID, dir string) (string, error) {
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("output directory must be an absolute path: %s", dir)
+	}
+
+	target := filepath.Join(dir, storageID)
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output folder: %w", err)
+	}
+
+	pointer := filepath.Join(s.rootFolder, storageID)
+	if err := os.MkdirAll(pointer, 0755); err != nil {
+		return "", fmt.Errorf("failed to create storage folder: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(pointer, locationFile), []byte(target+"\n"), 0644); err != nil {
+		return "", fmt.Errorf("failed to record output location: %w", err)
+	}
+
+	return target, nil
+}
+
+// ValidateOutputDir checks a requested output directory and returns its
+// canonical absolute form, with symlinks resolved. A leading ~ expands to the home directory. When
+// allowed is non-empty the directory must be one of, or inside one of, the
+// allowed directories. The directory is created if it doesn't exist.
+func ValidateOutputDir(dir string, allowed []string) (string, error) {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return "", fmt.Errorf("output directory is empty")
+	}
+
+	dir, err := expandHome(dir)
+	if err != nil {
+		return "", err
+	}
+
+	if !filepath.IsAbs(dir) {
+		return "", fmt.Errorf("output directory must be an absolute path: %s", dir)
+	}
+	// Compare where the directory really is, so a symlink inside an allowed
+	// directory can't lead outside it
+	dir = canonicalPath(dir)
+
+	if len(allowed) > 0 && !withinAny(dir, canonicalDirs(allowed)) {
+		return "", fmt.Errorf("output directory %s is outside the allowed directories (REPLICATE_VIDEO_ALLOWED_OUTPUT_DIRS)", dir)
+	}
+
+	if info, err := os.Stat(dir); err == nil {
+		if !info.IsDir() {
+			return "", fmt.Errorf("output directory is not a directory: %s", dir)
+		}
+	} else if os.IsNotExist(err) {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return "", fmt.Errorf("failed to create output directory: %w", err)
+		}
+	} else {
+		return "", fmt.Errorf("failed to access output directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+// withinAny reports whether dir is one of roots or nested inside one
+func withinAny(dir string, roots []string) bool {
+	for _, root := range roots {
+		root = filepath.Clean(root)
+		rel, err := filepath.Rel(root, dir)
+		if err != nil {
+			continue
+		}
+		if rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))) {
+			return true
+		}
+	}
+	return false
+}
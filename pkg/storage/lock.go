@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// locksFolder holds the lock files that serialize storage changes across
+// processes (the MCP server and the CLI)
+const locksFolder = ".locks"
+
+// storageLock is an in-process lock for one storage ID, shared by the
+// goroutines waiting on it
+type storageLock struct {
+	mu      sync.Mutex
+	waiters int
+}
+
+// Lock acquires the exclusive lock for a storage ID and returns the function
+// that releases it. Hold it across a download or a metadata
+// read-modify-write. Locks are not reentrant.
+func (s *Storage) Lock(storageID string) (unlock func()) {
+	s.locksMu.Lock()
+	lock, ok := s.locks[storageID]
+	if !ok {
+		lock = &storageLock{}
+		s.locks[storageID] = lock
+	}
+	lock.waiters++
+	s.locksMu.Unlock()
+
+	lock.mu.Lock()
+	release := s.lockFile(storageID)
+
+	return func() {
+		release()
+		lock.mu.Unlock()
+
+		s.locksMu.Lock()
+		lock.waiters--
+		if lock.waiters == 0 {
+			delete(s.locks, storageID)
+		}
+		s.locksMu.Unlock()
+	}
+}
+
+// UpdateMetadata applies update to a storage ID's metadata under its lock and
+// saves the result. Metadata is empty for unknown storage IDs.
+func (s *Storage) UpdateMetadata(storageID string, update func(metadata map[string]interface{}) error) error {
+	unlock := s.Lock(storageID)
+	defer unlock()
+
+	metadata, err := s.LoadMetadata(storageID)
+	if err != nil {
+		return err
+	}
+	if err := update(metadata); err != nil {
+		return err
+	}
+	return s.SaveMetadata(storageID, metadata)
+}
+
+// lockFile takes the on-disk lock for a storage ID. Without it other
+// processes aren't excluded, so failures are logged rather than returned.
+func (s *Storage) lockFile(storageID string) (release func()) {
+	dir := filepath.Join(s.rootFolder, locksFolder)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logging.Warn("failed to create lock folder", "error", err)
+		return func() {}
+	}
+	f, err := os.OpenFile(filepath.Join(dir, storageID+".lock"), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		logging.Warn("failed to open lock file", "storage_id", storageID, "error", err)
+		return func() {}
+	}
+	if err := lockExclusive(f); err != nil {
+		logging.Warn("failed to lock storage", "storage_id", storageID, "error", err)
+		f.Close()
+		return func() {}
+	}
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}
+}
+
+// removeLockFile deletes a deleted storage ID's lock file
+func (s *Storage) removeLockFile(storageID string) {
+	os.Remove(filepath.Join(s.rootFolder, locksFolder, storageID+".lock"))
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package storage
+
+import "os"
+
+// lockExclusive is a no-op where advisory file locks aren't available;
+// storage IDs are then only locked within one process
+func lockExclusive(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+)
+
+// maxMoovSize limits how much of an MP4 movie header is read into memory
+const maxMoovSize = 64 << 20
+
+// readMP4Metadata reads the duration and the video track's size from the
+// moov box of an MP4 or QuickTime file, without ffprobe. It only reads box
+// headers and the movie header, wherever it is in the file, so the media
+// data is skipped.
+func readMP4Metadata(path string) (duration float64, width, height int, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	moov, err := findTopLevelBox(f, info.Size(), "moov")
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	mvhd, ok := findBox(moov, "mvhd")
+	if !ok {
+		return 0, 0, 0, fmt.Errorf("no mvhd box found")
+	}
+	duration, err = parseMvhd(mvhd)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	for _, trak := range childBoxes(moov, "trak") {
+		mdia, ok := findBox(trak, "mdia")
+		if !ok {
+			continue
+		}
+		hdlr, ok := findBox(mdia, "hdlr")
+		if !ok || len(hdlr) < 12 || string(hdlr[8:12]) != "vide" {
+			continue
+		}
+		tkhd, ok := findBox(trak, "tkhd")
+		if !ok || len(tkhd) < 8 {
+			continue
+		}
+		// Width and height end the track header as 16.16 fixed point
+		width = int(binary.BigEndian.Uint32(tkhd[len(tkhd)-8:]) >> 16)
+		height = int(binary.BigEndian.Uint32(tkhd[len(tkhd)-4:]) >> 16)
+		if width > 0 && height > 0 {
+			return duration, width, height, nil
+		}
+	}
+	return 0, 0, 0, fmt.Errorf("no video track found")
+}
+
+// findTopLevelBox walks the boxes at the top of the file and returns the
+// payload of the first one of type name
+func findTopLevelBox(r io.ReaderAt, size int64, name string) ([]byte, error) {
+	header := make([]byte, 16)
+	for offset := int64(0); offset+8 <= size; {
+		if _, err := r.ReadAt(header[:8], offset); err != nil {
+			return nil, err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		boxType := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0: // Extends to the end of the file
+			boxSize = size - offset
+		case 1: // 64-bit size follows the type
+			if _, err := r.ReadAt(header[8:16], offset+8); err != nil {
+				return nil, err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || offset+boxSize > size {
+			return nil, fmt.Errorf("invalid %q box at offset %d", boxType, offset)
+		}
+
+		if boxType == name {
+			payloadSize := boxSize - headerSize
+			if payloadSize > maxMoovSize {
+				return nil, fmt.Errorf("%s box too large (%d bytes)", name, payloadSize)
+			}
+			payload := make([]byte, payloadSize)
+			if _, err := r.ReadAt(payload, offset+headerSize); err != nil {
+				return nil, err
+			}
+			return payload, nil
+		}
+		offset += boxSize
+	}
+	return nil, fmt.Errorf("no %s box found", name)
+}
+
+// childBoxes returns the payloads of the boxes of type name directly inside
+// data. It stops at the first malformed box.
+func childBoxes(data []byte, name string) [][]byte {
+	var boxes [][]byte
+	for len(data) >= 8 {
+		boxSize := uint64(binary.BigEndian.Uint32(data[:4]))
+		boxType := string(data[4:8])
+		headerSize := uint64(8)
+		switch boxSize {
+		case 0:
+			boxSize = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return boxes
+			}
+			boxSize = binary.BigEndian.Uint64(data[8:16])
+			headerSize = 16
+		}
+		if boxSize < headerSize || boxSize > uint64(len(data)) {
+			return boxes
+		}
+		if boxType == name {
+			boxes = append(boxes, data[headerSize:boxSize])
+		}
+		data = data[boxSize:]
+	}
+	return boxes
+}
+
+// findBox returns the payload of the first box of type name inside data
+func findBox(data []byte, name string) ([]byte, bool) {
+	boxes := childBoxes(data, name)
+	if len(boxes) == 0 {
+		return nil, false
+	}
+	return boxes[0], true
+}
+
+// parseMvhd returns the duration in seconds from a movie header payload
+func parseMvhd(mvhd []byte) (float64, error) {
+	if len(mvhd) < 4 {
+		return 0, fmt.Errorf("mvhd box too short")
+	}
+	var timescale uint32
+	var duration uint64
+	switch mvhd[0] {
+	case 0:
+		if len(mvhd) < 20 {
+			return 0, fmt.Errorf("mvhd box too short")
+		}
+		timescale = binary.BigEndian.Uint32(mvhd[12:16])
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	case 1:
+		if len(mvhd) < 32 {
+			return 0, fmt.Errorf("mvhd box too short")
+		}
+		timescale = binary.BigEndian.Uint32(mvhd[20:24])
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	default:
+		return 0, fmt.Errorf("unsupported mvhd version %d", mvhd[0])
+	}
+	if timescale == 0 {
+		return 0, fmt.Errorf("mvhd timescale is zero")
+	}
+	return float64(duration) / float64(timescale), nil
+}
@@ -0,0 +1,34 @@
+package storage
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+)
+
+// namespacesFolder holds one storage root per namespace
+const namespacesFolder = "namespaces"
+
+// namespacePattern restricts namespaces to safe folder names
+var namespacePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
+// ValidateNamespace checks that a namespace is usable as a folder name
+func ValidateNamespace(namespace string) error {
+	if !namespacePattern.MatchString(namespace) {
+		return fmt.Errorf("invalid namespace %q: use letters, digits, '.', '_' or '-' (max 64 characters)", namespace)
+	}
+	return nil
+}
+
+// NamespaceRoot returns the storage root of a namespace. Each namespace has
+// its own folders, index, and metadata under <root>/namespaces/<namespace>;
+// the empty namespace is the root itself.
+func NamespaceRoot(rootFolder, namespace string) (string, error) {
+	if namespace == "" {
+		return rootFolder, nil
+	}
+	if err := ValidateNamespace(namespace); err != nil {
+		return "", err
+	}
+	return filepath.Join(rootFolder, namespacesFolder, namespace), nil
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ValidateStorageID checks that a storage ID from a request names a folder
+// directly under the storage root, so it can't reach outside it
+func ValidateStorageID(storageID string) error {
+	if storageID == "" || storageID == "." || storageID == ".." ||
+		strings.ContainsAny(storageID, `/\`) || storageID != filepath.Base(storageID) {
+		return fmt.Errorf("invalid storage ID: %s", storageID)
+	}
+	return nil
+}
+
+// ValidateInputPath checks a file a request asks the server to read, such as
+// an input image, and returns its canonical path: absolute, cleaned, and
+// with symlinks resolved. A leading ~ expands to the home directory. When
+// allowed is non-empty the file must be inside one of the allowed
+// directories after symlinks are resolved, so a link can't point outside.
+func ValidateInputPath(path string, allowed []string) (string, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return "", fmt.Errorf("path is empty")
+	}
+	expanded, err := expandHome(path)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %s: %w", path, err)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("file not found: %s", path)
+		}
+		return "", fmt.Errorf("failed to access %s: %w", path, err)
+	}
+
+	if len(allowed) > 0 && !withinAny(resolved, canonicalDirs(allowed)) {
+		return "", fmt.Errorf("%s is outside the allowed input directories (REPLICATE_VIDEO_ALLOWED_INPUT_DIRS)", path)
+	}
+
+	info, err := os.Stat(resolved)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s: %w", path, err)
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+	return resolved, nil
+}
+
+// InputPath checks a file a request asks to read with ValidateInputPath.
+// Files under the storage root, such as extracted frames, are always
+// allowed.
+func (s *Storage) InputPath(path string, allowed []string) (string, error) {
+	if len(allowed) > 0 {
+		allowed = append(append([]string(nil), allowed...), s.rootFolder)
+	}
+	return ValidateInputPath(path, allowed)
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to expand ~: %w", err)
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~")), nil
+}
+
+// canonicalPath resolves the symlinks in the part of path that exists, so a
+// directory that is about to be created is compared by where it will be
+func canonicalPath(path string) string {
+	existing, rest := filepath.Clean(path), ""
+	for {
+		if resolved, err := filepath.EvalSymlinks(existing); err == nil {
+			return filepath.Join(resolved, rest)
+		}
+		parent := filepath.Dir(existing)
+		if parent == existing {
+			return filepath.Clean(path)
+		}
+		rest = filepath.Join(filepath.Base(existing), rest)
+		existing = parent
+	}
+}
+
+// canonicalDirs resolves the symlinks in each allowed directory, so that
+// /tmp and /private/tmp on macOS compare equal
+func canonicalDirs(dirs []string) []string {
+	canonical := make([]string, len(dirs))
+	for i, dir := range dirs {
+		canonical[i] = canonicalPath(dir)
+	}
+	return canonical
+}
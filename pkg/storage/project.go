@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// projectNamePattern restricts project names to safe folder names
+var projectNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._ -]{0,63}$`)
+
+// reservedFolders are root folders that are never projects
+var reservedFolders = map[string]bool{
+	"logs":           true,
+	exportsFolder:    true,
+	locksFolder:      true,
+	namespacesFolder: true,
+}
+
+// ValidateProjectName checks that a project name is usable as a folder name
+func ValidateProjectName(project string) error {
+	if reservedFolders[project] {
+		return fmt.Errorf("invalid project name %q: the name is reserved", project)
+	}
+	if !projectNamePattern.MatchString(project) {
+		return fmt.Errorf("invalid project name %q: use letters, digits, spaces, '.', '_' or '-' (max 64 characters)", project)
+	}
+	return nil
+}
+
+// SetProject places a storage ID's files in <root>/<project>/<storageID>
+// instead of directly under the root
+func (s *Storage) SetProject(storageID, project string) (string, error) {
+	if err := ValidateProjectName(project); err != nil {
+		return "", err
+	}
+
+	folder := filepath.Join(s.rootFolder, project, storageID)
+	if err := os.MkdirAll(folder, 0755); err != nil {
+		return "", fmt.Errorf("failed to create project folder: %w", err)
+	}
+	s.setLocation(storageID, folder)
+	return folder, nil
+}
+
+// setLocation caches the folder of a storage ID that isn't directly under
+// the root
+func (s *Storage) setLocation(storageID, folder string) {
+	s.locMu.Lock()
+	s.locations[storageID] = folder
+	s.locMu.Unlock()
+}
+
+// projectFolder finds a storage ID inside a project folder, using the cache
+// first and then scanning the root's subfolders
+func (s *Storage) projectFolder(storageID string) (string, bool) {
+	s.locMu.Lock()
+	folder, ok := s.locations[storageID]
+	s.locMu.Unlock()
+	if ok {
+		return folder, true
+	}
+
+	entries, err := os.ReadDir(s.rootFolder)
+	if err != nil {
+		return "", false
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || reservedFolders[entry.Name()] {
+			continue
+		}
+		candidate := filepath.Join(s.rootFolder, entry.Name(), storageID)
+		if _, err := os.Stat(filepath.Join(candidate, "metadata.yaml")); err == nil {
+			s.setLocation(storageID, candidate)
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// isStorageFolder reports whether a folder under the root holds a single
+// generation (rather than being a project folder)
+func isStorageFolder(folder string) bool {
+	for _, name := range []string{"metadata.yaml", locationFile} {
+		if _, err := os.Stat(filepath.Join(folder, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
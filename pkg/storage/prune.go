@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
+)
+
+// PruneOptions selects the generations Prune deletes
+type PruneOptions struct {
+	Statuses  []string      // Statuses to delete; "any" matches every status
+	OlderThan time.Duration // Only generations created longer ago; 0 for all
+	Project   string        // Only generations of this project
+	DryRun    bool          // Select without deleting
+}
+
+// Prune deletes the generations matching opts, skipping pending ones, which
+// their poll would recreate. It returns the records it deleted (or would
+// delete, on a dry run) and the errors of those it failed to delete.
+func (s *Storage) Prune(opts PruneOptions) ([]Record, map[string]error) {
+	var pruned []Record
+	failures := make(map[string]error)
+	for _, record := range s.ListRecords(Filter{Project: opts.Project}) {
+		status := record.String("status")
+		if !slices.Contains(opts.Statuses, "any") && !slices.Contains(opts.Statuses, status) {
+			continue
+		}
+		if status == "starting" || status == "processing" {
+			continue
+		}
+		if opts.OlderThan > 0 {
+			created, err := time.Parse(time.RFC3339, record.String("created_at"))
+			if err != nil || time.Since(created) < opts.OlderThan {
+				continue
+			}
+		}
+
+		if !opts.DryRun {
+			if err := s.DeleteStorage(record.StorageID); err != nil {
+				failures[record.StorageID] = err
+				continue
+			}
+		}
+		pruned = append(pruned, record)
+	}
+	return pruned, failures
+}
+
+// ApplyRetention deletes failed and canceled generations older than failed,
+// and completed ones older than completed. A zero period keeps them.
+func (s *Storage) ApplyRetention(failed, completed time.Duration) {
+	periods := []struct {
+		statuses []string
+		period   time.Duration
+	}{
+		{[]string{"failed", "canceled"}, failed},
+		{[]string{"completed"}, completed},
+	}
+	for _, p := range periods {
+		if p.period <= 0 {
+			continue
+		}
+		pruned, failures := s.Prune(PruneOptions{Statuses: p.statuses, OlderThan: p.period})
+		for storageID, err := range failures {
+			logging.Warn("failed to delete expired generation", "storage_id", storageID, "error", err)
+		}
+		if len(pruned) > 0 {
+			logging.Info("deleted expired generations", "statuses", p.statuses, "count", len(pruned), "retention", p.period.String())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+// Quality flags raised by AnalyzeQuality
+const (
+	FlagMostlyBlack = "mostly_black"
+	FlagFrozen      = "frozen"
+	FlagLowBitrate  = "low_bitrate"
+	FlagNoAudio     = "no_audio"
+)
+
+// Thresholds for raising quality flags
+const (
+	mostlyBlackRatio = 0.5 // Share of the clip that is black
+	frozenRatio      = 0.5 // Share of the clip without motion
+	lowBitrate       = 100 // kbit/s
+)
+
+var (
+	blackDurationPattern  = regexp.MustCompile(`black_duration:\s*([0-9.]+)`)
+	freezeStartPattern    = regexp.MustCompile(`freeze_start:\s*([0-9.]+)`)
+	freezeDurationPattern = regexp.MustCompile(`freeze_duration:\s*([0-9.]+)`)
+)
+
+// QualityReport summarizes a lightweight automatic check of a video, so
+// obviously broken generations can be flagged without watching them
+type QualityReport struct {
+	Duration      float64 // Seconds
+	BlackSeconds  float64 // Total length of black stretches
+	FrozenSeconds float64 // Total length of stretches without motion
+	Bitrate       int     // Average bitrate in kbit/s
+	HasAudio      bool
+	Flags         []string // Problems found, e.g. mostly_black
+}
+
+// Map converts the report for storing in metadata
+func (q *QualityReport) Map() map[string]interface{} {
+	flags := q.Flags
+	if flags == nil {
+		flags = []string{}
+	}
+	return map[string]interface{}{
+		"duration":       q.Duration,
+		"black_seconds":  q.BlackSeconds,
+		"frozen_seconds": q.FrozenSeconds,
+		"bitrate_kbps":   q.Bitrate,
+		"has_audio":      q.HasAudio,
+		"flags":          flags,
+	}
+}
+
+// AnalyzeQuality runs ffprobe for the bitrate and audio streams and one
+// ffmpeg decoding pass with the blackdetect and freezedetect filters.
+// Requires both tools. The no_audio flag is left to callers, which know
+// whether the model produces sound.
+func (s *Storage) AnalyzeQuality(ctx context.Context, videoPath string) (*QualityReport, error) {
+	ffprobePath, err := ffprobeBinary()
+	if err != nil {
+		return nil, fmt.Errorf("ffprobe is required for quality reports")
+	}
+	ffmpegPath, err := ffmpegBinary()
+	if err != nil {
+		return nil, fmt.Errorf("ffmpeg is required for quality reports")
+	}
+
+	output, err := mediaCommand(ctx, probeTimeout, ffprobePath,
+		"-v", "error",
+		"-show_entries", "format=duration,bit_rate:stream=codec_type",
+		"-of", "json",
+		videoPath,
+	).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe video: %w", err)
+	}
+	var probe struct {
+		Format struct {
+			Duration string `json:"duration"`
+			BitRate  string `json:"bit_rate"`
+		} `json:"format"`
+		Streams []struct {
+			CodecType string `json:"codec_type"`
+		} `json:"streams"`
+	}
+	if err := json.Unmarshal(output, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse ffprobe output: %w", err)
+	}
+
+	report := &QualityReport{}
+	report.Duration, _ = strconv.ParseFloat(probe.Format.Duration, 64)
+	if bitRate, err := strconv.Atoi(probe.Format.BitRate); err == nil {
+		report.Bitrate = bitRate / 1000
+	}
+	for _, stream := range probe.Streams {
+		if stream.CodecType == "audio" {
+			report.HasAudio = true
+		}
+	}
+
+	// The filters log what they detect; the frames themselves are discarded
+	output, err = mediaCommand(ctx, renderTimeout, ffmpegPath,
+		"-hide_banner",
+		"-i", videoPath,
+		"-vf", "blackdetect=d=0.1:pix_th=0.10,freezedetect=n=-60dB:d=0.5",
+		"-an",
+		"-f", "null",
+		"-",
+	).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("failed to analyze video: %s", lastLine(string(output)))
+	}
+	for _, match := range blackDurationPattern.FindAllStringSubmatch(string(output), -1) {
+		d, _ := strconv.ParseFloat(match[1], 64)
+		report.BlackSeconds += d
+	}
+	freezes := freezeDurationPattern.FindAllStringSubmatch(string(output), -1)
+	for _, match := range freezes {
+		d, _ := strconv.ParseFloat(match[1], 64)
+		report.FrozenSeconds += d
+	}
+	// A freeze lasting to the end of the clip is never closed with a duration
+	if starts := freezeStartPattern.FindAllStringSubmatch(string(output), -1); len(starts) > len(freezes) && report.Duration > 0 {
+		start, _ := strconv.ParseFloat(starts[len(starts)-1][1], 64)
+		report.FrozenSeconds += report.Duration - start
+	}
+
+	if report.Duration > 0 {
+		if report.BlackSeconds/report.Duration >= mostlyBlackRatio {
+			report.Flags = append(report.Flags, FlagMostlyBlack)
+		}
+		if report.FrozenSeconds/report.Duration >= frozenRatio {
+			report.Flags = append(report.Flags, FlagFrozen)
+		}
+	}
+	if report.Bitrate > 0 && report.Bitrate < lowBitrate {
+		report.Flags = append(report.Flags, FlagLowBitrate)
+	}
+
+	return report, nil
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// UsageBucket is the disk usage of a group of videos
+type UsageBucket struct {
+	Name  string
+	Count int
+	Bytes int64
+}
+
+// StatsEntry identifies one stored video in a stats report
+type StatsEntry struct {
+	StorageID string
+	CreatedAt string
+	Model     string
+	Prompt    string
+	Bytes     int64
+}
+
+// Stats summarizes disk usage of the storage root
+type Stats struct {
+	RootFolder  string
+	TotalBytes  int64 // Videos plus logs and exports
+	VideoBytes  int64
+	LogBytes    int64
+	ExportBytes int64
+	VideoCount  int
+	ByModel     []UsageBucket
+	ByProject   []UsageBucket
+	ByStatus    []UsageBucket
+	Oldest      *StatsEntry
+	Newest      *StatsEntry
+}
+
+// Stats reports disk usage from the storage index, so only the logs and
+// exports folders are measured on each call
+func (s *Storage) Stats() Stats {
+	records := s.ListRecords(Filter{}) // Newest first
+
+	stats := Stats{
+		RootFolder: s.rootFolder,
+		VideoCount: len(records),
+	}
+	models := make(map[string]*UsageBucket)
+	projects := make(map[string]*UsageBucket)
+	statuses := make(map[string]*UsageBucket)
+	for _, record := range records {
+		stats.VideoBytes += record.Size
+		addUsage(models, record.ModelName(), record.Size)
+		addUsage(projects, record.String("project"), record.Size)
+		addUsage(statuses, record.String("status"), record.Size)
+	}
+
+	if len(records) > 0 {
+		stats.Newest = statsEntry(records[0])
+		stats.Oldest = statsEntry(records[len(records)-1])
+	}
+
+	stats.ByModel = sortedUsage(models)
+	stats.ByProject = sortedUsage(projects)
+	stats.ByStatus = sortedUsage(statuses)
+
+	stats.LogBytes = pathSize(filepath.Join(s.rootFolder, "logs"))
+	stats.ExportBytes = pathSize(filepath.Join(s.rootFolder, exportsFolder))
+	stats.TotalBytes = stats.VideoBytes + stats.LogBytes + stats.ExportBytes
+	return stats
+}
+
+// addUsage counts a video in its bucket. Videos without a value are grouped
+// under "none".
+func addUsage(buckets map[string]*UsageBucket, name string, bytes int64) {
+	if name == "" {
+		name = "none"
+	}
+	bucket, ok := buckets[name]
+	if !ok {
+		bucket = &UsageBucket{Name: name}
+		buckets[name] = bucket
+	}
+	bucket.Count++
+	bucket.Bytes += bytes
+}
+
+// sortedUsage returns buckets largest first
+func sortedUsage(buckets map[string]*UsageBucket) []UsageBucket {
+	usage := make([]UsageBucket, 0, len(buckets))
+	for _, bucket := range buckets {
+		usage = append(usage, *bucket)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Bytes != usage[j].Bytes {
+			return usage[i].Bytes > usage[j].Bytes
+		}
+		return usage[i].Name < usage[j].Name
+	})
+	return usage
+}
+
+func statsEntry(record Record) *StatsEntry {
+	return &StatsEntry{
+		StorageID: record.StorageID,
+		CreatedAt: record.String("created_at"),
+		Model:     record.ModelName(),
+		Prompt:    record.Parameter("prompt"),
+		Bytes:     record.Size,
+	}
+}
@@ -1,17 +1,19 @@
 package storage
 
 import (
+	"context"
 	"encoding/base64"
 	"fmt"
 	"io"
-	"log"
+	"math"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gomcpgo/replicate_video_ai/pkg/logging"
 	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +22,27 @@ import (
 type Storage struct {
 	rootFolder string
 	debug      bool
+	index      *index
+
+	// locations caches folders of storage IDs kept in project folders
+	locMu     sync.Mutex
+	locations map[string]string
+
+	// locks serialize downloads and metadata updates per storage ID
+	locksMu sync.Mutex
+	locks   map[string]*storageLock
+
+	// filenameTemplate names downloaded videos (see SetFilenameTemplate)
+	filenameTemplate string
+
+	// downloadIdle abandons a download that receives no data for this long
+	downloadIdle time.Duration
+	// downloadTransport carries downloads; nil uses http.DefaultTransport
+	downloadTransport http.RoundTripper
+
+	// maxImageBytes and imageTypes limit input images (see SetImageLimits)
+	maxImageBytes int64
+	imageTypes    []string
 }
 
 // NewStorage creates a new storage instance
@@ -27,6 +50,13 @@ func NewStorage(rootFolder string, debug bool) *Storage {
 	return &Storage{
 		rootFolder: rootFolder,
 		debug:      debug,
+		index:      newIndex(),
+		locations:  make(map[string]string),
+		locks:      make(map[string]*storageLock),
+
+		downloadIdle:  defaultDownloadIdle,
+		maxImageBytes: defaultMaxImageBytes,
+		imageTypes:    defaultImageTypes,
 	}
 }
 
@@ -39,73 +69,16 @@ func (s *Storage) GenerateStorageID() string {
 
 // CreateStorageFolder creates a folder for storing video and metadata
 func (s *Storage) CreateStorageFolder(storageID string) (string, error) {
-	folderPath := filepath.Join(s.rootFolder, storageID)
+	folderPath := s.folderPath(storageID)
 	if err := os.MkdirAll(folderPath, 0755); err != nil {
 		return "", fmt.Errorf("failed to create storage folder: %w", err)
 	}
 	return folderPath, nil
 }
 
-// SaveVideoFromURL downloads and saves a video from URL
-func (s *Storage) SaveVideoFromURL(url string, storageID string, filename string) (string, int64, error) {
-	// Create storage folder
-	folderPath, err := s.CreateStorageFolder(storageID)
-	if err != nil {
-		return "", 0, err
-	}
-
-	// Determine file extension from URL or default to mp4
-	ext := ".mp4"
-	if strings.Contains(url, ".webm") {
-		ext = ".webm"
-	} else if strings.Contains(url, ".gif") {
-		ext = ".gif"
-	}
-
-	// Use provided filename or default
-	if filename == "" {
-		filename = "video"
-	}
-	if !strings.Contains(filename, ".") {
-		filename = filename + ext
-	}
-
-	outputPath := filepath.Join(folderPath, filename)
-
-	// Download the video
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
-
-	resp, err := http.Get(url)
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to download video: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return "", 0, fmt.Errorf("failed to download video: status %d", resp.StatusCode)
-	}
-
-	// Create the output file
-	out, err := os.Create(outputPath)
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to create output file: %w", err)
-	}
-	defer out.Close()
-
-	// Copy the video data
-	size, err := io.Copy(out, resp.Body)
-	if err != nil {
-		return "", 0, fmt.Errorf("failed to save video: %w", err)
-	}
-
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
-
-	return outputPath, size, nil
-}
-
 // LoadMetadata loads metadata from a YAML file
 func (s *Storage) LoadMetadata(storageID string) (map[string]interface{}, error) {
-	folderPath := filepath.Join(s.rootFolder, storageID)
+	folderPath := s.folderPath(storageID)
 	metadataPath := filepath.Join(folderPath, "metadata.yaml")
 	
 	data, err := os.ReadFile(metadataPath)
@@ -142,24 +115,71 @@ func (s *Storage) SaveMetadata(storageID string, metadata map[string]interface{}
 		return fmt.Errorf("failed to marshal metadata: %w", err)
 	}
 
-	if err := os.WriteFile(metadataPath, data, 0644); err != nil {
+	if err := writeFileAtomic(metadataPath, data, 0644); err != nil {
 		return fmt.Errorf("failed to save metadata: %w", err)
 	}
 
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	s.indexRecord(storageID, metadata)
+	logging.Debug("metadata saved", "storage_id", storageID, "path", metadataPath)
 
 	return nil
 }
 
+// LogsFile is the name prediction logs are saved under in a storage folder
+const LogsFile = "logs.txt"
+
+// maxLogTailLines caps the log lines LogTail returns
+const maxLogTailLines = 20
+
+// SaveLogs writes a prediction's full logs to logs.txt in its storage folder
+func (s *Storage) SaveLogs(storageID, logs string) (string, error) {
+	folderPath, err := s.CreateStorageFolder(storageID)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(folderPath, LogsFile)
+	if err := writeFileAtomic(path, []byte(logs), 0644); err != nil {
+		return "", fmt.Errorf("failed to save logs: %w", err)
+	}
+	return path, nil
+}
+
+// LogTail returns the last lines of prediction logs, which usually hold the
+// model's actual error
+func LogTail(logs string) string {
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	if len(lines) > maxLogTailLines {
+		lines = lines[len(lines)-maxLogTailLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
 // SaveInputImage saves the input image for I2V generation
 func (s *Storage) SaveInputImage(storageID string, imagePath string) (string, error) {
-	folderPath := filepath.Join(s.rootFolder, storageID)
-	
-	// Read the input image
-	data, err := os.ReadFile(imagePath)
+	return s.saveImageCopy(storageID, imagePath, "input")
+}
+
+// SaveInputImageN saves the nth image of a multi-image request as
+// input_<n>.<ext>; the first image is saved by SaveInputImage
+func (s *Storage) SaveInputImageN(storageID string, imagePath string, n int) (string, error) {
+	return s.saveImageCopy(storageID, imagePath, fmt.Sprintf("input_%d", n))
+}
+
+// SaveStyleImage saves a copy of a text-to-video style reference image to
+// storage as style.<ext>
+func (s *Storage) SaveStyleImage(storageID string, imagePath string) (string, error) {
+	return s.saveImageCopy(storageID, imagePath, "style")
+}
+
+// saveImageCopy copies an image into a storage folder under name plus the
+// image's extension
+func (s *Storage) saveImageCopy(storageID, imagePath, name string) (string, error) {
+	// Open the input image
+	f, _, _, err := s.openImage(imagePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read input image: %w", err)
+		return "", fmt.Errorf("failed to read %s image: %w", name, err)
 	}
+	defer f.Close()
 
 	// Determine extension
 	ext := filepath.Ext(imagePath)
@@ -167,73 +187,153 @@ func (s *Storage) SaveInputImage(storageID string, imagePath string) (string, er
 		ext = ".jpg"
 	}
 
-	// Save to storage folder
-	outputPath := filepath.Join(folderPath, "input"+ext)
-	if err := os.WriteFile(outputPath, data, 0644); err != nil {
-		return "", fmt.Errorf("failed to save input image: %w", err)
+	// Save to storage folder, which doesn't exist yet for a new generation
+	folderPath, err := s.CreateStorageFolder(storageID)
+	if err != nil {
+		return "", err
+	}
+	outputPath := filepath.Join(folderPath, name+ext)
+	size, err := writeAtomic(outputPath, 0644, func(w io.Writer) (int64, error) {
+		return s.copyImage(w, f)
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save %s image: %w", name, err)
 	}
 
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	logging.Debug("image saved", "storage_id", storageID, "kind", name, "path", outputPath, "size", size)
 
 	return outputPath, nil
 }
 
-// ImageToDataURL converts an image file to a data URL
+// ImageToDataURL converts an image file to a data URL. The image is
+// encoded as it is read, into a buffer sized for the whole data URL, so it
+// is held in memory only once, as base64.
 func (s *Storage) ImageToDataURL(imagePath string) (string, error) {
-	// Read the image file
-	data, err := os.ReadFile(imagePath)
+	// Open the image file, with its MIME type detected from its content
+	f, size, mimeType, err := s.openImage(imagePath)
 	if err != nil {
-		return "", fmt.Errorf("failed to read image file: %w", err)
-	}
-
-	// Determine MIME type based on extension
-	ext := strings.ToLower(filepath.Ext(imagePath))
-	var mimeType string
-	switch ext {
-	case ".jpg", ".jpeg":
-		mimeType = "image/jpeg"
-	case ".png":
-		mimeType = "image/png"
-	case ".webp":
-		mimeType = "image/webp"
-	case ".gif":
-		mimeType = "image/gif"
-	default:
-		// Try to detect from content
-		contentType := http.DetectContentType(data)
-		if strings.HasPrefix(contentType, "image/") {
-			mimeType = contentType
-		} else {
-			mimeType = "image/jpeg" // Default fallback
-		}
+		return "", err
 	}
+	defer f.Close()
+
+	prefix := "data:" + mimeType + ";base64,"
+	var dataURL strings.Builder
+	dataURL.Grow(len(prefix) + base64.StdEncoding.EncodedLen(int(size)))
+	dataURL.WriteString(prefix)
 
 	// Encode to base64
-	encoded := base64.StdEncoding.EncodeToString(data)
-	dataURL := fmt.Sprintf("data:%s;base64,%s", mimeType, encoded)
+	encoder := base64.NewEncoder(base64.StdEncoding, &dataURL)
+	n, err := s.copyImage(encoder, f)
+	if err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	logging.Debug("image encoded as data URL", "path", imagePath, "mime_type", mimeType, "size", n)
+
+	return dataURL.String(), nil
+}
+
+// DeleteStorage removes a storage folder and its index entry
+func (s *Storage) DeleteStorage(storageID string) error {
+	if err := ValidateStorageID(storageID); err != nil {
+		return err
+	}
+
+	folderPath := s.folderPath(storageID)
+	if _, err := os.Stat(filepath.Join(folderPath, "metadata.yaml")); err != nil {
+		return fmt.Errorf("storage ID not found: %s", storageID)
+	}
+
+	if err := os.RemoveAll(folderPath); err != nil {
+		return fmt.Errorf("failed to delete storage folder: %w", err)
+	}
+	// Remove the pointer left in the root for output_dir overrides
+	if pointer := filepath.Join(s.rootFolder, storageID); pointer != folderPath {
+		if err := os.RemoveAll(pointer); err != nil {
+			logging.Warn("failed to remove storage pointer", "storage_id", storageID, "error", err)
+		}
+	}
+	s.forget(storageID)
+	s.removeLockFile(storageID)
+	s.locMu.Lock()
+	delete(s.locations, storageID)
+	s.locMu.Unlock()
 
-	// Note: Debug logging disabled in MCP mode to avoid stdout pollution
+	logging.Info("storage deleted", "storage_id", storageID)
+	return nil
+}
 
-	return dataURL, nil
+// DiscardStorage removes the folder of a generation that failed before its
+// metadata was saved, such as one refused by a limit, with any input images
+// copied into it. A folder that has metadata is left alone.
+func (s *Storage) DiscardStorage(storageID string) {
+	if ValidateStorageID(storageID) != nil {
+		return
+	}
+	folderPath := s.folderPath(storageID)
+	if _, err := os.Stat(filepath.Join(folderPath, "metadata.yaml")); err == nil {
+		return
+	}
+	for _, path := range []string{folderPath, filepath.Join(s.rootFolder, storageID)} {
+		if err := os.RemoveAll(path); err != nil {
+			logging.Warn("failed to remove storage folder", "storage_id", storageID, "error", err)
+		}
+	}
+	s.locMu.Lock()
+	delete(s.locations, storageID)
+	s.locMu.Unlock()
 }
 
 // GetStoragePath returns the full path for a storage ID
 func (s *Storage) GetStoragePath(storageID string) string {
-	return filepath.Join(s.rootFolder, storageID)
+	return s.folderPath(storageID)
+}
+
+// CheckWritable verifies that files can be created in the storage root by
+// writing and removing a temporary file
+func (s *Storage) CheckWritable() error {
+	if err := os.MkdirAll(s.rootFolder, 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.rootFolder, ".write-check.*"+tempSuffix)
+	if err != nil {
+		return err
+	}
+	_, err = tmp.WriteString("ok")
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if removeErr := os.Remove(tmp.Name()); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// HasFFmpeg reports whether ffmpeg is available for thumbnails and editing
+func HasFFmpeg() bool {
+	return DetectMediaTools().FFmpeg.Available()
+}
+
+// HasFFprobe reports whether ffprobe is available for metadata extraction
+func HasFFprobe() bool {
+	return DetectMediaTools().FFprobe.Available()
 }
 
 // GenerateThumbnail attempts to generate a thumbnail from video using ffmpeg
 // Returns the thumbnail path if successful, empty string if ffmpeg is not available
-func (s *Storage) GenerateThumbnail(storageID string, videoPath string) (string, error) {
+func (s *Storage) GenerateThumbnail(ctx context.Context, storageID string, videoPath string) (string, error) {
 	// Check if ffmpeg is available
-	ffmpegPath, err := exec.LookPath("ffmpeg")
+	ffmpegPath, err := ffmpegBinary()
 	if err != nil {
-		log.Printf("WARNING: ffmpeg not found, skipping thumbnail generation: %v", err)
+		logging.Warn("ffmpeg not found, skipping thumbnail generation", "error", err)
 		return "", nil // Not an error, just degraded functionality
 	}
 	
 	// Create thumbnail path
-	folderPath := filepath.Join(s.rootFolder, storageID)
+	folderPath := s.folderPath(storageID)
 	thumbnailPath := filepath.Join(folderPath, "thumbnail.jpg")
 	
 	// Build ffmpeg command to extract frame at 2 seconds (or middle if shorter)
@@ -242,7 +342,7 @@ func (s *Storage) GenerateThumbnail(storageID string, videoPath string) (string,
 	// -vframes 1: extract 1 frame
 	// -vf scale=320:-1: scale to 320px width, maintain aspect ratio
 	// -q:v 2: JPEG quality (2 is good quality)
-	cmd := exec.Command(ffmpegPath,
+	cmd := mediaCommand(ctx, probeTimeout, ffmpegPath,
 		"-ss", "2",
 		"-i", videoPath,
 		"-vframes", "1",
@@ -256,7 +356,7 @@ func (s *Storage) GenerateThumbnail(storageID string, videoPath string) (string,
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		// Try extracting first frame instead if seeking to 2 seconds failed
-		cmd = exec.Command(ffmpegPath,
+		cmd = mediaCommand(ctx, probeTimeout, ffmpegPath,
 			"-i", videoPath,
 			"-vframes", "1",
 			"-vf", "scale=320:-1",
@@ -266,33 +366,89 @@ func (s *Storage) GenerateThumbnail(storageID string, videoPath string) (string,
 		)
 		output, err = cmd.CombinedOutput()
 		if err != nil {
-			log.Printf("WARNING: Failed to generate thumbnail: %v, output: %s", err, string(output))
+			logging.Warn("failed to generate thumbnail", "storage_id", storageID, "error", err, "output", string(output))
 			return "", nil // Not a critical error
 		}
 	}
 	
 	// Verify thumbnail was created
 	if _, err := os.Stat(thumbnailPath); os.IsNotExist(err) {
-		log.Printf("WARNING: Thumbnail file was not created")
+		logging.Warn("thumbnail file was not created", "storage_id", storageID)
 		return "", nil
 	}
 	
-	log.Printf("Successfully generated thumbnail: %s", thumbnailPath)
+	logging.Info("generated thumbnail", "storage_id", storageID, "path", thumbnailPath)
 	return thumbnailPath, nil
 }
 
+// GenerateContactSheet extracts evenly spaced frames from a video and
+// tiles them into a single JPEG grid (contact_sheet.jpg in the storage folder)
+func (s *Storage) GenerateContactSheet(ctx context.Context, storageID string, videoPath string, frames int) (string, error) {
+	ffmpegPath, err := ffmpegBinary()
+	if err != nil {
+		return "", fmt.Errorf("ffmpeg is required to generate a contact sheet")
+	}
+
+	if frames < 2 {
+		frames = 2
+	}
+	if frames > 36 {
+		frames = 36
+	}
+
+	duration, _, _ := s.ExtractVideoMetadata(ctx, videoPath)
+	if duration <= 0 {
+		return "", fmt.Errorf("could not determine video duration")
+	}
+
+	// Grid as close to square as possible, wider than tall
+	cols := int(math.Ceil(math.Sqrt(float64(frames))))
+	rows := (frames + cols - 1) / cols
+
+	contactSheetPath := filepath.Join(s.folderPath(storageID), "contact_sheet.jpg")
+
+	// fps=N/duration samples N evenly spaced frames; tile lays them out in a grid
+	filter := fmt.Sprintf("fps=%d/%.3f,scale=320:-1,tile=%dx%d:padding=4:margin=4", frames, duration, cols, rows)
+	cmd := mediaCommand(ctx, renderTimeout, ffmpegPath,
+		"-i", videoPath,
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "3",
+		"-y",
+		contactSheetPath,
+	)
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		logging.Warn("failed to generate contact sheet", "storage_id", storageID, "error", err, "output", string(output))
+		return "", fmt.Errorf("failed to generate contact sheet: %w", err)
+	}
+
+	if _, err := os.Stat(contactSheetPath); err != nil {
+		return "", fmt.Errorf("contact sheet file was not created")
+	}
+
+	logging.Info("generated contact sheet", "storage_id", storageID, "path", contactSheetPath, "frames", frames)
+	return contactSheetPath, nil
+}
+
 // ExtractVideoMetadata attempts to extract video metadata using ffmpeg
 // Returns duration and resolution if successful
-func (s *Storage) ExtractVideoMetadata(videoPath string) (duration float64, resolution string, err error) {
-	// Check if ffprobe is available (comes with ffmpeg)
-	ffprobePath, err := exec.LookPath("ffprobe")
+func (s *Storage) ExtractVideoMetadata(ctx context.Context, videoPath string) (duration float64, resolution string, err error) {
+	// Check if ffprobe is available (comes with ffmpeg); without it, MP4
+	// and QuickTime files are read directly
+	ffprobePath, err := ffprobeBinary()
 	if err != nil {
-		log.Printf("WARNING: ffprobe not found, skipping metadata extraction: %v", err)
-		return 0, "", nil
+		d, width, height, mp4Err := readMP4Metadata(videoPath)
+		if mp4Err != nil {
+			logging.Warn("ffprobe not found and MP4 metadata unreadable, skipping metadata extraction", "path", videoPath, "error", mp4Err)
+			return 0, "", nil
+		}
+		return d, fmt.Sprintf("%dx%d", width, height), nil
 	}
 	
 	// Get duration
-	durationCmd := exec.Command(ffprobePath,
+	durationCmd := mediaCommand(ctx, probeTimeout, ffprobePath,
 		"-v", "error",
 		"-show_entries", "format=duration",
 		"-of", "default=noprint_wrappers=1:nokey=1",
@@ -301,7 +457,7 @@ func (s *Storage) ExtractVideoMetadata(videoPath string) (duration float64, reso
 	
 	durationOutput, err := durationCmd.Output()
 	if err != nil {
-		log.Printf("WARNING: Failed to extract duration: %v", err)
+		logging.Warn("failed to extract duration", "path", videoPath, "error", err)
 	} else {
 		// Parse duration string
 		var d float64
@@ -310,7 +466,7 @@ func (s *Storage) ExtractVideoMetadata(videoPath string) (duration float64, reso
 	}
 	
 	// Get resolution
-	resCmd := exec.Command(ffprobePath,
+	resCmd := mediaCommand(ctx, probeTimeout, ffprobePath,
 		"-v", "error",
 		"-select_streams", "v:0",
 		"-show_entries", "stream=width,height",
@@ -320,7 +476,7 @@ func (s *Storage) ExtractVideoMetadata(videoPath string) (duration float64, reso
 	
 	resOutput, err := resCmd.Output()
 	if err != nil {
-		log.Printf("WARNING: Failed to extract resolution: %v", err)
+		logging.Warn("failed to extract resolution", "path", videoPath, "error", err)
 	} else {
 		resolution = strings.TrimSpace(string(resOutput))
 	}
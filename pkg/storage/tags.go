@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxTagLength keeps tags short enough to read in listings
+const maxTagLength = 64
+
+// NormalizeTag lowercases and trims a tag so "Beach " and "beach" match
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// Tags returns the tags recorded in metadata
+func (r Record) Tags() []string {
+	var tags []string
+	switch v := r.Metadata["tags"].(type) {
+	case []string:
+		tags = append(tags, v...)
+	case []interface{}:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				tags = append(tags, s)
+			}
+		}
+	}
+	return tags
+}
+
+// HasTag reports whether the record carries the given tag
+func (r Record) HasTag(tag string) bool {
+	tag = NormalizeTag(tag)
+	for _, t := range r.Tags() {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Favorite reports whether the video is marked as a favorite
+func (r Record) Favorite() bool {
+	favorite, _ := r.Metadata["favorite"].(bool)
+	return favorite
+}
+
+// UpdateTags adds and removes tags on a stored video and optionally sets its
+// favorite flag, returning the updated record
+func (s *Storage) UpdateTags(storageID string, add, remove []string, favorite *bool) (Record, error) {
+	for _, tag := range add {
+		if len(NormalizeTag(tag)) > maxTagLength {
+			return Record{}, fmt.Errorf("tag %q is longer than %d characters", tag, maxTagLength)
+		}
+	}
+
+	// Serialize read-modify-write so concurrent tag edits aren't lost
+	unlock := s.Lock(storageID)
+	defer unlock()
+
+	metadata, err := s.LoadMetadata(storageID)
+	if err != nil {
+		return Record{}, err
+	}
+	if len(metadata) == 0 {
+		return Record{}, fmt.Errorf("storage ID not found: %s", storageID)
+	}
+	record := Record{StorageID: storageID, Metadata: metadata}
+
+	set := make(map[string]bool)
+	for _, tag := range record.Tags() {
+		set[tag] = true
+	}
+	for _, tag := range add {
+		if tag = NormalizeTag(tag); tag != "" {
+			set[tag] = true
+		}
+	}
+	for _, tag := range remove {
+		delete(set, NormalizeTag(tag))
+	}
+
+	tags := make([]string, 0, len(set))
+	for tag := range set {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	if len(tags) > 0 {
+		metadata["tags"] = tags
+	} else {
+		delete(metadata, "tags")
+	}
+	if favorite != nil {
+		if *favorite {
+			metadata["favorite"] = true
+		} else {
+			delete(metadata, "favorite")
+		}
+	}
+
+	if err := s.SaveMetadata(storageID, metadata); err != nil {
+		return Record{}, err
+	}
+	return record, nil
+}
@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// MediaTool is an external binary located once at startup
+type MediaTool struct {
+	Path    string // Empty when the tool isn't available
+	Version string // First line of its -version output
+	Err     error  // Why the tool isn't available
+}
+
+// Available reports whether the tool was found and runs
+func (t MediaTool) Available() bool {
+	return t.Path != ""
+}
+
+// MediaTools describes the ffmpeg and ffprobe binaries in use
+type MediaTools struct {
+	FFmpeg  MediaTool
+	FFprobe MediaTool
+}
+
+var (
+	mediaToolsOnce  sync.Once
+	mediaTools      MediaTools
+	ffmpegOverride  string
+	ffprobeOverride string
+)
+
+// SetMediaToolPaths makes ffmpeg and ffprobe run from the given paths
+// (FFMPEG_PATH, FFPROBE_PATH) instead of being looked up in PATH. Empty
+// paths keep the lookup. It must be called before the tools are first used.
+func SetMediaToolPaths(ffmpegPath, ffprobePath string) {
+	ffmpegOverride, ffprobeOverride = ffmpegPath, ffprobePath
+}
+
+// DetectMediaTools locates ffmpeg and ffprobe the first time it's called,
+// and returns that result from then on, so thumbnails and edits don't
+// search PATH each time
+func DetectMediaTools() MediaTools {
+	mediaToolsOnce.Do(func() {
+		mediaTools = MediaTools{
+			FFmpeg:  locateTool("ffmpeg", ffmpegOverride),
+			FFprobe: locateTool("ffprobe", ffprobeOverride),
+		}
+	})
+	return mediaTools
+}
+
+// locateTool finds a tool at its configured path, or in PATH, and checks
+// that it runs
+func locateTool(name, configured string) MediaTool {
+	path := configured
+	if path == "" {
+		path = name
+	}
+	resolved, err := exec.LookPath(path)
+	if err != nil {
+		if configured != "" {
+			return MediaTool{Err: fmt.Errorf("%s not found at %s", name, configured)}
+		}
+		return MediaTool{Err: fmt.Errorf("%s not found in PATH", name)}
+	}
+	output, err := mediaCommand(context.Background(), probeTimeout, resolved, "-version").Output()
+	if err != nil {
+		return MediaTool{Err: fmt.Errorf("%s at %s does not run: %w", name, resolved, err)}
+	}
+	version, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return MediaTool{Path: resolved, Version: version}
+}
+
+// ffmpegBinary returns the path of ffmpeg, or why it isn't available
+func ffmpegBinary() (string, error) {
+	tool := DetectMediaTools().FFmpeg
+	return tool.Path, tool.Err
+}
+
+// ffprobeBinary returns the path of ffprobe, or why it isn't available
+func ffprobeBinary() (string, error) {
+	tool := DetectMediaTools().FFprobe
+	return tool.Path, tool.Err
+}
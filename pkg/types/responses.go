@@ -1,24 +1,37 @@
 package types
 
+import "time"
+
+// SchemaVersion is the version of the response schemas get_response_schema
+// publishes, reported in every response. The minor version grows when
+// fields are added, the major version when fields are removed or change
+// meaning.
+const SchemaVersion = "1.0"
+
 // SuccessResponse represents a successful operation response
 type SuccessResponse struct {
-	Success      bool                   `json:"success"`
-	Operation    string                 `json:"operation"`
-	StorageID    string                 `json:"storage_id"`
-	PredictionID string                 `json:"prediction_id,omitempty"`
-	Status       string                 `json:"status"`
-	Paths        map[string]string      `json:"paths"`
-	Model        map[string]string      `json:"model"`
-	Parameters   map[string]interface{} `json:"parameters"`
-	Metrics      map[string]interface{} `json:"metrics,omitempty"`
-	Message      string                 `json:"message,omitempty"`
+	Success       bool                     `json:"success"`
+	SchemaVersion string                   `json:"schema_version"`
+	Operation     string                   `json:"operation"`
+	StorageID     string                   `json:"storage_id"`
+	PredictionID  string                   `json:"prediction_id,omitempty"`
+	Status        string                   `json:"status"`
+	Paths         map[string]string        `json:"paths"`
+	URLs          map[string]string        `json:"urls,omitempty"` // Signed HTTP URLs, when the file server is enabled
+	Model         map[string]string        `json:"model"`
+	Parameters    map[string]interface{}   `json:"parameters"`
+	Metrics       map[string]interface{}   `json:"metrics,omitempty"`
+	Message       string                   `json:"message,omitempty"`
+	Warnings      []string                 `json:"warnings,omitempty"` // Parameters the model ignored
+	Attempts      []map[string]interface{} `json:"attempts,omitempty"` // Failed predictions resubmitted by auto_retry
 }
 
 // ErrorResponse represents an error response
 type ErrorResponse struct {
-	Success   bool                   `json:"success"`
-	Operation string                 `json:"operation"`
-	Error     ErrorDetails           `json:"error"`
+	Success       bool         `json:"success"`
+	SchemaVersion string       `json:"schema_version"`
+	Operation     string       `json:"operation"`
+	Error         ErrorDetails `json:"error"`
 }
 
 // ErrorDetails contains error information
@@ -30,11 +43,518 @@ type ErrorDetails struct {
 
 // ProcessingResponse represents an async operation in progress
 type ProcessingResponse struct {
-	Success      bool   `json:"success"`
-	Status       string `json:"status"`
-	Operation    string `json:"operation"`
-	PredictionID string `json:"prediction_id"`
-	StorageID    string `json:"storage_id,omitempty"`
-	Message      string `json:"message"`
-	WaitTime     int    `json:"wait_time,omitempty"`
-}
\ No newline at end of file
+	Success       bool   `json:"success"`
+	SchemaVersion string `json:"schema_version"`
+	Status        string `json:"status"`
+	Operation     string `json:"operation"`
+	OperationID   string `json:"operation_id,omitempty"` // Background poll, for continue_operation
+	PredictionID  string `json:"prediction_id"`
+	StorageID     string `json:"storage_id,omitempty"`
+	Message       string `json:"message"`
+	WaitTime      int    `json:"wait_time,omitempty"`
+	// EstimatedTime is the typical total generation time for the model
+	EstimatedTime      int        `json:"estimated_time,omitempty"`
+	SuggestedContinues int        `json:"suggested_continues,omitempty"`
+	Heartbeat          *Heartbeat `json:"heartbeat,omitempty"`
+	Warnings           []string   `json:"warnings,omitempty"` // Parameters the model ignored
+}
+
+// Heartbeat reports the progress of a prediction that is still being polled
+// in the background
+type Heartbeat struct {
+	PredictionStatus string  `json:"prediction_status"`
+	Elapsed          int     `json:"elapsed"` // Seconds the background poll has been running
+	Polls            int     `json:"polls"`
+	Progress         float64 `json:"progress,omitempty"` // Percent complete, when the model's logs show it
+	UpdatedAt        string  `json:"updated_at,omitempty"`
+}
+
+// VideoSummary describes a stored video in list and search results
+type VideoSummary struct {
+	StorageID      string            `json:"storage_id"`
+	PredictionID   string            `json:"prediction_id,omitempty"`
+	Operation      string            `json:"operation,omitempty"`
+	SessionID      string            `json:"session_id,omitempty"`
+	Project        string            `json:"project,omitempty"`
+	Tags           []string          `json:"tags,omitempty"`
+	Favorite       bool              `json:"favorite,omitempty"`
+	Status         string            `json:"status"`
+	Model          string            `json:"model,omitempty"`
+	Prompt         string            `json:"prompt,omitempty"`
+	NegativePrompt string            `json:"negative_prompt,omitempty"`
+	CreatedAt      string            `json:"created_at,omitempty"`
+	Paths          map[string]string `json:"paths,omitempty"`
+	Score          float64           `json:"score,omitempty"`
+	MatchedTerms   []string          `json:"matched_terms,omitempty"`
+}
+
+// ListResponse represents a list of stored videos
+type ListResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Count         int                    `json:"count"`
+	Filters       map[string]interface{} `json:"filters,omitempty"`
+	Videos        []VideoSummary         `json:"videos"`
+}
+
+// PredictionStatus is the outcome of checking one prediction in a batch
+type PredictionStatus struct {
+	PredictionID string            `json:"prediction_id"`
+	StorageID    string            `json:"storage_id,omitempty"`
+	Status       string            `json:"status"`
+	Paths        map[string]string `json:"paths,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	ErrorType    string            `json:"error_type,omitempty"` // Kind of failure, e.g. content_flagged or billing
+	LogsTail     string            `json:"logs_tail,omitempty"`  // Last prediction log lines of a failed generation
+}
+
+// ComparisonEntry is one model's generation in a comparison
+type ComparisonEntry struct {
+	Model          string            `json:"model"`
+	ModelName      string            `json:"model_name,omitempty"`
+	StorageID      string            `json:"storage_id,omitempty"`
+	PredictionID   string            `json:"prediction_id,omitempty"`
+	OperationID    string            `json:"operation_id,omitempty"`
+	Status         string            `json:"status"`
+	Paths          map[string]string `json:"paths,omitempty"`
+	URLs           map[string]string `json:"urls,omitempty"`
+	EstimatedCost  float64           `json:"estimated_cost,omitempty"`  // USD at default settings
+	ElapsedSeconds int               `json:"elapsed_seconds,omitempty"` // From creation to completion, or so far
+	Error          string            `json:"error,omitempty"`
+	ErrorType      string            `json:"error_type,omitempty"`
+}
+
+// ComparisonResponse reports every generation of a compare_models call
+type ComparisonResponse struct {
+	Success            bool              `json:"success"`
+	SchemaVersion      string            `json:"schema_version"`
+	Operation          string            `json:"operation"`
+	ComparisonID       string            `json:"comparison_id"`
+	Prompt             string            `json:"prompt,omitempty"`
+	Count              int               `json:"count"`
+	Completed          int               `json:"completed"`
+	Pending            int               `json:"pending"`
+	Failed             int               `json:"failed"`
+	TotalEstimatedCost float64           `json:"total_estimated_cost"`
+	Results            []ComparisonEntry `json:"results"`
+	Message            string            `json:"message,omitempty"`
+}
+
+// VariationEntry is one expanded prompt of a variation set
+type VariationEntry struct {
+	Index         int               `json:"index"`
+	Prompt        string            `json:"prompt"`
+	Substitutions map[string]string `json:"substitutions,omitempty"`
+	Seed          *int              `json:"seed,omitempty"`
+	Status        string            `json:"status"` // queued until a concurrency slot frees up, then the prediction status
+	StorageID     string            `json:"storage_id,omitempty"`
+	PredictionID  string            `json:"prediction_id,omitempty"`
+	Paths         map[string]string `json:"paths,omitempty"`
+	URLs          map[string]string `json:"urls,omitempty"`
+	Error         string            `json:"error,omitempty"`
+	ErrorType     string            `json:"error_type,omitempty"`
+}
+
+// VariationSetResponse reports every generation of a generate_variations call
+type VariationSetResponse struct {
+	Success        bool             `json:"success"`
+	SchemaVersion  string           `json:"schema_version"`
+	Operation      string           `json:"operation"`
+	VariationSetID string           `json:"variation_set_id"`
+	Model          string           `json:"model,omitempty"`
+	BasePrompt     string           `json:"base_prompt,omitempty"`
+	Count          int              `json:"count"`
+	Queued         int              `json:"queued"`
+	Pending        int              `json:"pending"`
+	Completed      int              `json:"completed"`
+	Failed         int              `json:"failed"`
+	Variations     []VariationEntry `json:"variations"`
+	Message        string           `json:"message,omitempty"`
+}
+
+// StoryboardScene is one scene of a storyboard
+type StoryboardScene struct {
+	Scene        int               `json:"scene"`
+	Prompt       string            `json:"prompt"`
+	Model        string            `json:"model,omitempty"`
+	Status       string            `json:"status"` // waiting for the previous scene (chain mode), queued until a concurrency slot frees up, then the prediction status
+	StorageID    string            `json:"storage_id,omitempty"`
+	PredictionID string            `json:"prediction_id,omitempty"`
+	Paths        map[string]string `json:"paths,omitempty"`
+	URLs         map[string]string `json:"urls,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	ErrorType    string            `json:"error_type,omitempty"`
+}
+
+// StoryboardVideo is the video assembled from a storyboard's scenes
+type StoryboardVideo struct {
+	Status    string            `json:"status"` // waiting, assembling, completed, or failed
+	StorageID string            `json:"storage_id,omitempty"`
+	Crossfade float64           `json:"crossfade"`
+	Paths     map[string]string `json:"paths,omitempty"`
+	URLs      map[string]string `json:"urls,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// StoryboardResponse reports the progress of a generate_storyboard call
+type StoryboardResponse struct {
+	Success       bool              `json:"success"`
+	SchemaVersion string            `json:"schema_version"`
+	Operation     string            `json:"operation"`
+	StoryboardID  string            `json:"storyboard_id"`
+	Status        string            `json:"status"` // generating, assembling, completed, or failed
+	SceneCount    int               `json:"scene_count"`
+	Queued        int               `json:"queued"`
+	Pending       int               `json:"pending"`
+	Completed     int               `json:"completed"`
+	Failed        int               `json:"failed"`
+	Progress      float64           `json:"progress"` // Fraction of scenes completed, 0-1
+	Scenes        []StoryboardScene `json:"scenes"`
+	Video         *StoryboardVideo  `json:"video,omitempty"`
+	Message       string            `json:"message,omitempty"`
+}
+
+// BatchStatusResponse represents the result of checking several predictions
+type BatchStatusResponse struct {
+	Success       bool               `json:"success"`
+	SchemaVersion string             `json:"schema_version"`
+	Operation     string             `json:"operation"`
+	Count         int                `json:"count"`
+	Completed     int                `json:"completed"`
+	Pending       int                `json:"pending"`
+	Failed        int                `json:"failed"`
+	Message       string             `json:"message,omitempty"`
+	Results       []PredictionStatus `json:"results"`
+}
+
+// ModelInfo describes a registered model
+type ModelInfo struct {
+	Alias             string   `json:"alias"`
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	Version           string   `json:"version,omitempty"`
+	Type              string   `json:"type"`
+	DefaultResolution string   `json:"default_resolution,omitempty"`
+	MaxDuration       int      `json:"max_duration,omitempty"`
+	Features          []string `json:"features,omitempty"`
+	Effects           []string `json:"effects,omitempty"`             // Names accepted by effect
+	EstimatedCost     float64  `json:"estimated_cost,omitempty"`      // USD at default settings
+	CostPerSecond     float64  `json:"cost_per_second,omitempty"`     // USD, for models priced by duration
+	RecentGenerations int      `json:"recent_generations"`            // Finished generations behind success_rate
+	SuccessRate       float64  `json:"success_rate"`                  // 1 without recent generations
+	MedianWaitSeconds int      `json:"median_wait_seconds,omitempty"` // Of recent completions
+}
+
+// CapabilitiesResponse describes what this server deployment supports
+type CapabilitiesResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Server        map[string]string      `json:"server"`
+	Subsystems    map[string]interface{} `json:"subsystems"`
+	Models        []ModelInfo            `json:"models"`
+	Tools         []string               `json:"tools"`
+}
+
+// AccountResponse reports the Replicate account status
+type AccountResponse struct {
+	Success       bool   `json:"success"`
+	SchemaVersion string `json:"schema_version"`
+	Operation     string `json:"operation"`
+	AccountStatus
+}
+
+// HealthCheckResponse reports the server's self-test
+type HealthCheckResponse struct {
+	Success       bool   `json:"success"`
+	SchemaVersion string `json:"schema_version"`
+	Operation     string `json:"operation"`
+	HealthReport
+}
+
+// ModelVersionInfo summarizes one upstream model version
+type ModelVersionInfo struct {
+	ID         string `json:"id"`
+	CreatedAt  string `json:"created_at"`
+	CogVersion string `json:"cog_version,omitempty"`
+	Pinned     bool   `json:"pinned,omitempty"`
+}
+
+// ModelVersionsResponse lists a model's versions and its latest input schema
+type ModelVersionsResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Model         string                 `json:"model"`
+	Alias         string                 `json:"alias,omitempty"`
+	Versions      []ModelVersionInfo     `json:"versions"`
+	InputSchema   map[string]interface{} `json:"input_schema,omitempty"`
+}
+
+// DeploymentInfo summarizes one of the account's deployments
+type DeploymentInfo struct {
+	Model        string `json:"model"` // What run_custom_video_model takes, e.g. deployment:acme/my-wan
+	RunsModel    string `json:"runs_model"`
+	Version      string `json:"version"`
+	Release      int    `json:"release"`
+	Hardware     string `json:"hardware,omitempty"`
+	MinInstances int    `json:"min_instances"`
+	MaxInstances int    `json:"max_instances"`
+}
+
+// DeploymentsResponse lists the account's deployments
+type DeploymentsResponse struct {
+	Success       bool             `json:"success"`
+	SchemaVersion string           `json:"schema_version"`
+	Operation     string           `json:"operation"`
+	Count         int              `json:"count"`
+	Deployments   []DeploymentInfo `json:"deployments"`
+}
+
+// ModelRecommendation describes one model considered by recommend_model
+type ModelRecommendation struct {
+	Model               string  `json:"model"`
+	Name                string  `json:"name"`
+	EstimatedCost       float64 `json:"estimated_cost"`        // USD at default settings
+	ExpectedWaitSeconds int     `json:"expected_wait_seconds"` // Median of recent completions, or the model's typical wait
+	SuccessRate         float64 `json:"success_rate"`          // Of recent finished generations; 1 without any
+	RecentGenerations   int     `json:"recent_generations"`
+	Excluded            string  `json:"excluded,omitempty"` // Why the model doesn't fit the constraints
+}
+
+// RecommendModelResponse ranks the registered models for a request's
+// constraints, best first
+type RecommendModelResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Recommended   string                 `json:"recommended"`
+	Constraints   map[string]interface{} `json:"constraints,omitempty"`
+	Candidates    []ModelRecommendation  `json:"candidates"`
+}
+
+// TagResponse reports a video's tags after tag_video
+type TagResponse struct {
+	Success       bool     `json:"success"`
+	SchemaVersion string   `json:"schema_version"`
+	Operation     string   `json:"operation"`
+	StorageID     string   `json:"storage_id"`
+	Tags          []string `json:"tags"`
+	Favorite      bool     `json:"favorite"`
+}
+
+// TagCount is how many stored videos carry a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagListResponse lists the tags in use across stored videos
+type TagListResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Count         int                    `json:"count"`
+	Favorites     int                    `json:"favorites"`
+	Filters       map[string]interface{} `json:"filters,omitempty"`
+	Tags          []TagCount             `json:"tags"`
+}
+
+// ExportResponse describes an export of stored videos
+type ExportResponse struct {
+	Success       bool     `json:"success"`
+	SchemaVersion string   `json:"schema_version"`
+	Operation     string   `json:"operation"`
+	Format        string   `json:"format"`
+	Path          string   `json:"path"`
+	Count         int      `json:"count"`
+	Size          int64    `json:"size"`
+	StorageIDs    []string `json:"storage_ids"`
+}
+
+// FrameInfo describes one extracted frame
+type FrameInfo struct {
+	Path      string   `json:"path"`
+	Timestamp *float64 `json:"timestamp,omitempty"` // Seconds into the video, when taken at a timestamp
+}
+
+// FramesResponse lists frames extracted from a stored video
+type FramesResponse struct {
+	Success       bool        `json:"success"`
+	SchemaVersion string      `json:"schema_version"`
+	Operation     string      `json:"operation"`
+	StorageID     string      `json:"storage_id"`
+	Folder        string      `json:"folder"`
+	Format        string      `json:"format"`
+	Count         int         `json:"count"`
+	Frames        []FrameInfo `json:"frames"`
+}
+
+// DuplicateGroup lists stored videos that share an identical file
+type DuplicateGroup struct {
+	Kind        string   `json:"kind"`
+	Hash        string   `json:"sha256"`
+	Size        int64    `json:"size"`
+	WastedBytes int64    `json:"wasted_bytes"`
+	StorageIDs  []string `json:"storage_ids"`
+}
+
+// DuplicatesResponse reports groups of duplicate videos or input images
+type DuplicatesResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Count         int                    `json:"count"`
+	WastedBytes   int64                  `json:"wasted_bytes"`
+	Backfilled    int                    `json:"backfilled,omitempty"`
+	Filters       map[string]interface{} `json:"filters,omitempty"`
+	Duplicates    []DuplicateGroup       `json:"duplicates"`
+}
+
+// UsageBucket is the disk usage of a group of stored videos
+type UsageBucket struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Bytes int64  `json:"bytes"`
+}
+
+// StatsEntry identifies one stored video in a storage stats report
+type StatsEntry struct {
+	StorageID string `json:"storage_id"`
+	CreatedAt string `json:"created_at,omitempty"`
+	Model     string `json:"model,omitempty"`
+	Prompt    string `json:"prompt,omitempty"`
+	Bytes     int64  `json:"bytes"`
+}
+
+// StorageStatsResponse reports disk usage of the storage root
+type StorageStatsResponse struct {
+	Success       bool          `json:"success"`
+	SchemaVersion string        `json:"schema_version"`
+	Operation     string        `json:"operation"`
+	RootFolder    string        `json:"root_folder"`
+	TotalBytes    int64         `json:"total_bytes"`
+	VideoBytes    int64         `json:"video_bytes"`
+	LogBytes      int64         `json:"log_bytes"`
+	ExportBytes   int64         `json:"export_bytes"`
+	VideoCount    int           `json:"video_count"`
+	ByModel       []UsageBucket `json:"by_model"`
+	ByProject     []UsageBucket `json:"by_project"`
+	ByStatus      []UsageBucket `json:"by_status"`
+	Oldest        *StatsEntry   `json:"oldest,omitempty"`
+	Newest        *StatsEntry   `json:"newest,omitempty"`
+}
+
+// AuditRecord is one tool call in the audit log
+type AuditRecord struct {
+	Timestamp     time.Time              `json:"timestamp"`
+	Tool          string                 `json:"tool"`
+	Namespace     string                 `json:"namespace,omitempty"`
+	Parameters    map[string]interface{} `json:"parameters,omitempty"`
+	Status        string                 `json:"status"` // success, processing, or error
+	ErrorType     string                 `json:"error_type,omitempty"`
+	PredictionID  string                 `json:"prediction_id,omitempty"`
+	StorageID     string                 `json:"storage_id,omitempty"`
+	EstimatedCost float64                `json:"estimated_cost,omitempty"` // USD, for generations of known models
+	DurationMS    int64                  `json:"duration_ms"`
+}
+
+// AuditLogResponse lists recent audit log records, newest first
+type AuditLogResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Path          string                 `json:"path"`
+	Count         int                    `json:"count"`
+	Filters       map[string]interface{} `json:"filters,omitempty"`
+	Records       []AuditRecord          `json:"records"`
+}
+
+// QueuedJob is a generation waiting in the queue for a free slot
+type QueuedJob struct {
+	Position  int    `json:"position"` // 1 starts next
+	StorageID string `json:"storage_id"`
+	Priority  string `json:"priority"`
+	Tool      string `json:"tool"`
+	Model     string `json:"model,omitempty"`
+	Prompt    string `json:"prompt,omitempty"`
+	QueuedAt  string `json:"queued_at"`
+
+	StartAfter string `json:"start_after,omitempty"` // A deferred request waits until then
+	Schedule   string `json:"schedule,omitempty"`    // The cron schedule start_after came from
+}
+
+// QueuedResponse reports a generation request that was queued rather than
+// started
+type QueuedResponse struct {
+	Success       bool      `json:"success"`
+	SchemaVersion string    `json:"schema_version"`
+	Status        string    `json:"status"` // Always "queued"
+	Operation     string    `json:"operation"`
+	StorageID     string    `json:"storage_id"`
+	Queue         QueuedJob `json:"queue"`
+	Message       string    `json:"message"`
+}
+
+// QueueStatusResponse lists the queued generations in the order they start
+type QueueStatusResponse struct {
+	Success       bool        `json:"success"`
+	SchemaVersion string      `json:"schema_version"`
+	Operation     string      `json:"operation"`
+	Running       int         `json:"running"`                  // Predictions counted against the concurrency limit
+	MaxConcurrent int         `json:"max_concurrent,omitempty"` // 0 when there is no limit
+	Count         int         `json:"count"`
+	Queued        []QueuedJob `json:"queued"`
+}
+
+// SessionModel is one model's share of a session summary
+type SessionModel struct {
+	Model         string  `json:"model"`
+	Generations   int     `json:"generations"`
+	Completed     int     `json:"completed"`
+	Failed        int     `json:"failed"`
+	EstimatedCost float64 `json:"estimated_cost,omitempty"` // USD at default settings
+}
+
+// SessionVideo is a completed or failed generation in a session summary
+type SessionVideo struct {
+	StorageID   string `json:"storage_id"`
+	Model       string `json:"model,omitempty"`
+	Prompt      string `json:"prompt,omitempty"`
+	Path        string `json:"path,omitempty"`
+	Error       string `json:"error,omitempty"`
+	CreatedAt   string `json:"created_at"`
+	WaitSeconds int    `json:"wait_seconds,omitempty"` // From creation to completion
+}
+
+// SessionSummaryResponse recaps everything generated in a server session
+type SessionSummaryResponse struct {
+	Success            bool           `json:"success"`
+	SchemaVersion      string         `json:"schema_version"`
+	Operation          string         `json:"operation"`
+	Since              string         `json:"since"`
+	SessionID          string         `json:"session_id,omitempty"`
+	Generations        int            `json:"generations"`
+	Completed          int            `json:"completed"`
+	Failed             int            `json:"failed"`
+	Pending            int            `json:"pending"`
+	Queued             int            `json:"queued"`
+	Retries            int            `json:"retries,omitempty"`
+	EstimatedCost      float64        `json:"estimated_cost"`       // USD at default settings
+	WaitSeconds        int            `json:"wait_seconds"`         // Summed over finished generations
+	AverageWaitSeconds int            `json:"average_wait_seconds"` // Per finished generation
+	Models             []SessionModel `json:"models"`
+	Videos             []SessionVideo `json:"videos"`
+	Failures           []SessionVideo `json:"failures"`
+	Message            string         `json:"message"`
+}
+
+// ResponseSchemaResponse publishes JSON Schemas of the response types
+type ResponseSchemaResponse struct {
+	Success       bool                   `json:"success"`
+	SchemaVersion string                 `json:"schema_version"`
+	Operation     string                 `json:"operation"`
+	Schemas       map[string]interface{} `json:"schemas"`   // JSON Schema by response type
+	Available     []string               `json:"available"` // Every response type with a schema
+}
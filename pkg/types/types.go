@@ -9,6 +9,7 @@ type ReplicatePredictionRequest struct {
 // ReplicatePredictionResponse represents the response from Replicate API
 type ReplicatePredictionResponse struct {
 	ID          string                 `json:"id"`
+	Model       string                 `json:"model"` // owner/name
 	Version     string                 `json:"version"`
 	Status      string                 `json:"status"`
 	Input       map[string]interface{} `json:"input"`
@@ -29,4 +30,96 @@ const (
 	StatusSucceeded  = "succeeded"
 	StatusFailed     = "failed"
 	StatusCanceled   = "canceled"
-)
\ No newline at end of file
+)
+
+// ReplicateAccount represents the response from Replicate's account endpoint
+type ReplicateAccount struct {
+	Type      string `json:"type"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	GithubURL string `json:"github_url,omitempty"`
+}
+
+// AccountStatus summarizes whether the API token works and whether the
+// account has an outstanding billing issue
+type AccountStatus struct {
+	TokenValid    bool              `json:"token_valid"`
+	BillingStatus string            `json:"billing_status"`
+	BillingDetail string            `json:"billing_detail,omitempty"`
+	Account       *ReplicateAccount `json:"account,omitempty"`
+	Message       string            `json:"message,omitempty"`
+}
+
+// Billing status constants
+const (
+	BillingOK      = "ok"
+	BillingIssue   = "issue"
+	BillingUnknown = "unknown"
+)
+
+// HealthCheck is the result of one self-test check
+type HealthCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Message    string `json:"message"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// Health check status constants
+const (
+	HealthOK      = "ok"
+	HealthWarning = "warning" // Works, but some features are unavailable
+	HealthError   = "error"
+)
+
+// HealthReport lists the self-test checks and the features they leave
+// working. It is healthy when no check failed.
+type HealthReport struct {
+	Healthy      bool            `json:"healthy"`
+	Checks       []HealthCheck   `json:"checks"`
+	Capabilities map[string]bool `json:"capabilities"`
+}
+
+// ReplicateModelVersion represents a version from Replicate's models API
+type ReplicateModelVersion struct {
+	ID            string                 `json:"id"`
+	CreatedAt     string                 `json:"created_at"`
+	CogVersion    string                 `json:"cog_version,omitempty"`
+	OpenAPISchema map[string]interface{} `json:"openapi_schema,omitempty"`
+}
+
+// ReplicateVersionList represents a page of model versions
+type ReplicateVersionList struct {
+	Next     string                  `json:"next"`
+	Previous string                  `json:"previous"`
+	Results  []ReplicateModelVersion `json:"results"`
+}
+
+// ReplicateDeployment represents a deployment from Replicate's deployments
+// API: a model version run on dedicated hardware under the owner's name
+type ReplicateDeployment struct {
+	Owner          string                     `json:"owner"`
+	Name           string                     `json:"name"`
+	CurrentRelease ReplicateDeploymentRelease `json:"current_release"`
+}
+
+// ReplicateDeploymentRelease is the model version and hardware a deployment
+// currently runs
+type ReplicateDeploymentRelease struct {
+	Number        int    `json:"number"`
+	Model         string `json:"model"`
+	Version       string `json:"version"`
+	CreatedAt     string `json:"created_at"`
+	Configuration struct {
+		Hardware     string `json:"hardware"`
+		MinInstances int    `json:"min_instances"`
+		MaxInstances int    `json:"max_instances"`
+	} `json:"configuration"`
+}
+
+// ReplicateDeploymentList represents a page of deployments
+type ReplicateDeploymentList struct {
+	Next     string                `json:"next"`
+	Previous string                `json:"previous"`
+	Results  []ReplicateDeployment `json:"results"`
+}
@@ -0,0 +1,115 @@
+package update
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultReleaseFeed is the GitHub API endpoint for the latest release
+const DefaultReleaseFeed = "https://api.github.com/repos/gomcpgo/replicate-video-ai/releases/latest"
+
+// Release describes a published server release
+type Release struct {
+	Version     string `json:"tag_name"`
+	Name        string `json:"name"`
+	URL         string `json:"html_url"`
+	Notes       string `json:"body"`
+	PublishedAt string `json:"published_at"`
+}
+
+// CheckResult reports how the running version compares to the latest release
+type CheckResult struct {
+	CurrentVersion  string
+	Latest          *Release
+	UpdateAvailable bool
+}
+
+// Check fetches the latest release from feedURL and compares it with current
+func Check(ctx context.Context, feedURL, current string) (*CheckResult, error) {
+	if feedURL == "" {
+		feedURL = DefaultReleaseFeed
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query release feed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read release feed: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("release feed error (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var release Release
+	if err := json.Unmarshal(body, &release); err != nil {
+		return nil, fmt.Errorf("failed to parse release feed: %w", err)
+	}
+	if release.Version == "" {
+		return nil, fmt.Errorf("release feed did not include a version")
+	}
+
+	return &CheckResult{
+		CurrentVersion:  current,
+		Latest:          &release,
+		UpdateAvailable: CompareVersions(release.Version, current) > 0,
+	}, nil
+}
+
+// CompareVersions compares two dotted versions (an optional leading "v" and
+// any pre-release suffix are ignored). Returns 1 if a > b, -1 if a < b, else 0.
+func CompareVersions(a, b string) int {
+	pa, pb := parseVersion(a), parseVersion(b)
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		if x != y {
+			if x > y {
+				return 1
+			}
+			return -1
+		}
+	}
+	return 0
+}
+
+func parseVersion(v string) []int {
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	if idx := strings.IndexAny(v, "-+"); idx >= 0 {
+		v = v[:idx]
+	}
+
+	var parts []int
+	for _, p := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			n = 0
+		}
+		parts = append(parts, n)
+	}
+	return parts
+}
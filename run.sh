@@ -16,67 +16,55 @@ case "$1" in
         go test ./pkg/...
         ;;
     
-    "list-models"|"list")
-        # Check for API token for terminal operations
-        if [ -z "$REPLICATE_API_TOKEN" ]; then
-            echo "Error: REPLICATE_API_TOKEN environment variable is not set"
-            echo "Please set it in your environment or create a .env file"
-            exit 1
-        fi
-        go run ./cmd -list
-        ;;
-    
     "t2v")
-        # Text-to-video generation
-        if [ -z "$REPLICATE_API_TOKEN" ]; then
-            echo "Error: REPLICATE_API_TOKEN environment variable is not set"
-            echo "Please set it in your environment or create a .env file"
-            exit 1
-        fi
+        # Text-to-video generation; extra flags go before the prompt
         if [ -z "$2" ]; then
-            echo "Usage: ./run.sh t2v <model> [prompt]"
-            echo "Models: wan-t2v-fast, veo3, kling-master"
+            echo "Usage: ./run.sh t2v <model> [flags] <prompt>"
+            echo "Models: wan-t2v-fast, veo3, veo3-fast, veo2, kling-master, pixverse"
             exit 1
         fi
-        go run ./cmd -t2v "$2" -p "${3:-}"
+        model="$2"
+        shift 2
+        go run ./cmd generate -model "$model" "$@"
         ;;
     
     "i2v")
-        # Image-to-video generation
-        if [ -z "$REPLICATE_API_TOKEN" ]; then
-            echo "Error: REPLICATE_API_TOKEN environment variable is not set"
-            echo "Please set it in your environment or create a .env file"
-            exit 1
-        fi
+        # Image-to-video generation; extra flags go before the image
         if [ -z "$2" ] || [ -z "$3" ]; then
-            echo "Usage: ./run.sh i2v <model> <image_path> [prompt]"
-            echo "Models: wan-i2v-fast, veo3, kling-master"
+            echo "Usage: ./run.sh i2v <model> [flags] <image_path> [prompt]"
+            echo "Models: wan-i2v-fast, veo3, veo3-fast, veo2, kling-master, kling-standard, kling-pro, pixverse"
             exit 1
         fi
-        go run ./cmd -i2v "$2" -image "$3" -p "${4:-}"
+        model="$2"
+        shift 2
+        go run ./cmd i2v -model "$model" "$@"
         ;;
     
-    "continue")
-        # Continue checking prediction
-        if [ -z "$REPLICATE_API_TOKEN" ]; then
-            echo "Error: REPLICATE_API_TOKEN environment variable is not set"
-            echo "Please set it in your environment or create a .env file"
-            exit 1
-        fi
-        if [ -z "$2" ]; then
-            echo "Usage: ./run.sh continue <prediction_id>"
-            exit 1
-        fi
-        go run ./cmd -continue "$2"
+    "continue"|"status")
+        # Check a generation and download the video once it completed
+        shift
+        go run ./cmd status "$@"
+        ;;
+    
+    "cancel")
+        shift
+        go run ./cmd cancel "$@"
+        ;;
+    
+    "list-models"|"models"|"list"|"prune"|"export"|"import"|"health"|"help")
+        # Local commands; no API token needed
+        command="$1"
+        [ "$command" = "list-models" ] && command="models"
+        shift
+        go run ./cmd "$command" "$@"
         ;;
     
     "test-async")
-        if [ -z "$REPLICATE_API_TOKEN" ]; then
-            echo "Error: REPLICATE_API_TOKEN environment variable is not set"
-            echo "Please set it in your environment or create a .env file"
-            exit 1
-        fi
-        go run ./cmd -test-async
+        go run ./cmd test-async
+        ;;
+    
+    "version")
+        go run ./cmd version -check
         ;;
     
     "run"|"server")
@@ -91,25 +79,43 @@ case "$1" in
         ./run.sh "$@"
         ;;
     
+    "mock")
+        # Run with the offline mock client (no API token or credits needed)
+        export REPLICATE_VIDEO_MOCK=true
+        shift
+        ./run.sh "$@"
+        ;;
+    
     *)
-        echo "Usage: $0 {build|test|list-models|t2v|i2v|continue|test-async|run|debug}"
+        echo "Usage: $0 {build|test|models|list|t2v|i2v|status|cancel|prune|export|import|health|test-async|version|run|debug|mock}"
         echo ""
         echo "Commands:"
         echo "  build       - Build the server binary"
         echo "  test        - Run tests"
-        echo "  list-models - List available video models"
+        echo "  models      - List available video models"
+        echo "  list        - List stored videos"
         echo "  t2v         - Generate text-to-video"
         echo "  i2v         - Generate image-to-video"
-        echo "  continue    - Continue checking a prediction"
+        echo "  status      - Check a generation (alias: continue)"
+        echo "  cancel      - Cancel a running generation"
+        echo "  prune       - Delete failed or canceled generations"
+        echo "  export      - Bundle stored videos into a zip archive"
+        echo "  import      - Import an existing video into storage"
+        echo "  health      - Check the network, API token, storage, and ffmpeg"
         echo "  test-async  - Test async generation flow"
+        echo "  version     - Show version and check for updates"
         echo "  run         - Start MCP server"
         echo "  debug       - Run any command with debug mode"
+        echo "  mock        - Run any command against the offline mock client"
+        echo ""
+        echo "Flags for each command: go run ./cmd help <command>"
         echo ""
         echo "Examples:"
         echo "  ./run.sh t2v wan-t2v-fast \"A sunset over the ocean\""
+        echo "  ./run.sh t2v kling-master -duration 10 \"City timelapse\""
         echo "  ./run.sh i2v wan-i2v-fast images/car.webp \"Make the car drive\""
-        echo "  ./run.sh continue abc123xyz"
+        echo "  ./run.sh status abc123xyz"
         echo "  ./run.sh debug t2v wan-t2v-fast \"Test prompt\""
+        echo "  ./run.sh mock test-async"
         ;;
 esac
-